@@ -7,10 +7,13 @@ import (
 	"io"
 	"net/http"
 	"path"
+	"strings"
 
+	"github.com/google/uuid"
 	"github.com/mandelsoft/engine/pkg/database"
 	"github.com/mandelsoft/engine/pkg/glob"
 	"github.com/mandelsoft/engine/pkg/impl/database/filesystem"
+	"github.com/mandelsoft/engine/pkg/processing/api"
 	"github.com/mandelsoft/engine/pkg/processing/model/support/db"
 	"github.com/mandelsoft/vfs/pkg/vfs"
 	"github.com/spf13/cobra"
@@ -23,12 +26,29 @@ type Apply struct {
 	mainopts *Options
 	filemode bool
 	setns    bool
+	plan     bool
+	atomic   bool
+	output   string
+	update   UpdateOptions
 }
 
 func NewApply(opts *Options) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "apply <options>",
 		Short: "apply objects to database",
+		Long: `
+Apply objects to the database. With option --plan the objects are
+not applied. Instead, the engine predicts which elements would be
+reprocessed by the changes and which processing steps would be
+skipped, because their effective version does not change.
+
+With option --atomic the objects are applied in a transaction
+using an update request: the elements triggered by the objects are
+locked before the objects are applied and released afterwards, so
+that all changes are processed together. All objects must be
+located in the same namespace. If an object cannot be applied, the
+request is aborted. Objects already applied are processed anyway.
+`,
 	}
 	TweakCommand(cmd)
 
@@ -40,11 +60,18 @@ func NewApply(opts *Options) *cobra.Command {
 	flags := cmd.Flags()
 	flags.BoolVarP(&c.filemode, "file", "f", false, "manifest files")
 	flags.BoolVarP(&c.setns, "set-namespace", "N", false, "set namespace")
+	flags.BoolVarP(&c.plan, "plan", "", false, "show the elements affected by the changes instead of applying them")
+	flags.StringVarP(&c.output, "output", "o", "", "output format for plan")
+	flags.BoolVarP(&c.atomic, "atomic", "", false, "apply objects in a transaction")
+	c.update.AddFlags(cmd, true)
 
 	return cmd
 }
 
 func (c *Apply) Run(args []string) error {
+	if c.plan {
+		return c.Plan(args)
+	}
 
 	handler := func(f string, items ...Object) error {
 		var cmderr error
@@ -106,9 +133,169 @@ func (c *Apply) Run(args []string) error {
 		}
 		return cmderr
 	}
+	if c.atomic {
+		return c.Atomic(args, handler)
+	}
 	return HandleObjects(c.cmd, c.mainopts, args, handler)
 }
 
+type objectSet struct {
+	file  string
+	items []Object
+}
+
+// Atomic applies the objects using an update request locking all
+// objects, which are applied.
+func (c *Apply) Atomic(args []string, handler func(f string, items ...Object) error) error {
+	var sets []objectSet
+	var refs []database.LocalObjectRef
+
+	ns := ""
+	collect := func(f string, items ...Object) error {
+		for _, o := range items {
+			if c.setns && c.mainopts.namespace != "" {
+				o.SetNamespace(c.mainopts.namespace)
+			}
+			if len(refs) == 0 {
+				ns = o.GetNamespace()
+			}
+			if o.GetNamespace() != ns {
+				return fmt.Errorf("%s: all objects must be in namespace %q", database.NewObjectRefFor(o), ns)
+			}
+			refs = append(refs, database.NewLocalObjectRefFor(o))
+		}
+		sets = append(sets, objectSet{f, items})
+		return nil
+	}
+	err := HandleObjects(c.cmd, c.mainopts, args, collect)
+	if err != nil {
+		return err
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+
+	name := "apply-" + uuid.NewString()
+	client := c.update.Client(c.mainopts, "/"+path.Join(ns, name))
+	apply := func() error {
+		var cmderr error
+		for _, s := range sets {
+			if err := handler(s.file, s.items...); err != nil {
+				cmderr = err
+			}
+		}
+		return cmderr
+	}
+
+	ctx, cancel := c.update.Context()
+	defer cancel()
+	err = client.Atomic(ctx, apply, refs...)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.cmd.OutOrStdout(), "%s: committed\n", database.NewObjectRefFor(client.Id()))
+	return nil
+}
+
+func (c *Apply) Plan(args []string) error {
+	var req api.PlanRequest
+
+	handler := func(f string, items ...Object) error {
+		var cmderr error
+		multi := len(items) > 1
+		for i, o := range items {
+			if c.setns && c.mainopts.namespace != "" {
+				o.SetNamespace(c.mainopts.namespace)
+			}
+			data, err := json.Marshal(o)
+			if err != nil {
+				cmderr = IndexError(c.cmd, multi, i, f, "cannot marshal manifest", err)
+				continue
+			}
+			req.Objects = append(req.Objects, data)
+		}
+		return cmderr
+	}
+	err := HandleObjects(c.cmd, c.mainopts, args, handler)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(&req)
+	if err != nil {
+		return err
+	}
+	post, err := http.Post(c.mainopts.GetEngineURL()+api.CMD_PLAN, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	data, err = ResponseData(post)
+	if err != nil {
+		return fmt.Errorf("plan failed: %w", err)
+	}
+	var result api.PlanResult
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		return err
+	}
+	return PrintPlan(c.cmd.OutOrStdout(), result.Elements, c.output)
+}
+
+func PrintPlan(w io.Writer, elems []api.PlannedElement, output string) error {
+	switch strings.ToLower(strings.TrimSpace(output)) {
+	case "", "wide":
+		if len(elems) == 0 {
+			fmt.Fprintf(w, "no element affected\n")
+			return nil
+		}
+		wide := output != ""
+		columnList := []string{"ELEMENT", "ACTION", "TRIGGER"}
+		if wide {
+			columnList = append(columnList, "OLD VERSION", "NEW VERSION")
+		}
+		var fieldList [][]string
+		for _, e := range elems {
+			l := []string{e.Element, e.Action, e.Trigger}
+			if wide {
+				l = append(l, e.OldVersion, e.NewVersion)
+			}
+			fieldList = append(fieldList, l)
+		}
+
+		max := make([]int, len(columnList), len(columnList))
+		for i, s := range columnList {
+			max[i] = len(s)
+		}
+		for _, cols := range fieldList {
+			for i, s := range cols {
+				if max[i] < len(s) {
+					max[i] = len(s)
+				}
+			}
+		}
+		f := formatString(max)
+		printLine(w, columnList, f)
+		for _, cols := range fieldList {
+			printLine(w, cols, f)
+		}
+	case "json":
+		data, err := json.Marshal(elems)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\n", string(data))
+	case "yaml":
+		data, err := yaml.Marshal(elems)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\n", string(data))
+	default:
+		return fmt.Errorf("unknown output format %q", output)
+	}
+	return nil
+}
+
 func isList(m map[string]interface{}) bool {
 	if len(m) != 1 || m["items"] == nil {
 		return false
@@ -0,0 +1,101 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/processing/api"
+	"github.com/spf13/cobra"
+)
+
+type Approve struct {
+	cmd *cobra.Command
+
+	mainopts *Options
+	command  string
+	message  string
+}
+
+func NewApprove(opts *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "approve <type> <object> <runid> <input version> [<phase>]",
+		Short: "approve a run awaiting approval",
+		Long: `
+Approve the run of a phase awaiting approval. The run id and the
+input version must match the pending approval request described
+by the status of the object. If no phase is given, the phase
+awaiting approval is used.
+`,
+	}
+	return newApprove(cmd, opts, api.CMD_APPROVE)
+}
+
+func NewReject(opts *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "reject <type> <object> <runid> <input version> [<phase>]",
+		Short: "reject a run awaiting approval",
+		Long: `
+Reject the run of a phase awaiting approval. The run fails.
+The run id and the input version must match the pending approval
+request described by the status of the object. If no phase is given,
+the phase awaiting approval is used.
+`,
+	}
+	return newApprove(cmd, opts, api.CMD_REJECT)
+}
+
+func newApprove(cmd *cobra.Command, opts *Options, command string) *cobra.Command {
+	TweakCommand(cmd)
+
+	c := &Approve{
+		cmd:      cmd,
+		mainopts: opts,
+		command:  command,
+	}
+	c.cmd.RunE = func(cmd *cobra.Command, args []string) error { return c.Run(args) }
+	flags := cmd.Flags()
+	flags.StringVarP(&c.message, "message", "m", "", "explanation of the decision")
+	return cmd
+}
+
+func (c *Approve) Run(args []string) error {
+	if len(args) < 4 || len(args) > 5 {
+		return fmt.Errorf("type, object, run id, input version and optional phase required")
+	}
+	if args[0] == "" {
+		return fmt.Errorf("non-empty type required")
+	}
+
+	oid := ObjectIdForArg(c.mainopts, args[0], args[1])
+	q := url.Values{}
+	q.Set(api.PARAM_RUNID, args[2])
+	q.Set(api.PARAM_VERSION, args[3])
+	if len(args) > 4 {
+		q.Set(api.PARAM_PHASE, args[4])
+	}
+	if c.message != "" {
+		q.Set(api.PARAM_MESSAGE, c.message)
+	}
+	u := c.mainopts.GetEngineURL() + path.Join(c.command, oid.GetType(), oid.GetNamespace(), oid.GetName()) + "?" + q.Encode()
+
+	r, err := http.Post(u, "application/json", nil)
+	if err != nil {
+		return err
+	}
+	data, err := ResponseData(r)
+	if err != nil {
+		return fmt.Errorf("%s: %w", database.StringId(oid), err)
+	}
+
+	var result api.ApprovalResult
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.cmd.OutOrStdout(), "%s: run %s %s\n", result.Element, result.RunId, result.Decision)
+	return nil
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/processing/api"
+	"github.com/spf13/cobra"
+)
+
+type Cancel struct {
+	cmd *cobra.Command
+
+	mainopts *Options
+}
+
+func NewCancel(opts *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cancel <type> <object> [<phase>]",
+		Short: "cancel active runs of an object",
+		Long: `
+Abort the active runs for the phases of an object. The type may be
+an external type or an internal type. If no phase is given, the
+runs of all phases of the object are cancelled.
+`,
+	}
+	TweakCommand(cmd)
+
+	c := &Cancel{
+		cmd:      cmd,
+		mainopts: opts,
+	}
+	c.cmd.RunE = func(cmd *cobra.Command, args []string) error { return c.Run(args) }
+	return cmd
+}
+
+func (c *Cancel) Run(args []string) error {
+	if len(args) < 2 || len(args) > 3 {
+		return fmt.Errorf("type and object and optional phase required")
+	}
+	if args[0] == "" {
+		return fmt.Errorf("non-empty type required")
+	}
+
+	oid := ObjectIdForArg(c.mainopts, args[0], args[1])
+	u := c.mainopts.GetEngineURL() + path.Join(api.CMD_CANCEL, oid.GetType(), oid.GetNamespace(), oid.GetName())
+	if len(args) > 2 {
+		u += "?" + api.PARAM_PHASE + "=" + url.QueryEscape(args[2])
+	}
+
+	r, err := http.Post(u, "application/json", nil)
+	if err != nil {
+		return err
+	}
+	data, err := ResponseData(r)
+	if err != nil {
+		return fmt.Errorf("%s: %w", database.StringId(oid), err)
+	}
+
+	var result api.CancelResult
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		return err
+	}
+	if len(result.Cancelled) == 0 {
+		fmt.Fprintf(c.cmd.OutOrStdout(), "%s: no active run\n", database.StringId(oid))
+		return nil
+	}
+	for _, e := range result.Cancelled {
+		fmt.Fprintf(c.cmd.OutOrStdout(), "%s: run %s cancelled\n", e.Element, e.RunId)
+	}
+	return nil
+}
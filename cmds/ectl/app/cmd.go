@@ -1,6 +1,8 @@
 package app
 
 import (
+	"fmt"
+	"net/url"
 	"strings"
 
 	"github.com/mandelsoft/goutils/general"
@@ -12,10 +14,11 @@ import (
 type Options struct {
 	address   string
 	namespace string
+	model     string
 	fs        vfs.FileSystem
 }
 
-func (o *Options) GetURL() string {
+func (o *Options) getBaseURL() string {
 	a := o.address
 	if !strings.HasPrefix(a, "http://") && !strings.HasPrefix(a, "https://") {
 		a = "https://" + a
@@ -23,7 +26,53 @@ func (o *Options) GetURL() string {
 	if !strings.HasSuffix(a, "/") {
 		a += "/"
 	}
-	return a + "db/"
+	return a
+}
+
+// modelPath provides the path suffix for the selected model.
+// Without model the default model of the engine is used.
+func (o *Options) modelPath() string {
+	if o.model == "" {
+		return ""
+	}
+	return o.model + "/"
+}
+
+// GetURL provides the URL of the database service.
+func (o *Options) GetURL() string {
+	return o.getBaseURL() + "db/" + o.modelPath()
+}
+
+// GetEngineURL provides the URL of the engine API.
+func (o *Options) GetEngineURL() string {
+	return o.getBaseURL() + "engine/" + o.modelPath()
+}
+
+// GetAdminURL provides the URL of the privileged engine API.
+func (o *Options) GetAdminURL() string {
+	return o.getBaseURL() + "admin/" + o.modelPath()
+}
+
+// GetModelsURL provides the URL of the model list of the engine.
+func (o *Options) GetModelsURL() string {
+	return o.getBaseURL() + "models"
+}
+
+// GetWatchURL provides the URL of the watch endpoint.
+func (o *Options) GetWatchURL() (string, error) {
+	u, err := url.Parse(o.getBaseURL())
+	if err != nil {
+		return "", fmt.Errorf("invalid url: %w", err)
+	}
+	scheme := "ws"
+	if u.Scheme == "https" {
+		scheme = "wss"
+	}
+	a := fmt.Sprintf("%s://%s/watch", scheme, u.Host)
+	if o.model != "" {
+		a += "/" + o.model
+	}
+	return a, nil
 }
 
 func New(fss ...vfs.FileSystem) *cobra.Command {
@@ -39,6 +88,9 @@ func New(fss ...vfs.FileSystem) *cobra.Command {
 	if cfg.Namespace != nil {
 		opts.namespace = *cfg.Namespace
 	}
+	if cfg.Model != nil {
+		opts.model = *cfg.Model
+	}
 
 	// fmt.Printf("server %s\nnamepace %s\n", opts.address, opts.namespace)
 	maincmd := &cobra.Command{
@@ -57,10 +109,26 @@ the processing engine.
 
 	flags.StringVarP(&opts.namespace, "namespace", "n", opts.namespace, "namespace for operation")
 	flags.StringVarP(&opts.address, "server", "s", opts.address, "engine server")
+	flags.StringVarP(&opts.model, "model", "M", opts.model, "model hosted by the engine (default model of the engine if not set)")
 
 	maincmd.AddCommand(NewGet(opts))
 	maincmd.AddCommand(NewApply(opts))
 	maincmd.AddCommand(NewDelete(opts))
 	maincmd.AddCommand(NewWatch(opts))
+	maincmd.AddCommand(NewCancel(opts))
+	maincmd.AddCommand(NewSuspend(opts))
+	maincmd.AddCommand(NewResume(opts))
+	maincmd.AddCommand(NewApprove(opts))
+	maincmd.AddCommand(NewReject(opts))
+	maincmd.AddCommand(NewRuns(opts))
+	maincmd.AddCommand(NewDescribe(opts))
+	maincmd.AddCommand(NewRun(opts))
+	maincmd.AddCommand(NewExplain(opts))
+	maincmd.AddCommand(NewEvents(opts))
+	maincmd.AddCommand(NewUpdate(opts))
+	maincmd.AddCommand(NewWait(opts))
+	maincmd.AddCommand(NewModels(opts))
+	maincmd.AddCommand(NewTypes(opts))
+	maincmd.AddCommand(NewStuck(opts))
 	return maincmd
 }
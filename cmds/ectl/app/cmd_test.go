@@ -3,7 +3,9 @@ package app_test
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"time"
 
 	. "github.com/mandelsoft/engine/pkg/database/service/testtypes"
@@ -20,12 +22,58 @@ import (
 	"github.com/mandelsoft/engine/pkg/database"
 	"github.com/mandelsoft/engine/pkg/database/service"
 	"github.com/mandelsoft/engine/pkg/impl/database/filesystem"
+	"github.com/mandelsoft/engine/pkg/processing/api"
 	"github.com/mandelsoft/engine/pkg/server"
 	service2 "github.com/mandelsoft/engine/pkg/service"
 )
 
 const PORT = 8080
 
+var threshold string
+
+// engine fakes the engine and admin API used to enforce deletions.
+type engine struct {
+	db database.Database[Object]
+	// admin grants the access to the admin API.
+	admin bool
+}
+
+func (e *engine) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var result interface{}
+	status := http.StatusOK
+	switch {
+	case req.URL.Path == "/engine/"+api.CMD_STUCK:
+		threshold = req.URL.Query().Get(api.PARAM_THRESHOLD)
+		result = &api.StuckResult{Objects: []api.StuckObject{{
+			Object:       "A/ns1/o2",
+			DeletionTime: time.Now().Add(-2 * time.Hour),
+			Finalizers:   []api.Finalizer{{Name: "test", Controller: "test controller"}, {Name: "other"}},
+			Locks:        []api.ActiveRun{{Element: "AState/ns1/o2:Phase", RunId: "r1"}},
+		}}}
+	case req.URL.Path == "/admin/"+api.CMD_FORCE_DELETE+"/A/ns1/o2" && req.Method == http.MethodPost:
+		if !e.admin {
+			result, status = &api.Error{Error: "access denied"}, http.StatusForbidden
+			break
+		}
+		o, err := e.db.GetObject(database.NewObjectId("A", "ns1", "o2"))
+		if err == nil {
+			o.(database.Finalizable).SetFinalizers(nil)
+			err = e.db.SetObject(o)
+		}
+		if err != nil {
+			result, status = &api.Error{Error: err.Error()}, http.StatusInternalServerError
+			break
+		}
+		result = &api.ForceDeleteResult{Elements: []string{"AState/ns1/o2:Phase"}}
+	default:
+		result, status = &api.Error{Error: "unknown command"}, http.StatusNotFound
+	}
+	data, _ := json.Marshal(result)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
 var _ = Describe("Test Environment", func() {
 	var ctx context.Context
 
@@ -59,6 +107,8 @@ var _ = Describe("Test Environment", func() {
 	AfterEach(func() {
 		MustBeSuccessful(srv.Shutdown(ctx))
 		done.Wait()
+		// connections kept alive for a shutdown server cannot be reused.
+		http.DefaultClient.CloseIdleConnections()
 	})
 
 	Context("get", func() {
@@ -184,14 +234,40 @@ A/ns1/o1: deleted
 A/ns1/o2: deletion requested
 `))
 		})
+		It("requests deletion with deletion policy", func() {
+			cmd.SetOut(buf)
+			cmd.SetArgs([]string{"-n", "ns1", "delete", "--cascade=orphan", "A", "o2"})
+			MustBeSuccessful(cmd.Execute())
+			Expect("\n" + buf.String()).To(Equal(`
+A/ns1/o2: deletion requested
+`))
+			o := Must(db.GetObject(database.NewObjectId("A", "ns1", "o2")))
+			Expect(o.(database.DeletionPolicyAccess).GetDeletionPolicy()).To(Equal(database.DELETION_POLICY_ORPHAN))
+		})
+		It("rejects invalid deletion policy", func() {
+			cmd.SetOut(buf)
+			cmd.SetArgs([]string{"-n", "ns1", "delete", "--cascade=purge", "A", "o2"})
+			ExpectError(cmd.Execute()).To(MatchError(`invalid deletion policy "purge"`))
+		})
 		It("forces deletion", func() {
+			srv.Handle("/admin/", &engine{db: db, admin: true})
 			cmd.SetOut(buf)
 			cmd.SetArgs([]string{"-n", "ns1", "delete", "--force", "A", "o2"})
 			MustBeSuccessful(cmd.Execute())
 			fmt.Printf("\n%s\n", buf.String())
 			Expect("\n" + buf.String()).To(Equal(`
 A/ns1/o2: deletion enforced
+  cleaning up element AState/ns1/o2:Phase
 `))
+			_, err := db.GetObject(database.NewObjectId("A", "ns1", "o2"))
+			Expect(err).To(MatchError(database.ErrNotExist))
+		})
+		It("rejects forced deletion", func() {
+			srv.Handle("/admin/", &engine{db: db})
+			cmd.SetOut(buf)
+			cmd.SetArgs([]string{"-n", "ns1", "delete", "--force", "A", "o2"})
+			Expect(cmd.Execute()).To(HaveOccurred())
+			Expect(buf.String()).To(Equal("cannot enforce deletion for \"A/ns1/o2\": access denied\n"))
 		})
 		It("handles files", func() {
 			cmd.SetOut(buf)
@@ -203,4 +279,37 @@ A/ns1/o1: deleted
 `))
 		})
 	})
+
+	Context("stuck", func() {
+		It("shows stuck deletions", func() {
+			srv.Handle("/engine/", &engine{db: db})
+			cmd.SetArgs([]string{"stuck", "-t", "1h"})
+			MustBeSuccessful(cmd.Execute())
+			Expect("\n" + buf.String()).To(MatchRegexp(`
+A/ns1/o2: deleting since .* \(.*\)
+  finalizer test \(test controller\)
+  finalizer other \(unknown controller\)
+  element AState/ns1/o2:Phase locked by run r1
+`))
+			Expect(threshold).To(Equal("1h0m0s"))
+		})
+	})
+
+	Context("models", func() {
+		It("selects model", func() {
+			service.New(db, "/db/m1").RegisterHandler(srv)
+			cmd.SetArgs([]string{"-M", "m1", "-n", "ns1", "get", "A"})
+			MustBeSuccessful(cmd.Execute())
+			Expect("\n" + buf.String()).To(Equal(`
+NAMESPACE NAME STATUS
+ns1       o1   Completed
+ns1       o2
+`))
+		})
+
+		It("rejects unknown model", func() {
+			cmd.SetArgs([]string{"-M", "unknown", "-n", "ns1", "get", "A", "o1"})
+			Expect(cmd.Execute()).To(HaveOccurred())
+		})
+	})
 })
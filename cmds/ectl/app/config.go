@@ -11,6 +11,7 @@ import (
 type Config struct {
 	Namespace *string `json:"namespace,omitempty"`
 	Server    *string `jso:"server,omitempty"`
+	Model     *string `json:"model,omitempty"`
 }
 
 func GetConfig() *Config {
@@ -34,6 +35,9 @@ func GetConfig() *Config {
 	if v := os.Getenv("ENGINE_NAMESPACE"); v != "" {
 		cfg.Namespace = generics.Pointer(v)
 	}
+	if v := os.Getenv("ENGINE_MODEL"); v != "" {
+		cfg.Model = generics.Pointer(v)
+	}
 	if cfg.Server == nil || *cfg.Server == "" {
 		cfg.Server = generics.Pointer("http://localhost:8080")
 	}
@@ -64,4 +68,7 @@ func Mergeonfig(cfg *Config, add *Config) {
 	if add.Server != nil {
 		cfg.Server = add.Server
 	}
+	if add.Model != nil {
+		cfg.Model = add.Model
+	}
 }
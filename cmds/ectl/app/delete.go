@@ -1,7 +1,6 @@
 package app
 
 import (
-	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -10,6 +9,8 @@ import (
 	"strings"
 
 	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/database/service"
+	"github.com/mandelsoft/engine/pkg/processing/api"
 	"github.com/mandelsoft/goutils/sliceutils"
 	"github.com/spf13/cobra"
 )
@@ -22,12 +23,31 @@ type Delete struct {
 	all      bool
 	filemode bool
 	setns    bool
+	cascade  string
 }
 
 func NewDelete(opts *Options) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "delete <type> {<object>} <options>",
 		Short: "delete objects from database",
+		Long: `
+Delete objects from the database. The option --cascade selects
+the policy used to propagate the deletion of an external object
+to the elements depending on it:
+- Block:   refuse the deletion as long as dependent elements exist
+           (reported by the status of the deleted object)
+- Cascade: delete the external objects of the dependent elements, too
+- Orphan:  delete the object and let the dependent elements fail
+
+Without this option, the default policy of the external type is used.
+The option without value selects Cascade.
+
+With option --force, the deletion of objects, which cannot be deleted
+immediately, is enforced by the engine: the finalizers of the objects
+are removed and active runs of their elements are rolled back. This
+uses the privileged admin API of the engine and requires an engine
+started with option --force-deletion.
+`,
 	}
 	TweakCommand(cmd)
 
@@ -37,10 +57,12 @@ func NewDelete(opts *Options) *cobra.Command {
 	}
 	c.cmd.RunE = func(cmd *cobra.Command, args []string) error { return c.Run(args) }
 	flags := cmd.Flags()
-	flags.BoolVarP(&c.force, "force", "F", false, "force mode")
+	flags.BoolVarP(&c.force, "force", "F", false, "enforce deletion by removing finalizers")
 	flags.BoolVarP(&c.all, "all", "A", false, "all objects")
 	flags.BoolVarP(&c.filemode, "file", "f", false, "manifest files")
 	flags.BoolVarP(&c.setns, "set-namespace", "N", false, "set namespace")
+	flags.StringVarP(&c.cascade, "cascade", "", "", "deletion policy for dependent elements (Block, Cascade, Orphan)")
+	flags.Lookup("cascade").NoOptDefVal = string(database.DELETION_POLICY_CASCADE)
 
 	return cmd
 }
@@ -51,6 +73,14 @@ func (c *Delete) Run(args []string) error {
 	if len(args) < 1 && !c.filemode {
 		return fmt.Errorf("object type required")
 	}
+	policy, err := database.ParseDeletionPolicy(c.cascade)
+	if err != nil {
+		return err
+	}
+	query := ""
+	if policy != "" {
+		query = "?" + service.PARAM_DELETION_POLICY + "=" + string(policy)
+	}
 
 	handler := func(f string, list ...database.ObjectId) error {
 		var cmderr error
@@ -59,7 +89,7 @@ func (c *Delete) Run(args []string) error {
 			if c.setns && c.mainopts.namespace != "" {
 				o = database.NewObjectId(o.GetType(), c.mainopts.namespace, o.GetName())
 			}
-			req, err := http.NewRequest("DELETE", c.mainopts.GetURL()+path.Join(o.GetType(), o.GetNamespace(), o.GetName()), nil)
+			req, err := http.NewRequest("DELETE", c.mainopts.GetURL()+path.Join(o.GetType(), o.GetNamespace(), o.GetName())+query, nil)
 			if err != nil {
 				cmderr = IndexError(c.cmd, multi, i+1, database.StringId(o), "deletion failed", err)
 				continue
@@ -69,35 +99,27 @@ func (c *Delete) Run(args []string) error {
 				cmderr = IndexError(c.cmd, multi, i+1, database.StringId(o), "request failed", err)
 				continue
 			}
+			if r.StatusCode == http.StatusBadRequest {
+				_, err = ResponseData(r)
+				cmderr = IndexError(c.cmd, multi, i+1, database.StringId(o), "deletion failed", err)
+				continue
+			}
 			if r.StatusCode == http.StatusOK {
 				fmt.Fprintf(c.cmd.OutOrStdout(), "%s: deleted\n", database.StringId(o))
 			} else {
 				if c.force {
-					obj, err := GetObject(c.mainopts, o)
+					elems, err := c.forceDelete(o)
+					if errors.Is(err, database.ErrNotExist) {
+						fmt.Fprintf(c.cmd.OutOrStdout(), "%s: deleted\n", database.StringId(o))
+						continue
+					}
 					if err != nil {
-						if !errors.Is(err, database.ErrNotExist) {
-							cmderr = IndexError(c.cmd, multi, i+1, database.StringId(obj), "cannot remove finalizers", err)
-							continue
-						}
-					} else {
-						obj.SetFinalizers(nil)
-						data, err := json.Marshal(obj)
-						if err != nil {
-							cmderr = IndexError(c.cmd, multi, i+1, database.StringId(obj), "cannot remove finalizers", err)
-							continue
-						}
-
-						post, err := http.Post(c.mainopts.GetURL()+path.Join(obj.GetType(), obj.GetNamespace(), obj.GetName()), "application/json", bytes.NewReader(data))
-						if err != nil {
-							cmderr = IndexError(c.cmd, multi, i+1, database.StringId(obj), "cannot remove finalizers", err)
-							continue
-						}
-						_, err = ResponseData(post)
-						if err != nil {
-							cmderr = IndexError(c.cmd, multi, i+1, database.StringId(obj), "cannot remove finalizers", err)
-							continue
-						}
-						fmt.Fprintf(c.cmd.OutOrStdout(), "%s: deletion enforced\n", database.StringId(o))
+						cmderr = IndexError(c.cmd, multi, i+1, database.StringId(o), "cannot enforce deletion", err)
+						continue
+					}
+					fmt.Fprintf(c.cmd.OutOrStdout(), "%s: deletion enforced\n", database.StringId(o))
+					for _, e := range elems {
+						fmt.Fprintf(c.cmd.OutOrStdout(), "  cleaning up element %s\n", e)
 					}
 				} else {
 					fmt.Fprintf(c.cmd.OutOrStdout(), "%s: deletion requested\n", database.StringId(o))
@@ -179,4 +201,28 @@ func (c *Delete) Run(args []string) error {
 	return cmderr
 }
 
+// forceDelete requests the forced deletion of an object from the
+// admin API of the engine.
+// It provides the elements cleaned up by the engine.
+func (c *Delete) forceDelete(oid database.ObjectId) ([]string, error) {
+	r, err := http.Post(c.mainopts.GetAdminURL()+path.Join(api.CMD_FORCE_DELETE, oid.GetType(), oid.GetNamespace(), oid.GetName()), "application/json", nil)
+	if err != nil {
+		return nil, err
+	}
+	if r.StatusCode == http.StatusNotFound {
+		// the object has been deleted in the meantime.
+		return nil, database.ErrNotExist
+	}
+	data, err := ResponseData(r)
+	if err != nil {
+		return nil, err
+	}
+	var result api.ForceDeleteResult
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result.Elements, nil
+}
+
 var ObjectIdFor = database.GetObjectId[Object]
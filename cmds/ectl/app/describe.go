@@ -0,0 +1,227 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/processing/api"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+type Describe struct {
+	cmd *cobra.Command
+
+	mainopts *Options
+	output   string
+	limit    int
+}
+
+func NewDescribe(opts *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "describe <type> <object> <options>",
+		Short: "describe the processing state of an object",
+		Long: `
+Describe an object together with its processing state kept by the
+engine: the internal object with every phase (status, lock, current and
+target versions, links), the upstream and downstream elements as
+dependency trees, the generated slave elements, the finalizers and the
+recent events and runs.
+`,
+	}
+	TweakCommand(cmd)
+
+	c := &Describe{
+		cmd:      cmd,
+		mainopts: opts,
+	}
+	c.cmd.RunE = func(cmd *cobra.Command, args []string) error { return c.Run(args) }
+	flags := cmd.Flags()
+	flags.StringVarP(&c.output, "output", "o", "", "output format (json, yaml)")
+	flags.IntVarP(&c.limit, "limit", "l", 10, "number of recent events and runs")
+	return cmd
+}
+
+// Description is the complete description of an object.
+type Description struct {
+	Object     Object              `json:"object,omitempty"`
+	Processing *api.DescribeResult `json:"processing,omitempty"`
+}
+
+func (c *Describe) Run(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("type and object required")
+	}
+	if args[0] == "" {
+		return fmt.Errorf("non-empty type required")
+	}
+
+	oid := ObjectIdForArg(c.mainopts, args[0], args[1])
+
+	var d Description
+	o, err := GetObject(c.mainopts, oid)
+	if err != nil {
+		return err
+	}
+	d.Object = o
+
+	u := c.mainopts.GetEngineURL() + path.Join(api.CMD_DESCRIBE, oid.GetType(), oid.GetNamespace(), oid.GetName())
+	r, err := http.Get(fmt.Sprintf("%s?%s=%d", u, api.PARAM_LIMIT, c.limit))
+	if err != nil {
+		return err
+	}
+	data, err := ResponseData(r)
+	if err != nil {
+		if !errors.Is(err, database.ErrNotExist) {
+			return fmt.Errorf("%s: %w", database.StringId(oid), err)
+		}
+		// not yet processed by the engine.
+	} else {
+		var result api.DescribeResult
+		err = json.Unmarshal(data, &result)
+		if err != nil {
+			return err
+		}
+		d.Processing = &result
+	}
+	return PrintDescription(c.cmd.OutOrStdout(), &d, c.output)
+}
+
+func PrintDescription(w io.Writer, d *Description, output string) error {
+	switch strings.ToLower(strings.TrimSpace(output)) {
+	case "":
+		o := d.Object
+		fmt.Fprintf(w, "Object:     %s\n", database.StringId(o))
+		fmt.Fprintf(w, "Generation: %d\n", o.GetGeneration())
+		fmt.Fprintf(w, "Status:     %s\n", o.GetStatusValue())
+		if msg := o.GetStatusMessage(); msg != "" {
+			fmt.Fprintf(w, "Message:    %s\n", msg)
+		}
+		if o.IsDeleting() {
+			fmt.Fprintf(w, "Deleting:   true\n")
+		}
+		if f := o.GetFinalizers(); len(f) > 0 {
+			fmt.Fprintf(w, "Finalizers: %s\n", strings.Join(f, ", "))
+		}
+		if list := o.GetConditions(); len(list) > 0 {
+			fmt.Fprintf(w, "Conditions:\n")
+			for _, c := range list {
+				fmt.Fprintf(w, "  %s: %s (%s) generation %d", c.Type, c.Status, c.Reason, c.ObservedGeneration)
+				if c.Message != "" {
+					fmt.Fprintf(w, ": %s", c.Message)
+				}
+				fmt.Fprintf(w, "\n")
+			}
+		}
+
+		p := d.Processing
+		if p == nil {
+			fmt.Fprintf(w, "\nnot processed by engine\n")
+			return nil
+		}
+		fmt.Fprintf(w, "\nInternal:   %s\n", p.Internal)
+		if len(p.Finalizers) > 0 {
+			fmt.Fprintf(w, "Finalizers: %s\n", strings.Join(p.Finalizers, ", "))
+		}
+		fmt.Fprintf(w, "Phases:\n")
+		for _, ph := range p.Phases {
+			printPhase(w, &ph)
+		}
+		if len(p.Slaves) > 0 {
+			fmt.Fprintf(w, "Slaves:\n")
+			for _, s := range p.Slaves {
+				fmt.Fprintf(w, "  %s\n", s)
+			}
+		}
+		if len(p.Events) > 0 {
+			fmt.Fprintf(w, "\nEvents:\n")
+			err := PrintEvents(w, p.Events, "")
+			if err != nil {
+				return err
+			}
+		}
+		if len(p.Runs) > 0 {
+			fmt.Fprintf(w, "\nRuns:\n")
+			return PrintRuns(w, p.Runs, "")
+		}
+	case "json":
+		data, err := json.Marshal(d)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\n", string(data))
+	case "yaml":
+		data, err := yaml.Marshal(d)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\n", string(data))
+	default:
+		return fmt.Errorf("unknown output format %q", output)
+	}
+	return nil
+}
+
+func printPhase(w io.Writer, ph *api.PhaseDescription) {
+	fmt.Fprintf(w, "  %s\n", ph.Element)
+	fmt.Fprintf(w, "    Status:   %s\n", ph.Status)
+	if ph.RunId != "" {
+		fmt.Fprintf(w, "    Lock:     %s\n", ph.RunId)
+	}
+	if ph.Deletion {
+		fmt.Fprintf(w, "    Deletion: requested\n")
+	}
+	printState(w, "Current", ph.Current)
+	printState(w, "Target", ph.Target)
+	if len(ph.Upstream) > 0 {
+		fmt.Fprintf(w, "    Upstream:\n")
+		printDependencies(w, "      ", ph.Upstream)
+	}
+	if len(ph.Downstream) > 0 {
+		fmt.Fprintf(w, "    Downstream:\n")
+		printDependencies(w, "      ", ph.Downstream)
+	}
+}
+
+func printState(w io.Writer, title string, s *api.StateDescription) {
+	if s == nil {
+		return
+	}
+	fmt.Fprintf(w, "    %s:\n", title)
+	if s.ObjectVersion != "" {
+		fmt.Fprintf(w, "      object: %s\n", s.ObjectVersion)
+	}
+	if s.InputVersion != "" {
+		fmt.Fprintf(w, "      input:  %s\n", s.InputVersion)
+	}
+	if s.OutputVersion != "" {
+		fmt.Fprintf(w, "      output: %s\n", s.OutputVersion)
+	}
+	if s.FormalVersion != "" {
+		fmt.Fprintf(w, "      formal: %s\n", s.FormalVersion)
+	}
+	if len(s.Links) > 0 {
+		fmt.Fprintf(w, "      links:  %s\n", strings.Join(s.Links, ", "))
+	}
+}
+
+func printDependencies(w io.Writer, gap string, deps []api.Dependency) {
+	for i, d := range deps {
+		prefix, next := "├── ", "│   "
+		if i == len(deps)-1 {
+			prefix, next = "└── ", "    "
+		}
+		if d.Status != "" {
+			fmt.Fprintf(w, "%s%s%s (%s)\n", gap, prefix, d.Element, d.Status)
+		} else {
+			fmt.Fprintf(w, "%s%s%s\n", gap, prefix, d.Element)
+		}
+		printDependencies(w, gap+next, d.Dependencies)
+	}
+}
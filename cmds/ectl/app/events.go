@@ -0,0 +1,141 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/mandelsoft/engine/pkg/processing/api"
+	"github.com/mandelsoft/engine/pkg/recorder"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+type Events struct {
+	cmd *cobra.Command
+
+	mainopts *Options
+	output   string
+	object   string
+}
+
+func NewEvents(opts *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "events <options>",
+		Short: "show the recorded events",
+		Long: `
+Show the events recorded for the status transitions of elements.
+By default, the events of all namespaces are shown. With option
+--for only the events for the phases of a dedicated object are
+shown. The type may be an external type or an internal type.
+`,
+	}
+	TweakCommand(cmd)
+
+	c := &Events{
+		cmd:      cmd,
+		mainopts: opts,
+	}
+	c.cmd.RunE = func(cmd *cobra.Command, args []string) error { return c.Run(args) }
+	flags := cmd.Flags()
+	flags.StringVarP(&c.output, "output", "o", "", "output format")
+	flags.StringVarP(&c.object, "for", "", "", "show events for object (<type>/<name>)")
+	return cmd
+}
+
+func (c *Events) Run(args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("no arguments expected")
+	}
+
+	u := c.mainopts.GetEngineURL() + api.CMD_EVENTS
+	if c.object != "" {
+		i := strings.Index(c.object, "/")
+		if i <= 0 || i == len(c.object)-1 {
+			return fmt.Errorf("invalid object %q: <type>/<name> required", c.object)
+		}
+		oid := ObjectIdForArg(c.mainopts, c.object[:i], c.object[i+1:])
+		u += "/" + path.Join(oid.GetType(), oid.GetNamespace(), oid.GetName())
+	} else if c.mainopts.namespace != "" {
+		u += "?" + api.PARAM_NAMESPACE + "=" + url.QueryEscape(c.mainopts.namespace)
+	}
+
+	r, err := http.Get(u)
+	if err != nil {
+		return err
+	}
+	data, err := ResponseData(r)
+	if err != nil {
+		return err
+	}
+
+	var result api.EventList
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		return err
+	}
+	return PrintEvents(c.cmd.OutOrStdout(), result.Events, c.output)
+}
+
+func PrintEvents(w io.Writer, events []recorder.Record, output string) error {
+	switch strings.ToLower(strings.TrimSpace(output)) {
+	case "", "wide":
+		if len(events) == 0 {
+			fmt.Fprintf(w, "no event found\n")
+			return nil
+		}
+		wide := output != ""
+		columnList := []string{"LAST SEEN", "TYPE", "REASON", "OBJECT", "COUNT", "MESSAGE"}
+		if wide {
+			columnList = append(columnList, "FIRST SEEN")
+		}
+		var fieldList [][]string
+		for _, e := range events {
+			l := []string{
+				e.Status.LastTimestamp.Local().Format(time.DateTime), string(e.Spec.Type), e.Spec.Reason,
+				e.Spec.InvolvedObject.String(), fmt.Sprintf("%d", e.Status.Count), e.Spec.Message,
+			}
+			if wide {
+				l = append(l, e.Status.FirstTimestamp.Local().Format(time.DateTime))
+			}
+			fieldList = append(fieldList, l)
+		}
+
+		max := make([]int, len(columnList), len(columnList))
+		for i, s := range columnList {
+			max[i] = len(s)
+		}
+		for _, cols := range fieldList {
+			for i, s := range cols {
+				if max[i] < len(s) {
+					max[i] = len(s)
+				}
+			}
+		}
+		f := formatString(max)
+		printLine(w, columnList, f)
+		for _, cols := range fieldList {
+			printLine(w, cols, f)
+		}
+	case "json":
+		data, err := json.Marshal(events)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\n", string(data))
+	case "yaml":
+		data, err := yaml.Marshal(events)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\n", string(data))
+	default:
+		return fmt.Errorf("unknown output format %q", output)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/processing/api"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+type Explain struct {
+	cmd *cobra.Command
+
+	mainopts *Options
+	output   string
+}
+
+func NewExplain(opts *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "explain <type> <object> [<phase>] <options>",
+		Short: "explain the formal versions of an object",
+		Long: `
+Explain the formal versions of the phases of an object. The composed
+structure of the committed formal version is compared with the one
+of the active run or the one a new run would use based on the
+actual inputs. The differences name the changed input nodes and
+object versions. With output format wide, the composed structures
+are shown, also.
+`,
+	}
+	TweakCommand(cmd)
+
+	c := &Explain{
+		cmd:      cmd,
+		mainopts: opts,
+	}
+	c.cmd.RunE = func(cmd *cobra.Command, args []string) error { return c.Run(args) }
+	flags := cmd.Flags()
+	flags.StringVarP(&c.output, "output", "o", "", "output format")
+	return cmd
+}
+
+func (c *Explain) Run(args []string) error {
+	if len(args) < 2 || len(args) > 3 {
+		return fmt.Errorf("type and object and optional phase required")
+	}
+	if args[0] == "" {
+		return fmt.Errorf("non-empty type required")
+	}
+
+	oid := ObjectIdForArg(c.mainopts, args[0], args[1])
+	u := c.mainopts.GetEngineURL() + path.Join(api.CMD_EXPLAIN, oid.GetType(), oid.GetNamespace(), oid.GetName())
+	if len(args) > 2 {
+		u += "?" + api.PARAM_PHASE + "=" + url.QueryEscape(args[2])
+	}
+	r, err := http.Get(u)
+	if err != nil {
+		return err
+	}
+	data, err := ResponseData(r)
+	if err != nil {
+		return fmt.Errorf("%s: %w", database.StringId(oid), err)
+	}
+
+	var result api.ExplainResult
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		return err
+	}
+	return PrintExplanations(c.cmd.OutOrStdout(), result.Elements, c.output)
+}
+
+func PrintExplanations(w io.Writer, list []api.ElementExplanation, output string) error {
+	switch strings.ToLower(strings.TrimSpace(output)) {
+	case "", "wide":
+		for i, x := range list {
+			if i > 0 {
+				fmt.Fprintf(w, "\n")
+			}
+			fmt.Fprintf(w, "%s\n", x.Element)
+			if output != "" {
+				fmt.Fprintf(w, "  current: %s\n", x.Current)
+				fmt.Fprintf(w, "  target:  %s\n", x.Target)
+			}
+			if len(x.Changes) == 0 {
+				fmt.Fprintf(w, "  no changes\n")
+				continue
+			}
+			fmt.Fprintf(w, "  changes:\n")
+			for _, c := range x.Changes {
+				fmt.Fprintf(w, "  - %s\n", c)
+				if len(c.Path) > 0 {
+					fmt.Fprintf(w, "    via %s\n", strings.Join(c.Path, " -> "))
+				}
+			}
+		}
+	case "json":
+		data, err := json.Marshal(list)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\n", string(data))
+	case "yaml":
+		data, err := yaml.Marshal(list)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\n", string(data))
+	default:
+		return fmt.Errorf("unknown output format %q", output)
+	}
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"path"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/mandelsoft/engine/pkg/database"
 	"github.com/mandelsoft/goutils/sliceutils"
@@ -38,7 +39,7 @@ func NewGet(opts *Options) *cobra.Command {
 	c.cmd.RunE = func(cmd *cobra.Command, args []string) error { return c.Run(args) }
 	flags := cmd.Flags()
 	flags.StringVarP(&c.sort, "sort", "s", "", "sort field")
-	flags.StringVarP(&c.output, "output", "o", "", "output format")
+	flags.StringVarP(&c.output, "output", "o", "", "output format (wide, conditions, json, yaml)")
 	flags.BoolVarP(&c.closure, "closure", "c", false, "namespace closure")
 	return cmd
 }
@@ -105,24 +106,11 @@ func (c *Get) Run(args []string) error {
 		}
 
 		for _, typ := range typlist {
-			req, err := http.NewRequest("LIST", c.mainopts.GetURL()+path.Join(typ, ns), nil)
+			l, err := ListObjects(c.mainopts, typ, ns)
 			if err != nil {
 				return err
 			}
-			r, err := http.DefaultClient.Do(req)
-			if err != nil {
-				return err
-			}
-			data, err := ResponseData(r)
-			if err != nil {
-				return fmt.Errorf("get failed with status code %s", r.Status)
-			}
-			var l List
-			err = json.Unmarshal(data, &l)
-			if err != nil {
-				return err
-			}
-			list = append(list, l.Items...)
+			list = append(list, l...)
 		}
 	}
 
@@ -142,7 +130,11 @@ func (c *Get) Run(args []string) error {
 
 	switch strings.ToLower(strings.TrimSpace(c.output)) {
 	case "":
-		return PrintObjectList(c.cmd.OutOrStdout(), list, RequireTypeField(list), c.sort)
+		return PrintObjectList(c.cmd.OutOrStdout(), list, RequireTypeField(list), false, c.sort)
+	case "wide":
+		return PrintObjectList(c.cmd.OutOrStdout(), list, RequireTypeField(list), true, c.sort)
+	case "conditions":
+		return PrintConditions(c.cmd.OutOrStdout(), list, RequireTypeField(list))
 	case "json":
 		data, err := json.Marshal(elems)
 		if err != nil {
@@ -159,18 +151,21 @@ func (c *Get) Run(args []string) error {
 	return nil
 }
 
-func PrintObjectList(w io.Writer, list []Object, typeField bool, sortField string) error {
+func PrintObjectList(w io.Writer, list []Object, typeField bool, wide bool, sortField string) error {
 	if len(list) == 0 {
 		fmt.Fprintf(w, "no resource found\n")
 		return nil
 	}
-	fieldList := MapFields(list, typeField)
+	fieldList := MapFields(list, typeField, wide)
 	var columnList []string
 	if typeField {
 		columnList = []string{"NAMESPACE", "NAME", "TYPE", "STATUS"}
 	} else {
 		columnList = []string{"NAMESPACE", "NAME", "STATUS"}
 	}
+	if wide {
+		columnList = append(columnList, "MESSAGE")
+	}
 
 	sortField = strings.ToUpper(strings.TrimSpace(sortField))
 	sort := -1
@@ -208,6 +203,54 @@ func PrintObjectList(w io.Writer, list []Object, typeField bool, sortField strin
 	return nil
 }
 
+// PrintConditions prints the conditions of the objects.
+func PrintConditions(w io.Writer, list []Object, typeField bool) error {
+	if len(list) == 0 {
+		fmt.Fprintf(w, "no resource found\n")
+		return nil
+	}
+	columnList := []string{"NAMESPACE", "NAME"}
+	if typeField {
+		columnList = append(columnList, "TYPE")
+	}
+	columnList = append(columnList, "CONDITION", "STATUS", "REASON", "GENERATION", "LAST TRANSITION", "MESSAGE")
+
+	var fieldList [][]string
+	for _, o := range list {
+		for _, c := range o.GetConditions() {
+			l := []string{o.GetNamespace(), o.GetName()}
+			if typeField {
+				l = append(l, o.GetType())
+			}
+			l = append(l, c.Type, string(c.Status), c.Reason, fmt.Sprintf("%d", c.ObservedGeneration),
+				c.LastTransitionTime.Local().Format(time.DateTime), c.Message)
+			fieldList = append(fieldList, l)
+		}
+	}
+	if len(fieldList) == 0 {
+		fmt.Fprintf(w, "no condition found\n")
+		return nil
+	}
+
+	max := make([]int, len(columnList), len(columnList))
+	for i, s := range columnList {
+		max[i] = len(s)
+	}
+	for _, cols := range fieldList {
+		for i, s := range cols {
+			if max[i] < len(s) {
+				max[i] = len(s)
+			}
+		}
+	}
+	f := formatString(max)
+	printLine(w, columnList, f)
+	for _, cols := range fieldList {
+		printLine(w, cols, f)
+	}
+	return nil
+}
+
 func printLine(w io.Writer, cols []string, msg string) {
 	fmt.Fprintf(w, "%s\n", strings.TrimRight(fmt.Sprintf(msg, sliceutils.Convert[any](cols)...), " "))
 }
@@ -220,7 +263,7 @@ func formatString(max []int) string {
 	return msg[:len(msg)-1]
 }
 
-func MapFields(list []Object, typeField bool) [][]string {
+func MapFields(list []Object, typeField bool, wide bool) [][]string {
 	var r [][]string
 	for _, o := range list {
 		var l []string
@@ -242,6 +285,9 @@ func MapFields(list []Object, typeField bool) [][]string {
 				o.GetNamespace(), o.GetName(), s,
 			}
 		}
+		if wide {
+			l = append(l, o.GetStatusMessage())
+		}
 		r = append(r, l)
 	}
 	return r
@@ -266,6 +312,9 @@ func GetObject(opts *Options, id database.ObjectId) (Object, error) {
 		return nil, fmt.Errorf("%s: %w", database.NewObjectRefFor(id), err)
 	}
 	data, err := ResponseData(get)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", database.NewObjectRefFor(id), err)
+	}
 
 	var o Object
 	err = json.Unmarshal(data, &o)
@@ -274,3 +323,26 @@ func GetObject(opts *Options, id database.ObjectId) (Object, error) {
 	}
 	return o, nil
 }
+
+// ListObjects lists the objects of a type in a namespace.
+// A trailing * for the namespace describes the namespace closure.
+func ListObjects(opts *Options, typ string, ns string) ([]Object, error) {
+	req, err := http.NewRequest("LIST", opts.GetURL()+path.Join(typ, ns), nil)
+	if err != nil {
+		return nil, err
+	}
+	r, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ResponseData(r)
+	if err != nil {
+		return nil, fmt.Errorf("get failed with status code %s", r.Status)
+	}
+	var l List
+	err = json.Unmarshal(data, &l)
+	if err != nil {
+		return nil, err
+	}
+	return l.Items, nil
+}
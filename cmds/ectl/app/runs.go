@@ -0,0 +1,201 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/history"
+	"github.com/mandelsoft/engine/pkg/processing/api"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+type Runs struct {
+	cmd *cobra.Command
+
+	mainopts *Options
+	output   string
+}
+
+func NewRuns(opts *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "runs <type> <object> [<phase>] <options>",
+		Short: "show the recorded runs of an object",
+		Long: `
+Show the recorded runs for the phases of an object. The type may be
+an external type or an internal type. If no phase is given, the
+runs of all phases of the object are shown.
+`,
+	}
+	TweakCommand(cmd)
+
+	c := &Runs{
+		cmd:      cmd,
+		mainopts: opts,
+	}
+	c.cmd.RunE = func(cmd *cobra.Command, args []string) error { return c.Run(args) }
+	flags := cmd.Flags()
+	flags.StringVarP(&c.output, "output", "o", "", "output format")
+	return cmd
+}
+
+func (c *Runs) Run(args []string) error {
+	if len(args) < 2 || len(args) > 3 {
+		return fmt.Errorf("type and object and optional phase required")
+	}
+	if args[0] == "" {
+		return fmt.Errorf("non-empty type required")
+	}
+
+	oid := ObjectIdForArg(c.mainopts, args[0], args[1])
+	u := c.mainopts.GetEngineURL() + path.Join(api.CMD_RUNS, oid.GetType(), oid.GetNamespace(), oid.GetName())
+	if len(args) > 2 {
+		u += "?" + api.PARAM_PHASE + "=" + url.QueryEscape(args[2])
+	}
+	runs, err := getRuns(u)
+	if err != nil {
+		return fmt.Errorf("%s: %w", database.StringId(oid), err)
+	}
+	return PrintRuns(c.cmd.OutOrStdout(), runs, c.output)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type Run struct {
+	cmd *cobra.Command
+
+	mainopts *Options
+	output   string
+}
+
+func NewRun(opts *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "run <runid> <options>",
+		Short: "show the recorded element runs of a run",
+		Long: `
+Show the records of all elements processed by a run, including
+the triggering object version, the used inputs, the resulting
+output version and the status transitions. By default, the
+runs are searched in all namespaces.
+`,
+	}
+	TweakCommand(cmd)
+
+	c := &Run{
+		cmd:      cmd,
+		mainopts: opts,
+	}
+	c.cmd.RunE = func(cmd *cobra.Command, args []string) error { return c.Run(args) }
+	flags := cmd.Flags()
+	flags.StringVarP(&c.output, "output", "o", "yaml", "output format")
+	return cmd
+}
+
+func (c *Run) Run(args []string) error {
+	if len(args) != 1 || args[0] == "" {
+		return fmt.Errorf("run id required")
+	}
+
+	u := c.mainopts.GetEngineURL() + path.Join(api.CMD_RUN, args[0])
+	if c.mainopts.namespace != "" {
+		u += "?" + api.PARAM_NAMESPACE + "=" + url.QueryEscape(c.mainopts.namespace)
+	}
+	runs, err := getRuns(u)
+	if err != nil {
+		return fmt.Errorf("run %s: %w", args[0], err)
+	}
+	return PrintRuns(c.cmd.OutOrStdout(), runs, c.output)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func getRuns(u string) ([]history.Record, error) {
+	r, err := http.Get(u)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ResponseData(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result api.RunList
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result.Runs, nil
+}
+
+func PrintRuns(w io.Writer, runs []history.Record, output string) error {
+	switch strings.ToLower(strings.TrimSpace(output)) {
+	case "", "wide":
+		if len(runs) == 0 {
+			fmt.Fprintf(w, "no run found\n")
+			return nil
+		}
+		wide := output != ""
+		columnList := []string{"RUNID", "ELEMENT", "STATUS", "TRIGGER", "STARTED", "DURATION"}
+		if wide {
+			columnList = append(columnList, "OUTPUT", "MESSAGE")
+		}
+		var fieldList [][]string
+		for _, r := range runs {
+			trigger := ""
+			if r.Spec.Trigger != nil {
+				trigger = r.Spec.Trigger.Type + ":" + r.Spec.Trigger.Version
+			}
+			duration := ""
+			if r.Status.EndTime != nil {
+				duration = r.Status.EndTime.Sub(r.Status.StartTime).Round(time.Millisecond).String()
+			}
+			l := []string{
+				r.Spec.RunId, r.Spec.Element(), r.Status.Status, trigger,
+				r.Status.StartTime.Local().Format(time.DateTime), duration,
+			}
+			if wide {
+				l = append(l, r.Status.OutputVersion, r.Status.Message)
+			}
+			fieldList = append(fieldList, l)
+		}
+
+		max := make([]int, len(columnList), len(columnList))
+		for i, s := range columnList {
+			max[i] = len(s)
+		}
+		for _, cols := range fieldList {
+			for i, s := range cols {
+				if max[i] < len(s) {
+					max[i] = len(s)
+				}
+			}
+		}
+		f := formatString(max)
+		printLine(w, columnList, f)
+		for _, cols := range fieldList {
+			printLine(w, cols, f)
+		}
+	case "json":
+		data, err := json.Marshal(runs)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\n", string(data))
+	case "yaml":
+		data, err := yaml.Marshal(runs)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\n", string(data))
+	default:
+		return fmt.Errorf("unknown output format %q", output)
+	}
+	return nil
+}
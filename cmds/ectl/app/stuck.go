@@ -0,0 +1,108 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mandelsoft/engine/pkg/processing/api"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+type Stuck struct {
+	cmd *cobra.Command
+
+	mainopts  *Options
+	output    string
+	threshold time.Duration
+}
+
+func NewStuck(opts *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stuck <options>",
+		Short: "show objects stuck in deletion",
+		Long: `
+Show the objects deleting longer than the given threshold together
+with their remaining finalizers, the controllers owning them and the
+active runs of the elements of the objects. The deletion of such
+objects can be enforced with delete --force.
+`,
+	}
+	TweakCommand(cmd)
+
+	c := &Stuck{
+		cmd:      cmd,
+		mainopts: opts,
+	}
+	c.cmd.RunE = func(cmd *cobra.Command, args []string) error { return c.Run(args) }
+	flags := cmd.Flags()
+	flags.StringVarP(&c.output, "output", "o", "", "output format (json, yaml)")
+	flags.DurationVarP(&c.threshold, "threshold", "t", 5*time.Minute, "minimal duration of the deletion")
+	return cmd
+}
+
+func (c *Stuck) Run(args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("no arguments expected")
+	}
+
+	u := c.mainopts.GetEngineURL() + api.CMD_STUCK + "?" + api.PARAM_THRESHOLD + "=" + url.QueryEscape(c.threshold.String())
+	r, err := http.Get(u)
+	if err != nil {
+		return err
+	}
+	data, err := ResponseData(r)
+	if err != nil {
+		return err
+	}
+
+	var result api.StuckResult
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		return err
+	}
+	return PrintStuck(c.cmd.OutOrStdout(), result.Objects, c.output)
+}
+
+func PrintStuck(w io.Writer, list []api.StuckObject, output string) error {
+	switch strings.ToLower(strings.TrimSpace(output)) {
+	case "":
+		if len(list) == 0 {
+			fmt.Fprintf(w, "no stuck deletion found\n")
+			return nil
+		}
+		for _, o := range list {
+			fmt.Fprintf(w, "%s: deleting since %s (%s)\n", o.Object, o.DeletionTime.Local().Format(time.DateTime), time.Since(o.DeletionTime).Round(time.Second))
+			for _, f := range o.Finalizers {
+				controller := f.Controller
+				if controller == "" {
+					controller = "unknown controller"
+				}
+				fmt.Fprintf(w, "  finalizer %s (%s)\n", f.Name, controller)
+			}
+			for _, l := range o.Locks {
+				fmt.Fprintf(w, "  element %s locked by run %s\n", l.Element, l.RunId)
+			}
+		}
+	case "json":
+		data, err := json.Marshal(list)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\n", string(data))
+	case "yaml":
+		data, err := yaml.Marshal(list)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\n", string(data))
+	default:
+		return fmt.Errorf("unknown output format %q", output)
+	}
+	return nil
+}
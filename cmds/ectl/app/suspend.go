@@ -0,0 +1,108 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/spf13/cobra"
+)
+
+type Suspend struct {
+	cmd *cobra.Command
+
+	mainopts *Options
+	suspend  bool
+}
+
+func NewSuspend(opts *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "suspend <type> {<object>}",
+		Short: "suspend processing for objects",
+		Long: `
+Suspend the processing for external objects or namespace objects.
+No new runs are initiated for elements triggered by a suspended object
+or located in a suspended namespace. Active runs are finished.
+`,
+	}
+	return newSuspend(cmd, opts, true)
+}
+
+func NewResume(opts *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "resume <type> {<object>}",
+		Short: "resume processing for objects",
+		Long: `
+Resume the processing for suspended external objects or namespace objects.
+Elements suspended by those objects are retriggered.
+`,
+	}
+	return newSuspend(cmd, opts, false)
+}
+
+func newSuspend(cmd *cobra.Command, opts *Options, suspend bool) *cobra.Command {
+	TweakCommand(cmd)
+
+	c := &Suspend{
+		cmd:      cmd,
+		mainopts: opts,
+		suspend:  suspend,
+	}
+	c.cmd.RunE = func(cmd *cobra.Command, args []string) error { return c.Run(args) }
+	return cmd
+}
+
+func (c *Suspend) Run(args []string) error {
+	var cmderr error
+
+	if len(args) < 2 {
+		return fmt.Errorf("type and at least one object required")
+	}
+	if args[0] == "" {
+		return fmt.Errorf("non-empty type required")
+	}
+
+	multi := len(args) > 2
+	for i, a := range args[1:] {
+		oid := ObjectIdForArg(c.mainopts, args[0], a)
+		msg, err := c.update(oid)
+		if err != nil {
+			cmderr = IndexError(c.cmd, multi, i+1, database.StringId(oid), "update failed", err)
+			continue
+		}
+		fmt.Fprintf(c.cmd.OutOrStdout(), "%s: %s\n", database.StringId(oid), msg)
+	}
+	return cmderr
+}
+
+func (c *Suspend) update(oid database.ObjectId) (string, error) {
+	state := "resumed"
+	if c.suspend {
+		state = "suspended"
+	}
+
+	obj, err := GetObject(c.mainopts, oid)
+	if err != nil {
+		return "", err
+	}
+	if !obj.SetSuspended(c.suspend) {
+		return "already " + state, nil
+	}
+
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+	post, err := http.Post(c.mainopts.GetURL()+path.Join(oid.GetType(), oid.GetNamespace(), oid.GetName()), "application/json", bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	_, err = ResponseData(post)
+	if err != nil {
+		return "", err
+	}
+	return state, nil
+}
@@ -0,0 +1,196 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/mandelsoft/engine/pkg/processing/api"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+type Models struct {
+	cmd *cobra.Command
+
+	mainopts *Options
+	output   string
+}
+
+func NewModels(opts *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "models <options>",
+		Short: "show the models hosted by the engine",
+		Long: `
+Show the models hosted by the engine. A model can be selected
+for all other commands with the main option --model. Without
+this option, the default model of the engine is used.
+`,
+	}
+	TweakCommand(cmd)
+
+	c := &Models{
+		cmd:      cmd,
+		mainopts: opts,
+	}
+	c.cmd.RunE = func(cmd *cobra.Command, args []string) error { return c.Run(args) }
+	flags := cmd.Flags()
+	flags.StringVarP(&c.output, "output", "o", "", "output format (json, yaml)")
+	return cmd
+}
+
+func (c *Models) Run(args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("no arguments expected")
+	}
+
+	r, err := http.Get(c.mainopts.GetModelsURL())
+	if err != nil {
+		return err
+	}
+	data, err := ResponseData(r)
+	if err != nil {
+		return err
+	}
+
+	var result api.ModelList
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		return err
+	}
+	return PrintModels(c.cmd.OutOrStdout(), &result, c.output)
+}
+
+func PrintModels(w io.Writer, list *api.ModelList, output string) error {
+	switch strings.ToLower(strings.TrimSpace(output)) {
+	case "":
+		for _, m := range list.Models {
+			if m == list.Default {
+				fmt.Fprintf(w, "%s (default)\n", m)
+			} else {
+				fmt.Fprintf(w, "%s\n", m)
+			}
+		}
+	case "json":
+		data, err := json.Marshal(list)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\n", string(data))
+	case "yaml":
+		data, err := yaml.Marshal(list)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\n", string(data))
+	default:
+		return fmt.Errorf("unknown output format %q", output)
+	}
+	return nil
+}
+
+type Types struct {
+	cmd *cobra.Command
+
+	mainopts *Options
+	output   string
+}
+
+func NewTypes(opts *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "types <options>",
+		Short: "show the types of a model",
+		Long: `
+Show the types of the metamodel of the model selected with the
+main option --model. External types are shown together with
+the phase triggered by them, internal types with their phases.
+`,
+	}
+	TweakCommand(cmd)
+
+	c := &Types{
+		cmd:      cmd,
+		mainopts: opts,
+	}
+	c.cmd.RunE = func(cmd *cobra.Command, args []string) error { return c.Run(args) }
+	flags := cmd.Flags()
+	flags.StringVarP(&c.output, "output", "o", "", "output format (json, yaml)")
+	return cmd
+}
+
+func (c *Types) Run(args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("no arguments expected")
+	}
+
+	r, err := http.Get(c.mainopts.GetEngineURL() + api.CMD_TYPES)
+	if err != nil {
+		return err
+	}
+	data, err := ResponseData(r)
+	if err != nil {
+		return err
+	}
+
+	var result api.TypesResult
+	err = json.Unmarshal(data, &result)
+	if err != nil {
+		return err
+	}
+	return PrintTypes(c.cmd.OutOrStdout(), &result, c.output)
+}
+
+func PrintTypes(w io.Writer, types *api.TypesResult, output string) error {
+	switch strings.ToLower(strings.TrimSpace(output)) {
+	case "":
+		fmt.Fprintf(w, "Model:          %s\n", types.Model)
+		fmt.Fprintf(w, "Namespace type: %s\n", types.NamespaceType)
+		if types.UpdateRequestType != "" {
+			fmt.Fprintf(w, "Update request: %s\n", types.UpdateRequestType)
+		}
+		fmt.Fprintf(w, "\n")
+
+		columnList := []string{"TYPE", "KIND", "PHASES"}
+		var fieldList [][]string
+		for _, t := range types.ExternalTypes {
+			fieldList = append(fieldList, []string{t.Name, "external", t.Trigger})
+		}
+		for _, t := range types.InternalTypes {
+			fieldList = append(fieldList, []string{t.Name, "internal", strings.Join(t.Phases, ", ")})
+		}
+
+		max := make([]int, len(columnList), len(columnList))
+		for i, s := range columnList {
+			max[i] = len(s)
+		}
+		for _, cols := range fieldList {
+			for i, s := range cols {
+				if max[i] < len(s) {
+					max[i] = len(s)
+				}
+			}
+		}
+		f := formatString(max)
+		printLine(w, columnList, f)
+		for _, cols := range fieldList {
+			printLine(w, cols, f)
+		}
+	case "json":
+		data, err := json.Marshal(types)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\n", string(data))
+	case "yaml":
+		data, err := yaml.Marshal(types)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\n", string(data))
+	default:
+		return fmt.Errorf("unknown output format %q", output)
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/processing/updaterequest"
+	"github.com/spf13/cobra"
+)
+
+// DEFAULT_UPDATEREQUEST_TYPE is the default type name
+// used for update requests.
+const DEFAULT_UPDATEREQUEST_TYPE = "UpdateRequest"
+
+// UpdateOptions describes the options used to execute
+// update requests.
+type UpdateOptions struct {
+	typ     string
+	lease   time.Duration
+	timeout time.Duration
+}
+
+func (o *UpdateOptions) AddFlags(cmd *cobra.Command, lease bool) {
+	flags := cmd.Flags()
+	flags.StringVarP(&o.typ, "request-type", "", DEFAULT_UPDATEREQUEST_TYPE, "type of update requests")
+	flags.DurationVarP(&o.timeout, "timeout", "", 5*time.Minute, "timeout for waiting for the request status")
+	if lease {
+		flags.DurationVarP(&o.lease, "lease", "", 0, "lease for the locks held by the request")
+	}
+}
+
+func (o *UpdateOptions) Client(opts *Options, name string) *updaterequest.Client {
+	id := ObjectIdForArg(opts, o.typ, name)
+	return updaterequest.New(updaterequest.ForService(opts.GetURL()), id.GetType(), id.GetNamespace(), id.GetName()).SetLease(o.lease)
+}
+
+func (o *UpdateOptions) Context() (context.Context, context.CancelFunc) {
+	if o.timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), o.timeout)
+}
+
+func NewUpdate(opts *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "update <cmd>",
+		Short: "execute update requests",
+		Long: `
+Execute a transaction for a set of objects using an update request.
+With "begin" the elements triggered by the given objects are locked.
+Afterwards, the objects can be modified (for example with "apply")
+without being processed. "commit" releases the locks, so that all
+modifications are processed together. "abort" releases the locks
+without waiting for the processing.
+
+With option --lease the engine automatically releases the locks, if
+the transaction is not committed in time.
+`,
+	}
+	TweakCommand(cmd)
+	cmd.AddCommand(NewUpdateBegin(opts))
+	cmd.AddCommand(NewUpdateCommit(opts))
+	cmd.AddCommand(NewUpdateAbort(opts))
+	return cmd
+}
+
+type UpdateBegin struct {
+	cmd *cobra.Command
+
+	mainopts *Options
+	opts     UpdateOptions
+	objects  []string
+}
+
+func NewUpdateBegin(opts *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "begin <request> --objects <type>/<name>,...",
+		Short: "begin a transaction",
+		Long: `
+Create an update request and wait until the elements triggered
+by the given objects are locked. The objects must be located in the
+namespace of the request.
+`,
+	}
+	TweakCommand(cmd)
+
+	c := &UpdateBegin{
+		cmd:      cmd,
+		mainopts: opts,
+	}
+	c.cmd.RunE = func(cmd *cobra.Command, args []string) error { return c.Run(args) }
+	c.opts.AddFlags(cmd, true)
+	cmd.Flags().StringSliceVarP(&c.objects, "objects", "", nil, "objects to lock (<type>/<name>)")
+	return cmd
+}
+
+func (c *UpdateBegin) Run(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("request name required")
+	}
+	var refs []database.LocalObjectRef
+	for _, o := range c.objects {
+		i := strings.Index(o, "/")
+		if i <= 0 || i == len(o)-1 || strings.Count(o, "/") > 1 {
+			return fmt.Errorf("invalid object %q: <type>/<name> required", o)
+		}
+		refs = append(refs, database.NewLocalObjectRef(o[:i], o[i+1:]))
+	}
+	if len(refs) == 0 {
+		return fmt.Errorf("at least one object required")
+	}
+
+	ctx, cancel := c.opts.Context()
+	defer cancel()
+	client := c.opts.Client(c.mainopts, args[0])
+	err := client.Begin(ctx, refs...)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.cmd.OutOrStdout(), "%s: locked\n", database.NewObjectRefFor(client.Id()))
+	return nil
+}
+
+type UpdateCommit struct {
+	cmd *cobra.Command
+
+	mainopts *Options
+	opts     UpdateOptions
+	abort    bool
+}
+
+func NewUpdateCommit(opts *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "commit <request>",
+		Short: "commit a transaction",
+		Long: `
+Release the locks of an update request and wait until the objects
+are handed over to the processing. Afterwards, the request is deleted.
+`,
+	}
+	return newUpdateCommit(cmd, opts, false)
+}
+
+func NewUpdateAbort(opts *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "abort <request>",
+		Short: "abort a transaction",
+		Long: `
+Delete an update request. This releases its locks without waiting
+for the objects to be processed.
+`,
+	}
+	return newUpdateCommit(cmd, opts, true)
+}
+
+func newUpdateCommit(cmd *cobra.Command, opts *Options, abort bool) *cobra.Command {
+	TweakCommand(cmd)
+
+	c := &UpdateCommit{
+		cmd:      cmd,
+		mainopts: opts,
+		abort:    abort,
+	}
+	c.cmd.RunE = func(cmd *cobra.Command, args []string) error { return c.Run(args) }
+	c.opts.AddFlags(cmd, false)
+	return cmd
+}
+
+func (c *UpdateCommit) Run(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("request name required")
+	}
+
+	client := c.opts.Client(c.mainopts, args[0])
+	if c.abort {
+		err := client.Abort()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.cmd.OutOrStdout(), "%s: aborted\n", database.NewObjectRefFor(client.Id()))
+		return nil
+	}
+
+	ctx, cancel := c.opts.Context()
+	defer cancel()
+	err := client.Commit(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.cmd.OutOrStdout(), "%s: committed\n", database.NewObjectRefFor(client.Id()))
+	return nil
+}
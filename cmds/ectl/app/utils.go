@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
 	"github.com/mandelsoft/engine/pkg/database"
 	"github.com/mandelsoft/engine/pkg/database/service"
@@ -43,3 +44,24 @@ func TweakCommand(cmd *cobra.Command) {
 	cmd.SilenceUsage = true
 	cmd.TraverseChildren = true
 }
+
+// ObjectIdForArg provides the object id for an object argument
+// relative to the namespace given by the main options.
+// A leading / describes an absolute namespace.
+func ObjectIdForArg(opts *Options, typ string, arg string) database.ObjectId {
+	ns := opts.namespace
+	for strings.HasPrefix(arg, "/") {
+		ns = ""
+		arg = arg[1:]
+	}
+	i := strings.LastIndex(arg, "/")
+	if i > 0 {
+		if ns != "" {
+			ns = ns + "/" + arg[:i]
+		} else {
+			ns = arg[:i]
+		}
+		arg = arg[i+1:]
+	}
+	return database.NewObjectId(typ, ns, arg)
+}
@@ -0,0 +1,294 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/processing/model"
+	"github.com/mandelsoft/engine/pkg/processing/model/support/db"
+	elemwatch "github.com/mandelsoft/engine/pkg/processing/watch"
+	"github.com/mandelsoft/engine/pkg/watch"
+	"github.com/spf13/cobra"
+)
+
+const (
+	WAIT_STATUS    = "status"
+	WAIT_CONDITION = "condition"
+	WAIT_DELETE    = "delete"
+)
+
+type Wait struct {
+	cmd *cobra.Command
+
+	mainopts  *Options
+	condition string
+	closure   bool
+	timeout   time.Duration
+	interval  time.Duration
+}
+
+func NewWait(opts *Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "wait <type> {<object>} --for=status=<status>|condition=<condition>[=<status>]|delete",
+		Short: "wait for objects to reach a status, a condition or to be deleted",
+		Long: `
+Wait until the given objects reach a status, report a condition with the
+expected status (default True) or are deleted. If no object is given, all
+objects of the given type in the namespace (or the namespace closure with
+option --closure) are awaited.
+
+Only states observed by the engine for the generation of the object found
+when starting to wait are accepted, therefore the command does not return
+on outdated states of a modified object. States observed for the actual
+content of the object are accepted, too. The generation and content digest
+observed by the engine are reported with the conditions maintained for
+every phase and the overall Ready condition.
+
+The command fails, if an object reaches the status Failed or Invalid.
+The engine watch is used to get notified about changes, additionally
+the objects are polled with the given interval.
+`,
+	}
+	TweakCommand(cmd)
+
+	c := &Wait{
+		cmd:      cmd,
+		mainopts: opts,
+	}
+	c.cmd.RunE = func(cmd *cobra.Command, args []string) error { return c.Run(args) }
+	flags := cmd.Flags()
+	flags.StringVarP(&c.condition, "for", "", "condition="+model.CONDITION_READY, "wait condition (status=<status>, condition=<condition>[=<status>] or delete)")
+	flags.BoolVarP(&c.closure, "closure", "c", false, "namespace closure")
+	flags.DurationVarP(&c.timeout, "timeout", "", 5*time.Minute, "timeout")
+	flags.DurationVarP(&c.interval, "interval", "", 5*time.Second, "poll interval")
+	return cmd
+}
+
+func (c *Wait) Run(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("type required")
+	}
+	spec, err := ParseWaitSpec(c.condition)
+	if err != nil {
+		return err
+	}
+
+	// generations found when starting to wait.
+	pending := map[database.ObjectId]int64{}
+	if len(args) > 1 {
+		for _, a := range args[1:] {
+			id := database.NewObjectIdFor(ObjectIdForArg(c.mainopts, args[0], a))
+			pending[id] = 0
+			o, err := GetObject(c.mainopts, id)
+			if err == nil {
+				pending[id] = o.GetGeneration()
+			}
+		}
+	} else {
+		ns := c.mainopts.namespace
+		if c.closure {
+			ns += "*"
+		}
+		list, err := ListObjects(c.mainopts, args[0], ns)
+		if err != nil {
+			return err
+		}
+		for _, o := range list {
+			pending[database.NewObjectIdFor(o)] = o.GetGeneration()
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	trigger := make(chan struct{}, 1)
+	c.watch(ctx, trigger)
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	var errs []error
+	for {
+		for id, gen := range pending {
+			done, err := spec.Check(c.mainopts, id, gen)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", database.NewObjectRefFor(id), err))
+				delete(pending, id)
+				continue
+			}
+			if done {
+				fmt.Fprintf(c.cmd.OutOrStdout(), "%s: %s\n", database.NewObjectRefFor(id), spec)
+				delete(pending, id)
+			}
+		}
+		if len(pending) == 0 {
+			return errors.Join(errs...)
+		}
+		select {
+		case <-ctx.Done():
+			for id := range pending {
+				errs = append(errs, fmt.Errorf("%s: timeout waiting for %s", database.NewObjectRefFor(id), spec))
+			}
+			return errors.Join(errs...)
+		case <-trigger:
+		case <-ticker.C:
+		}
+	}
+}
+
+// watch registers a watch for the engine processing
+// in the namespace closure. Every event triggers a new
+// check. If the watch cannot be established, the
+// objects are just polled.
+func (c *Wait) watch(ctx context.Context, trigger chan struct{}) {
+	a, err := c.mainopts.GetWatchURL()
+	if err != nil {
+		return
+	}
+	client := watch.NewClient[elemwatch.Request, elemwatch.Event](a)
+	_, err = client.Register(ctx, elemwatch.Request{Namespace: c.mainopts.namespace, Flat: !c.closure}, triggerHandler(trigger))
+	if err != nil {
+		fmt.Fprintf(c.cmd.ErrOrStderr(), "watch not possible (%s): polling objects\n", err)
+	}
+}
+
+type triggerHandler chan struct{}
+
+func (h triggerHandler) HandleEvent(e elemwatch.Event) {
+	select {
+	case h <- struct{}{}:
+	default:
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// WaitSpec describes the state of an object to wait for.
+type WaitSpec struct {
+	Kind      string
+	Condition string
+	Status    string
+}
+
+// ParseWaitSpec parses a wait specification of the
+// form status=<status>, condition=<type>[=<status>] or delete.
+func ParseWaitSpec(spec string) (*WaitSpec, error) {
+	if spec == WAIT_DELETE {
+		return &WaitSpec{Kind: WAIT_DELETE}, nil
+	}
+	kind, s, _ := strings.Cut(spec, "=")
+	switch kind {
+	case WAIT_STATUS:
+		if s == "" {
+			return nil, fmt.Errorf("invalid wait condition %q: status=<status> required", spec)
+		}
+		return &WaitSpec{Kind: WAIT_STATUS, Status: s}, nil
+	case WAIT_CONDITION:
+		typ, status, err := ParseConditionSpec(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid wait condition %q: %w", spec, err)
+		}
+		return &WaitSpec{Kind: WAIT_CONDITION, Condition: typ, Status: string(status)}, nil
+	}
+	return nil, fmt.Errorf("invalid wait condition %q: status=<status>, condition=<condition>[=<status>] or delete required", spec)
+}
+
+func (s *WaitSpec) String() string {
+	switch s.Kind {
+	case WAIT_DELETE:
+		return "deleted"
+	case WAIT_CONDITION:
+		return fmt.Sprintf("condition %s=%s", s.Condition, s.Status)
+	default:
+		return fmt.Sprintf("status %s", s.Status)
+	}
+}
+
+// Check checks whether the object has reached the awaited state.
+// Only states observed by the engine for at least the given generation
+// are considered. An error is returned if the object reached
+// a failure state.
+func (s *WaitSpec) Check(opts *Options, id database.ObjectId, gen int64) (bool, error) {
+	o, err := GetObject(opts, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotExist) {
+			if s.Kind == WAIT_DELETE {
+				return true, nil
+			}
+			return false, nil
+		}
+		return false, err
+	}
+	if s.Kind == WAIT_DELETE || !Observed(o, gen) {
+		return false, nil
+	}
+
+	status := o.GetStatusValue()
+	switch s.Kind {
+	case WAIT_STATUS:
+		if status == s.Status {
+			return true, nil
+		}
+	case WAIT_CONDITION:
+		if ConditionMet(o, s.Condition, model.ConditionStatus(s.Status)) {
+			return true, nil
+		}
+	}
+	if status == string(model.STATUS_FAILED) || status == string(model.STATUS_INVALID) {
+		msg := o.GetStatusMessage()
+		if msg != "" {
+			return false, fmt.Errorf("status %s: %s", status, msg)
+		}
+		return false, fmt.Errorf("status %s", status)
+	}
+	return false, nil
+}
+
+// Observed checks whether the state of an object has been
+// observed by the engine for at least the given generation
+// or for the actual content of the object.
+// Objects without conditions provide no information about the
+// observed generation, their state is always considered as observed.
+func Observed(o Object, gen int64) bool {
+	conditions := o.GetConditions()
+	if len(conditions) == 0 {
+		return true
+	}
+	c := conditions.Get(model.CONDITION_READY)
+	if c == nil {
+		return false
+	}
+	return c.ObservedGeneration >= gen || (c.ObservedDigest != "" && c.ObservedDigest == db.ContentDigest(o))
+}
+
+// ParseConditionSpec parses a condition specification of the
+// form <type>[=<status>].
+func ParseConditionSpec(spec string) (string, model.ConditionStatus, error) {
+	if spec == "" {
+		return "", "", fmt.Errorf("condition type required")
+	}
+	typ, status, ok := strings.Cut(spec, "=")
+	if !ok {
+		return typ, model.CONDITION_TRUE, nil
+	}
+	switch strings.ToLower(status) {
+	case "true":
+		return typ, model.CONDITION_TRUE, nil
+	case "false":
+		return typ, model.CONDITION_FALSE, nil
+	case "unknown":
+		return typ, model.CONDITION_UNKNOWN, nil
+	}
+	return "", "", fmt.Errorf("invalid condition status %q", status)
+}
+
+// ConditionMet checks whether an object reports a condition
+// with the given status.
+func ConditionMet(o Object, typ string, status model.ConditionStatus) bool {
+	c := o.GetConditions().Get(typ)
+	return c != nil && c.Status == status
+}
@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"net/url"
 
 	elemwatch "github.com/mandelsoft/engine/pkg/processing/watch"
 	"github.com/mandelsoft/engine/pkg/watch"
@@ -38,13 +37,9 @@ func NewWatch(opts *Options) *cobra.Command {
 
 func (c *Watch) Run(args []string) error {
 
-	u, err := url.Parse(c.mainopts.GetURL())
+	a, err := c.mainopts.GetWatchURL()
 	if err != nil {
-		return fmt.Errorf("invalid url: %w", err)
-	}
-	scheme := "ws"
-	if u.Scheme == "https" {
-		scheme = "wss"
+		return err
 	}
 
 	ns := c.mainopts.namespace
@@ -55,8 +50,6 @@ func (c *Watch) Run(args []string) error {
 		ns = args[0]
 	}
 
-	a := fmt.Sprintf("%s://%s/watch", scheme, u.Host)
-
 	s, err := Consume(c.cmd.OutOrStdout(), a, c.closure, ns)
 	if err != nil {
 		return err
@@ -4,19 +4,26 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	dbservice "github.com/mandelsoft/engine/pkg/database/service"
+	leader "github.com/mandelsoft/engine/pkg/election"
+	"github.com/mandelsoft/engine/pkg/history"
 	"github.com/mandelsoft/engine/pkg/impl/database/filesystem"
-	"github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub"
-	"github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub/controllers"
+	"github.com/mandelsoft/engine/pkg/metrics"
+	"github.com/mandelsoft/engine/pkg/notification"
 	"github.com/mandelsoft/engine/pkg/processing/model"
 	"github.com/mandelsoft/engine/pkg/processing/model/support/db"
 	"github.com/mandelsoft/engine/pkg/processing/objectbase"
 	"github.com/mandelsoft/engine/pkg/processing/processor"
 	elemwatch "github.com/mandelsoft/engine/pkg/processing/watch"
+	"github.com/mandelsoft/engine/pkg/recorder"
 	"github.com/mandelsoft/engine/pkg/server"
 	"github.com/mandelsoft/engine/pkg/service"
+	"github.com/mandelsoft/engine/pkg/sharding"
+	"github.com/mandelsoft/engine/pkg/tracing"
 	"github.com/mandelsoft/engine/pkg/version"
 	"github.com/mandelsoft/engine/pkg/watch"
 	"github.com/mandelsoft/logging"
@@ -36,22 +43,66 @@ func main() {
 	var files string
 	var level string = "info"
 	var delay time.Duration
+	var election bool
+	var identity string
+	var leaseDuration time.Duration = leader.DEFAULT_LEASE_DURATION
+	var shards bool
+	var shardNamespaces []string
+	var endpoint string
+	var traceFile string
+	var runHistory bool
+	var retention = history.Retention{MaxRuns: history.DEFAULT_MAX_RUNS}
+	var orphanScan = processor.DEFAULT_ORPHAN_SCAN_INTERVAL
+	var orphanDeletion bool
+	var events bool
+	var eventTTL = recorder.DEFAULT_TTL
+	var modelArgs = []string{"expression"}
+	var notifications bool
+	var notificationOpts = notification.Options{RetryInterval: notification.DEFAULT_RETRY_INTERVAL}
+	var forceDeletion bool
 
 	flags := pflag.NewFlagSet("engine", pflag.ExitOnError)
 
 	flags.IntVarP(&port, "port", "p", 8080, "server port")
+	flags.StringArrayVarP(&modelArgs, "model", "m", modelArgs, fmt.Sprintf("hosted model <name>[=<type>], the first one is the default model (types: %s)", strings.Join(ModelTypes(), ", ")))
 	flags.StringVarP(&watchPattern, "pattern", "P", "/watch", "watch path pattern")
 	flags.BoolVarP(&consume, "consumer", "c", false, "run consumer")
 	flags.StringVarP(&level, "log-level", "L", level, "log level")
-	flags.StringVarP(&database, "database", "d", database, "database path")
+	flags.StringVarP(&database, "database", "d", database, "database path (sub directory per model for several models)")
 	flags.StringVarP(&files, "files", "F", database, "file server base directory for /ui")
 	flags.DurationVarP(&delay, "delay", "D", 0, "processing delay (duration)")
+	flags.BoolVarP(&election, "leader-election", "E", false, "run processing only while holding the lease of the object space")
+	flags.StringVarP(&identity, "identity", "I", "", "candidate identity for leader election (default <host>-<pid>)")
+	flags.DurationVarP(&leaseDuration, "lease-duration", "", leaseDuration, "lease duration for leader election and shard membership")
+	flags.BoolVarP(&shards, "sharding", "S", false, "share the namespaces with other engine instances")
+	flags.StringSliceVarP(&shardNamespaces, "shard-namespaces", "", nil, "base namespaces explicitly assigned to this instance")
+	flags.StringVarP(&endpoint, "endpoint", "", "", "watch endpoint announced to other shard members (default ws://<host>:<port><pattern>)")
+	flags.StringVarP(&traceFile, "trace-file", "", "", "export run traces to file (OTLP JSON lines)")
+	flags.BoolVarP(&runHistory, "run-history", "H", false, "record element runs as Run objects")
+	flags.IntVarP(&retention.MaxRuns, "run-history-runs", "", retention.MaxRuns, "maximal number of finished runs kept per element (0 = unlimited)")
+	flags.DurationVarP(&retention.MaxAge, "run-history-age", "", 0, "maximal age of finished runs (0 = unlimited)")
+	flags.DurationVarP(&orphanScan, "orphan-scan", "", orphanScan, "interval used to scan for orphaned internal objects (0 = disabled)")
+	flags.BoolVarP(&events, "events", "", false, "record events for element status transitions")
+	flags.DurationVarP(&eventTTL, "event-ttl", "", eventTTL, "time events are kept after their last occurrence (0 = unlimited)")
+	flags.BoolVarP(&orphanDeletion, "orphan-deletion", "", false, "delete orphaned internal objects found by the orphan scan")
+	flags.BoolVarP(&notifications, "notifications", "", false, "deliver element status transitions to the sinks configured by Notification objects")
+	flags.StringVarP(&notificationOpts.FileDirectory, "notification-dir", "", "", "base directory for file sinks (sub directory per model for several models, empty = disabled)")
+	flags.BoolVarP(&notificationOpts.Exec, "notification-exec", "", false, "enable exec sinks (only for restricted database access)")
+	flags.DurationVarP(&notificationOpts.RetryInterval, "notification-retry", "", notificationOpts.RetryInterval, "interval used to retry failed deliveries")
+	flags.BoolVarP(&forceDeletion, "force-deletion", "", false, "enable the privileged admin API to enforce the deletion of objects (only for restricted admin access)")
 
 	err := flags.Parse(os.Args[1:])
 	if err != nil {
 		Error("invalid arguments: %s", err)
 	}
 
+	if election && shards {
+		Error("leader election and sharding are exclusive")
+	}
+	if notifications && shards {
+		Error("notifications and sharding are exclusive")
+	}
+
 	l, err := logging.ParseLevel(level)
 	if err != nil {
 		Error("invalid log level %q", level)
@@ -60,28 +111,180 @@ func main() {
 	lctx.AddRule(logging.NewConditionRule(l, logging.NewRealmPrefix("engine")))
 	lctx.AddRule(logging.NewConditionRule(l, logging.NewRealmPrefix("database")))
 
-	dbspec := filesystem.NewSpecification[db.Object](database)
-	mspec := sub.NewModelSpecification("expression", dbspec)
-	m, err := model.NewModel(mspec)
-	if err != nil {
-		Error("cannot create model: %s", err.Error())
+	if traceFile != "" {
+		exp, err := tracing.NewFileExporter(traceFile)
+		if err != nil {
+			Error("cannot create trace exporter: %s", err.Error())
+		}
+		defer exp.Shutdown()
+		tracing.Default.SetExporter(exp)
 	}
 
-	proc, err := processor.NewController(lctx, m, 1, version.Composed)
-	if err != nil {
-		Error("cannot create processor: %s", err.Error())
+	var configs []*ModelConfig
+	for _, a := range modelArgs {
+		cfg, err := ParseModel(a)
+		if err != nil {
+			Error("%s", err.Error())
+		}
+		for _, c := range configs {
+			if c.Name == cfg.Name {
+				Error("model %q configured twice", cfg.Name)
+			}
+		}
+		configs = append(configs, cfg)
+	}
+	if len(configs) == 0 {
+		Error("at least one model required")
 	}
 
-	if delay > 0 {
-		proc.SetDelay(delay)
+	host, _ := os.Hostname()
+	if identity == "" {
+		identity = fmt.Sprintf("%s-%d", host, os.Getpid())
 	}
-	odb := objectbase.GetDatabase[db.Object](proc.Model().ObjectBase())
-	cntr := controllers.NewExpressionController(lctx, 1, odb)
 
 	srv := server.NewServer(port, true, 20*time.Second)
-	log.Info("serving watch on {{path}}", "path", watchPattern)
-	proc.RegisterWatchHandler(srv, watchPattern)
-	dbservice.New(odb, "/db").RegisterHandler(srv)
+	reg := service.New(context.Background())
+
+	models := &modelList{Default: configs[0].Name}
+	for i, cfg := range configs {
+		// the default model is additionally served without model prefix.
+		def := i == 0
+
+		// create provides the services of a model. With leader election,
+		// they are created again for every term of leadership, because
+		// services cannot be restarted. The handlers registered for the
+		// model are replaced by the ones of the actual services.
+		create := func() (*processor.Controller, []service.Service, error) {
+			// every model uses its own database, if several models are hosted.
+			path := database
+			if len(configs) > 1 {
+				path = filepath.Join(database, cfg.Name)
+			}
+			dbspec := filesystem.NewSpecification[db.Object](path)
+			m, err := model.NewModel(cfg.Type.Create(cfg.Name, dbspec))
+			if err != nil {
+				return nil, nil, fmt.Errorf("cannot create model %q: %w", cfg.Name, err)
+			}
+
+			proc, err := processor.NewController(lctx, m, 1, version.Composed)
+			if err != nil {
+				return nil, nil, fmt.Errorf("cannot create processor for model %q: %w", cfg.Name, err)
+			}
+
+			if delay > 0 {
+				proc.SetDelay(delay)
+			}
+			proc.SetOrphanScan(orphanScan, orphanDeletion)
+			proc.EnableForceDeletion(forceDeletion)
+			for f, c := range cfg.Type.Finalizers {
+				proc.RegisterFinalizer(f, c)
+			}
+			odb := objectbase.GetDatabase[db.Object](proc.Model().ObjectBase())
+			types := odb.SchemeTypes()
+
+			var cntrs []service.Service
+			if cfg.Type.Controllers != nil {
+				cntrs = cfg.Type.Controllers(lctx, odb)
+			}
+
+			if runHistory {
+				if !types.HasType(db.TYPE_RUN) {
+					return nil, nil, fmt.Errorf("model %q does not support run history", cfg.Name)
+				}
+				log.Info("recording runs for model {{model}} (keeping {{runs}} runs per element, max age {{age}})", "model", cfg.Name, "runs", retention.MaxRuns, "age", retention.MaxAge)
+				proc.SetRunHistory(history.New(lctx, odb, db.NewRun("", ""), retention))
+			}
+
+			if events {
+				if !types.HasType(db.TYPE_EVENT) {
+					return nil, nil, fmt.Errorf("model %q does not support events", cfg.Name)
+				}
+				log.Info("recording events for model {{model}} (ttl {{ttl}})", "model", cfg.Name, "ttl", eventTTL)
+				proc.SetEventRecorder(recorder.New(lctx, odb, db.NewEvent("", ""), eventTTL))
+			}
+
+			if notifications {
+				if !types.HasType(db.TYPE_NOTIFICATION) || !types.HasType(db.TYPE_NOTIFICATION_EVENT) {
+					return nil, nil, fmt.Errorf("model %q does not support notifications", cfg.Name)
+				}
+				opts := notificationOpts
+				if opts.FileDirectory != "" && len(configs) > 1 {
+					opts.FileDirectory = filepath.Join(opts.FileDirectory, cfg.Name)
+				}
+				log.Info("delivering notifications for model {{model}} (retry {{retry}})", "model", cfg.Name, "retry", opts.RetryInterval)
+				cntrs = append(cntrs, notification.New(lctx, odb, proc, db.NewNotification("", ""), db.NewNotificationEvent("", ""), opts))
+			}
+
+			watchPath := watchPattern + "/" + cfg.Name
+			if shards {
+				if !types.HasType(db.TYPE_SHARD_MEMBER) {
+					return nil, nil, fmt.Errorf("model %q does not support sharding", cfg.Name)
+				}
+				ep := endpoint
+				if ep == "" {
+					ep = fmt.Sprintf("ws://%s:%d%s", host, port, watchPattern)
+				}
+				if len(configs) > 1 {
+					ep += "/" + cfg.Name
+				}
+				log.Info("using sharding for model {{model}} with identity {{identity}}", "model", cfg.Name, "identity", identity)
+				spec := sharding.MemberSpec{
+					Identity:   identity,
+					Endpoint:   ep,
+					Namespaces: shardNamespaces,
+				}
+				members := sharding.New(lctx, odb, db.NewShardMember("", ""), spec, leaseDuration)
+				proc.SetSharding(members)
+				cntrs = append(cntrs, members)
+			}
+
+			log.Info("serving model {{model}} on /db/{{model}}, /engine/{{model}}, /admin/{{model}} and watch on {{path}}", "model", cfg.Name, "path", watchPath)
+			proc.RegisterWatchHandler(srv, watchPath)
+			dbservice.New(odb, "/db/"+cfg.Name).RegisterHandler(srv)
+			proc.RegisterAPIHandler(srv, "/engine/"+cfg.Name)
+			proc.RegisterAdminHandler(srv, "/admin/"+cfg.Name)
+			if def {
+				log.Info("serving default model {{model}} on /db, /engine, /admin and watch on {{path}}", "model", cfg.Name, "path", watchPattern)
+				proc.RegisterWatchHandler(srv, watchPattern)
+				dbservice.New(odb, "/db").RegisterHandler(srv)
+				proc.RegisterAPIHandler(srv, "/engine")
+				proc.RegisterAdminHandler(srv, "/admin")
+			}
+			return proc, append(cntrs, proc), nil
+		}
+
+		proc, srvs, err := create()
+		if err != nil {
+			Error("%s", err.Error())
+		}
+		models.Models = append(models.Models, cfg.Name)
+
+		if election {
+			odb := objectbase.GetDatabase[db.Object](proc.Model().ObjectBase())
+			if !odb.SchemeTypes().HasType(db.TYPE_LEASE) {
+				Error("model %q does not support leader election", cfg.Name)
+			}
+			log.Info("using leader election for model {{model}} with identity {{identity}}", "model", cfg.Name, "identity", identity)
+			elector := leader.New(lctx, odb, db.NewLease("", "engine"), identity, leaseDuration)
+			reg.Add(service.NewReelectedLeaderElected(elector, func() ([]service.Service, error) {
+				if srvs == nil {
+					_, list, err := create()
+					return list, err
+				}
+				// the initially created services are used for the first term.
+				list := srvs
+				srvs = nil
+				return list, nil
+			}))
+		} else {
+			for _, s := range srvs {
+				reg.Add(s)
+			}
+		}
+	}
+
+	srv.Handle("/models", models)
+	srv.Handle("/metrics", metrics.Default)
 
 	if files != "" {
 		dir, err := server.NewDirectoryHandlerFor(files, "/ui")
@@ -92,9 +295,6 @@ func main() {
 		dir.RegisterHandler(srv)
 	}
 
-	reg := service.New(context.Background())
-	reg.Add(cntr)
-	reg.Add(proc)
 	reg.Add(srv)
 
 	err = reg.Start()
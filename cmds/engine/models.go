@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/impl/metamodels/demo"
+	"github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/simple"
+	simplecontrollers "github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/simple/controllers"
+	"github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub"
+	"github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub/controllers"
+	"github.com/mandelsoft/engine/pkg/impl/metamodels/multidemo"
+	"github.com/mandelsoft/engine/pkg/processing/api"
+	"github.com/mandelsoft/engine/pkg/processing/model"
+	"github.com/mandelsoft/engine/pkg/processing/model/support/db"
+	"github.com/mandelsoft/engine/pkg/service"
+	"github.com/mandelsoft/goutils/maputils"
+	"github.com/mandelsoft/logging"
+)
+
+// ModelType describes a model implementation hosted by the engine.
+type ModelType struct {
+	// Create provides the model specification for a model name.
+	Create func(name string, dbspec database.Specification[db.Object]) model.ModelSpecification
+	// Controllers provides the additional controllers
+	// required by the model implementation.
+	Controllers func(lctx logging.Context, odb database.Database[db.Object]) []service.Service
+	// Finalizers maps the finalizers used by the additional
+	// controllers to the controller names.
+	Finalizers map[string]string
+}
+
+var modelTypes = map[string]ModelType{
+	"expression": {
+		Create: sub.NewModelSpecification,
+		Controllers: func(lctx logging.Context, odb database.Database[db.Object]) []service.Service {
+			return []service.Service{controllers.NewExpressionController(lctx, 1, odb)}
+		},
+		Finalizers: map[string]string{controllers.FINALIZER: "expression controller"},
+	},
+	"calculator": {
+		Create: simple.NewModelSpecification,
+		Controllers: func(lctx logging.Context, odb database.Database[db.Object]) []service.Service {
+			return []service.Service{simplecontrollers.NewExpressionController(lctx, 1, odb)}
+		},
+	},
+	"multidemo": {Create: multidemo.NewModelSpecification},
+	"demo":      {Create: demo.NewModelSpecification},
+}
+
+// ModelTypes provides the names of the available model types.
+func ModelTypes() []string {
+	return maputils.OrderedKeys(modelTypes)
+}
+
+// ModelConfig describes a model hosted by the engine.
+type ModelConfig struct {
+	Name string
+	Type ModelType
+}
+
+// ParseModel parses a model argument of the form <name>[=<type>].
+// Without explicit type, the name is used as type.
+func ParseModel(arg string) (*ModelConfig, error) {
+	name, typ, ok := strings.Cut(arg, "=")
+	if !ok {
+		typ = name
+	}
+	if name == "" || strings.Contains(name, "/") {
+		return nil, fmt.Errorf("invalid model name %q", name)
+	}
+	t, ok := modelTypes[typ]
+	if !ok {
+		return nil, fmt.Errorf("unknown model type %q (available: %s)", typ, strings.Join(ModelTypes(), ", "))
+	}
+	return &ModelConfig{Name: name, Type: t}, nil
+}
+
+// modelList serves the list of hosted models.
+type modelList api.ModelList
+
+func (l *modelList) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	data, _ := json.Marshal(l)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
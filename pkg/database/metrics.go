@@ -0,0 +1,51 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"github.com/mandelsoft/engine/pkg/metrics"
+)
+
+const (
+	OP_GET    = "get"
+	OP_SET    = "set"
+	OP_LIST   = "list"
+	OP_DELETE = "delete"
+)
+
+var (
+	operationDuration = metrics.NewHistogramVec("engine_database_operation_duration_seconds",
+		"Latency of database operations.", nil, "operation", "type")
+	operationErrors = metrics.NewCounterVec("engine_database_operation_errors_total",
+		"Number of failed database operations.", "operation", "type")
+	conflicts = metrics.NewCounterVec("engine_database_conflicts_total",
+		"Number of rejected updates caused by concurrent modifications (ErrModified).", "type")
+)
+
+func init() {
+	metrics.MustRegister(operationDuration, operationErrors, conflicts)
+}
+
+// ObserveOperation records the latency and outcome of a database
+// operation for an object type. It is intended to be deferred by
+// database implementations using the named error result, e.g.
+//
+//	defer database.ObserveOperation(database.OP_GET, typ, time.Now(), &err)
+//
+// Conflicts (ErrModified) are counted separately and not reported
+// as errors. A missing object is a regular outcome.
+func ObserveOperation(op string, typ string, start time.Time, errp *error) {
+	var err error
+	if errp != nil {
+		err = *errp
+	}
+	operationDuration.WithLabelValues(op, typ).Observe(time.Since(start).Seconds())
+	switch {
+	case err == nil, errors.Is(err, ErrNotExist):
+	case errors.Is(err, ErrModified):
+		conflicts.WithLabelValues(typ).Inc()
+	default:
+		operationErrors.WithLabelValues(op, typ).Inc()
+	}
+}
@@ -64,6 +64,37 @@ func CreateOrModify[O Object, DBO Object](db Database[DBO], obj *O, mod func(O)
 	}
 }
 
+// CreateOrUpdate updates the object with the given id using the
+// modifier mod taking race conditions into account. If the object
+// does not exist, a new one is created using the type scheme of the
+// database. The object must implement T.
+func CreateOrUpdate[T any, DBO Object](db Database[DBO], id ObjectId, mod func(T) error) error {
+	for {
+		o, err := db.GetObject(id)
+		if err != nil {
+			if !errors.Is(err, ErrNotExist) {
+				return err
+			}
+			o, err = db.SchemeTypes().CreateObject(id.GetType(), SetObjectNameFromId[DBO](id))
+			if err != nil {
+				return err
+			}
+		}
+		t, ok := any(o).(T)
+		if !ok {
+			return fmt.Errorf("non-matching Go type %T for %q", o, id.GetType())
+		}
+		err = mod(t)
+		if err != nil {
+			return err
+		}
+		err = db.SetObject(o)
+		if !errors.Is(err, ErrModified) {
+			return err
+		}
+	}
+}
+
 func ModifyExisting[O Object, DBO Object](db Database[DBO], obj *O, mod func(O) bool) (bool, error) {
 	for {
 		_o, err := db.GetObject(*obj)
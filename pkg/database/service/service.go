@@ -81,10 +81,23 @@ func (a *DatabaseAccess[O]) ServeHTTP(w http.ResponseWriter, req *http.Request)
 			ns := strings.Join(comps[1:len(comps)-1], "/")
 			oid := database.NewObjectId(typ, ns, name)
 
-			deleted, err := a.database.DeleteObject(oid)
+			var deleted bool
+			policy, err := database.ParseDeletionPolicy(req.URL.Query().Get(PARAM_DELETION_POLICY))
+			if err != nil {
+				err = fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+			} else if policy != "" {
+				err = a.setDeletionPolicy(oid, policy)
+			}
+			if err == nil {
+				deleted, err = a.database.DeleteObject(oid)
+			}
 			if err != nil {
 				if errors.Is(err, database.ErrNotExist) {
 					status = http.StatusNotFound
+				} else if errors.Is(err, ErrInvalidRequest) {
+					e := &Error{err.Error()}
+					data, _ = json.Marshal(e)
+					status = http.StatusBadRequest
 				} else {
 					e := &Error{err.Error()}
 					data, _ = json.Marshal(e)
@@ -187,6 +200,35 @@ func (a *DatabaseAccess[O]) ServeHTTP(w http.ResponseWriter, req *http.Request)
 	}
 }
 
+// PARAM_DELETION_POLICY is the query parameter used to pass
+// the deletion policy for a DELETE request.
+const PARAM_DELETION_POLICY = "propagation"
+
+// ErrInvalidRequest is used for invalid request parameters.
+var ErrInvalidRequest = fmt.Errorf("invalid request")
+
+// setDeletionPolicy sets the deletion policy of an object
+// before its deletion is requested.
+func (a *DatabaseAccess[O]) setDeletionPolicy(oid database.ObjectId, policy database.DeletionPolicy) error {
+	for {
+		obj, err := a.database.GetObject(oid)
+		if err != nil {
+			return err
+		}
+		p, ok := any(obj).(database.DeletionPolicyAccess)
+		if !ok {
+			return fmt.Errorf("%w: deletion policy not supported for type %q", ErrInvalidRequest, oid.GetType())
+		}
+		if !p.SetDeletionPolicy(policy) {
+			return nil
+		}
+		err = a.database.SetObject(obj)
+		if !errors.Is(err, database.ErrModified) {
+			return err
+		}
+	}
+}
+
 type Error struct {
 	Error string `json:"error"`
 }
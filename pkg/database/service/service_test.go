@@ -55,6 +55,8 @@ var _ = Describe("Test Environment", func() {
 	AfterEach(func() {
 		MustBeSuccessful(srv.Shutdown(ctx))
 		done.Wait()
+		// avoid reusing connections to the stopped server.
+		http.DefaultClient.CloseIdleConnections()
 	})
 
 	Context("get", func() {
@@ -294,6 +296,30 @@ items:
 			ExpectError(db.GetObject(oid)).To(Equal(database.ErrNotExist))
 		})
 
+		It("request deletion with deletion policy", func() {
+			oid := database.NewObjectId(TYPE_A, NS, "o1")
+
+			o := Must(db.GetObject(oid))
+			o.AddFinalizer("test")
+			MustBeSuccessful(db.SetObject(o))
+
+			req := Must(http.NewRequest("DELETE", URL+path.Join(oid.GetType(), oid.GetNamespace(), oid.GetName())+"?"+service.PARAM_DELETION_POLICY+"=cascade", nil))
+			r := Must(http.DefaultClient.Do(req))
+			Expect(r.StatusCode).To(Equal(http.StatusAccepted))
+
+			o = Must(db.GetObject(oid))
+			Expect(o.IsDeleting()).To(BeTrue())
+			Expect(o.(database.DeletionPolicyAccess).GetDeletionPolicy()).To(Equal(database.DELETION_POLICY_CASCADE))
+		})
+
+		It("rejects invalid deletion policy", func() {
+			oid := database.NewObjectId(TYPE_A, NS, "o1")
+			req := Must(http.NewRequest("DELETE", URL+path.Join(oid.GetType(), oid.GetNamespace(), oid.GetName())+"?"+service.PARAM_DELETION_POLICY+"=purge", nil))
+			r := Must(http.DefaultClient.Do(req))
+			Expect(r.StatusCode).To(Equal(http.StatusBadRequest))
+
+			Expect(Must(db.GetObject(oid)).IsDeleting()).To(BeFalse())
+		})
 	})
 
 })
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/mandelsoft/engine/pkg/runtime"
 	"github.com/mandelsoft/engine/pkg/utils"
@@ -103,6 +104,14 @@ func (g *FinalizedMeta) GetDeletionInfo() DeletionInfo {
 	return g.DeletionTime
 }
 
+func (g *FinalizedMeta) GetDeletionTime() *time.Time {
+	if g.DeletionTime == nil {
+		return nil
+	}
+	t := g.DeletionTime.Time()
+	return &t
+}
+
 func (g *FinalizedMeta) PreserveDeletion(info DeletionInfo) {
 	if g.DeletionTime == nil && info != nil {
 		g.DeletionTime = info.(*utils.Timestamp)
@@ -151,6 +160,53 @@ func (g *FinalizedMeta) RemoveFinalizer(f string) bool {
 
 ////////////////////////////////////////////////////////////////////////////////
 
+// DeletionPolicy describes how the deletion of an object is
+// propagated to objects depending on it.
+type DeletionPolicy string
+
+const (
+	// DELETION_POLICY_BLOCK refuses the deletion as long as
+	// dependent objects exist.
+	DELETION_POLICY_BLOCK = DeletionPolicy("Block")
+	// DELETION_POLICY_CASCADE deletes the dependent objects, too.
+	DELETION_POLICY_CASCADE = DeletionPolicy("Cascade")
+	// DELETION_POLICY_ORPHAN deletes the object and leaves
+	// the dependent objects without their dependency.
+	DELETION_POLICY_ORPHAN = DeletionPolicy("Orphan")
+)
+
+var DeletionPolicies = []DeletionPolicy{DELETION_POLICY_BLOCK, DELETION_POLICY_CASCADE, DELETION_POLICY_ORPHAN}
+
+// ParseDeletionPolicy parses a deletion policy (case-insensitive).
+// An empty string provides the empty (default) policy.
+func ParseDeletionPolicy(s string) (DeletionPolicy, error) {
+	if s == "" {
+		return "", nil
+	}
+	for _, p := range DeletionPolicies {
+		if strings.EqualFold(s, string(p)) {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("invalid deletion policy %q", s)
+}
+
+// DeletionPolicyAccess is an optional interface of
+// objects featuring a deletion policy.
+type DeletionPolicyAccess interface {
+	GetDeletionPolicy() DeletionPolicy
+	SetDeletionPolicy(DeletionPolicy) bool
+}
+
+// DeletionTimeAccess is an optional interface of
+// finalizable objects providing the time their
+// deletion has been requested.
+type DeletionTimeAccess interface {
+	GetDeletionTime() *time.Time
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
 type StatusSource interface {
 	GetStatusValue() string
 }
@@ -64,6 +64,22 @@ func GetObjectId[O Object](o O) ObjectId {
 	return NewObjectIdFor(o)
 }
 
+// ListObjectsAs provides the objects of a type in a namespace
+// (or namespace closure) implementing T.
+func ListObjectsAs[T any, DBO Object](db Database[DBO], typ string, closure bool, ns string) ([]T, error) {
+	list, err := db.ListObjects(typ, closure, ns)
+	if err != nil {
+		return nil, err
+	}
+	var result []T
+	for _, o := range list {
+		if t, ok := any(o).(T); ok {
+			result = append(result, t)
+		}
+	}
+	return result, nil
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 
 func MatchNamespace(closure bool, ns string, cand string) bool {
@@ -27,6 +27,13 @@ type Database[O database.Object, W Object[S], S database.Object] interface {
 	database.Database[O]
 	Wrapped[S]
 	WrapObject(s S) (O, error)
+	DecodeObject(data []byte) (O, error)
+}
+
+// Decoder is implemented by databases able to decode
+// serialized objects without persisting them.
+type Decoder[O database.Object] interface {
+	DecodeObject(data []byte) (O, error)
 }
 
 type IdMapping[S database.Object] interface {
@@ -172,6 +179,22 @@ func (w *wrappingDatabase[O, W, S]) WrapObject(s S) (O, error) {
 	return generics.Cast[O](o), nil
 }
 
+// DecodeObject decodes a technical object and provides its wrapper.
+// The object is not stored in the database.
+func (w *wrappingDatabase[O, W, S]) DecodeObject(data []byte) (O, error) {
+	var _nil O
+
+	e, ok := w.db.SchemeTypes().(runtime.Encoding[S])
+	if !ok {
+		return _nil, fmt.Errorf("database does not support decoding")
+	}
+	s, err := e.Decode(data)
+	if err != nil {
+		return _nil, err
+	}
+	return w.WrapObject(s)
+}
+
 func (w *wrappingDatabase[O, W, S]) SetObject(o O) error {
 	i, ok := generics.TryCast[W](o)
 	if !ok {
@@ -0,0 +1,271 @@
+package election
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/service"
+	"github.com/mandelsoft/engine/pkg/utils"
+	"github.com/mandelsoft/logging"
+)
+
+var REALM = logging.DefineRealm("engine/election", "leader election")
+
+// DEFAULT_LEASE_DURATION is the default duration of a lease.
+const DEFAULT_LEASE_DURATION = 15 * time.Second
+
+// Elector is a lease based leader election for a set of candidates
+// sharing a database. The lease is stored as database object and
+// concurrent updates are detected by the generation checks of the
+// database.
+// The holder renews the lease periodically. A candidate takes over the
+// lease, if it has been released, or if it could not observe a renewal
+// for the lease duration. A holder, which cannot renew its lease within
+// the renew deadline, considers the leadership as lost.
+type Elector[O database.Object] struct {
+	lock     sync.Mutex
+	log      logging.Logger
+	db       database.Database[O]
+	id       database.ObjectId
+	identity string
+
+	duration      time.Duration
+	renewDeadline time.Duration
+	retryPeriod   time.Duration
+
+	observedGeneration int64
+	observedTime       time.Time
+
+	leading bool
+	cancel  context.CancelFunc
+	renewed chan struct{}
+}
+
+var _ service.Leadership = (*Elector[database.Object])(nil)
+
+// New creates an Elector for the candidate identity using the lease
+// object id. If the lease object does not exist, it is created.
+// The renew deadline is 2/3 and the retry period 1/5 of the lease
+// duration.
+func New[O database.Object](lctx logging.AttributionContextProvider, db database.Database[O], id database.ObjectId, identity string, duration time.Duration) *Elector[O] {
+	if duration <= 0 {
+		duration = DEFAULT_LEASE_DURATION
+	}
+	return &Elector[O]{
+		log:                lctx.AttributionContext().Logger(REALM).WithValues("lease", database.StringId(id), "identity", identity),
+		db:                 db,
+		id:                 database.NewObjectIdFor(id),
+		identity:           identity,
+		duration:           duration,
+		renewDeadline:      duration * 2 / 3,
+		retryPeriod:        duration / 5,
+		observedGeneration: -1,
+	}
+}
+
+func (e *Elector[O]) Identity() string {
+	return e.identity
+}
+
+func (e *Elector[O]) IsLeader() bool {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.leading
+}
+
+// Acquire blocks until the lease is acquired or the context is
+// cancelled. After acquisition, the lease is renewed until the
+// leadership is released or lost. The returned channel is closed,
+// if the leadership is lost.
+func (e *Elector[O]) Acquire(ctx context.Context) (<-chan struct{}, error) {
+	e.log.Info("trying to acquire lease")
+	for {
+		ok, err := e.tryAcquireOrRenew()
+		if err != nil {
+			e.log.LogError(err, "cannot acquire lease")
+		}
+		if ok {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(e.retryPeriod):
+		}
+	}
+	e.log.Info("lease acquired")
+
+	lost := make(chan struct{})
+	rctx, cancel := context.WithCancel(ctx)
+
+	e.lock.Lock()
+	e.leading = true
+	e.cancel = cancel
+	e.renewed = make(chan struct{})
+	e.lock.Unlock()
+
+	go e.renew(rctx, e.renewed, lost)
+	return lost, nil
+}
+
+func (e *Elector[O]) renew(ctx context.Context, done chan struct{}, lost chan struct{}) {
+	defer close(done)
+
+	last := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(e.retryPeriod):
+		}
+		ok, err := e.tryAcquireOrRenew()
+		if ok {
+			last = time.Now()
+			continue
+		}
+		if err != nil {
+			e.log.LogError(err, "cannot renew lease")
+		}
+		if time.Since(last) > e.renewDeadline {
+			e.log.Info("leadership lost")
+			e.lock.Lock()
+			e.leading = false
+			e.lock.Unlock()
+			close(lost)
+			return
+		}
+	}
+}
+
+// Release stops the renewal and releases an acquired lease,
+// so that another candidate can take over immediately.
+func (e *Elector[O]) Release() error {
+	e.lock.Lock()
+	cancel, renewed := e.cancel, e.renewed
+	e.cancel, e.renewed = nil, nil
+	e.lock.Unlock()
+
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+	<-renewed
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if !e.leading {
+		return nil
+	}
+	e.leading = false
+
+	for {
+		o, l, err := e.get()
+		if err != nil {
+			if errors.Is(err, database.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		spec := l.GetLeaseSpec()
+		if spec.Holder != e.identity {
+			return nil
+		}
+		spec.Holder = ""
+		spec.AcquireTime = nil
+		spec.RenewTime = nil
+		l.SetLeaseSpec(spec)
+		err = e.db.SetObject(o)
+		if !errors.Is(err, database.ErrModified) {
+			if err == nil {
+				e.log.Info("lease released")
+			}
+			return err
+		}
+	}
+}
+
+func (e *Elector[O]) get() (O, Lease, error) {
+	var _nil O
+
+	o, err := e.db.GetObject(e.id)
+	if err != nil {
+		return _nil, nil, err
+	}
+	l, ok := any(o).(Lease)
+	if !ok {
+		return _nil, nil, fmt.Errorf("object %s is no lease", database.StringId(e.id))
+	}
+	return o, l, nil
+}
+
+// tryAcquireOrRenew tries to acquire or renew the lease.
+// It returns true, if the candidate holds the lease.
+func (e *Elector[O]) tryAcquireOrRenew() (bool, error) {
+	now := time.Now()
+
+	o, l, err := e.get()
+	if err != nil {
+		if !errors.Is(err, database.ErrNotExist) {
+			return false, err
+		}
+		o, err = e.db.SchemeTypes().CreateObject(e.id.GetType(), database.SetObjectNameFromId[O](e.id))
+		if err != nil {
+			return false, err
+		}
+		var ok bool
+		l, ok = any(o).(Lease)
+		if !ok {
+			return false, fmt.Errorf("type %q is no lease type", e.id.GetType())
+		}
+	}
+	if _, ok := any(o).(database.GenerationAccess); !ok {
+		return false, fmt.Errorf("lease type %q does not support generations", e.id.GetType())
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	// expiration is based on the local time of the observation
+	// of a change to be independent of clock skews between candidates.
+	gen := database.GetGeneration(o)
+	if gen != e.observedGeneration {
+		e.observedGeneration = gen
+		e.observedTime = now
+	}
+
+	spec := l.GetLeaseSpec()
+	if spec.Holder != "" && spec.Holder != e.identity {
+		duration := spec.GetDuration()
+		if duration <= 0 {
+			duration = e.duration
+		}
+		if e.observedTime.Add(duration).After(now) {
+			return false, nil
+		}
+		e.log.Info("lease of {{holder}} expired", "holder", spec.Holder)
+	}
+
+	if spec.Holder != e.identity {
+		spec.Holder = e.identity
+		spec.AcquireTime = utils.NewTimestampPFor(now)
+		spec.Transitions++
+	}
+	spec.RenewTime = utils.NewTimestampPFor(now)
+	spec.DurationSeconds = int(e.duration / time.Second)
+	l.SetLeaseSpec(spec)
+
+	err = e.db.SetObject(o)
+	if err != nil {
+		if errors.Is(err, database.ErrModified) {
+			return false, nil
+		}
+		return false, err
+	}
+	e.observedGeneration = database.GetGeneration(o)
+	e.observedTime = now
+	return true, nil
+}
@@ -0,0 +1,183 @@
+package election_test
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/engine/pkg/ctxutil"
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/election"
+	"github.com/mandelsoft/engine/pkg/impl/database/filesystem"
+	"github.com/mandelsoft/engine/pkg/processing/model/support/db"
+	"github.com/mandelsoft/engine/pkg/service"
+	"github.com/mandelsoft/logging"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+)
+
+var _ = Describe("elector", func() {
+	var odb database.Database[db.Object]
+	var ctx context.Context
+
+	lease := db.NewLease("", "engine")
+
+	BeforeEach(func() {
+		scheme := db.NewScheme[db.Object]()
+		database.MustRegisterType[db.Lease, db.Object](scheme)
+		odb = Must(filesystem.New[db.Object](scheme, "/db", memoryfs.New()))
+		ctx = ctxutil.CancelContext(context.Background())
+	})
+
+	AfterEach(func() {
+		ctxutil.Cancel(ctx)
+	})
+
+	getLease := func() election.LeaseSpec {
+		return Must(odb.GetObject(lease)).(*db.Lease).Spec
+	}
+
+	It("acquires a free lease", func() {
+		e := election.New(logging.DefaultContext(), odb, lease, "A", time.Second)
+		Must(e.Acquire(ctx))
+		Expect(e.IsLeader()).To(BeTrue())
+
+		spec := getLease()
+		Expect(spec.Holder).To(Equal("A"))
+		Expect(spec.Transitions).To(Equal(1))
+		Expect(spec.DurationSeconds).To(Equal(1))
+		MustBeSuccessful(e.Release())
+		Expect(e.IsLeader()).To(BeFalse())
+		Expect(getLease().Holder).To(Equal(""))
+	})
+
+	It("blocks other candidates", func() {
+		a := election.New(logging.DefaultContext(), odb, lease, "A", time.Second)
+		b := election.New(logging.DefaultContext(), odb, lease, "B", time.Second)
+		Must(a.Acquire(ctx))
+		defer a.Release()
+
+		// the context passed to Acquire controls the renewal,
+		// therefore only the blocked candidate uses a timeout.
+		tctx := ctxutil.TimeoutContext(ctx, 2*time.Second)
+		defer ctxutil.Cancel(tctx)
+		_, err := b.Acquire(tctx)
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+		Expect(getLease().Holder).To(Equal("A"))
+	})
+
+	It("takes over a released lease", func() {
+		a := election.New(logging.DefaultContext(), odb, lease, "A", time.Second)
+		b := election.New(logging.DefaultContext(), odb, lease, "B", time.Second)
+		Must(a.Acquire(ctx))
+		MustBeSuccessful(a.Release())
+
+		Must(b.Acquire(ctx))
+		defer b.Release()
+		spec := getLease()
+		Expect(spec.Holder).To(Equal("B"))
+		Expect(spec.Transitions).To(Equal(2))
+	})
+
+	It("takes over an expired lease", func() {
+		l := db.NewLease("", "engine")
+		l.Spec = election.LeaseSpec{Holder: "crashed", DurationSeconds: 1, Transitions: 1}
+		MustBeSuccessful(odb.SetObject(l))
+
+		b := election.New(logging.DefaultContext(), odb, lease, "B", time.Second)
+		start := time.Now()
+		Must(b.Acquire(ctx))
+		defer b.Release()
+		Expect(time.Since(start)).To(BeNumerically(">=", time.Second))
+		Expect(getLease().Holder).To(Equal("B"))
+	})
+
+	It("detects a lost leadership", func() {
+		a := election.New(logging.DefaultContext(), odb, lease, "A", time.Second)
+		lost := Must(a.Acquire(ctx))
+
+		o := Must(odb.GetObject(lease)).(*db.Lease)
+		MustBeSuccessful(database.DirectModify(odb, &o, func(o *db.Lease) bool {
+			o.Spec.Holder = "B"
+			return true
+		}))
+
+		Eventually(lost, 2*time.Second).Should(BeClosed())
+		Expect(a.IsLeader()).To(BeFalse())
+		MustBeSuccessful(a.Release())
+		Expect(getLease().Holder).To(Equal("B"))
+	})
+
+	It("re-enters the election after a lost leadership", func() {
+		a := election.New(logging.DefaultContext(), odb, lease, "A", time.Second)
+
+		var lock sync.Mutex
+		var terms []*testService
+		le := service.NewReelectedLeaderElected(a, func() ([]service.Service, error) {
+			lock.Lock()
+			defer lock.Unlock()
+			s := newTestService()
+			terms = append(terms, s)
+			return []service.Service{s}, nil
+		})
+		count := func() int {
+			lock.Lock()
+			defer lock.Unlock()
+			return len(terms)
+		}
+		term := func(i int) *testService {
+			lock.Lock()
+			defer lock.Unlock()
+			return terms[i]
+		}
+
+		Must2(le.Start(ctx))
+		MustBeSuccessful(le.Leading().Wait())
+		Expect(count()).To(Equal(1))
+
+		o := Must(odb.GetObject(lease)).(*db.Lease)
+		MustBeSuccessful(database.DirectModify(odb, &o, func(o *db.Lease) bool {
+			o.Spec.Holder = "B"
+			return true
+		}))
+
+		Eventually(term(0).stopped, 2*time.Second).Should(BeClosed())
+		Eventually(count, 5*time.Second).Should(Equal(2))
+		Expect(getLease().Holder).To(Equal("A"))
+
+		ctxutil.Cancel(ctx)
+		MustBeSuccessful(le.Wait())
+		Expect(term(1).stopped).To(BeClosed())
+	})
+})
+
+// testService is a service running until its context is cancelled.
+type testService struct {
+	stopped chan struct{}
+	done    service.Trigger
+}
+
+func newTestService() *testService {
+	return &testService{
+		stopped: make(chan struct{}),
+		done:    service.SyncTrigger(),
+	}
+}
+
+func (s *testService) Start(ctx context.Context) (service.Syncher, service.Syncher, error) {
+	ready := service.SyncTrigger()
+	ready.Trigger()
+	go func() {
+		<-ctx.Done()
+		close(s.stopped)
+		s.done.Trigger()
+	}()
+	return ready, s.done, nil
+}
+
+func (s *testService) Wait() error {
+	return s.done.Wait()
+}
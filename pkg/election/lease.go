@@ -0,0 +1,41 @@
+package election
+
+import (
+	"time"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/utils"
+)
+
+// LeaseSpec describes the state of a lease used for
+// the leader election.
+type LeaseSpec struct {
+	// Holder is the identity of the actual holder of the lease.
+	// An empty holder indicates a released lease.
+	Holder string `json:"holder,omitempty"`
+	// AcquireTime is the time the lease has been acquired by
+	// the actual holder.
+	AcquireTime *utils.Timestamp `json:"acquireTime,omitempty"`
+	// RenewTime is the time of the last renewal by the actual holder.
+	RenewTime *utils.Timestamp `json:"renewTime,omitempty"`
+	// DurationSeconds is the time a candidate has to wait after
+	// the last observed renewal before taking over the lease.
+	DurationSeconds int `json:"durationSeconds,omitempty"`
+	// Transitions is the number of changes of the holder.
+	Transitions int `json:"transitions,omitempty"`
+}
+
+// GetDuration provides the lease duration.
+func (s *LeaseSpec) GetDuration() time.Duration {
+	return time.Duration(s.DurationSeconds) * time.Second
+}
+
+// Lease is the interface of database objects used as lease.
+// The object must support generations (database.GenerationAccess)
+// to detect concurrent modifications.
+type Lease interface {
+	database.Object
+
+	GetLeaseSpec() LeaseSpec
+	SetLeaseSpec(LeaseSpec)
+}
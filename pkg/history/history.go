@@ -0,0 +1,234 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/logging"
+)
+
+var REALM = logging.DefineRealm("engine/history", "run history")
+
+// DEFAULT_MAX_RUNS is the default number of finished runs
+// kept per element.
+const DEFAULT_MAX_RUNS = 10
+
+// Retention describes which finished runs are kept.
+type Retention struct {
+	// MaxRuns is the maximal number of finished runs kept per element.
+	// A value <= 0 keeps all runs.
+	MaxRuns int
+	// MaxAge is the maximal age of finished runs.
+	// A value <= 0 keeps runs independently of their age.
+	MaxAge time.Duration
+}
+
+// History persists run records as database objects.
+// Run objects are stored in the namespace of the element
+// using the name provided by ObjectName.
+// Whenever a finished run is recorded, the retention is applied
+// to the finished runs of its element.
+// To avoid listing all runs of a namespace for every finished run,
+// the finished runs are indexed per element. The index for a
+// namespace is initialized from the database on first use.
+type History[O database.Object] struct {
+	log       logging.Logger
+	db        database.Database[O]
+	typ       string
+	retention Retention
+
+	lock sync.Mutex
+	// loaded contains the namespaces with an initialized index.
+	loaded map[string]struct{}
+	// finished contains the finished runs per element
+	// ordered by their start time.
+	finished map[element][]*Record
+}
+
+// element identifies the phase of an element the runs are recorded for.
+type element struct {
+	namespace, typ, name, phase string
+}
+
+func elementOf(r *Record) element {
+	return element{r.Spec.Namespace, r.Spec.Type, r.Spec.Name, r.Spec.Phase}
+}
+
+// New creates a History storing runs as objects with
+// the type of proto.
+func New[O database.Object](lctx logging.AttributionContextProvider, db database.Database[O], proto database.ObjectId, retention Retention) *History[O] {
+	return &History[O]{
+		log:       lctx.AttributionContext().Logger(REALM),
+		db:        db,
+		typ:       proto.GetType(),
+		retention: retention,
+		loaded:    map[string]struct{}{},
+		finished:  map[element][]*Record{},
+	}
+}
+
+func (h *History[O]) Retention() Retention {
+	return h.retention
+}
+
+// Record creates or updates the run object for the given record.
+func (h *History[O]) Record(r *Record) error {
+	err := h.store(r)
+	if err != nil || !r.Status.IsFinished() || !h.pruning() {
+		return err
+	}
+	err = h.load(r.Spec.Namespace)
+	if err != nil {
+		return err
+	}
+	h.lock.Lock()
+	h.add(r.Copy())
+	h.lock.Unlock()
+	return h.Prune(r.Spec.Namespace, r.Spec.Type, r.Spec.Name, r.Spec.Phase)
+}
+
+func (h *History[O]) pruning() bool {
+	return h.retention.MaxRuns > 0 || h.retention.MaxAge > 0
+}
+
+// load initializes the index of finished runs for a namespace.
+func (h *History[O]) load(ns string) error {
+	h.lock.Lock()
+	_, ok := h.loaded[ns]
+	h.lock.Unlock()
+	if ok {
+		return nil
+	}
+
+	list, err := h.List(ns, false, func(r *Record) bool { return r.Status.IsFinished() })
+	if err != nil {
+		return err
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if _, ok := h.loaded[ns]; !ok {
+		for _, r := range list {
+			h.add(r)
+		}
+		h.loaded[ns] = struct{}{}
+	}
+	return nil
+}
+
+// add adds a finished run to the index.
+// The lock must be held by the caller.
+func (h *History[O]) add(r *Record) {
+	e := elementOf(r)
+	list := h.finished[e]
+	for i, o := range list {
+		if o.Name() == r.Name() {
+			list = slices.Delete(list, i, i+1)
+			break
+		}
+	}
+	i, _ := slices.BinarySearchFunc(list, r, CompareRecord)
+	h.finished[e] = slices.Insert(list, i, r)
+}
+
+func (h *History[O]) store(r *Record) error {
+	id := database.NewObjectId(h.typ, r.Spec.Namespace, r.Name())
+	return database.CreateOrUpdate(h.db, id, func(ro Run) error {
+		ro.SetRunRecord(*r.Copy())
+		return nil
+	})
+}
+
+// Get provides the record for a run of an element.
+func (h *History[O]) Get(ns, runid, typ, name, phase string) (*Record, error) {
+	o, err := h.db.GetObject(database.NewObjectId(h.typ, ns, ObjectName(runid, typ, name, phase)))
+	if err != nil {
+		return nil, err
+	}
+	ro, ok := any(o).(Run)
+	if !ok {
+		return nil, fmt.Errorf("type %q is no run type", h.typ)
+	}
+	r := ro.GetRunRecord()
+	return &r, nil
+}
+
+// List provides the records of a namespace (or namespace closure)
+// matching the given function, ordered by their start time.
+func (h *History[O]) List(ns string, closure bool, match func(r *Record) bool) ([]*Record, error) {
+	list, err := database.ListObjectsAs[Run](h.db, h.typ, closure, ns)
+	if err != nil {
+		return nil, err
+	}
+	var result []*Record
+	for _, ro := range list {
+		r := ro.GetRunRecord()
+		if match == nil || match(&r) {
+			result = append(result, &r)
+		}
+	}
+	slices.SortFunc(result, CompareRecord)
+	return result, nil
+}
+
+// Prune applies the retention to the finished runs of an element.
+func (h *History[O]) Prune(ns, typ, name, phase string) error {
+	if !h.pruning() {
+		return nil
+	}
+	err := h.load(ns)
+	if err != nil {
+		return err
+	}
+
+	e := element{ns, typ, name, phase}
+	now := time.Now()
+	var obsolete []*Record
+
+	h.lock.Lock()
+	list := h.finished[e]
+	var kept []*Record
+	for i, r := range list {
+		keep := len(list) - i
+		if h.retention.MaxRuns > 0 && keep > h.retention.MaxRuns ||
+			h.retention.MaxAge > 0 && now.Sub(*r.Status.EndTime) > h.retention.MaxAge {
+			obsolete = append(obsolete, r)
+		} else {
+			kept = append(kept, r)
+		}
+	}
+	if len(kept) == 0 {
+		delete(h.finished, e)
+	} else {
+		h.finished[e] = kept
+	}
+	h.lock.Unlock()
+
+	var errs []error
+	for _, r := range obsolete {
+		h.log.Debug("removing run {{runid}} for {{element}}", "runid", r.Spec.RunId, "element", r.Spec.Element())
+		_, err := h.db.DeleteObject(database.NewObjectId(h.typ, ns, r.Name()))
+		if err != nil && !errors.Is(err, database.ErrNotExist) {
+			errs = append(errs, err)
+			// keep it for the next attempt
+			h.lock.Lock()
+			h.add(r)
+			h.lock.Unlock()
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CompareRecord orders records by their start time.
+func CompareRecord(a, b *Record) int {
+	d := a.Status.StartTime.Compare(b.Status.StartTime)
+	if d == 0 {
+		d = strings.Compare(a.Name(), b.Name())
+	}
+	return d
+}
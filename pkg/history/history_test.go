@@ -0,0 +1,137 @@
+package history_test
+
+import (
+	"encoding/json"
+	"time"
+
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/history"
+	"github.com/mandelsoft/engine/pkg/impl/database/filesystem"
+	"github.com/mandelsoft/engine/pkg/processing/model/support/db"
+	"github.com/mandelsoft/logging"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+)
+
+var _ = Describe("run history", func() {
+	var odb database.Database[db.Object]
+
+	start := time.Now().Add(-time.Hour)
+
+	record := func(runid string, offset int, finished bool) *history.Record {
+		r := &history.Record{
+			Spec: history.RunSpec{
+				RunId:     runid,
+				Type:      "State",
+				Namespace: "ns",
+				Name:      "A",
+				Phase:     "Phase",
+				Inputs:    map[string]string{"State/ns/B:Phase": "v1"},
+			},
+			Status: history.RunStatus{
+				Status:      "Processing",
+				StartTime:   start.Add(time.Duration(offset) * time.Minute),
+				Transitions: []history.Transition{{Status: "Processing"}},
+			},
+		}
+		if finished {
+			t := r.Status.StartTime.Add(time.Second)
+			r.Status.Status = "Completed"
+			r.Status.EndTime = &t
+		}
+		return r
+	}
+
+	asJSON := func(r *history.Record) string {
+		return string(Must(json.Marshal(r)))
+	}
+
+	BeforeEach(func() {
+		scheme := db.NewScheme[db.Object]()
+		database.MustRegisterType[db.Run, db.Object](scheme)
+		odb = Must(filesystem.New[db.Object](scheme, "/db", memoryfs.New()))
+	})
+
+	It("records runs", func() {
+		h := history.New(logging.DefaultContext(), odb, db.NewRun("", ""), history.Retention{})
+
+		r := record("r1", 0, false)
+		MustBeSuccessful(h.Record(r))
+		Expect(asJSON(Must(h.Get("ns", "r1", "State", "A", "Phase")))).To(MatchJSON(asJSON(r)))
+
+		r.Status.Status = "Completed"
+		r.Status.Transitions = append(r.Status.Transitions, history.Transition{Status: "Completed"})
+		MustBeSuccessful(h.Record(r))
+		Expect(asJSON(Must(h.Get("ns", "r1", "State", "A", "Phase")))).To(MatchJSON(asJSON(r)))
+
+		o := Must(odb.GetObject(database.NewObjectId(db.TYPE_RUN, "ns", r.Name())))
+		Expect(o.GetStatusValue()).To(Equal("Completed"))
+	})
+
+	It("lists runs ordered by start time", func() {
+		h := history.New(logging.DefaultContext(), odb, db.NewRun("", ""), history.Retention{})
+
+		MustBeSuccessful(h.Record(record("r2", 2, false)))
+		MustBeSuccessful(h.Record(record("r1", 1, false)))
+		r := record("r3", 0, false)
+		r.Spec.Namespace = "ns/sub"
+		MustBeSuccessful(h.Record(r))
+
+		runids := func(list []*history.Record) []string {
+			var ids []string
+			for _, r := range list {
+				ids = append(ids, r.Spec.RunId)
+			}
+			return ids
+		}
+		Expect(runids(Must(h.List("ns", false, nil)))).To(Equal([]string{"r1", "r2"}))
+		Expect(runids(Must(h.List("ns", true, nil)))).To(Equal([]string{"r3", "r1", "r2"}))
+		Expect(runids(Must(h.List("", true, func(r *history.Record) bool { return r.Spec.RunId == "r2" })))).To(Equal([]string{"r2"}))
+	})
+
+	It("keeps the configured number of finished runs", func() {
+		h := history.New(logging.DefaultContext(), odb, db.NewRun("", ""), history.Retention{MaxRuns: 2})
+
+		MustBeSuccessful(h.Record(record("r1", 1, true)))
+		MustBeSuccessful(h.Record(record("r2", 2, true)))
+		MustBeSuccessful(h.Record(record("r4", 4, false)))
+		MustBeSuccessful(h.Record(record("r3", 3, true)))
+
+		list := Must(h.List("ns", false, nil))
+		Expect(list).To(HaveLen(3))
+		Expect(list[0].Spec.RunId).To(Equal("r2"))
+		Expect(list[1].Spec.RunId).To(Equal("r3"))
+		Expect(list[2].Spec.RunId).To(Equal("r4"))
+	})
+
+	It("applies the retention to runs recorded by a previous instance", func() {
+		h := history.New(logging.DefaultContext(), odb, db.NewRun("", ""), history.Retention{})
+		MustBeSuccessful(h.Record(record("r1", 1, true)))
+		MustBeSuccessful(h.Record(record("r2", 2, true)))
+		r := record("r0", 0, true)
+		r.Spec.Name = "B"
+		MustBeSuccessful(h.Record(r))
+
+		h = history.New(logging.DefaultContext(), odb, db.NewRun("", ""), history.Retention{MaxRuns: 1})
+		MustBeSuccessful(h.Record(record("r3", 3, true)))
+
+		list := Must(h.List("ns", false, nil))
+		Expect(list).To(HaveLen(2))
+		Expect(list[0].Spec.RunId).To(Equal("r0"))
+		Expect(list[1].Spec.RunId).To(Equal("r3"))
+	})
+
+	It("removes outdated finished runs", func() {
+		h := history.New(logging.DefaultContext(), odb, db.NewRun("", ""), history.Retention{MaxAge: 30 * time.Minute})
+
+		MustBeSuccessful(h.Record(record("r1", 0, true)))
+		MustBeSuccessful(h.Record(record("r2", 50, true)))
+
+		list := Must(h.List("ns", false, nil))
+		Expect(list).To(HaveLen(1))
+		Expect(list[0].Spec.RunId).To(Equal("r2"))
+	})
+})
@@ -0,0 +1,123 @@
+package history
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mandelsoft/engine/pkg/database"
+)
+
+// RunSpec describes the run of an element: the element,
+// what triggered it and which inputs have been used.
+type RunSpec struct {
+	// RunId is the id of the run. All elements locked for
+	// a run share the same id.
+	RunId string `json:"runId"`
+
+	// Type, Namespace, Name and Phase describe the element.
+	Type      string `json:"type"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Phase     string `json:"phase"`
+
+	// Trigger describes the external object version
+	// gathered as target state for the run.
+	Trigger *Trigger `json:"trigger,omitempty"`
+	// Inputs describes the output versions of the
+	// used inputs.
+	Inputs map[string]string `json:"inputs,omitempty"`
+	// InputVersion is the digest of all inputs.
+	InputVersion string `json:"inputVersion,omitempty"`
+	// FormalVersion is the formal graph version of the run.
+	FormalVersion string `json:"formalVersion,omitempty"`
+}
+
+// Trigger is the external object triggering a run.
+type Trigger struct {
+	Type    string `json:"type"`
+	Version string `json:"version"`
+}
+
+// Element provides the string representation of the element id.
+func (s *RunSpec) Element() string {
+	return fmt.Sprintf("%s/%s/%s:%s", s.Type, s.Namespace, s.Name, s.Phase)
+}
+
+// Transition is a status transition of a run.
+type Transition struct {
+	Status  string    `json:"status"`
+	Time    time.Time `json:"time"`
+	Message string    `json:"message,omitempty"`
+}
+
+// RunStatus describes the progress and result of a run.
+type RunStatus struct {
+	// Status is the actual status of the run.
+	Status string `json:"status"`
+	// Message is the last error message.
+	Message string `json:"message,omitempty"`
+	// OutputVersion is the resulting output version of a completed run.
+	OutputVersion string `json:"outputVersion,omitempty"`
+
+	StartTime   time.Time    `json:"startTime"`
+	EndTime     *time.Time   `json:"endTime,omitempty"`
+	Transitions []Transition `json:"transitions,omitempty"`
+}
+
+// IsFinished checks whether the run has been finished.
+func (s *RunStatus) IsFinished() bool {
+	return s.EndTime != nil
+}
+
+// Record is the complete description of a run.
+type Record struct {
+	Spec   RunSpec   `json:"spec"`
+	Status RunStatus `json:"status"`
+}
+
+// Copy provides a deep copy of the record.
+func (r *Record) Copy() *Record {
+	if r == nil {
+		return nil
+	}
+	c := *r
+	if r.Spec.Trigger != nil {
+		t := *r.Spec.Trigger
+		c.Spec.Trigger = &t
+	}
+	if r.Spec.Inputs != nil {
+		c.Spec.Inputs = map[string]string{}
+		for k, v := range r.Spec.Inputs {
+			c.Spec.Inputs[k] = v
+		}
+	}
+	if r.Status.EndTime != nil {
+		t := *r.Status.EndTime
+		c.Status.EndTime = &t
+	}
+	c.Status.Transitions = append([]Transition(nil), r.Status.Transitions...)
+	return &c
+}
+
+// Name provides the name of the run object for the record.
+// It is unique for the element in its namespace.
+func (r *Record) Name() string {
+	return ObjectName(r.Spec.RunId, r.Spec.Type, r.Spec.Name, r.Spec.Phase)
+}
+
+// ObjectName provides the name of a run object for
+// a run of an element. Object names are restricted
+// to alphanumeric characters, dashes and underscores,
+// therefore the components are separated by underscores.
+func ObjectName(runid, typ, name, phase string) string {
+	return fmt.Sprintf("%s_%s_%s_%s", typ, name, phase, runid)
+}
+
+// Run is the interface of database objects used to
+// persist run records.
+type Run interface {
+	database.Object
+
+	GetRunRecord() Record
+	SetRunRecord(Record)
+}
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/mandelsoft/engine/pkg/database"
 	"github.com/mandelsoft/goutils/general"
@@ -47,7 +48,8 @@ func (d *Database[O]) SchemeTypes() database.SchemeTypes[O] {
 	return d.encoding
 }
 
-func (d *Database[O]) ListObjects(typ string, closure bool, ns string) ([]O, error) {
+func (d *Database[O]) ListObjects(typ string, closure bool, ns string) (_ []O, err error) {
+	defer database.ObserveOperation(database.OP_LIST, typ, time.Now(), &err)
 	d.lock.Lock()
 	defer d.lock.Unlock()
 
@@ -66,7 +68,8 @@ func (d *Database[O]) ListObjects(typ string, closure bool, ns string) ([]O, err
 	return result, err
 }
 
-func (d *Database[O]) ListObjectIds(typ string, closure bool, ns string, atomic ...func()) ([]database.ObjectId, error) {
+func (d *Database[O]) ListObjectIds(typ string, closure bool, ns string, atomic ...func()) (_ []database.ObjectId, err error) {
+	defer database.ObserveOperation(database.OP_LIST, typ, time.Now(), &err)
 	d.lock.Lock()
 	defer d.lock.Unlock()
 	list, err := d.listObjectIds(typ, closure, ns)
@@ -141,11 +144,12 @@ func (d *Database[O]) list(typ, ns string, dir, closure bool) ([]database.Object
 	return result, nil
 }
 
-func (d *Database[O]) GetObject(id database.ObjectId) (O, error) {
+func (d *Database[O]) GetObject(id database.ObjectId) (_ O, err error) {
 	var _nil O
 	if !CheckId(id) {
 		return _nil, fmt.Errorf("invalid id %q", id)
 	}
+	defer database.ObserveOperation(database.OP_GET, id.GetType(), time.Now(), &err)
 
 	d.lock.Lock()
 	defer d.lock.Unlock()
@@ -175,16 +179,17 @@ func (d *Database[O]) get(id database.ObjectId) (O, error) {
 	return o, nil
 }
 
-func (d *Database[O]) SetObject(o O) error {
+func (d *Database[O]) SetObject(o O) (err error) {
 	if !CheckId(o) {
 		return fmt.Errorf("invalid id %q", database.NewObjectIdFor(o))
 	}
+	defer database.ObserveOperation(database.OP_SET, o.GetType(), time.Now(), &err)
 
 	path := d.OPath(o)
 
 	log := logging.DefaultContext().Logger(REALM)
 	log.Debug("set object", "path", path)
-	err := d.fs.MkdirAll(filepath.Dir(path), 0o700)
+	err = d.fs.MkdirAll(filepath.Dir(path), 0o700)
 	if err != nil {
 		log.LogError(err, "cannot create folder", "path", filepath.Dir(path))
 		return err
@@ -256,6 +261,7 @@ func (d *Database[O]) DeleteObject(id database.ObjectId) (done bool, err error)
 	if !CheckId(id) {
 		return false, fmt.Errorf("invalid id %q", id)
 	}
+	defer database.ObserveOperation(database.OP_DELETE, id.GetType(), time.Now(), &err)
 	path := d.OPath(id)
 	log := logging.DefaultContext().Logger(REALM)
 
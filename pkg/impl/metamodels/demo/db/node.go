@@ -43,12 +43,13 @@ type NodeSpec struct {
 }
 
 type NodeStatus struct {
-	Status           model.Status `json:"status,omitempty"`
-	Message          string       `json:"message,omitempty"`
-	RunId            RunId        `json:"runid,omitempty"`
-	DetectedVersion  string       `json:"detectedVersion,omitempty"`
-	ObservedVersion  string       `json:"observedVersion,omitempty"`
-	EffectiveVersion string       `json:"effectiveVersion,omitempty"`
+	Status           model.Status     `json:"status,omitempty"`
+	Message          string           `json:"message,omitempty"`
+	Conditions       model.Conditions `json:"conditions,omitempty"`
+	RunId            RunId            `json:"runid,omitempty"`
+	DetectedVersion  string           `json:"detectedVersion,omitempty"`
+	ObservedVersion  string           `json:"observedVersion,omitempty"`
+	EffectiveVersion string           `json:"effectiveVersion,omitempty"`
 
 	Result *int `json:"result,omitempty"`
 }
@@ -8,5 +8,7 @@ import (
 var Scheme = db.NewScheme[db.Object]()
 
 func init() {
-	database.MustRegisterType[db.Namespace, db.Object](Scheme) // Goland requires second type parameter
+	database.MustRegisterType[db.Namespace, db.Object](Scheme)   // Goland requires second type parameter
+	database.MustRegisterType[db.Lease, db.Object](Scheme)       // Goland requires second type parameter
+	database.MustRegisterType[db.ShardMember, db.Object](Scheme) // Goland requires second type parameter
 }
@@ -31,6 +31,10 @@ func (n *Node) UpdateStatus(lctx model.Logging, ob objectbase.Objectbase, elem E
 	_, err := wrapped2.Modify(ob, n, func(_o db2.Object) (bool, bool) {
 		o := _o.(*db.Node)
 		mod := false
+		support.UpdateConditions(&o.Status.Conditions, o, update, &mod)
+		if update.IsObservationReport() {
+			return mod, mod
+		}
 		support.UpdateField(&o.Status.RunId, update.RunId, &mod)
 		support.UpdateField(&o.Status.EffectiveVersion, update.EffectiveVersion, &mod)
 		if update.ObservedVersion != nil {
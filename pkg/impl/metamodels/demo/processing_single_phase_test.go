@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"path/filepath"
 	"runtime"
 	"time"
 
@@ -25,6 +26,7 @@ import (
 	mymodel "github.com/mandelsoft/engine/pkg/impl/metamodels/demo"
 	"github.com/mandelsoft/engine/pkg/impl/metamodels/demo/db"
 	mymetamodel "github.com/mandelsoft/engine/pkg/metamodels/demo"
+	"github.com/mandelsoft/engine/pkg/processing/metamodel"
 	"github.com/mandelsoft/engine/pkg/processing/mmids"
 	"github.com/mandelsoft/engine/pkg/processing/model"
 	db2 "github.com/mandelsoft/engine/pkg/processing/model/support/db"
@@ -38,6 +40,17 @@ import (
 
 const NS = "testspace"
 
+// crossNamespaceMetaModel provides a variant of the demo metamodel
+// accepting operands from other namespaces.
+func crossNamespaceMetaModel() metamodel.MetaModelSpecification {
+	spec := mymetamodel.MetaModelSpecification()
+	spec.InternalTypes = []metamodel.InternalTypeSpecification{
+		metamodel.IntSpec(mymetamodel.TYPE_NODE_STATE,
+			metamodel.PhaseSpec(mymetamodel.PHASE_UPDATING, metamodel.CrossNamespaceDep(mymetamodel.TYPE_NODE_STATE, mymetamodel.PHASE_UPDATING))),
+	}
+	return spec
+}
+
 var _ = Describe("Processing", func() {
 	var fs vfs.FileSystem
 	// var ob objectbase.Objectbase
@@ -153,6 +166,75 @@ var _ = Describe("Processing", func() {
 		})
 	})
 
+	Context("cross namespace", func() {
+		const OTHER = "otherspace"
+
+		BeforeEach(func() {
+			spec := mymodel.NewModelSpecification("test", filesystem.NewSpecification[db2.Object]("testdata", fs))
+			spec.MetaModel = crossNamespaceMetaModel()
+			MustBeSuccessful(spec.Validate())
+
+			m := Must(model.NewModel(spec))
+			proc = Must(processor.NewController(lctx, m, 1))
+			odb = objectbase.GetDatabase[db2.Object](proc.Model().ObjectBase())
+		})
+
+		It("node with operand in other namespace", func() {
+			proc.Start(ctx)
+
+			n5 := db.NewValueNode(OTHER, "A", 5)
+			MustBeSuccessful(odb.SetObject(n5))
+			n6 := db.NewValueNode(NS, "B", 6)
+			MustBeSuccessful(odb.SetObject(n6))
+			na := db.NewOperatorNode(NS, "C", db.OP_ADD, mmids.QualifiedName(NS, OTHER, "A"), "B")
+			MustBeSuccessful(odb.SetObject(na))
+
+			cid := mmids.NewElementId(mymetamodel.TYPE_NODE_STATE, NS, "C", mymetamodel.FINAL_PHASE)
+			aid := mmids.NewElementId(mymetamodel.TYPE_NODE_STATE, OTHER, "A", mymetamodel.FINAL_PHASE)
+			Expect(proc.WaitFor(ctxutil.TimeoutContext(ctx, 20*time.Second), model.STATUS_COMPLETED, cid)).To(BeTrue())
+
+			nan := Must(odb.GetObject(na))
+			Expect(nan.(*db.Node).Status.Result).NotTo(BeNil())
+			Expect(*nan.(*db.Node).Status.Result).To(Equal(11))
+
+			Expect(proc.GetElement(cid).GetCurrentState().GetLinks()).To(ContainElement(aid))
+			evt := processor.NewWatchEvent(proc.GetElement(cid))
+			Expect(evt.ForeignLinks).To(ConsistOf(watch2.NewId(aid)))
+
+			d := Must(proc.Describe(n5, 0))
+			Expect(d.Phases).To(HaveLen(1))
+			Expect(d.Phases[0].Downstream).To(HaveLen(1))
+			Expect(d.Phases[0].Downstream[0].Id).To(Equal(cid))
+
+			dbo := (db2.Object)(n5)
+			_ = Must(database.Modify(odb, &dbo, func(o db2.Object) (bool, bool) {
+				o.(*db.Node).Spec.Value = generics.Pointer(7)
+				return true, true
+			}))
+
+			Eventually(func() *int {
+				n := Must(odb.GetObject(na))
+				return n.(*db.Node).Status.Result
+			}, 20*time.Second).Should(Equal(generics.Pointer(13)))
+		})
+
+		It("does not report objects with dependants in other namespaces as orphans", func() {
+			proc.Start(ctx)
+
+			n5 := db.NewValueNode(OTHER, "A", 5)
+			MustBeSuccessful(odb.SetObject(n5))
+			na := db.NewOperatorNode(NS, "C", db.OP_ADD, mmids.QualifiedName(NS, OTHER, "A"))
+			MustBeSuccessful(odb.SetObject(na))
+
+			cid := mmids.NewElementId(mymetamodel.TYPE_NODE_STATE, NS, "C", mymetamodel.FINAL_PHASE)
+			Expect(proc.WaitFor(ctxutil.TimeoutContext(ctx, 20*time.Second), model.STATUS_COMPLETED, cid)).To(BeTrue())
+
+			// remove the triggering object bypassing the database
+			MustBeSuccessful(fs.Remove(filepath.Join("testdata", filesystem.Path(database.NewObjectIdFor(n5)))))
+			Expect(Must(proc.Orphans())).To(BeEmpty())
+		})
+	})
+
 	Context("watches", func() {
 		var ctx context.Context
 		var services service.Services
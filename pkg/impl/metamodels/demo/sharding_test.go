@@ -0,0 +1,86 @@
+package demo_test
+
+import (
+	"time"
+
+	. "github.com/mandelsoft/engine/pkg/processing/testutils"
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	mymodel "github.com/mandelsoft/engine/pkg/impl/metamodels/demo"
+	"github.com/mandelsoft/engine/pkg/impl/metamodels/demo/db"
+	mymetamodel "github.com/mandelsoft/engine/pkg/metamodels/demo"
+	"github.com/mandelsoft/engine/pkg/processing/mmids"
+	"github.com/mandelsoft/engine/pkg/processing/model"
+	db2 "github.com/mandelsoft/engine/pkg/processing/model/support/db"
+)
+
+var _ = Describe("Sharding", func() {
+	const NSA = "shard-a"
+	const NSB = "shard-b"
+
+	var envA, envB *TestEnv
+
+	crossNamespace := func(name string, dbspec database.Specification[db2.Object]) model.ModelSpecification {
+		spec := mymodel.NewModelSpecification(name, dbspec)
+		spec.MetaModel = crossNamespaceMetaModel()
+		return spec
+	}
+
+	newEnv := func(identity string, ns string, opts ...Option) *TestEnv {
+		env := Must(NewTestEnv("test", "testdata", crossNamespace, append(opts, Sharding(identity, time.Second, ns))...))
+		env.Start()
+		return env
+	}
+
+	members := func(env *TestEnv) func() []string {
+		return func() []string {
+			return env.Processor().Assignment().Members()
+		}
+	}
+
+	acknowledged := func(env *TestEnv) func() bool {
+		return func() bool {
+			return env.Processor().Assignment().Acknowledged()
+		}
+	}
+
+	AfterEach(func() {
+		// envB shares the filesystem of envA
+		if envB != nil {
+			envB.Cleanup()
+		}
+		envA.Cleanup()
+	})
+
+	It("does not orphan elements linking a namespace released to another shard", func() {
+		envA = newEnv("A", NSA)
+		Eventually(members(envA), 5*time.Second).Should(ConsistOf("A"))
+
+		cid := mmids.NewElementId(mymetamodel.TYPE_NODE_STATE, NSA, "C", mymetamodel.FINAL_PHASE)
+		fC := envA.CompletedFuture(cid)
+		MustBeSuccessful(envA.SetObject(db.NewValueNode(NSB, "A", 5)))
+		nC := db.NewOperatorNode(NSA, "C", db.OP_ADD, mmids.QualifiedName(NSA, NSB, "A"))
+		MustBeSuccessful(envA.SetObject(nC))
+		Expect(envA.WaitWithTimeout(fC)).To(BeTrue())
+
+		envB = newEnv("B", NSB, FileSystem(envA.FileSystem()))
+		Eventually(members(envA), 5*time.Second).Should(ConsistOf("A", "B"))
+		Eventually(acknowledged(envA), 5*time.Second).Should(BeTrue())
+		envA.Stop()
+
+		// after a restart, the linked element is not loaded by
+		// instance A, but its object still exists.
+		restarted := newEnv("A", NSA, FileSystem(envA.FileSystem()))
+		defer restarted.Cleanup()
+		Eventually(members(restarted), 5*time.Second).Should(ConsistOf("A", "B"))
+		Expect(restarted.Processor().Model().Namespaces()).NotTo(ContainElement(NSB))
+		Expect(restarted.Processor().Model().GetElement(cid)).NotTo(BeNil())
+
+		Consistently(func() string {
+			return Must(restarted.GetObject(nC)).(*db.Node).Status.Message
+		}, 2*time.Second).ShouldNot(HaveSuffix(" deleted"))
+	})
+})
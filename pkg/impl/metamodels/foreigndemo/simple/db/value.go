@@ -35,13 +35,14 @@ type ValueStatus struct {
 	// and propagated as part of the status in the external object.
 	ValueStateSpec `json:",inline"`
 
-	Status           model.Status `json:"status,omitempty"`
-	Message          string       `json:"message,omitempty"`
-	RunId            RunId        `json:"runid,omitempty"`
-	FormalVersion    string       `json:"formalVersion,omitempty"`
-	DetectedVersion  string       `json:"detectedVersion,omitempty"`
-	ObservedVersion  string       `json:"observedVersion,omitempty"`
-	EffectiveVersion string       `json:"effectiveVersion,omitempty"`
+	Status           model.Status     `json:"status,omitempty"`
+	Message          string           `json:"message,omitempty"`
+	Conditions       model.Conditions `json:"conditions,omitempty"`
+	RunId            RunId            `json:"runid,omitempty"`
+	FormalVersion    string           `json:"formalVersion,omitempty"`
+	DetectedVersion  string           `json:"detectedVersion,omitempty"`
+	ObservedVersion  string           `json:"observedVersion,omitempty"`
+	EffectiveVersion string           `json:"effectiveVersion,omitempty"`
 }
 
 func NewValueNode(ns, n string, value int) *Value {
@@ -33,6 +33,10 @@ func (n *Operator) UpdateStatus(lctx model.Logging, ob objectbase.Objectbase, el
 	_, err := wrapped.Modify(ob, n, func(_o db2.Object) (bool, bool) {
 		o := _o.(*db.Operator)
 		mod := false
+		support.UpdateConditions(&o.Status.Conditions, o, update, &mod)
+		if update.IsObservationReport() {
+			return mod, mod
+		}
 		support.UpdateField(&o.Status.Phase, generics.Pointer(elem.GetPhase()), &mod)
 		support.UpdateField(&o.Status.RunId, update.RunId, &mod)
 		support.UpdateField(&o.Status.DetectedVersion, update.DetectedVersion, &mod)
@@ -0,0 +1,79 @@
+package sub_test
+
+import (
+	. "github.com/mandelsoft/engine/pkg/processing/testutils"
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/processing/metamodel"
+	"github.com/mandelsoft/engine/pkg/processing/mmids"
+	"github.com/mandelsoft/engine/pkg/processing/model"
+
+	"github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub/controllers"
+	"github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub/db"
+	mymetamodel "github.com/mandelsoft/engine/pkg/metamodels/foreigndemo"
+)
+
+var _ = Describe("Approval", func() {
+	var env *TestEnv
+	var approval *model.ApprovalState
+
+	eid := mmids.NewElementId(mymetamodel.TYPE_OPERATOR_STATE, NS, "C", mymetamodel.PHASE_EXPOSE)
+	oid := database.NewObjectId(mymetamodel.TYPE_OPERATOR, NS, "C")
+
+	BeforeEach(func() {
+		env = Must(NewTestEnv("test", "testdata", withPhase(mymetamodel.TYPE_OPERATOR_STATE, mymetamodel.PHASE_EXPOSE, metamodel.PhaseSpecification.WithApproval)))
+		env.AddService(controllers.NewExpressionController(env.Logging(), 1, env.Database()))
+		env.Start()
+
+		fa := env.FutureFor(model.STATUS_AWAITING_APPROVAL, eid)
+		MustBeSuccessful(env.SetObject(db.NewValueNode(NS, "A", 5)))
+		opC := db.NewOperatorNode(NS, "C").
+			AddOperand("iA", "A").
+			AddOperation("eA", db.OP_ADD, "iA", "iA").
+			AddOutput("C-A", "eA")
+		MustBeSuccessful(env.SetObject(opC))
+		Expect(env.WaitWithTimeout(fa)).To(BeTrue())
+
+		e := env.Processor().Model().GetNamespace(NS).GetElement(eid)
+		approval = e.GetObject().GetApprovalState(eid.GetPhase())
+		Expect(approval).NotTo(BeNil())
+		Expect(approval.RunId).To(Equal(e.GetLock()))
+		Expect(approval.Decision).To(Equal(model.APPROVAL_PENDING))
+
+		o := Must(env.GetObject(oid))
+		Expect(o.(*db.Operator).Status.Status).To(Equal(model.STATUS_AWAITING_APPROVAL))
+		Expect(o.(*db.Operator).Status.Message).To(ContainSubstring(string(approval.RunId)))
+	})
+
+	AfterEach(func() {
+		if env != nil {
+			env.Cleanup()
+		}
+	})
+
+	It("processes approved runs", func() {
+		mCA := ValueCompleted(env, "C-A")
+		Expect(env.Processor().Approve(eid, approval.RunId, approval.InputVersion, "")).To(Succeed())
+		Expect(env.Wait(mCA)).To(BeTrue())
+		mCA.Check(env, 10, "C")
+	})
+
+	It("fails rejected runs", func() {
+		ff := env.FutureFor(model.STATUS_FAILED, eid)
+		_, err := env.Processor().DecideObject(oid, "", approval.RunId, approval.InputVersion, model.APPROVAL_REJECTED, "not allowed")
+		Expect(err).To(Succeed())
+		Expect(env.WaitWithTimeout(ff)).To(BeTrue())
+
+		o := Must(env.GetObject(oid))
+		Expect(o.(*db.Operator).Status.Status).To(Equal(model.STATUS_FAILED))
+		Expect(o.(*db.Operator).Status.Message).To(Equal("run rejected: " + string(approval.RunId) + ": not allowed"))
+	})
+
+	It("rejects decisions for other input versions", func() {
+		err := env.Processor().Approve(eid, approval.RunId, "other", "")
+		Expect(err).To(MatchError("no pending approval for run " + string(approval.RunId) + " with input version other for element " + eid.String()))
+	})
+})
@@ -0,0 +1,103 @@
+package sub_test
+
+import (
+	. "github.com/mandelsoft/engine/pkg/processing/testutils"
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/processing/model"
+	db2 "github.com/mandelsoft/engine/pkg/processing/model/support/db"
+
+	mymodel "github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub"
+	"github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub/controllers"
+	"github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub/db"
+	mymetamodel "github.com/mandelsoft/engine/pkg/metamodels/foreigndemo"
+)
+
+var _ = Describe("Conditions", func() {
+	var env *TestEnv
+
+	oid := database.NewObjectId(mymetamodel.TYPE_OPERATOR, NS, "C")
+
+	conditions := func() model.Conditions {
+		return Must(env.GetObject(oid)).(*db.Operator).Status.Conditions
+	}
+
+	BeforeEach(func() {
+		env = Must(NewTestEnv("test", "testdata", mymodel.NewModelSpecification))
+		env.AddService(controllers.NewExpressionController(env.Logging(), 1, env.Database()))
+		env.Start()
+	})
+
+	AfterEach(func() {
+		env.Cleanup()
+	})
+
+	It("reports a condition per phase and the ready condition", func() {
+		mCA := ValueCompleted(env, "C-A")
+		MustBeSuccessful(env.SetObject(db.NewValueNode(NS, "A", 5)))
+		opC := db.NewOperatorNode(NS, "C").
+			AddOperand("iA", "A").
+			AddOperation("eA", db.OP_ADD, "iA", "iA").
+			AddOutput("C-A", "eA")
+		MustBeSuccessful(env.SetObject(opC))
+		Expect(env.Wait(mCA)).To(BeTrue())
+
+		Eventually(func() model.ConditionStatus {
+			c := conditions().Get(model.CONDITION_READY)
+			if c == nil {
+				return ""
+			}
+			return c.Status
+		}, "5s").Should(Equal(model.CONDITION_TRUE))
+
+		Eventually(func() bool {
+			o := Must(env.GetObject(oid)).(*db.Operator)
+			return o.Status.Conditions.Get(model.CONDITION_READY).ObservedDigest == db2.ContentDigest(o)
+		}, "5s").Should(BeTrue())
+
+		list := conditions()
+		for _, ph := range []string{string(mymetamodel.PHASE_GATHER), string(mymetamodel.PHASE_EXPOSE)} {
+			c := list.Get(ph)
+			Expect(c).NotTo(BeNil())
+			Expect(c.Status).To(Equal(model.CONDITION_TRUE))
+			Expect(c.Reason).To(Equal(string(model.STATUS_COMPLETED)))
+		}
+		observed := list.Get(model.CONDITION_READY).ObservedGeneration
+		Expect(list.Get(string(mymetamodel.PHASE_GATHER)).ObservedGeneration).To(Equal(observed))
+
+		// an object changed without requiring processing is observed again
+		o := Must(env.GetObject(oid)).(*db.Operator)
+		o.AddFinalizer("test")
+		MustBeSuccessful(env.SetObject(o))
+		o = Must(env.GetObject(oid)).(*db.Operator)
+		Expect(o.GetGeneration()).To(BeNumerically(">", observed))
+		// a concurrent status update may increase the generation
+		// observed by the engine, but not the content.
+		Eventually(func() bool {
+			c := conditions().Get(model.CONDITION_READY)
+			return c.ObservedGeneration >= o.GetGeneration() && c.ObservedDigest == db2.ContentDigest(o)
+		}, "5s").Should(BeTrue())
+		Expect(conditions().Get(model.CONDITION_READY).Status).To(Equal(model.CONDITION_TRUE))
+	})
+
+	It("reports blocked phases", func() {
+		MustBeSuccessful(env.SetObject(db.NewOperatorNode(NS, "C").
+			AddOperand("iA", "A").
+			AddOperation("eA", db.OP_ADD, "iA", "iA").
+			AddOutput("C-A", "eA")))
+
+		Eventually(func() *model.Condition {
+			return conditions().Get(model.CONDITION_READY)
+		}, "5s").Should(And(Not(BeNil()), HaveField("Status", model.CONDITION_FALSE)))
+
+		c := conditions().Get(model.CONDITION_READY)
+		Expect(c.Reason).To(Equal(string(model.STATUS_BLOCKED)))
+		Expect(c.Message).To(ContainSubstring("is " + string(model.STATUS_BLOCKED)))
+		c = conditions().Get(string(mymetamodel.PHASE_GATHER))
+		Expect(c.Status).To(Equal(model.CONDITION_FALSE))
+		Expect(c.Message).To(ContainSubstring("ValueState/" + NS + "/A"))
+	})
+})
@@ -8,6 +8,7 @@ import (
 	"github.com/mandelsoft/engine/pkg/pool"
 	db2 "github.com/mandelsoft/engine/pkg/processing/model/support/db"
 	"github.com/mandelsoft/engine/pkg/service"
+	"github.com/mandelsoft/engine/pkg/tracing"
 	"github.com/mandelsoft/logging"
 )
 
@@ -19,6 +20,7 @@ type ExpressionController struct {
 	db      database.Database[db2.Object]
 	handler *Handler
 	log     logging.Logger
+	tracer  *tracing.Tracer
 }
 
 var _ pool.Action = (*reconciler)(nil)
@@ -28,10 +30,11 @@ func NewExpressionController(lctx logging.AttributionContextProvider, size int,
 	p := pool.NewPool(lctx, "controller", size, 0, true)
 
 	c := &ExpressionController{
-		pool: p,
-		db:   db,
-		sync: true,
-		log:  logging.DynamicLogger(logging.DefaultContext().AttributionContext().WithContext(REALM)),
+		pool:   p,
+		db:     db,
+		sync:   true,
+		tracer: tracing.Default,
+		log:    logging.DynamicLogger(logging.DefaultContext().AttributionContext().WithContext(REALM)),
 	}
 	return c
 }
@@ -40,6 +43,12 @@ func (c *ExpressionController) SetSyncMode(b bool) {
 	c.sync = b
 }
 
+// SetTracer sets the tracer used to trace reconcilations.
+// By default, tracing.Default is used.
+func (c *ExpressionController) SetTracer(t *tracing.Tracer) {
+	c.tracer = t
+}
+
 func (c *ExpressionController) Wait() error {
 	return c.pool.Wait()
 }
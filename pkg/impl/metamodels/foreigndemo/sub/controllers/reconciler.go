@@ -15,6 +15,7 @@ import (
 	"github.com/mandelsoft/engine/pkg/processing/model"
 	"github.com/mandelsoft/engine/pkg/processing/model/support"
 	db2 "github.com/mandelsoft/engine/pkg/processing/model/support/db"
+	"github.com/mandelsoft/engine/pkg/tracing"
 	"github.com/mandelsoft/engine/pkg/version"
 	"github.com/mandelsoft/goutils/generics"
 	"github.com/mandelsoft/goutils/maputils"
@@ -46,7 +47,12 @@ func (c *reconciler) Reconcile(_ pool.Pool, messageContext pool.MessageContext,
 	if err != nil {
 		return pool.StatusCompleted(err)
 	}
-	return newReconcilation(c, log, _o).Reconcile()
+
+	// continue the trace of the run, which lastly updated the expression.
+	span := c.tracer.Start("reconcile "+database.StringId(id), tracing.SpanContextFor(_o), tracing.Attr("controller", "expression"))
+	status := newReconcilation(c, log, _o).Reconcile()
+	span.EndWithError(status.Error)
+	return status
 }
 
 type obj = db.Expression
@@ -61,14 +61,15 @@ func (o Operation) String() string {
 }
 
 type OperatorStatus struct {
-	Phase            Phase        `json:"phase,omitempty"`
-	Status           model.Status `json:"status,omitempty"`
-	Message          string       `json:"message,omitempty"`
-	RunId            RunId        `json:"runid,omitempty"`
-	DetectedVersion  string       `json:"detectedVersion,omitempty"`
-	FormalVersion    string       `json:"formalVersion,omitempty"`
-	ObservedVersion  string       `json:"observedVersion,omitempty"`
-	EffectiveVersion string       `json:"effectiveVersion,omitempty"`
+	Phase            Phase            `json:"phase,omitempty"`
+	Status           model.Status     `json:"status,omitempty"`
+	Message          string           `json:"message,omitempty"`
+	Conditions       model.Conditions `json:"conditions,omitempty"`
+	RunId            RunId            `json:"runid,omitempty"`
+	DetectedVersion  string           `json:"detectedVersion,omitempty"`
+	FormalVersion    string           `json:"formalVersion,omitempty"`
+	ObservedVersion  string           `json:"observedVersion,omitempty"`
+	EffectiveVersion string           `json:"effectiveVersion,omitempty"`
 
 	Result ExposeOutput `json:"result,omitempty"`
 }
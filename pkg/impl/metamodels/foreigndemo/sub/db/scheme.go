@@ -11,13 +11,50 @@ var Scheme = db.NewScheme[db.Object]()
 func init() {
 	database.MustRegisterType[db.Namespace, db.Object](Scheme)     // Goland requires second type parameter
 	database.MustRegisterType[db.UpdateRequest, db.Object](Scheme) // Goland requires second type parameter
+	database.MustRegisterType[db.Lease, db.Object](Scheme)         // Goland requires second type parameter
+	database.MustRegisterType[db.ShardMember, db.Object](Scheme)   // Goland requires second type parameter
+	database.MustRegisterType[db.Run, db.Object](Scheme)           // Goland requires second type parameter
+	database.MustRegisterType[db.Event, db.Object](Scheme)         // Goland requires second type parameter
+
+	database.MustRegisterType[db.Notification, db.Object](Scheme)      // Goland requires second type parameter
+	database.MustRegisterType[db.NotificationEvent, db.Object](Scheme) // Goland requires second type parameter
 }
 
 type Namespace = db.Namespace
 type UpdateRequest = db.UpdateRequest
+type Lease = db.Lease
+type ShardMember = db.ShardMember
+type Run = db.Run
+type Event = db.Event
+type Notification = db.Notification
+type NotificationEvent = db.NotificationEvent
 
 func NewUpdateRequest(ns, n string) *db.UpdateRequest {
 	return &db.UpdateRequest{
 		ObjectMeta: db.NewObjectMeta(mymetamodel.TYPE_UPDATEREQUEST, ns, n),
 	}
 }
+
+func NewLease(ns, n string) *db.Lease {
+	return db.NewLease(ns, n)
+}
+
+func NewShardMember(ns, n string) *db.ShardMember {
+	return db.NewShardMember(ns, n)
+}
+
+func NewRun(ns, n string) *db.Run {
+	return db.NewRun(ns, n)
+}
+
+func NewEvent(ns, n string) *db.Event {
+	return db.NewEvent(ns, n)
+}
+
+func NewNotification(ns, n string) *db.Notification {
+	return db.NewNotification(ns, n)
+}
+
+func NewNotificationEvent(ns, n string) *db.NotificationEvent {
+	return db.NewNotificationEvent(ns, n)
+}
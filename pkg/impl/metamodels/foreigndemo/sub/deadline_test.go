@@ -0,0 +1,107 @@
+package sub_test
+
+import (
+	"slices"
+	"time"
+
+	. "github.com/mandelsoft/engine/pkg/processing/testutils"
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/processing/metamodel"
+	"github.com/mandelsoft/engine/pkg/processing/mmids"
+	"github.com/mandelsoft/engine/pkg/processing/model"
+	db2 "github.com/mandelsoft/engine/pkg/processing/model/support/db"
+
+	mymodel "github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub"
+	"github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub/db"
+	mymetamodel "github.com/mandelsoft/engine/pkg/metamodels/foreigndemo"
+)
+
+// withPhase provides a model creator modifying the specification
+// of a phase of an internal type.
+func withPhase(typ string, phase mmids.Phase, mod func(metamodel.PhaseSpecification) metamodel.PhaseSpecification) ModelCreator {
+	return func(name string, dbspec database.Specification[db2.Object]) model.ModelSpecification {
+		mmspec := mymetamodel.MetaModelSpecification()
+		mmspec.UpdateRequestType = mymetamodel.TYPE_UPDATEREQUEST
+		for i, t := range mmspec.InternalTypes {
+			if t.Name == typ {
+				// don't modify the phases of the shared default specification
+				t.Phases = slices.Clone(t.Phases)
+				for j, p := range t.Phases {
+					if p.Name == phase {
+						t.Phases[j] = mod(p)
+					}
+				}
+				mmspec.InternalTypes[i] = t
+			}
+		}
+		return mymodel.NewModelSpecificationFor(name, mmspec, dbspec)
+	}
+}
+
+// withTimeout provides a model creator setting a deadline for the
+// calculation phase, which is never completed without an expression
+// controller.
+func withTimeout(d time.Duration) ModelCreator {
+	return withPhase(mymetamodel.TYPE_EXPRESSION_STATE, mymetamodel.PHASE_CALCULATE, func(p metamodel.PhaseSpecification) metamodel.PhaseSpecification {
+		return p.WithTimeout(d)
+	})
+}
+
+var _ = Describe("Deadlines", func() {
+	var env *TestEnv
+
+	eid := mmids.NewElementId(mymetamodel.TYPE_EXPRESSION_STATE, NS, "C", mymetamodel.PHASE_CALCULATE)
+
+	AfterEach(func() {
+		if env != nil {
+			env.Cleanup()
+		}
+	})
+
+	setup := func(d time.Duration) {
+		env = Must(NewTestEnv("test", "testdata", withTimeout(d)))
+		env.Processor().SetWatchdogInterval(100 * time.Millisecond)
+		env.Start()
+
+		MustBeSuccessful(env.SetObject(db.NewValueNode(NS, "A", 5)))
+		opC := db.NewOperatorNode(NS, "C").
+			AddOperand("iA", "A").
+			AddOperation("eA", db.OP_ADD, "iA", "iA").
+			AddOutput("C-A", "eA")
+		MustBeSuccessful(env.SetObject(opC))
+	}
+
+	It("fails overdue runs", func() {
+		setup(time.Second)
+		ff := env.FutureFor(model.STATUS_FAILED, eid)
+		Expect(env.WaitWithTimeout(ff)).To(BeTrue())
+
+		o := Must(env.GetObject(database.NewObjectId(mymetamodel.TYPE_EXPRESSION_STATE, NS, "C")))
+		s := o.(*db.ExpressionState)
+		Expect(s.EvaluationState.RunId).To(Equal(mmids.RunId("")))
+		Expect(s.EvaluationState.Status).To(Equal(model.STATUS_FAILED))
+	})
+
+	It("cancels runs", func() {
+		setup(time.Hour)
+		fw := env.FutureFor(model.STATUS_WAITING, eid)
+		Expect(env.WaitWithTimeout(fw)).To(BeTrue())
+
+		ff := env.FutureFor(model.STATUS_FAILED, eid)
+		runs := Must(env.Processor().CancelObject(database.NewObjectId(mymetamodel.TYPE_EXPRESSION, NS, "C"), ""))
+		Expect(runs).To(HaveKey(eid))
+		Expect(env.WaitWithTimeout(ff)).To(BeTrue())
+	})
+
+	It("rejects unknown types", func() {
+		setup(time.Hour)
+		_, err := env.Processor().CancelObject(database.NewObjectId("Unknown", NS, "C"), "")
+		Expect(err).To(MatchError(`unknown type "Unknown"`))
+		_, err = env.Processor().CancelObject(database.NewObjectId(mymetamodel.TYPE_VALUE, NS, "A"), "Unknown")
+		Expect(err).To(MatchError(`unknown phase "Unknown" for type "ValueState"`))
+	})
+})
@@ -0,0 +1,222 @@
+package sub_test
+
+import (
+	"time"
+
+	. "github.com/mandelsoft/engine/pkg/processing/testutils"
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/processing/mmids"
+	"github.com/mandelsoft/engine/pkg/processing/model"
+	"github.com/mandelsoft/engine/pkg/processing/processor"
+
+	mymodel "github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub"
+	"github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub/controllers"
+	"github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub/db"
+	mymetamodel "github.com/mandelsoft/engine/pkg/metamodels/foreigndemo"
+)
+
+var _ = Describe("Deletion policies", func() {
+	var env *TestEnv
+	var vA *db.Value
+	var opC *db.Operator
+
+	BeforeEach(func() {
+		env = Must(NewTestEnv("test", "testdata", mymodel.NewModelSpecification))
+		env.AddService(controllers.NewExpressionController(env.Logging(), 1, env.Database()))
+		env.Start()
+
+		vA = db.NewValueNode(NS, "A", 5)
+		MustBeSuccessful(env.SetObject(vA))
+
+		opC = db.NewOperatorNode(NS, "C").
+			AddOperand("iA", "A").
+			AddOperation("eA", db.OP_ADD, "iA", "iA").
+			AddOutput("C-A", "eA")
+
+		mCA := ValueCompleted(env, "C-A")
+		MustBeSuccessful(env.SetObject(opC))
+		Expect(env.Wait(mCA)).To(BeTrue())
+		mCA.Check(env, 10, "C")
+	})
+
+	AfterEach(func() {
+		if env != nil {
+			env.Cleanup()
+		}
+	})
+
+	deleteWithPolicy := func(id database.ObjectId, policy model.DeletionPolicy) {
+		if policy != "" {
+			o := Must(env.GetObject(id))
+			Expect(o.SetDeletionPolicy(policy)).To(BeTrue())
+			MustBeSuccessful(env.SetObject(o))
+		}
+		MustBeSuccessful(env.DeleteObject(id))
+	}
+
+	gone := func(id database.ObjectId) func() error {
+		return func() error {
+			_, err := env.GetObject(id)
+			return err
+		}
+	}
+
+	It("blocks deletion while dependants exist", func() {
+		deleteWithPolicy(vA, "")
+
+		Eventually(func() string {
+			return Must(env.GetObject(vA)).(*db.Value).Status.Message
+		}).Should(Equal("deletion blocked by dependent elements OperatorState/" + NS + "/C:Gathering"))
+		Consistently(gone(vA), "500ms").Should(Succeed())
+
+		MustBeSuccessful(env.DeleteObject(opC))
+		Eventually(gone(opC), "10s").Should(MatchError(database.ErrNotExist))
+		Eventually(gone(vA), "10s").Should(MatchError(database.ErrNotExist))
+	})
+
+	It("cascades deletion to dependants", func() {
+		deleteWithPolicy(vA, model.DELETION_POLICY_CASCADE)
+
+		Eventually(gone(vA), "10s").Should(MatchError(database.ErrNotExist))
+		Eventually(gone(opC), "10s").Should(MatchError(database.ErrNotExist))
+		Eventually(gone(database.NewObjectId(mymetamodel.TYPE_VALUE, NS, "C-A")), "10s").Should(MatchError(database.ErrNotExist))
+	})
+
+	It("orphans dependants", func() {
+		eid := mmids.NewElementId(mymetamodel.TYPE_OPERATOR_STATE, NS, "C", mymetamodel.PHASE_GATHER)
+		fC := env.FutureFor(model.STATUS_FAILED, eid)
+		deleteWithPolicy(vA, model.DELETION_POLICY_ORPHAN)
+
+		Eventually(gone(vA), "10s").Should(MatchError(database.ErrNotExist))
+		Expect(env.WaitWithTimeout(fC)).To(BeTrue())
+
+		o := Must(env.GetObject(opC))
+		Expect(o.(*db.Operator).Status.Status).To(Equal(model.STATUS_FAILED))
+		Expect(o.(*db.Operator).Status.Message).To(Equal("dependency ValueState/" + NS + "/A:Propagating deleted"))
+	})
+})
+
+var _ = Describe("Force deletion", func() {
+	var env *TestEnv
+	var eC database.ObjectId
+
+	BeforeEach(func() {
+		env = Must(NewTestEnv("test", "testdata", mymodel.NewModelSpecification))
+		env.Processor().RegisterFinalizer(controllers.FINALIZER, "expression controller")
+		env.Start()
+
+		vA := db.NewValueNode(NS, "A", 5)
+		fA := env.CompletedFuture(mmids.NewElementId(mymetamodel.TYPE_VALUE_STATE, NS, "A", mymetamodel.PHASE_PROPAGATE))
+		MustBeSuccessful(env.SetObject(vA))
+		Expect(env.WaitWithTimeout(fA)).To(BeTrue())
+
+		// the expression controller is not running, therefore its
+		// finalizer is never removed.
+		opC := db.NewOperatorNode(NS, "C").
+			AddOperand("iA", "A").
+			AddOperation("eA", db.OP_ADD, "iA", "iA").
+			AddOutput("C-A", "eA")
+		MustBeSuccessful(env.SetObject(opC))
+
+		eC = database.NewObjectId(mymetamodel.TYPE_EXPRESSION, NS, "C")
+		Eventually(func() error {
+			_, err := env.GetObject(eC)
+			return err
+		}, "10s").Should(Succeed())
+		Eventually(func() error {
+			o := Must(env.GetObject(eC))
+			o.AddFinalizer(controllers.FINALIZER)
+			return env.SetObject(o)
+		}, "10s").Should(Succeed())
+		Must(env.DeleteObject(opC))
+	})
+
+	AfterEach(func() {
+		if env != nil {
+			env.Cleanup()
+		}
+	})
+
+	stuck := func() []*processor.StuckDeletion {
+		return Must(env.Processor().StuckDeletions(0))
+	}
+
+	stuckIds := func() []string {
+		var ids []string
+		for _, s := range stuck() {
+			ids = append(ids, database.StringId(s.Id))
+		}
+		return ids
+	}
+
+	It("reports stuck deletions", func() {
+		Eventually(stuckIds, "10s").Should(Equal([]string{database.StringId(eC)}))
+		s := stuck()[0]
+		Expect(s.Finalizers).To(ConsistOf(
+			processor.FinalizerOwner{Finalizer: processor.FINALIZER, Controller: "processor"},
+			processor.FinalizerOwner{Finalizer: controllers.FINALIZER, Controller: "expression controller"},
+		))
+		Expect(s.Locks).To(HaveKey(mmids.NewElementId(mymetamodel.TYPE_EXPRESSION_STATE, NS, "C", mymetamodel.PHASE_CALCULATE)))
+
+		Expect(Must(env.Processor().StuckDeletions(time.Hour))).To(BeEmpty())
+	})
+
+	It("requires enabled force deletion", func() {
+		Eventually(stuckIds, "10s").Should(Equal([]string{database.StringId(eC)}))
+		_, err := env.Processor().ForceDelete(eC)
+		Expect(err).To(MatchError(processor.ErrForceDeletionDisabled))
+	})
+
+	It("fails for unknown objects", func() {
+		env.Processor().EnableForceDeletion(true)
+		_, err := env.Processor().ForceDelete(database.NewObjectId(mymetamodel.TYPE_EXPRESSION, NS, "X"))
+		Expect(err).To(MatchError(database.ErrNotExist))
+	})
+
+	It("enforces deletion and rolls back locks", func() {
+		env.Processor().EnableForceDeletion(true)
+		Eventually(stuckIds, "10s").Should(Equal([]string{database.StringId(eC)}))
+
+		eid := mmids.NewElementId(mymetamodel.TYPE_EXPRESSION_STATE, NS, "C", mymetamodel.PHASE_CALCULATE)
+		Expect(Must(env.Processor().ForceDelete(eC))).To(ConsistOf(eid))
+
+		Eventually(func() error {
+			_, err := env.GetObject(eC)
+			return err
+		}, "10s").Should(MatchError(database.ErrNotExist))
+		Eventually(func() error {
+			_, err := env.GetObject(eid.ObjectId())
+			return err
+		}, "10s").Should(MatchError(database.ErrNotExist))
+		Eventually(func() processor.Element {
+			return env.Processor().Model().GetElement(eid)
+		}, "10s").Should(BeNil())
+		Expect(stuck()).To(BeEmpty())
+	})
+
+	It("resumes an enforced deletion after a restart", func() {
+		Eventually(stuckIds, "10s").Should(Equal([]string{database.StringId(eC)}))
+		env.Stop()
+
+		// simulate a processor stopped after recording the enforced
+		// deletion, but before the elements have been cleaned up.
+		eid := mmids.NewElementId(mymetamodel.TYPE_EXPRESSION_STATE, NS, "C", mymetamodel.PHASE_CALCULATE)
+		s := Must(env.GetObject(eid.ObjectId()))
+		s.SetForcedDeletion(eid.GetPhase())
+		MustBeSuccessful(env.SetObject(s))
+
+		restarted := Must(NewTestEnv("test", "testdata", mymodel.NewModelSpecification, FileSystem(env.FileSystem())))
+		defer restarted.Cleanup()
+		MustBeSuccessful(restarted.Start())
+
+		Eventually(func() error {
+			_, err := restarted.GetObject(eid.ObjectId())
+			return err
+		}, "10s").Should(MatchError(database.ErrNotExist))
+		Expect(restarted.Processor().Model().GetElement(eid)).To(BeNil())
+	})
+})
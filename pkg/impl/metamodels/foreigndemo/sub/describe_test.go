@@ -0,0 +1,120 @@
+package sub_test
+
+import (
+	. "github.com/mandelsoft/engine/pkg/processing/testutils"
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/processing/mmids"
+	"github.com/mandelsoft/engine/pkg/processing/model"
+	"github.com/mandelsoft/engine/pkg/processing/processor"
+
+	mymodel "github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub"
+	"github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub/controllers"
+	"github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub/db"
+	mymetamodel "github.com/mandelsoft/engine/pkg/metamodels/foreigndemo"
+)
+
+var _ = Describe("Describe", func() {
+	var env *TestEnv
+
+	oid := database.NewObjectId(mymetamodel.TYPE_OPERATOR, NS, "C")
+	gather := mmids.NewElementId(mymetamodel.TYPE_OPERATOR_STATE, NS, "C", mymetamodel.PHASE_GATHER)
+	expose := mmids.NewElementId(mymetamodel.TYPE_OPERATOR_STATE, NS, "C", mymetamodel.PHASE_EXPOSE)
+	valueA := mmids.NewElementId(mymetamodel.TYPE_VALUE_STATE, NS, "A", mymetamodel.PHASE_PROPAGATE)
+	valueCA := mmids.NewElementId(mymetamodel.TYPE_VALUE_STATE, NS, "C-A", mymetamodel.PHASE_PROPAGATE)
+
+	BeforeEach(func() {
+		env = Must(NewTestEnv("test", "testdata", mymodel.NewModelSpecification))
+		env.AddService(controllers.NewExpressionController(env.Logging(), 1, env.Database()))
+		env.Start()
+	})
+
+	AfterEach(func() {
+		env.Cleanup()
+	})
+
+	It("describes the phases, dependencies and slaves", func() {
+		mCA := ValueCompleted(env, "C-A")
+		MustBeSuccessful(env.SetObject(db.NewValueNode(NS, "A", 5)))
+		MustBeSuccessful(env.SetObject(db.NewOperatorNode(NS, "C").
+			AddOperand("iA", "A").
+			AddOperation("eA", db.OP_ADD, "iA", "iA").
+			AddOutput("C-A", "eA")))
+		Expect(env.Wait(mCA)).To(BeTrue())
+
+		d := Must(env.Processor().Describe(oid, processor.DEFAULT_DESCRIBE_LIMIT))
+		Expect(d.Id).To(Equal(gather.ObjectId()))
+		Expect(d.Finalizers).To(ContainElement(processor.FINALIZER))
+		Expect(d.Phases).To(HaveLen(2))
+		Expect(d.Slaves).To(ContainElements(valueCA, mmids.NewElementId(mymetamodel.TYPE_EXPRESSION_STATE, NS, "C", mymetamodel.PHASE_CALCULATE)))
+
+		for _, ph := range d.Phases {
+			Expect(ph.Status).To(Equal(model.STATUS_COMPLETED))
+			Expect(ph.Lock).To(BeEmpty())
+			Expect(ph.Current).NotTo(BeNil())
+			Expect(ph.Target).To(BeNil())
+
+			switch ph.Id {
+			case gather:
+				Expect(ph.Current.Links).To(ContainElement(valueA))
+				Expect(ph.Upstream).To(ContainElement(HaveField("Id", valueA)))
+				Expect(ph.Downstream).To(ContainElement(HaveField("Id", expose)))
+				// the tree continues via the expose phase to the slave
+				for _, dep := range ph.Downstream {
+					if dep.Id == expose {
+						Expect(dep.Dependencies).To(ContainElement(HaveField("Id", valueCA)))
+					}
+				}
+			case expose:
+				Expect(ph.Upstream).To(ContainElement(HaveField("Id", gather)))
+				Expect(ph.Downstream).To(ContainElement(And(HaveField("Id", valueCA), HaveField("Status", model.STATUS_COMPLETED))))
+			default:
+				Fail("unexpected phase " + ph.Id.String())
+			}
+		}
+	})
+
+	It("records the masters per phase", func() {
+		mCA := ValueCompleted(env, "C-A")
+		MustBeSuccessful(env.SetObject(db.NewValueNode(NS, "A", 5)))
+		MustBeSuccessful(env.SetObject(db.NewOperatorNode(NS, "C").
+			AddOperand("iA", "A").
+			AddOperation("eA", db.OP_ADD, "iA", "iA").
+			AddOutput("C-A", "eA")))
+		Expect(env.Wait(mCA)).To(BeTrue())
+
+		o := env.Processor().Model().GetNamespace(NS).GetElement(valueCA).GetObject()
+		Expect(o.GetSlaveInfo(valueCA.GetPhase()).Master(NS)).To(Equal(expose))
+
+		Expect(Must(o.SetMaster(env.Processor().Model().ObjectBase(), "other", gather))).To(BeTrue())
+		Expect(o.GetSlaveInfo(valueCA.GetPhase()).Master(NS)).To(Equal(expose))
+		Expect(o.GetSlaveInfo("other").Master(NS)).To(Equal(gather))
+		Expect(Must(o.SetMaster(env.Processor().Model().ObjectBase(), valueCA.GetPhase(), expose))).To(BeFalse())
+	})
+
+	It("keeps the slaves after a restart", func() {
+		mCA := ValueCompleted(env, "C-A")
+		MustBeSuccessful(env.SetObject(db.NewValueNode(NS, "A", 5)))
+		MustBeSuccessful(env.SetObject(db.NewOperatorNode(NS, "C").
+			AddOperand("iA", "A").
+			AddOperation("eA", db.OP_ADD, "iA", "iA").
+			AddOutput("C-A", "eA")))
+		Expect(env.Wait(mCA)).To(BeTrue())
+		env.Stop()
+
+		restarted := Must(NewTestEnv("test", "testdata", mymodel.NewModelSpecification, FileSystem(env.FileSystem())))
+		defer restarted.Cleanup()
+		MustBeSuccessful(restarted.Start())
+
+		d := Must(restarted.Processor().Describe(oid, processor.DEFAULT_DESCRIBE_LIMIT))
+		Expect(d.Slaves).To(ConsistOf(valueCA, mmids.NewElementId(mymetamodel.TYPE_EXPRESSION_STATE, NS, "C", mymetamodel.PHASE_CALCULATE)))
+	})
+
+	It("fails for unknown objects", func() {
+		_, err := env.Processor().Describe(database.NewObjectId(mymetamodel.TYPE_OPERATOR, NS, "X"), 0)
+		Expect(err).To(MatchError(database.ErrNotExist))
+	})
+})
@@ -0,0 +1,84 @@
+package sub_test
+
+import (
+	. "github.com/mandelsoft/engine/pkg/processing/testutils"
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/processing/processor"
+	"github.com/mandelsoft/engine/pkg/recorder"
+
+	mymodel "github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub"
+	"github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub/controllers"
+	"github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub/db"
+	mymetamodel "github.com/mandelsoft/engine/pkg/metamodels/foreigndemo"
+)
+
+var _ = Describe("Events", func() {
+	var env *TestEnv
+
+	oid := database.NewObjectId(mymetamodel.TYPE_OPERATOR, NS, "C")
+
+	reasons := func(list []*recorder.Record, phase string) []string {
+		var r []string
+		for _, e := range list {
+			if e.Spec.InvolvedObject.Phase == phase {
+				r = append(r, e.Spec.Reason)
+			}
+		}
+		return r
+	}
+
+	BeforeEach(func() {
+		env = Must(NewTestEnv("test", "testdata", mymodel.NewModelSpecification))
+		env.Processor().SetEventRecorder(recorder.New(env.Logging(), env.Database(), db.NewEvent("", ""), recorder.DEFAULT_TTL))
+		env.AddService(controllers.NewExpressionController(env.Logging(), 1, env.Database()))
+		env.Start()
+	})
+
+	AfterEach(func() {
+		env.Cleanup()
+	})
+
+	It("records the status transitions", func() {
+		mCA := ValueCompleted(env, "C-A")
+		MustBeSuccessful(env.SetObject(db.NewValueNode(NS, "A", 5)))
+		opC := db.NewOperatorNode(NS, "C").
+			AddOperand("iA", "A").
+			AddOperation("eA", db.OP_ADD, "iA", "iA").
+			AddOutput("C-A", "eA")
+		MustBeSuccessful(env.SetObject(opC))
+		Expect(env.Wait(mCA)).To(BeTrue())
+
+		list := Must(env.Processor().Events("", oid))
+		Expect(reasons(list, mymetamodel.PHASE_GATHER)).To(ConsistOf(processor.REASON_RUN_STARTED, processor.REASON_SLAVE_CREATED, processor.REASON_COMPLETED))
+		Expect(reasons(list, mymetamodel.PHASE_EXPOSE)).To(ContainElements(processor.REASON_SLAVE_CREATED, processor.REASON_COMPLETED))
+		for _, e := range list {
+			Expect(e.Spec.InvolvedObject.Type).To(Equal(mymetamodel.TYPE_OPERATOR_STATE))
+			Expect(e.Spec.Type).To(Equal(recorder.TYPE_NORMAL))
+			Expect(e.Status.Count).To(Equal(1))
+		}
+
+		Expect(len(Must(env.Processor().Events(NS, nil)))).To(BeNumerically(">", len(list)))
+	})
+
+	It("records blocked elements", func() {
+		MustBeSuccessful(env.SetObject(db.NewOperatorNode(NS, "C").
+			AddOperand("iA", "A").
+			AddOperation("eA", db.OP_ADD, "iA", "iA").
+			AddOutput("C-A", "eA")))
+
+		Eventually(func() []string {
+			return reasons(Must(env.Processor().Events("", oid)), mymetamodel.PHASE_GATHER)
+		}, "5s").Should(ContainElement(processor.REASON_BLOCKED))
+
+		for _, e := range Must(env.Processor().Events("", oid)) {
+			if e.Spec.Reason == processor.REASON_BLOCKED && e.Spec.InvolvedObject.Phase == mymetamodel.PHASE_GATHER {
+				Expect(e.Spec.Type).To(Equal(recorder.TYPE_WARNING))
+				Expect(e.Spec.Message).To(ContainSubstring("ValueState/" + NS + "/A"))
+			}
+		}
+	})
+})
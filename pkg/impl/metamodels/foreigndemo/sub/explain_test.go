@@ -0,0 +1,115 @@
+package sub_test
+
+import (
+	. "github.com/mandelsoft/engine/pkg/processing/testutils"
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/processing/metamodel"
+	"github.com/mandelsoft/engine/pkg/processing/mmids"
+	"github.com/mandelsoft/engine/pkg/processing/model"
+	"github.com/mandelsoft/engine/pkg/version"
+
+	mymodel "github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub"
+	"github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub/controllers"
+	"github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub/db"
+	mymetamodel "github.com/mandelsoft/engine/pkg/metamodels/foreigndemo"
+)
+
+var _ = Describe("Explain", func() {
+	var env *TestEnv
+
+	oid := database.NewObjectId(mymetamodel.TYPE_OPERATOR, NS, "C")
+	nodeA := version.GetEffName(version.NewId(mmids.NewTypeId(mymetamodel.TYPE_VALUE_STATE, mymetamodel.PHASE_PROPAGATE), "A"))
+
+	Context("completed", func() {
+		BeforeEach(func() {
+			env = Must(NewTestEnv("test", "testdata", mymodel.NewModelSpecification))
+			env.AddService(controllers.NewExpressionController(env.Logging(), 1, env.Database()))
+			env.Start()
+
+			mCA := ValueCompleted(env, "C-A")
+			MustBeSuccessful(env.SetObject(db.NewValueNode(NS, "A", 5)))
+			opC := db.NewOperatorNode(NS, "C").
+				AddOperand("iA", "A").
+				AddOperand("iB", "2").
+				AddOperation("eA", db.OP_ADD, "iA", "iB").
+				AddOutput("C-A", "eA")
+			MustBeSuccessful(env.SetObject(opC))
+			Expect(env.Wait(mCA)).To(BeTrue())
+			mCA.Check(env, 7, "C")
+		})
+
+		AfterEach(func() {
+			env.Cleanup()
+		})
+
+		It("explains the committed formal version", func() {
+			list := Must(env.Processor().Explain(oid, mymetamodel.PHASE_GATHER))
+			Expect(list).To(HaveLen(1))
+			x := list[0]
+			Expect(x.Current).To(ContainSubstring(nodeA + "["))
+			Expect(x.Target).To(Equal(x.Current))
+			Expect(x.Changes).To(BeEmpty())
+
+			// the formal graph is kept in the element status
+			o := Must(env.GetObject(database.NewObjectId(mymetamodel.TYPE_OPERATOR_STATE, NS, "C"))).(*db.OperatorState)
+			Expect(o.Gather.Current.FormalGraph).To(Equal(x.Current))
+
+			Expect(Must(env.Processor().Explain(oid, ""))).To(HaveLen(2))
+		})
+
+		It("names the changed input", func() {
+			old := Must(env.Processor().Explain(oid, mymetamodel.PHASE_GATHER))[0]
+
+			mCA := ValueCompleted(env, "C-A", true)
+			o := Must(env.GetObject(database.NewObjectId(mymetamodel.TYPE_VALUE, NS, "A"))).(*db.Value)
+			MustBeSuccessful(Modify(env, &o, func(o *db.Value) (bool, bool) {
+				o.Spec.Value = 6
+				return true, true
+			}))
+			Expect(mCA.WaitUntil(env, 8, "C")).To(BeTrue())
+
+			cur := Must(env.Processor().Explain(oid, mymetamodel.PHASE_GATHER))[0]
+			changes := Must(version.Diff(old.Current, cur.Current))
+			Expect(changes).To(HaveLen(1))
+			Expect(changes[0].Node).To(Equal(nodeA))
+			Expect(changes[0].Kind).To(Equal(version.CHANGE_VERSION))
+			Expect(changes[0].Path).To(HaveLen(1))
+		})
+	})
+
+	Context("pending", func() {
+		eid := mmids.NewElementId(mymetamodel.TYPE_OPERATOR_STATE, NS, "C", mymetamodel.PHASE_EXPOSE)
+
+		BeforeEach(func() {
+			env = Must(NewTestEnv("test", "testdata", withPhase(mymetamodel.TYPE_OPERATOR_STATE, mymetamodel.PHASE_EXPOSE, metamodel.PhaseSpecification.WithApproval)))
+			env.AddService(controllers.NewExpressionController(env.Logging(), 1, env.Database()))
+			env.Start()
+
+			fa := env.FutureFor(model.STATUS_AWAITING_APPROVAL, eid)
+			MustBeSuccessful(env.SetObject(db.NewValueNode(NS, "A", 5)))
+			opC := db.NewOperatorNode(NS, "C").
+				AddOperand("iA", "A").
+				AddOperation("eA", db.OP_ADD, "iA", "iA").
+				AddOutput("C-A", "eA")
+			MustBeSuccessful(env.SetObject(opC))
+			Expect(env.WaitWithTimeout(fa)).To(BeTrue())
+		})
+
+		AfterEach(func() {
+			env.Cleanup()
+		})
+
+		It("explains the target version of an active run", func() {
+			list := Must(env.Processor().Explain(oid, mymetamodel.PHASE_EXPOSE))
+			Expect(list).To(HaveLen(1))
+			x := list[0]
+			Expect(x.Current).To(BeEmpty())
+			Expect(x.Target).To(ContainSubstring(nodeA + "["))
+			Expect(x.Changes).To(Equal([]version.Change{{Node: version.GetEffName(version.NewId(eid.TypeId(), "C")), Kind: version.CHANGE_ADDED}}))
+		})
+	})
+})
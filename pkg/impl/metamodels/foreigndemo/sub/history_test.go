@@ -0,0 +1,122 @@
+package sub_test
+
+import (
+	"strings"
+
+	. "github.com/mandelsoft/engine/pkg/processing/testutils"
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/history"
+	"github.com/mandelsoft/engine/pkg/processing/mmids"
+	"github.com/mandelsoft/engine/pkg/processing/model"
+	db2 "github.com/mandelsoft/engine/pkg/processing/model/support/db"
+
+	mymodel "github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub"
+	"github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub/controllers"
+	"github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub/db"
+	mymetamodel "github.com/mandelsoft/engine/pkg/metamodels/foreigndemo"
+)
+
+var _ = Describe("Run History", func() {
+	var env *TestEnv
+
+	vid := database.NewObjectId(mymetamodel.TYPE_VALUE, NS, "A")
+	oid := database.NewObjectId(mymetamodel.TYPE_OPERATOR, NS, "C")
+
+	setValue := func(v int) {
+		o := Must(env.GetObject(vid)).(*db.Value)
+		MustBeSuccessful(Modify(env, &o, func(o *db.Value) (bool, bool) {
+			o.Spec.Value = v
+			return true, true
+		}))
+	}
+
+	BeforeEach(func() {
+		env = Must(NewTestEnv("test", "testdata", mymodel.NewModelSpecification))
+		env.Processor().SetRunHistory(history.New(env.Logging(), env.Database(), db.NewRun("", ""), history.Retention{MaxRuns: 2}))
+		env.AddService(controllers.NewExpressionController(env.Logging(), 1, env.Database()))
+		env.Start()
+
+		mCA := ValueCompleted(env, "C-A")
+		MustBeSuccessful(env.SetObject(db.NewValueNode(NS, "A", 5)))
+		opC := db.NewOperatorNode(NS, "C").
+			AddOperand("iA", "A").
+			AddOperand("iB", "2").
+			AddOperation("eA", db.OP_ADD, "iA", "iB").
+			AddOutput("C-A", "eA")
+		MustBeSuccessful(env.SetObject(opC))
+		Expect(env.Wait(mCA)).To(BeTrue())
+		mCA.Check(env, 7, "C")
+	})
+
+	AfterEach(func() {
+		env.Cleanup()
+	})
+
+	It("records runs with trigger, inputs and output", func() {
+		list := Must(env.Processor().ObjectRuns(oid, mymetamodel.PHASE_GATHER))
+		Expect(list).To(HaveLen(1))
+		r := list[0]
+		Expect(r.Spec.Element()).To(Equal("OperatorState/" + NS + "/C:" + mymetamodel.PHASE_GATHER))
+		Expect(r.Spec.Trigger).NotTo(BeNil())
+		Expect(r.Spec.Trigger.Type).To(Equal(mymetamodel.TYPE_OPERATOR))
+		Expect(r.Spec.Trigger.Version).NotTo(BeEmpty())
+		Expect(r.Spec.Inputs).To(HaveKey(mmids.NewElementId(mymetamodel.TYPE_VALUE_STATE, NS, "A", mymetamodel.PHASE_PROPAGATE).String()))
+		Expect(r.Spec.InputVersion).NotTo(BeEmpty())
+		Expect(r.Status.Status).To(Equal(string(model.STATUS_COMPLETED)))
+		Expect(r.Status.OutputVersion).NotTo(BeEmpty())
+		Expect(r.Status.IsFinished()).To(BeTrue())
+
+		var transitions []string
+		for _, t := range r.Status.Transitions {
+			transitions = append(transitions, t.Status)
+		}
+		Expect(transitions).To(ContainElements(string(model.STATUS_PROCESSING), string(model.STATUS_COMPLETED)))
+		Expect(transitions[len(transitions)-1]).To(Equal(string(model.STATUS_COMPLETED)))
+
+		// all elements of the run are recorded
+		elems := Must(env.Processor().Run("", mmids.RunId(r.Spec.RunId)))
+		Expect(len(elems)).To(BeNumerically(">=", 2))
+		for _, e := range elems {
+			Expect(e.Spec.RunId).To(Equal(r.Spec.RunId))
+		}
+
+		// runs are stored as objects
+		o := Must(env.GetObject(database.NewObjectId(db2.TYPE_RUN, NS, r.Name())))
+		Expect(o.GetStatusValue()).To(Equal(string(model.STATUS_COMPLETED)))
+	})
+
+	It("records recomputations and applies the retention", func() {
+		first := Must(env.Processor().ObjectRuns(oid, mymetamodel.PHASE_GATHER))[0]
+
+		mCA := ValueCompleted(env, "C-A", true)
+		setValue(6)
+		Expect(mCA.WaitUntil(env, 8, "C")).To(BeTrue())
+
+		list := Must(env.Processor().ObjectRuns(oid, mymetamodel.PHASE_GATHER))
+		Expect(list).To(HaveLen(2))
+		Expect(list[0].Spec.RunId).To(Equal(first.Spec.RunId))
+		second := list[1]
+		Expect(second.Spec.InputVersion).NotTo(Equal(first.Spec.InputVersion))
+		Expect(second.Spec.Trigger.Version).To(Equal(first.Spec.Trigger.Version))
+
+		setValue(7)
+		Expect(mCA.WaitUntil(env, 9, "C")).To(BeTrue())
+
+		list = Must(env.Processor().ObjectRuns(oid, mymetamodel.PHASE_GATHER))
+		Expect(list).To(HaveLen(2))
+		Expect(list[0].Spec.RunId).To(Equal(second.Spec.RunId))
+
+		// the external type and all phases can be used to query runs.
+		all := Must(env.Processor().ObjectRuns(oid, ""))
+		phases := map[string]bool{}
+		for _, r := range all {
+			Expect(strings.HasPrefix(r.Spec.Element(), mymetamodel.TYPE_OPERATOR_STATE+"/")).To(BeTrue())
+			phases[r.Spec.Phase] = true
+		}
+		Expect(phases).To(HaveKey(mymetamodel.PHASE_EXPOSE))
+	})
+})
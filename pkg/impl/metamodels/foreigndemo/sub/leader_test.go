@@ -0,0 +1,121 @@
+package sub_test
+
+import (
+	"time"
+
+	. "github.com/mandelsoft/engine/pkg/processing/testutils"
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/election"
+	"github.com/mandelsoft/engine/pkg/utils"
+
+	mymodel "github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub"
+	"github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub/controllers"
+	"github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub/db"
+	mymetamodel "github.com/mandelsoft/engine/pkg/metamodels/foreigndemo"
+)
+
+var _ = Describe("Leader Election", func() {
+	var envA, envB *TestEnv
+
+	lease := db.NewLease("", "engine")
+	vid := database.NewObjectId(mymetamodel.TYPE_VALUE, NS, "A")
+	nsid := database.NewObjectId(mymetamodel.TYPE_NAMESPACE, "", NS)
+
+	newEnv := func(identity string, opts ...Option) *TestEnv {
+		env := Must(NewTestEnv("test", "testdata", mymodel.NewModelSpecification, append(opts, LeaderElection(identity, time.Second))...))
+		env.AddService(controllers.NewExpressionController(env.Logging(), 1, env.Database()))
+		env.Start()
+		return env
+	}
+
+	getLease := func(env *TestEnv) election.LeaseSpec {
+		return Must(env.GetObject(lease)).(*db.Lease).Spec
+	}
+
+	setValue := func(env *TestEnv, v int) {
+		o := Must(env.GetObject(vid)).(*db.Value)
+		MustBeSuccessful(Modify(env, &o, func(o *db.Value) (bool, bool) {
+			o.Spec.Value = v
+			return true, true
+		}))
+	}
+
+	BeforeEach(func() {
+		envA = newEnv("A")
+		Expect(envA.WaitForLeadership()).To(Succeed())
+
+		mCA := ValueCompleted(envA, "C-A")
+		MustBeSuccessful(envA.SetObject(db.NewValueNode(NS, "A", 5)))
+		opC := db.NewOperatorNode(NS, "C").
+			AddOperand("iA", "A").
+			AddOperation("eA", db.OP_ADD, "iA", "iA").
+			AddOutput("C-A", "eA")
+		MustBeSuccessful(envA.SetObject(opC))
+		Expect(envA.Wait(mCA)).To(BeTrue())
+		mCA.Check(envA, 10, "C")
+	})
+
+	AfterEach(func() {
+		// envB shares the filesystem of envA
+		if envB != nil {
+			envB.Cleanup()
+			envB = nil
+		}
+		if envA != nil {
+			envA.Cleanup()
+			envA = nil
+		}
+	})
+
+	It("processes only on the leader and hands over", func() {
+		envB = newEnv("B", FileSystem(envA.FileSystem()))
+		time.Sleep(2 * time.Second)
+
+		spec := getLease(envB)
+		Expect(spec.Holder).To(Equal("A"))
+		Expect(spec.Transitions).To(Equal(1))
+		Expect(envB.Processor().Model().Namespaces()).NotTo(ContainElement(NS))
+
+		envA.Stop()
+		Expect(envB.WaitForLeadership()).To(Succeed())
+
+		spec = getLease(envB)
+		Expect(spec.Holder).To(Equal("B"))
+		Expect(spec.Transitions).To(Equal(2))
+
+		mCA := ValueCompleted(envB, "C-A", true)
+		setValue(envB, 6)
+		Expect(mCA.WaitUntil(envB, 12, "C")).To(BeTrue())
+	})
+
+	It("recovers stale locks of a crashed leader", func() {
+		envA.Stop()
+
+		// simulate a crashed leader holding the lease
+		// and an incompletely locked run.
+		l := Must(envA.GetObject(lease)).(*db.Lease)
+		MustBeSuccessful(Modify(envA, &l, func(o *db.Lease) (bool, bool) {
+			o.Spec.Holder = "A"
+			o.Spec.RenewTime = utils.NewTimestampP()
+			return true, true
+		}))
+		ns := Must(envA.GetObject(nsid)).(*db.Namespace)
+		MustBeSuccessful(Modify(envA, &ns, func(o *db.Namespace) (bool, bool) {
+			o.RunLock = "stale"
+			return true, true
+		}))
+
+		envB = newEnv("B", FileSystem(envA.FileSystem()))
+		Expect(envB.WaitForLeadership()).To(Succeed())
+		Expect(getLease(envB).Holder).To(Equal("B"))
+		Expect(Must(envB.GetObject(nsid)).(*db.Namespace).RunLock).To(BeEmpty())
+
+		mCA := ValueCompleted(envB, "C-A", true)
+		setValue(envB, 7)
+		Expect(mCA.WaitUntil(envB, 14, "C")).To(BeTrue())
+	})
+})
@@ -0,0 +1,98 @@
+package sub_test
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+
+	. "github.com/mandelsoft/engine/pkg/processing/testutils"
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/metrics"
+	"github.com/mandelsoft/engine/pkg/processing/metamodel"
+
+	"github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub/controllers"
+	"github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub/db"
+	mymetamodel "github.com/mandelsoft/engine/pkg/metamodels/foreigndemo"
+)
+
+var _ = Describe("Memoization", func() {
+	var env *TestEnv
+
+	hits := func() int {
+		buf := &bytes.Buffer{}
+		MustBeSuccessful(metrics.Default.WriteText(buf))
+		exp := regexp.MustCompile(`(?m)^engine_result_cache_requests_total\{processor="[^"]+",type="OperatorState",phase="Gathering",result="hit"\} (\d+)$`)
+		m := exp.FindStringSubmatch(buf.String())
+		if m == nil {
+			return 0
+		}
+		return Must(strconv.Atoi(m[1]))
+	}
+
+	setValue := func(v int) {
+		o := Must(env.GetObject(database.NewObjectId(mymetamodel.TYPE_VALUE, NS, "A"))).(*db.Value)
+		MustBeSuccessful(Modify(env, &o, func(o *db.Value) (bool, bool) {
+			o.Spec.Value = v
+			return true, true
+		}))
+	}
+
+	BeforeEach(func() {
+		env = Must(NewTestEnv("test", "testdata", withPhase(mymetamodel.TYPE_OPERATOR_STATE, mymetamodel.PHASE_GATHER, metamodel.PhaseSpecification.WithMemoization)))
+		env.AddService(controllers.NewExpressionController(env.Logging(), 1, env.Database()))
+		env.Start()
+
+		mCA := ValueCompleted(env, "C-A")
+		MustBeSuccessful(env.SetObject(db.NewValueNode(NS, "A", 5)))
+		opC := db.NewOperatorNode(NS, "C").
+			AddOperand("iA", "A").
+			AddOperand("iB", "2").
+			AddOperation("eA", db.OP_ADD, "iA", "iB").
+			AddOutput("C-A", "eA")
+		MustBeSuccessful(env.SetObject(opC))
+		Expect(env.Wait(mCA)).To(BeTrue())
+		mCA.Check(env, 7, "C")
+	})
+
+	AfterEach(func() {
+		env.Cleanup()
+	})
+
+	It("reuses the result for a known input set", func() {
+		start := hits()
+
+		mCA := ValueCompleted(env, "C-A", true)
+		setValue(6)
+		Expect(mCA.WaitUntil(env, 8, "C")).To(BeTrue())
+		Expect(hits()).To(Equal(start))
+
+		mCA = ValueCompleted(env, "C-A", true)
+		setValue(5)
+		Expect(mCA.WaitUntil(env, 7, "C")).To(BeTrue())
+		Expect(hits()).To(Equal(start + 1))
+
+		// the cached output is committed with the actual formal version
+		o := Must(env.GetObject(database.NewObjectId(mymetamodel.TYPE_OPERATOR_STATE, NS, "C"))).(*db.OperatorState)
+		Expect(o.Gather.Current.Output.Operands["iA"].Value).To(Equal(5))
+		x := Must(env.Processor().Explain(database.NewObjectId(mymetamodel.TYPE_OPERATOR, NS, "C"), mymetamodel.PHASE_GATHER))[0]
+		Expect(x.Changes).To(BeEmpty())
+	})
+
+	It("doesn't cache with disabled cache", func() {
+		env.Processor().SetResultCacheSize(0)
+		start := hits()
+
+		mCA := ValueCompleted(env, "C-A", true)
+		setValue(6)
+		Expect(mCA.WaitUntil(env, 8, "C")).To(BeTrue())
+
+		mCA = ValueCompleted(env, "C-A", true)
+		setValue(5)
+		Expect(mCA.WaitUntil(env, 7, "C")).To(BeTrue())
+		Expect(hits()).To(Equal(start))
+	})
+})
@@ -2,6 +2,7 @@ package sub
 
 import (
 	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/processing/metamodel"
 	"github.com/mandelsoft/engine/pkg/processing/model"
 	"github.com/mandelsoft/engine/pkg/processing/model/support"
 	db2 "github.com/mandelsoft/engine/pkg/processing/model/support/db"
@@ -21,6 +22,12 @@ var scheme = wrapped.NewTypeScheme[support.Object, db2.Object](db.Scheme)
 func NewModelSpecification(name string, dbspec database.Specification[db2.Object]) model.ModelSpecification {
 	mmspec := mymetamodel.MetaModelSpecification()
 	mmspec.UpdateRequestType = mymetamodel.TYPE_UPDATEREQUEST
+	return NewModelSpecificationFor(name, mmspec, dbspec)
+}
+
+// NewModelSpecificationFor provides a model specification
+// for a (modified) metamodel specification.
+func NewModelSpecificationFor(name string, mmspec metamodel.MetaModelSpecification, dbspec database.Specification[db2.Object]) model.ModelSpecification {
 	obspec := wrapped.NewSpecification[support.Object, db2.Object](scheme, db.Scheme, dbspec)
 	return model.NewModelSpecification(name, mmspec, obspec)
 }
@@ -0,0 +1,155 @@
+package sub_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	. "github.com/mandelsoft/engine/pkg/processing/testutils"
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/engine/pkg/ctxutil"
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/processing/api"
+	"github.com/mandelsoft/engine/pkg/processing/metamodel"
+	"github.com/mandelsoft/engine/pkg/processing/mmids"
+	"github.com/mandelsoft/engine/pkg/processing/model"
+	db2 "github.com/mandelsoft/engine/pkg/processing/model/support/db"
+	"github.com/mandelsoft/engine/pkg/server"
+
+	mymodel "github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub"
+	"github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub/controllers"
+	"github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub/db"
+	mymetamodel "github.com/mandelsoft/engine/pkg/metamodels/foreigndemo"
+)
+
+// valueMetaModel is a reduced version of the metamodel
+// only supporting values.
+func valueMetaModel() metamodel.MetaModelSpecification {
+	return metamodel.MetaModelSpecification{
+		NamespaceType: mymetamodel.TYPE_NAMESPACE,
+		ExternalTypes: []metamodel.ExternalTypeSpecification{
+			metamodel.ExtSpec(mymetamodel.TYPE_VALUE, mymetamodel.TYPE_VALUE_STATE, mymetamodel.PHASE_PROPAGATE),
+		},
+		InternalTypes: []metamodel.InternalTypeSpecification{
+			metamodel.IntSpec(mymetamodel.TYPE_VALUE_STATE,
+				metamodel.PhaseSpec(mymetamodel.PHASE_PROPAGATE),
+			),
+		},
+	}
+}
+
+var _ = Describe("Metamodel Update", func() {
+	var env *TestEnv
+
+	BeforeEach(func() {
+		env = Must(NewTestEnv("test", "testdata", func(name string, dbspec database.Specification[db2.Object]) model.ModelSpecification {
+			return mymodel.NewModelSpecificationFor(name, valueMetaModel(), dbspec)
+		}))
+		env.AddService(controllers.NewExpressionController(env.Logging(), 1, env.Database()))
+		env.Start()
+	})
+
+	AfterEach(func() {
+		env.Cleanup()
+	})
+
+	It("extends the metamodel of a running engine", func() {
+		mA := ValueCompleted(env, "A")
+		MustBeSuccessful(env.SetObject(db.NewValueNode(NS, "A", 5)))
+		Expect(env.Wait(mA)).To(BeTrue())
+
+		mCA := ValueCompleted(env, "C-A")
+		opC := db.NewOperatorNode(NS, "C").
+			AddOperand("iA", "A").
+			AddOperation("eA", db.OP_ADD, "iA", "iA").
+			AddOutput("C-A", "eA")
+		MustBeSuccessful(env.SetObject(opC))
+
+		changes := Must(env.Processor().UpdateMetaModel(mymetamodel.MetaModelSpecification()))
+		Expect(changes.ExternalTypes).To(ConsistOf(mymetamodel.TYPE_OPERATOR, mymetamodel.TYPE_EXPRESSION))
+		Expect(changes.InternalTypes).To(ConsistOf(mymetamodel.TYPE_OPERATOR_STATE, mymetamodel.TYPE_EXPRESSION_STATE))
+		Expect(changes.ModifiedTypes).To(ConsistOf(mymetamodel.TYPE_VALUE_STATE))
+		Expect(env.Processor().MetaModel().IsExternalType(mymetamodel.TYPE_OPERATOR)).To(BeTrue())
+
+		Expect(env.Wait(mCA)).To(BeTrue())
+		mA.Check(env, 5, "")
+		mCA.Check(env, 10, "C")
+	})
+
+	It("applies metamodel updates requested by the admin API", func() {
+		ctx := ctxutil.TimeoutContext(context.Background(), 20*time.Second)
+		srv := server.NewServer(8093, false, 10*time.Second)
+		env.Processor().RegisterAdminHandler(srv, "/admin")
+		ready, done := Must2(srv.Start(ctx))
+		defer func() {
+			srv.Shutdown(ctx)
+			done.Wait()
+		}()
+		MustBeSuccessful(ready.Wait())
+
+		update := func(spec metamodel.MetaModelSpecification, status int) *api.MetaModelChanges {
+			data := Must(json.Marshal(spec))
+			resp := Must(http.Post("http://localhost:8093/admin/"+api.CMD_METAMODEL, "application/json", bytes.NewReader(data)))
+			defer resp.Body.Close()
+			data = Must(io.ReadAll(resp.Body))
+			Expect(resp.StatusCode).To(Equal(status), string(data))
+			var changes api.MetaModelChanges
+			MustBeSuccessful(json.Unmarshal(data, &changes))
+			return &changes
+		}
+
+		mA := ValueCompleted(env, "A")
+		MustBeSuccessful(env.SetObject(db.NewValueNode(NS, "A", 5)))
+		Expect(env.Wait(mA)).To(BeTrue())
+
+		changes := update(mymetamodel.MetaModelSpecification(), http.StatusOK)
+		Expect(changes.ExternalTypes).To(ConsistOf(mymetamodel.TYPE_OPERATOR, mymetamodel.TYPE_EXPRESSION))
+		Expect(changes.ModifiedTypes).To(ConsistOf(mymetamodel.TYPE_VALUE_STATE))
+		Expect(env.Processor().MetaModel().IsExternalType(mymetamodel.TYPE_OPERATOR)).To(BeTrue())
+
+		changes = update(valueMetaModel(), http.StatusOK)
+		Expect(changes.RemovedExternalTypes).To(ConsistOf(mymetamodel.TYPE_OPERATOR, mymetamodel.TYPE_EXPRESSION))
+		Expect(env.Processor().MetaModel().IsExternalType(mymetamodel.TYPE_OPERATOR)).To(BeFalse())
+
+		spec := valueMetaModel()
+		spec.InternalTypes = []metamodel.InternalTypeSpecification{
+			metamodel.IntSpec(mymetamodel.TYPE_VALUE_STATE,
+				metamodel.PhaseSpec(mymetamodel.PHASE_GATHER),
+				metamodel.PhaseSpec(mymetamodel.PHASE_PROPAGATE, metamodel.LocalDep(mymetamodel.PHASE_GATHER)),
+			),
+		}
+		spec.ExternalTypes[0].Trigger.Phase = mymetamodel.PHASE_GATHER
+		update(spec, http.StatusConflict)
+		Expect(env.Processor().MetaModel().Phases(mymetamodel.TYPE_VALUE_STATE)).To(ConsistOf(mmids.Phase(mymetamodel.PHASE_PROPAGATE)))
+	})
+
+	It("rejects incompatible changes", func() {
+		mA := ValueCompleted(env, "A")
+		MustBeSuccessful(env.SetObject(db.NewValueNode(NS, "A", 5)))
+		Expect(env.Wait(mA)).To(BeTrue())
+
+		spec := mymetamodel.MetaModelSpecification()
+		spec.ExternalTypes = spec.ExternalTypes[1:]
+		_, err := env.Processor().UpdateMetaModel(spec)
+		Expect(err).To(MatchError(`root phase "Propagating" internal type "ValueState" not triggered by any external type`))
+
+		spec = valueMetaModel()
+		spec.InternalTypes = []metamodel.InternalTypeSpecification{
+			metamodel.IntSpec(mymetamodel.TYPE_VALUE_STATE,
+				metamodel.PhaseSpec(mymetamodel.PHASE_GATHER),
+				metamodel.PhaseSpec(mymetamodel.PHASE_PROPAGATE, metamodel.LocalDep(mymetamodel.PHASE_GATHER)),
+			),
+		}
+		spec.ExternalTypes[0].Trigger.Phase = mymetamodel.PHASE_GATHER
+		_, err = env.Processor().UpdateMetaModel(spec)
+		Expect(err).To(MatchError(`incompatible metamodel changes: trigger of external type "Value" changed from ValueState:Propagating to ValueState:Gathering; phase "Gathering" of internal type "ValueState" inserted before existing phase "Propagating"`))
+		Expect(err).To(BeAssignableToTypeOf(&metamodel.IncompatibleChangesError{}))
+		Expect(env.Processor().MetaModel().Phases(mymetamodel.TYPE_VALUE_STATE)).To(ConsistOf(mmids.Phase(mymetamodel.PHASE_PROPAGATE)))
+	})
+})
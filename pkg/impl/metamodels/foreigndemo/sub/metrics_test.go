@@ -0,0 +1,58 @@
+package sub_test
+
+import (
+	"bytes"
+
+	. "github.com/mandelsoft/engine/pkg/processing/testutils"
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/engine/pkg/metrics"
+
+	mymodel "github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub"
+	"github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub/controllers"
+	"github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub/db"
+)
+
+var _ = Describe("Metrics", func() {
+	var env *TestEnv
+
+	scrape := func() string {
+		buf := &bytes.Buffer{}
+		MustBeSuccessful(metrics.Default.WriteText(buf))
+		return buf.String()
+	}
+
+	BeforeEach(func() {
+		env = Must(NewTestEnv("test", "testdata", mymodel.NewModelSpecification))
+		env.AddService(controllers.NewExpressionController(env.Logging(), 1, env.Database()))
+		env.Start()
+	})
+
+	AfterEach(func() {
+		env.Cleanup()
+	})
+
+	It("exposes the processing metrics", func() {
+		mCA := ValueCompleted(env, "C-A")
+		MustBeSuccessful(env.SetObject(db.NewValueNode(NS, "A", 5)))
+		opC := db.NewOperatorNode(NS, "C").
+			AddOperand("iA", "A").
+			AddOperation("eA", db.OP_ADD, "iA", "iA").
+			AddOutput("C-A", "eA")
+		MustBeSuccessful(env.SetObject(opC))
+		Expect(env.Wait(mCA)).To(BeTrue())
+
+		text := scrape()
+		Expect(text).To(MatchRegexp(`(?m)^engine_workqueue_depth\{pool="[^"]+"\} \d+$`))
+		Expect(text).To(MatchRegexp(`(?m)^engine_workqueue_adds_total\{pool="[^"]+"\} [1-9]\d*$`))
+		Expect(text).To(MatchRegexp(`(?m)^engine_workqueue_queue_duration_seconds_count\{pool="[^"]+"\} [1-9]\d*$`))
+		Expect(text).To(MatchRegexp(`(?m)^engine_reconcile_total\{pool="[^"]+",command="elem",result="succeeded"\} [1-9]\d*$`))
+		Expect(text).To(MatchRegexp(`(?m)^engine_reconcile_duration_seconds_bucket\{pool="[^"]+",command="Value",le="\+Inf"\} [1-9]\d*$`))
+		Expect(text).To(MatchRegexp(`(?m)^engine_phase_run_duration_seconds_count\{processor="[^"]+",type="OperatorState",phase="[^"]+"\} [1-9]\d*$`))
+		Expect(text).To(MatchRegexp(`(?m)^engine_elements\{processor="[^"]+",namespace="` + NS + `",status="Completed"\} [1-9]\d*$`))
+		Expect(text).To(MatchRegexp(`(?m)^engine_pending_elements\{processor="[^"]+"\} \d+$`))
+		Expect(text).To(MatchRegexp(`(?m)^engine_database_operation_duration_seconds_count\{operation="set",type="Value"\} [1-9]\d*$`))
+	})
+})
@@ -0,0 +1,79 @@
+package sub_test
+
+import (
+	"path/filepath"
+	"time"
+
+	. "github.com/mandelsoft/engine/pkg/processing/testutils"
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/impl/database/filesystem"
+	"github.com/mandelsoft/engine/pkg/processing/mmids"
+
+	mymodel "github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub"
+	"github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub/controllers"
+	"github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub/db"
+	mymetamodel "github.com/mandelsoft/engine/pkg/metamodels/foreigndemo"
+)
+
+var _ = Describe("Orphans", func() {
+	var env *TestEnv
+
+	// remove removes an object bypassing the database to
+	// simulate an abnormal deletion.
+	remove := func(id database.ObjectId) {
+		MustBeSuccessful(env.FileSystem().Remove(filepath.Join("testdata", filesystem.Path(id))))
+	}
+
+	BeforeEach(func() {
+		env = Must(NewTestEnv("test", "testdata", mymodel.NewModelSpecification))
+		env.AddService(controllers.NewExpressionController(env.Logging(), 1, env.Database()))
+		env.Start()
+
+		mCA := ValueCompleted(env, "C-A")
+		mB := ValueCompleted(env, "B")
+		MustBeSuccessful(env.SetObject(db.NewValueNode(NS, "A", 5)))
+		MustBeSuccessful(env.SetObject(db.NewValueNode(NS, "B", 6)))
+		opC := db.NewOperatorNode(NS, "C").
+			AddOperand("iA", "A").
+			AddOperation("eA", db.OP_ADD, "iA", "iA").
+			AddOutput("C-A", "eA")
+		MustBeSuccessful(env.SetObject(opC))
+		Expect(env.Wait(mCA)).To(BeTrue())
+		Expect(env.Wait(mB)).To(BeTrue())
+	})
+
+	AfterEach(func() {
+		env.Cleanup()
+	})
+
+	It("finds no orphans", func() {
+		Expect(Must(env.Processor().Orphans())).To(BeEmpty())
+	})
+
+	It("ignores internal objects with dependants", func() {
+		remove(database.NewObjectId(mymetamodel.TYPE_VALUE, NS, "A"))
+		Expect(Must(env.Processor().Orphans())).To(BeEmpty())
+	})
+
+	It("finds and deletes orphaned internal objects", func() {
+		remove(database.NewObjectId(mymetamodel.TYPE_VALUE, NS, "B"))
+
+		oid := mmids.NewObjectId(mymetamodel.TYPE_VALUE_STATE, NS, "B")
+		Expect(Must(env.Processor().Orphans())).To(Equal([]mmids.ObjectId{oid}))
+
+		f := env.DeletedFuture(mmids.NewElementIdForPhase(oid, mymetamodel.PHASE_PROPAGATE))
+		Expect(Must(env.Processor().DeleteOrphans())).To(Equal([]mmids.ObjectId{oid}))
+		Expect(env.WaitWithTimeout(f)).To(BeTrue())
+
+		Eventually(func() []mmids.ObjectId { return Must(env.Processor().Orphans()) }, 5*time.Second).Should(BeEmpty())
+		// the deleted status is reported before the internal object is removed.
+		Eventually(func() error {
+			_, err := env.GetObject(oid)
+			return err
+		}, 5*time.Second).Should(MatchError(database.ErrNotExist))
+	})
+})
@@ -0,0 +1,102 @@
+package sub_test
+
+import (
+	"encoding/json"
+
+	. "github.com/mandelsoft/engine/pkg/processing/testutils"
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/processing/mmids"
+	"github.com/mandelsoft/engine/pkg/processing/model"
+	"github.com/mandelsoft/engine/pkg/processing/objectbase"
+	"github.com/mandelsoft/engine/pkg/processing/processor"
+
+	mymodel "github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub"
+	"github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub/controllers"
+	"github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub/db"
+	mymetamodel "github.com/mandelsoft/engine/pkg/metamodels/foreigndemo"
+)
+
+var _ = Describe("Plan", func() {
+	var env *TestEnv
+
+	vid := database.NewObjectId(mymetamodel.TYPE_VALUE, NS, "A")
+
+	propose := func(o database.Object) model.ExternalObject {
+		data := Must(json.Marshal(o))
+		return Must(objectbase.DecodeObject(env.Processor().Objectbase(), data)).(model.ExternalObject)
+	}
+
+	actions := func(list []*processor.PlannedElement) map[string]processor.PlanAction {
+		r := map[string]processor.PlanAction{}
+		for _, e := range list {
+			r[e.Id.String()] = e.Action
+		}
+		return r
+	}
+
+	BeforeEach(func() {
+		env = Must(NewTestEnv("test", "testdata", mymodel.NewModelSpecification))
+		env.AddService(controllers.NewExpressionController(env.Logging(), 1, env.Database()))
+		env.Start()
+
+		mCA := ValueCompleted(env, "C-A")
+		MustBeSuccessful(env.SetObject(db.NewValueNode(NS, "A", 5)))
+		opC := db.NewOperatorNode(NS, "C").
+			AddOperand("iA", "A").
+			AddOperand("iB", "2").
+			AddOperation("eA", db.OP_ADD, "iA", "iB").
+			AddOutput("C-A", "eA")
+		MustBeSuccessful(env.SetObject(opC))
+		Expect(env.Wait(mCA)).To(BeTrue())
+		mCA.Check(env, 7, "C")
+	})
+
+	AfterEach(func() {
+		env.Cleanup()
+	})
+
+	It("predicts the elements reprocessed by a changed value", func() {
+		o := Must(env.GetObject(vid)).(*db.Value)
+		o.Spec.Value = 6
+
+		list := Must(env.Processor().Plan(propose(o)))
+		Expect(actions(list)).To(Equal(map[string]processor.PlanAction{
+			mmids.NewElementId(mymetamodel.TYPE_VALUE_STATE, NS, "A", mymetamodel.PHASE_PROPAGATE).String():      processor.PLAN_RUN,
+			mmids.NewElementId(mymetamodel.TYPE_OPERATOR_STATE, NS, "C", mymetamodel.PHASE_GATHER).String():      processor.PLAN_RUN,
+			mmids.NewElementId(mymetamodel.TYPE_EXPRESSION_STATE, NS, "C", mymetamodel.PHASE_CALCULATE).String(): processor.PLAN_RUN,
+			mmids.NewElementId(mymetamodel.TYPE_OPERATOR_STATE, NS, "C", mymetamodel.PHASE_EXPOSE).String():      processor.PLAN_RUN,
+			mmids.NewElementId(mymetamodel.TYPE_VALUE_STATE, NS, "C-A", mymetamodel.PHASE_PROPAGATE).String():    processor.PLAN_RUN,
+		}))
+		Expect(list[0].Trigger).To(Equal(vid))
+		for _, e := range list {
+			Expect(e.OldVersion).NotTo(Equal(e.NewVersion))
+		}
+
+		// nothing is changed by the plan
+		Expect(Must(env.GetObject(vid)).(*db.Value).Spec.Value).To(Equal(5))
+	})
+
+	It("predicts no-ops for unchanged objects", func() {
+		o := Must(env.GetObject(vid)).(*db.Value)
+
+		list := Must(env.Processor().Plan(propose(o)))
+		Expect(list).To(HaveLen(5))
+		for _, e := range list {
+			Expect(e.Action).To(Equal(processor.PLAN_NONE))
+			Expect(e.OldVersion).To(Equal(e.NewVersion))
+		}
+	})
+
+	It("predicts new elements", func() {
+		list := Must(env.Processor().Plan(propose(db.NewValueNode(NS, "X", 1))))
+		Expect(list).To(HaveLen(1))
+		Expect(list[0].Id).To(Equal(mmids.NewElementId(mymetamodel.TYPE_VALUE_STATE, NS, "X", mymetamodel.PHASE_PROPAGATE)))
+		Expect(list[0].Action).To(Equal(processor.PLAN_CREATE))
+		Expect(list[0].OldVersion).To(BeEmpty())
+		Expect(list[0].NewVersion).NotTo(BeEmpty())
+	})
+})
@@ -0,0 +1,119 @@
+package sub_test
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	. "github.com/mandelsoft/engine/pkg/processing/testutils"
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/processing/metamodel"
+	"github.com/mandelsoft/engine/pkg/processing/mmids"
+	"github.com/mandelsoft/engine/pkg/processing/model"
+	db2 "github.com/mandelsoft/engine/pkg/processing/model/support/db"
+
+	"github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub/db"
+	mymetamodel "github.com/mandelsoft/engine/pkg/metamodels/foreigndemo"
+)
+
+// failingSpec provides databases refusing to store
+// objects of a dedicated type with a temporary error.
+type failingSpec struct {
+	database.Specification[db2.Object]
+	typ string
+
+	lock     sync.Mutex
+	attempts []time.Time
+}
+
+func (s *failingSpec) Create(enc database.SchemeTypes[db2.Object]) (database.Database[db2.Object], error) {
+	d, err := s.Specification.Create(enc)
+	if err != nil {
+		return nil, err
+	}
+	return &failingDatabase{d, s}, nil
+}
+
+func (s *failingSpec) Attempts() []time.Time {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]time.Time(nil), s.attempts...)
+}
+
+type failingDatabase struct {
+	database.Database[db2.Object]
+	spec *failingSpec
+}
+
+func (d *failingDatabase) SetObject(o db2.Object) error {
+	if o.GetType() == d.spec.typ {
+		d.spec.lock.Lock()
+		defer d.spec.lock.Unlock()
+		d.spec.attempts = append(d.spec.attempts, time.Now())
+		return errors.New("database temporarily unavailable")
+	}
+	return d.Database.SetObject(o)
+}
+
+var _ = Describe("Retries", func() {
+	var env *TestEnv
+	var spec *failingSpec
+
+	eid := mmids.NewElementId(mymetamodel.TYPE_EXPRESSION_STATE, NS, "C", mymetamodel.PHASE_CALCULATE)
+
+	BeforeEach(func() {
+		// the calculation phase fails with a temporary error,
+		// because its expression object cannot be created.
+		retry := withPhase(mymetamodel.TYPE_EXPRESSION_STATE, mymetamodel.PHASE_CALCULATE, func(p metamodel.PhaseSpecification) metamodel.PhaseSpecification {
+			return p.WithRetry(metamodel.RetryPolicy{
+				MaxAttempts:  3,
+				InitialDelay: time.Second,
+				Factor:       3,
+			})
+		})
+		env = Must(NewTestEnv("test", "testdata", func(name string, dbspec database.Specification[db2.Object]) model.ModelSpecification {
+			spec = &failingSpec{Specification: dbspec, typ: mymetamodel.TYPE_EXPRESSION}
+			return retry(name, spec)
+		}))
+		env.Start()
+	})
+
+	AfterEach(func() {
+		if env != nil {
+			env.Cleanup()
+		}
+	})
+
+	It("retries temporary errors with growing delays until the attempts are exhausted", func() {
+		ff := env.FutureFor(model.STATUS_FAILED, eid)
+
+		MustBeSuccessful(env.SetObject(db.NewValueNode(NS, "A", 5)))
+		opC := db.NewOperatorNode(NS, "C").
+			AddOperand("iA", "A").
+			AddOperation("eA", db.OP_ADD, "iA", "iA").
+			AddOutput("C-A", "eA")
+		MustBeSuccessful(env.SetObject(opC))
+
+		Expect(env.WaitWithTimeout(ff)).To(BeTrue())
+		Consistently(func() int { return len(spec.Attempts()) }, "1s").Should(Equal(3))
+
+		attempts := spec.Attempts()
+		first := attempts[1].Sub(attempts[0])
+		second := attempts[2].Sub(attempts[1])
+		// the retry time is persisted with a resolution of seconds.
+		Expect(first).To(BeNumerically(">=", 500*time.Millisecond))
+		Expect(second).To(BeNumerically(">=", 2500*time.Millisecond))
+		Expect(second).To(BeNumerically(">", first))
+
+		o := Must(env.GetObject(database.NewObjectId(mymetamodel.TYPE_EXPRESSION_STATE, NS, "C")))
+		s := o.(*db.ExpressionState)
+		Expect(s.EvaluationState.Status).To(Equal(model.STATUS_FAILED))
+		Expect(s.EvaluationState.RunId).To(Equal(mmids.RunId("")))
+		Expect(s.EvaluationState.Retry).NotTo(BeNil())
+		Expect(s.EvaluationState.Retry.Attempts).To(Equal(3))
+	})
+})
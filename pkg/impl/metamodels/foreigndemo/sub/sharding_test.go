@@ -0,0 +1,291 @@
+package sub_test
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	. "github.com/mandelsoft/engine/pkg/processing/testutils"
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/processing/model"
+	"github.com/mandelsoft/engine/pkg/processing/processor"
+	elemwatch "github.com/mandelsoft/engine/pkg/processing/watch"
+
+	mymodel "github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub"
+	"github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub/controllers"
+	"github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub/db"
+	mymetamodel "github.com/mandelsoft/engine/pkg/metamodels/foreigndemo"
+)
+
+var _ = Describe("Sharding", func() {
+	const NSA = "shard-a"
+	const NSB = "shard-b"
+
+	var envA, envB *TestEnv
+
+	newEnv := func(identity string, ns string, opts ...Option) *TestEnv {
+		env := Must(NewTestEnv("test", "testdata", mymodel.NewModelSpecification, append(opts, Sharding(identity, time.Second, ns))...))
+		env.AddService(controllers.NewExpressionController(env.Logging(), 1, env.Database()))
+		env.Start()
+		return env
+	}
+
+	setup := func(env *TestEnv, ns string) {
+		MustBeSuccessful(env.SetObject(db.NewValueNode(ns, "A", 5)))
+		opC := db.NewOperatorNode(ns, "C").
+			AddOperand("iA", "A").
+			AddOperation("eA", db.OP_ADD, "iA", "iA").
+			AddOutput("C-A", "eA")
+		MustBeSuccessful(env.SetObject(opC))
+	}
+
+	value := func(env *TestEnv, ns, name string) func() int {
+		return func() int {
+			o, err := env.GetObject(database.NewObjectId(mymetamodel.TYPE_VALUE, ns, name))
+			if err != nil {
+				return -1
+			}
+			return o.(*db.Value).Spec.Value
+		}
+	}
+
+	members := func(env *TestEnv) func() []string {
+		return func() []string {
+			return env.Processor().Assignment().Members()
+		}
+	}
+
+	acknowledged := func(env *TestEnv) func() bool {
+		return func() bool {
+			return env.Processor().Assignment().Acknowledged()
+		}
+	}
+
+	BeforeEach(func() {
+		envA = newEnv("A", NSA)
+		envB = newEnv("B", NSB, FileSystem(envA.FileSystem()))
+		Eventually(members(envA), 5*time.Second).Should(ConsistOf("A", "B"))
+		Eventually(members(envB), 5*time.Second).Should(ConsistOf("A", "B"))
+		Eventually(acknowledged(envA), 5*time.Second).Should(BeTrue())
+		Eventually(acknowledged(envB), 5*time.Second).Should(BeTrue())
+	})
+
+	AfterEach(func() {
+		// envB shares the filesystem of envA
+		envB.Cleanup()
+		envA.Cleanup()
+	})
+
+	It("distributes namespaces among instances", func() {
+		Expect(envA.Processor().Assignment().Owner(NSB)).To(Equal("B"))
+		Expect(envB.Processor().Assignment().Owner(NSA + "/nested")).To(Equal("A"))
+
+		setup(envA, NSA)
+		setup(envB, NSB)
+		Eventually(value(envA, NSA, "C-A"), 20*time.Second).Should(Equal(10))
+		Eventually(value(envB, NSB, "C-A"), 20*time.Second).Should(Equal(10))
+
+		Expect(envA.Processor().Model().Namespaces()).To(ContainElement(NSA))
+		Expect(envA.Processor().Model().Namespaces()).NotTo(ContainElement(NSB))
+		Expect(envB.Processor().Model().Namespaces()).To(ContainElement(NSB))
+		Expect(envB.Processor().Model().Namespaces()).NotTo(ContainElement(NSA))
+	})
+
+	It("ignores objects of foreign shards", func() {
+		setup(envA, NSB)
+		Consistently(envA.Processor().Model().Namespaces, 2*time.Second).ShouldNot(ContainElement(NSB))
+	})
+
+	It("rejects force deletion for objects of foreign shards", func() {
+		envA.Processor().EnableForceDeletion(true)
+		_, err := envA.Processor().ForceDelete(database.NewObjectId(mymetamodel.TYPE_OPERATOR, NSB, "C"))
+		Expect(err).To(MatchError(processor.ErrNotResponsible))
+	})
+
+	It("takes over the namespaces of a leaving instance", func() {
+		setup(envB, NSB)
+		Eventually(value(envB, NSB, "C-A"), 20*time.Second).Should(Equal(10))
+
+		envB.Stop()
+		Eventually(members(envA), 5*time.Second).Should(ConsistOf("A"))
+		Eventually(envA.Processor().Model().Namespaces, 5*time.Second).Should(ContainElement(NSB))
+
+		o := Must(envA.GetObject(database.NewObjectId(mymetamodel.TYPE_VALUE, NSB, "A"))).(*db.Value)
+		MustBeSuccessful(Modify(envA, &o, func(o *db.Value) (bool, bool) {
+			o.Spec.Value = 6
+			return true, true
+		}))
+		Eventually(value(envA, NSB, "C-A"), 20*time.Second).Should(Equal(12))
+	})
+})
+
+// processingLog records the elements processed by engine instances
+// in the order of the processing.
+type processingLog struct {
+	lock    sync.Mutex
+	entries []processingEntry
+}
+
+type processingEntry struct {
+	identity string
+	element  string
+}
+
+func (l *processingLog) Handler(identity string) *processingHandler {
+	return &processingHandler{l, identity}
+}
+
+// Violations provides the elements processed by instance first,
+// after they have been processed by instance second.
+func (l *processingLog) Violations(first, second string) []string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	var result []string
+	taken := map[string]bool{}
+	for _, e := range l.entries {
+		switch e.identity {
+		case second:
+			taken[e.element] = true
+		case first:
+			if taken[e.element] {
+				result = append(result, e.element)
+			}
+		}
+	}
+	return result
+}
+
+func (l *processingLog) Processed(identity string) int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	c := 0
+	for _, e := range l.entries {
+		if e.identity == identity {
+			c++
+		}
+	}
+	return c
+}
+
+type processingHandler struct {
+	log      *processingLog
+	identity string
+}
+
+func (h *processingHandler) HandleEvent(e elemwatch.Event) {
+	if e.Status != string(model.STATUS_PROCESSING) {
+		return
+	}
+	h.log.lock.Lock()
+	defer h.log.lock.Unlock()
+	h.log.entries = append(h.log.entries, processingEntry{h.identity, e.Node.String()})
+}
+
+var _ = Describe("Sharding hand-over", func() {
+	const NSX = "shard-x"
+
+	var envA, envB *TestEnv
+	var log *processingLog
+
+	newEnv := func(identity string, opts ...Option) *TestEnv {
+		env := Must(NewTestEnv("test", "testdata", mymodel.NewModelSpecification, opts...))
+		env.Processor().RegisterHandler(log.Handler(identity), false, "", true, "")
+		env.AddService(controllers.NewExpressionController(env.Logging(), 1, env.Database()))
+		MustBeSuccessful(env.Start())
+		return env
+	}
+
+	value := func(name string) func() int {
+		return func() int {
+			o, err := envA.GetObject(database.NewObjectId(mymetamodel.TYPE_VALUE, NSX, name))
+			if err != nil {
+				return -1
+			}
+			return o.(*db.Value).Spec.Value
+		}
+	}
+
+	// the filesystem database propagates events only to the
+	// instance changing an object.
+	setValue := func(env *TestEnv, v int) {
+		o := Must(env.GetObject(database.NewObjectId(mymetamodel.TYPE_VALUE, NSX, "A"))).(*db.Value)
+		MustBeSuccessful(Modify(env, &o, func(o *db.Value) (bool, bool) {
+			o.Spec.Value = v
+			return true, true
+		}))
+	}
+
+	BeforeEach(func() {
+		log = &processingLog{}
+		envA = newEnv("A", Sharding("A", time.Second))
+	})
+
+	AfterEach(func() {
+		// envB shares the filesystem of envA
+		if envB != nil {
+			envB.Cleanup()
+		}
+		envA.Cleanup()
+	})
+
+	It("never processes an element by two instances", func() {
+		MustBeSuccessful(envA.SetObject(db.NewValueNode(NSX, "A", 5)))
+		opC := db.NewOperatorNode(NSX, "C").
+			AddOperand("iA", "A").
+			AddOperation("eA", db.OP_ADD, "iA", "iA").
+			AddOutput("C-A", "eA")
+		MustBeSuccessful(envA.SetObject(opC))
+		Eventually(value("C-A"), 20*time.Second).Should(Equal(10))
+
+		// instance B explicitly claims the namespace. It is started
+		// while instance A is idle, because the filesystem database
+		// does not write atomically.
+		envB = newEnv("B", FileSystem(envA.FileSystem()), Sharding("B", time.Second, NSX))
+
+		// keep the namespace busy during the hand-over,
+		// until instance A released it.
+		stop := make(chan struct{})
+		done := make(chan struct{})
+		var once sync.Once
+		stopUpdates := func() {
+			once.Do(func() { close(stop) })
+			<-done
+		}
+		DeferCleanup(stopUpdates)
+		go func() {
+			defer GinkgoRecover()
+			defer close(done)
+			for v := 6; slices.Contains(envA.Processor().Model().Namespaces(), NSX); v++ {
+				select {
+				case <-stop:
+					return
+				case <-time.After(50 * time.Millisecond):
+				}
+				setValue(envA, v)
+			}
+		}()
+
+		Eventually(envB.Processor().Model().Namespaces, 10*time.Second).Should(ContainElement(NSX))
+		Expect(envA.Processor().Model().Namespaces()).NotTo(ContainElement(NSX))
+
+		stopUpdates()
+		Eventually(func() int {
+			// a completing run stores the processed value in the spec
+			// and may revert a concurrent change.
+			if value("A")() != 100 {
+				setValue(envB, 100)
+			}
+			return value("C-A")()
+		}, 20*time.Second).Should(Equal(200))
+
+		Expect(log.Processed("B")).To(BeNumerically(">", 0))
+		Expect(log.Processed("A")).To(BeNumerically(">", 0))
+		Expect(log.Violations("A", "B")).To(BeEmpty())
+	})
+})
@@ -0,0 +1,77 @@
+package sub_test
+
+import (
+	. "github.com/mandelsoft/engine/pkg/processing/testutils"
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/processing/model"
+
+	mymodel "github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub"
+	"github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub/db"
+	mymetamodel "github.com/mandelsoft/engine/pkg/metamodels/foreigndemo"
+)
+
+var _ = Describe("Suspension", func() {
+	var env *TestEnv
+
+	BeforeEach(func() {
+		env = Must(NewTestEnv("test", "testdata", mymodel.NewModelSpecification))
+		env.Start()
+	})
+
+	AfterEach(func() {
+		if env != nil {
+			env.Cleanup()
+		}
+	})
+
+	setSuspended := func(id database.ObjectId, b bool) {
+		o := Must(env.GetObject(id))
+		Expect(o.SetSuspended(b)).To(BeTrue())
+		MustBeSuccessful(env.SetObject(o))
+	}
+
+	It("suspends and resumes external objects", func() {
+		vA := db.NewValueNode(NS, "A", 5)
+		vA.SetSuspended(true)
+
+		fs := env.FutureForObjectStatus(model.STATUS_SUSPENDED, vA)
+		MustBeSuccessful(env.SetObject(vA))
+		Expect(env.WaitWithTimeout(fs)).To(BeTrue())
+
+		o := Must(env.GetObject(vA))
+		Expect(o.(*db.Value).Status.Message).To(Equal("object " + database.StringId(vA) + " suspended"))
+
+		mA := ValueCompleted(env, "A")
+		setSuspended(vA, false)
+		Expect(env.Wait(mA)).To(BeTrue())
+		mA.Check(env, 5, "")
+	})
+
+	It("suspends and resumes namespaces", func() {
+		vA := db.NewValueNode(NS, "A", 5)
+		mA := ValueCompleted(env, "A")
+		MustBeSuccessful(env.SetObject(vA))
+		Expect(env.Wait(mA)).To(BeTrue())
+
+		nid := database.NewObjectId(mymetamodel.TYPE_NAMESPACE, "", NS)
+		setSuspended(nid, true)
+
+		fs := env.FutureForObjectStatus(model.STATUS_SUSPENDED, vA)
+		o := Must(env.GetObject(vA))
+		o.(*db.Value).Spec.Value = 6
+		MustBeSuccessful(env.SetObject(o))
+		Expect(env.WaitWithTimeout(fs)).To(BeTrue())
+
+		o = Must(env.GetObject(vA))
+		Expect(o.(*db.Value).Status.Message).To(Equal("namespace " + NS + " suspended"))
+
+		mA = ValueCompleted(env, "A")
+		setSuspended(nid, false)
+		Expect(env.Wait(mA)).To(BeTrue())
+		mA.Check(env, 6, "")
+	})
+})
@@ -0,0 +1,98 @@
+package sub_test
+
+import (
+	"strings"
+
+	. "github.com/mandelsoft/engine/pkg/processing/testutils"
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/tracing"
+
+	mymodel "github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub"
+	"github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub/controllers"
+	"github.com/mandelsoft/engine/pkg/impl/metamodels/foreigndemo/sub/db"
+	mymetamodel "github.com/mandelsoft/engine/pkg/metamodels/foreigndemo"
+)
+
+var _ = Describe("Tracing", func() {
+	var env *TestEnv
+	var spans *tracing.MemoryExporter
+
+	named := func(prefix string) func(d *tracing.SpanData) bool {
+		return func(d *tracing.SpanData) bool {
+			return strings.HasPrefix(d.Name, prefix)
+		}
+	}
+
+	BeforeEach(func() {
+		spans = tracing.NewMemoryExporter()
+		tracing.Default.SetExporter(spans)
+		env = Must(NewTestEnv("test", "testdata", mymodel.NewModelSpecification))
+		env.AddService(controllers.NewExpressionController(env.Logging(), 1, env.Database()))
+		env.Start()
+	})
+
+	AfterEach(func() {
+		env.Cleanup()
+		tracing.Default.SetExporter(nil)
+	})
+
+	It("traces runs into slave and foreign objects", func() {
+		mCA := ValueCompleted(env, "C-A")
+		MustBeSuccessful(env.SetObject(db.NewValueNode(NS, "A", 5)))
+		opC := db.NewOperatorNode(NS, "C").
+			AddOperand("iA", "A").
+			AddOperand("iB", "2").
+			AddOperation("eA", db.OP_ADD, "iA", "iB").
+			AddOutput("C-A", "eA")
+		MustBeSuccessful(env.SetObject(opC))
+		Expect(env.Wait(mCA)).To(BeTrue())
+		mCA.Check(env, 7, "C")
+
+		// the run of the operator
+		runs := spans.Find(named("run OperatorState/" + NS + "/C:Gathering"))
+		Expect(runs).NotTo(BeEmpty())
+		run := runs[len(runs)-1]
+		Expect(run.Status).To(Equal(tracing.STATUS_OK))
+		Expect(run.Attribute("status")).To(Equal("Completed"))
+
+		trace := spans.Find(func(d *tracing.SpanData) bool { return d.Context.TraceId == run.Context.TraceId })
+		byId := map[tracing.SpanId]tracing.SpanData{}
+		for _, d := range trace {
+			byId[d.Context.SpanId] = d
+		}
+
+		process := spans.Find(func(d *tracing.SpanData) bool {
+			return d.Parent == run.Context.SpanId && strings.HasPrefix(d.Name, "process")
+		})
+		Expect(process).NotTo(BeEmpty())
+		Expect(spans.Find(func(d *tracing.SpanData) bool {
+			return d.Parent == run.Context.SpanId && d.Name == "commit"
+		})).To(HaveLen(1))
+
+		// slaves are part of the trace of the run
+		slaves := spans.Find(func(d *tracing.SpanData) bool {
+			return d.Context.TraceId == run.Context.TraceId && d.Name == "assure slaves"
+		})
+		Expect(slaves).NotTo(BeEmpty())
+		Expect(spans.Find(func(d *tracing.SpanData) bool {
+			return d.Parent == slaves[0].Context.SpanId && strings.HasPrefix(d.Name, "run ExpressionState/")
+		})).NotTo(BeEmpty())
+
+		// the expression controller continues the trace of the engine
+		eid := database.NewObjectId(mymetamodel.TYPE_EXPRESSION, NS, "C")
+		o := Must(env.GetObject(eid)).(*db.Expression)
+		parent := Must(tracing.ParseTraceParent(o.GetTraceParent()))
+		Expect(parent.TraceId).To(Equal(run.Context.TraceId))
+		Expect(byId[parent.SpanId].Name).To(Equal("assure external"))
+
+		foreign := spans.Find(func(d *tracing.SpanData) bool {
+			return d.Parent == parent.SpanId && d.Name == "reconcile "+database.StringId(eid)
+		})
+		Expect(foreign).NotTo(BeEmpty())
+		Expect(foreign[0].Context.TraceId).To(Equal(run.Context.TraceId))
+	})
+})
@@ -1,6 +1,9 @@
 package sub_test
 
 import (
+	"context"
+	"time"
+
 	. "github.com/mandelsoft/engine/pkg/processing/testutils"
 	. "github.com/mandelsoft/goutils/testutils"
 	. "github.com/onsi/ginkgo/v2"
@@ -9,6 +12,7 @@ import (
 	"github.com/mandelsoft/engine/pkg/database"
 	mymetamodel "github.com/mandelsoft/engine/pkg/metamodels/foreigndemo"
 	"github.com/mandelsoft/engine/pkg/processing/processor"
+	"github.com/mandelsoft/engine/pkg/processing/updaterequest"
 	"github.com/mandelsoft/logging"
 
 	"github.com/mandelsoft/engine/pkg/processing/model"
@@ -97,5 +101,69 @@ var _ = Describe("Controller Scenario Test Environment", func() {
 			Expect(mvA.WaitUntil(env, 8, "")).To(BeTrue())
 			Expect(mvB.WaitUntil(env, 9, "")).To(BeTrue())
 		})
+
+		It("releases the locks when the lease expires", func() {
+			env.AddService(me.NewExpressionController(env.Logging(), 1, env.Database()))
+			env.Start()
+
+			ovA := db.NewValueNode(NS, "A", 5)
+			MustBeSuccessful(env.SetObject(ovA))
+
+			orA := db.NewUpdateRequest(NS, "A").RequestAction(model.REQ_ACTION_LOCK, database.NewLocalObjectRefFor(ovA))
+			orA.Spec.Lease = "2s"
+			frA := env.FutureForObjectStatus(model.Status(model.REQ_STATUS_LOCKED), orA)
+			MustBeSuccessful(env.SetObject(orA))
+			Expect(env.WaitWithTimeout(frA)).To(BeTrue())
+			Expect(Must(env.GetObject(orA)).(*db.UpdateRequest).Status.Expiry).NotTo(BeNil())
+
+			mvA := NewValueMon(env, model.STATUS_COMPLETED, ovA.GetName())
+			frA = env.FutureForObjectStatus(model.Status(model.REQ_STATUS_EXPIRED), orA)
+			MustBeSuccessful(Modify(env, &ovA, func(o *db.Value) (bool, bool) {
+				o.Spec.Value = 8
+				return true, true
+			}))
+			Expect(env.WaitWithTimeout(frA)).To(BeTrue())
+			Expect(Must(env.GetObject(orA)).(*db.UpdateRequest).Status.Message).To(ContainSubstring("lease 2s expired"))
+			Expect(mvA.WaitUntil(env, 8, "")).To(BeTrue())
+		})
+
+		It("executes a transaction with the client", func() {
+			env.AddService(me.NewExpressionController(env.Logging(), 1, env.Database()))
+			env.Start()
+
+			ovA := db.NewValueNode(NS, "A", 5)
+			ovB := db.NewValueNode(NS, "B", 6)
+			MustBeSuccessful(env.SetObject(ovA))
+			MustBeSuccessful(env.SetObject(ovB))
+
+			ctx, cancel := context.WithTimeout(env.Context(), 20*time.Second)
+			defer cancel()
+
+			client := updaterequest.New(updaterequest.ForDatabase(env.Database()), mymetamodel.TYPE_UPDATEREQUEST, NS, "T").
+				SetLease(time.Minute).SetPollInterval(100 * time.Millisecond)
+			MustBeSuccessful(client.Begin(ctx, database.NewLocalObjectRefFor(ovA), database.NewLocalObjectRefFor(ovB)))
+			Expect(client.Begin(ctx)).To(MatchError(updaterequest.ErrExists))
+
+			owner := processor.Owner("T")
+			o := Must(env.GetObject(database.NewObjectId(mymetamodel.TYPE_VALUE_STATE, NS, "A")))
+			Expect(processor.IsObjectLock(o.(*db.ValueState).RunId)).To(Equal(&owner))
+
+			mvA := NewValueMon(env, model.STATUS_COMPLETED, ovA.GetName())
+			mvB := NewValueMon(env, model.STATUS_COMPLETED, ovB.GetName())
+			MustBeSuccessful(Modify(env, &ovA, func(o *db.Value) (bool, bool) {
+				o.Spec.Value = 8
+				return true, true
+			}))
+			MustBeSuccessful(Modify(env, &ovB, func(o *db.Value) (bool, bool) {
+				o.Spec.Value = 9
+				return true, true
+			}))
+			MustBeSuccessful(client.Commit(ctx))
+			Expect(mvA.WaitUntil(env, 8, "")).To(BeTrue())
+			Expect(mvB.WaitUntil(env, 9, "")).To(BeTrue())
+
+			_, err := env.GetObject(client.Id())
+			Expect(err).To(MatchError(database.ErrNotExist))
+		})
 	})
 })
@@ -46,13 +46,14 @@ type NodeSpec struct {
 }
 
 type NodeStatus struct {
-	Phase            Phase        `json:"phase,omitempty"`
-	Status           model.Status `json:"status,omitempty"`
-	Message          string       `json:"message,omitempty"`
-	RunId            RunId        `json:"runid,omitempty"`
-	DetectedVersion  string       `json:"detectedVersion,omitempty"`
-	ObservedVersion  string       `json:"observedVersion,omitempty"`
-	EffectiveVersion string       `json:"effectiveVersion,omitempty"`
+	Phase            Phase            `json:"phase,omitempty"`
+	Status           model.Status     `json:"status,omitempty"`
+	Message          string           `json:"message,omitempty"`
+	Conditions       model.Conditions `json:"conditions,omitempty"`
+	RunId            RunId            `json:"runid,omitempty"`
+	DetectedVersion  string           `json:"detectedVersion,omitempty"`
+	ObservedVersion  string           `json:"observedVersion,omitempty"`
+	EffectiveVersion string           `json:"effectiveVersion,omitempty"`
 
 	Result *int `json:"result,omitempty"`
 }
@@ -32,6 +32,10 @@ func (n *Value) UpdateStatus(lctx model.Logging, ob objectbase.Objectbase, elem
 	_, err := wrapped2.Modify(ob, n, func(_o db2.Object) (bool, bool) {
 		o := _o.(*db.Value)
 		mod := false
+		support.UpdateConditions(&o.Status.Conditions, o, update, &mod)
+		if update.IsObservationReport() {
+			return mod, mod
+		}
 		support.UpdateField(&o.Status.RunId, update.RunId, &mod)
 		support.UpdateField(&o.Status.EffectiveVersion, update.EffectiveVersion, &mod)
 		if update.ObservedVersion != nil {
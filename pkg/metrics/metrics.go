@@ -0,0 +1,314 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const (
+	TYPE_COUNTER   = "counter"
+	TYPE_GAUGE     = "gauge"
+	TYPE_HISTOGRAM = "histogram"
+)
+
+// DefBuckets are the default histogram buckets (in seconds).
+var DefBuckets = []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30}
+
+// Label is a label name/value pair of a sample.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// NewLabels provides labels for a list of name/value pairs.
+func NewLabels(pairs ...string) []Label {
+	var result []Label
+	for i := 0; i+1 < len(pairs); i += 2 {
+		result = append(result, Label{pairs[i], pairs[i+1]})
+	}
+	return result
+}
+
+// Sample is a single value of a metric family.
+// Suffix is appended to the family name (e.g. _bucket for histograms).
+type Sample struct {
+	Suffix string
+	Labels []Label
+	Value  float64
+}
+
+// Family is a set of samples sharing a metric name.
+type Family struct {
+	Name    string
+	Help    string
+	Type    string
+	Samples []Sample
+}
+
+// Collector provides metric families.
+type Collector interface {
+	Collect() []Family
+}
+
+type Counter interface {
+	Inc()
+	Add(v float64)
+}
+
+type Gauge interface {
+	Set(v float64)
+	Inc()
+	Dec()
+	Add(v float64)
+}
+
+type Observer interface {
+	Observe(v float64)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type vec[V any] struct {
+	lock   sync.Mutex
+	name   string
+	help   string
+	labels []string
+	create func() V
+	values map[string]V
+	keys   map[string][]string
+}
+
+func newVec[V any](name, help string, labels []string, create func() V) vec[V] {
+	return vec[V]{
+		name:   name,
+		help:   help,
+		labels: slices.Clone(labels),
+		create: create,
+		values: map[string]V{},
+		keys:   map[string][]string{},
+	}
+}
+
+func (v *vec[V]) with(values ...string) V {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metric %s requires %d label values, but %d given", v.name, len(v.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	e, ok := v.values[key]
+	if !ok {
+		e = v.create()
+		v.values[key] = e
+		v.keys[key] = slices.Clone(values)
+	}
+	return e
+}
+
+func (v *vec[V]) each(f func(labels []Label, e V)) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	keys := make([]string, 0, len(v.values))
+	for k := range v.values {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		f(labels(v.labels, v.keys[k]), v.values[k])
+	}
+}
+
+func labels(names []string, values []string) []Label {
+	var result []Label
+	for i, n := range names {
+		result = append(result, Label{n, values[i]})
+	}
+	return result
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type value struct {
+	lock  sync.Mutex
+	value float64
+}
+
+func (c *value) Inc() {
+	c.Add(1)
+}
+
+func (c *value) Dec() {
+	c.Add(-1)
+}
+
+func (c *value) Add(v float64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.value += v
+}
+
+func (c *value) Set(v float64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.value = v
+}
+
+func (c *value) get() float64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.value
+}
+
+// CounterVec is a set of counters partitioned by label values.
+type CounterVec struct {
+	vec[*value]
+}
+
+var _ Collector = (*CounterVec)(nil)
+
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{newVec[*value](name, help, labels, func() *value { return &value{} })}
+}
+
+func (c *CounterVec) WithLabelValues(values ...string) Counter {
+	return c.with(values...)
+}
+
+func (c *CounterVec) Collect() []Family {
+	f := Family{Name: c.name, Help: c.help, Type: TYPE_COUNTER}
+	c.each(func(labels []Label, e *value) {
+		f.Samples = append(f.Samples, Sample{Labels: labels, Value: e.get()})
+	})
+	return []Family{f}
+}
+
+// GaugeVec is a set of gauges partitioned by label values.
+type GaugeVec struct {
+	vec[*value]
+}
+
+var _ Collector = (*GaugeVec)(nil)
+
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{newVec[*value](name, help, labels, func() *value { return &value{} })}
+}
+
+func (c *GaugeVec) WithLabelValues(values ...string) Gauge {
+	return c.with(values...)
+}
+
+func (c *GaugeVec) Collect() []Family {
+	f := Family{Name: c.name, Help: c.help, Type: TYPE_GAUGE}
+	c.each(func(labels []Label, e *value) {
+		f.Samples = append(f.Samples, Sample{Labels: labels, Value: e.get()})
+	})
+	return []Family{f}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type histogram struct {
+	lock    sync.Mutex
+	buckets []float64
+	counts  []uint64
+	count   uint64
+	sum     float64
+}
+
+func (h *histogram) Observe(v float64) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	for i, b := range h.buckets {
+		if v <= b {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+// HistogramVec is a set of histograms partitioned by label values.
+type HistogramVec struct {
+	vec[*histogram]
+}
+
+var _ Collector = (*HistogramVec)(nil)
+
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+	return &HistogramVec{newVec[*histogram](name, help, labels, func() *histogram {
+		return &histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+	})}
+}
+
+func (c *HistogramVec) WithLabelValues(values ...string) Observer {
+	return c.with(values...)
+}
+
+func (c *HistogramVec) Collect() []Family {
+	f := Family{Name: c.name, Help: c.help, Type: TYPE_HISTOGRAM}
+	c.each(func(labels []Label, e *histogram) {
+		e.lock.Lock()
+		defer e.lock.Unlock()
+		for i, b := range e.buckets {
+			f.Samples = append(f.Samples, Sample{Suffix: "_bucket", Labels: append(slices.Clone(labels), Label{"le", formatFloat(b)}), Value: float64(e.counts[i])})
+		}
+		f.Samples = append(f.Samples,
+			Sample{Suffix: "_bucket", Labels: append(slices.Clone(labels), Label{"le", "+Inf"}), Value: float64(e.count)},
+			Sample{Suffix: "_sum", Labels: labels, Value: e.sum},
+			Sample{Suffix: "_count", Labels: labels, Value: float64(e.count)},
+		)
+	})
+	return []Family{f}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// GaugeFunc is a gauge, whose values are determined
+// by a callback function at collection time.
+type GaugeFunc struct {
+	name   string
+	help   string
+	labels []string
+	f      func(emit func(v float64, values ...string))
+}
+
+var _ Collector = (*GaugeFunc)(nil)
+
+// NewGaugeFunc provides a gauge whose samples are provided by f.
+// For every sample f calls emit with the value and the label values.
+func NewGaugeFunc(name, help string, f func(emit func(v float64, values ...string)), labels ...string) *GaugeFunc {
+	return &GaugeFunc{name: name, help: help, labels: slices.Clone(labels), f: f}
+}
+
+func (c *GaugeFunc) Collect() []Family {
+	f := Family{Name: c.name, Help: c.help, Type: TYPE_GAUGE}
+	c.f(func(v float64, values ...string) {
+		f.Samples = append(f.Samples, Sample{Labels: labels(c.labels, values), Value: v})
+	})
+	return []Family{f}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return fmt.Sprintf("%g", v)
+}
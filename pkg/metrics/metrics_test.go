@@ -0,0 +1,102 @@
+package metrics_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/engine/pkg/metrics"
+)
+
+func scrape(h http.Handler) string {
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	ExpectWithOffset(1, rec.Code).To(Equal(http.StatusOK))
+	ExpectWithOffset(1, rec.Header().Get("Content-Type")).To(Equal(metrics.CONTENT_TYPE))
+	return string(Must(io.ReadAll(rec.Body)))
+}
+
+var _ = Describe("Metrics", func() {
+	var reg *metrics.Registry
+
+	BeforeEach(func() {
+		reg = metrics.NewRegistry()
+	})
+
+	It("exposes counters and gauges", func() {
+		c := metrics.NewCounterVec("test_total", "a counter", "kind")
+		g := metrics.NewGaugeVec("test_depth", "a gauge", "pool")
+		reg.MustRegister(c, g)
+
+		c.WithLabelValues("b").Inc()
+		c.WithLabelValues("a").Add(2)
+		g.WithLabelValues("p").Set(5)
+		g.WithLabelValues("p").Dec()
+
+		Expect(scrape(reg)).To(Equal(`# HELP test_depth a gauge
+# TYPE test_depth gauge
+test_depth{pool="p"} 4
+# HELP test_total a counter
+# TYPE test_total counter
+test_total{kind="a"} 2
+test_total{kind="b"} 1
+`))
+	})
+
+	It("exposes histograms", func() {
+		h := metrics.NewHistogramVec("test_seconds", "a histogram", []float64{1, 0.5}, "op")
+		reg.MustRegister(h)
+
+		h.WithLabelValues("get").Observe(0.2)
+		h.WithLabelValues("get").Observe(0.7)
+		h.WithLabelValues("get").Observe(3)
+
+		Expect(scrape(reg)).To(Equal(`# HELP test_seconds a histogram
+# TYPE test_seconds histogram
+test_seconds_bucket{op="get",le="0.5"} 1
+test_seconds_bucket{op="get",le="1"} 2
+test_seconds_bucket{op="get",le="+Inf"} 3
+test_seconds_sum{op="get"} 3.9
+test_seconds_count{op="get"} 3
+`))
+	})
+
+	It("merges families and escapes label values", func() {
+		emit := func(v float64) *metrics.GaugeFunc {
+			return metrics.NewGaugeFunc("test_value", "values", func(emit func(v float64, values ...string)) {
+				emit(v, "a \"quoted\"\nvalue")
+			}, "name")
+		}
+		a, b := emit(1), emit(2)
+		reg.MustRegister(a, b)
+
+		Expect(scrape(reg)).To(Equal(`# HELP test_value values
+# TYPE test_value gauge
+test_value{name="a \"quoted\"\nvalue"} 1
+test_value{name="a \"quoted\"\nvalue"} 2
+`))
+
+		Expect(reg.Unregister(b)).To(BeTrue())
+		Expect(reg.Unregister(b)).To(BeFalse())
+		Expect(scrape(reg)).To(ContainSubstring(`} 1`))
+		Expect(scrape(reg)).NotTo(ContainSubstring(`} 2`))
+	})
+
+	It("rejects duplicate registrations", func() {
+		c := metrics.NewCounterVec("test_total", "a counter")
+		reg.MustRegister(c)
+		Expect(func() { reg.MustRegister(c) }).To(Panic())
+	})
+
+	It("rejects non GET requests", func() {
+		req := httptest.NewRequest(http.MethodPost, "/metrics", nil)
+		rec := httptest.NewRecorder()
+		reg.ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusMethodNotAllowed))
+	})
+})
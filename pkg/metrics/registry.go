@@ -0,0 +1,136 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// CONTENT_TYPE is the content type of the Prometheus text format.
+const CONTENT_TYPE = "text/plain; version=0.0.4; charset=utf-8"
+
+// Registry is a set of collectors, which can be exposed
+// in the Prometheus text format.
+type Registry struct {
+	lock       sync.Mutex
+	collectors []Collector
+}
+
+var _ http.Handler = (*Registry)(nil)
+
+// Default is the registry used for the metrics of the engine packages.
+var Default = NewRegistry()
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// MustRegister registers collectors.
+func MustRegister(cs ...Collector) {
+	Default.MustRegister(cs...)
+}
+
+// Unregister removes a collector from the default registry.
+func Unregister(c Collector) bool {
+	return Default.Unregister(c)
+}
+
+func (r *Registry) MustRegister(cs ...Collector) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for _, c := range cs {
+		if slices.Contains(r.collectors, c) {
+			panic(fmt.Sprintf("collector %T already registered", c))
+		}
+		r.collectors = append(r.collectors, c)
+	}
+}
+
+func (r *Registry) Unregister(c Collector) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	i := slices.Index(r.collectors, c)
+	if i < 0 {
+		return false
+	}
+	r.collectors = slices.Delete(r.collectors, i, i+1)
+	return true
+}
+
+// Gather collects the metric families of all collectors.
+// Families with the same name provided by different collectors
+// are merged. The result is ordered by name.
+func (r *Registry) Gather() []Family {
+	r.lock.Lock()
+	collectors := slices.Clone(r.collectors)
+	r.lock.Unlock()
+
+	families := map[string]*Family{}
+	for _, c := range collectors {
+		for _, f := range c.Collect() {
+			if e := families[f.Name]; e != nil {
+				e.Samples = append(e.Samples, f.Samples...)
+			} else {
+				families[f.Name] = &f
+			}
+		}
+	}
+
+	var result []Family
+	for _, f := range families {
+		result = append(result, *f)
+	}
+	slices.SortFunc(result, func(a, b Family) int { return strings.Compare(a.Name, b.Name) })
+	return result
+}
+
+// WriteText writes the metrics in the Prometheus text format.
+func (r *Registry) WriteText(w io.Writer) error {
+	buf := &bytes.Buffer{}
+	for _, f := range r.Gather() {
+		if f.Help != "" {
+			fmt.Fprintf(buf, "# HELP %s %s\n", f.Name, escape(f.Help, false))
+		}
+		fmt.Fprintf(buf, "# TYPE %s %s\n", f.Name, f.Type)
+		for _, s := range f.Samples {
+			buf.WriteString(f.Name + s.Suffix)
+			if len(s.Labels) > 0 {
+				buf.WriteString("{")
+				for i, l := range s.Labels {
+					if i > 0 {
+						buf.WriteString(",")
+					}
+					fmt.Fprintf(buf, "%s=\"%s\"", l.Name, escape(l.Value, true))
+				}
+				buf.WriteString("}")
+			}
+			fmt.Fprintf(buf, " %s\n", formatFloat(s.Value))
+		}
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", CONTENT_TYPE)
+	r.WriteText(w)
+}
+
+func escape(s string, quote bool) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	if quote {
+		s = strings.ReplaceAll(s, `"`, `\"`)
+	}
+	return s
+}
@@ -0,0 +1,157 @@
+package notification
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	elemwatch "github.com/mandelsoft/engine/pkg/processing/watch"
+	"github.com/mandelsoft/engine/pkg/utils"
+)
+
+// States of a notification.
+const (
+	// STATE_ACTIVE is used for notifications delivering their events.
+	STATE_ACTIVE = "Active"
+	// STATE_FAILING is used for notifications whose last
+	// delivery attempt failed. Their events are kept and
+	// delivered again later.
+	STATE_FAILING = "Failing"
+	// STATE_INVALID is used for notifications with an invalid
+	// or disabled sink. They are ignored until their spec is changed.
+	STATE_INVALID = "Invalid"
+)
+
+// Selector describes the element events delivered by a notification.
+type Selector struct {
+	// Types are the element types (internal types) of the selected
+	// events. An empty list selects all types.
+	Types []string `json:"types,omitempty"`
+	// Namespace is the namespace of the selected elements.
+	Namespace string `json:"namespace,omitempty"`
+	// Closure selects the elements of the complete namespace closure.
+	Closure bool `json:"closure,omitempty"`
+	// Status is the set of selected status values. An empty set
+	// selects all status transitions.
+	Status []string `json:"status,omitempty"`
+}
+
+// Match checks whether an element event is selected.
+func (s *Selector) Match(e *elemwatch.Event) bool {
+	if len(s.Types) > 0 && !slices.Contains(s.Types, e.Node.Kind) {
+		return false
+	}
+	if len(s.Status) > 0 && !slices.Contains(s.Status, e.Status) {
+		return false
+	}
+	return database.MatchNamespace(s.Closure, s.Namespace, e.Node.Namespace)
+}
+
+// WebhookSink describes the delivery of events by HTTP POST requests.
+type WebhookSink struct {
+	// URL is the URL the events are posted to.
+	URL string `json:"url"`
+	// Secret is used to sign the payload with HMAC-SHA256. The
+	// signature is passed with the header X-Engine-Signature.
+	Secret string `json:"secret,omitempty"`
+	// Retries is the number of retries for a failed request
+	// (default DEFAULT_RETRIES). A negative value disables retries.
+	Retries int `json:"retries,omitempty"`
+	// TimeoutSeconds is the timeout for a single request.
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
+}
+
+// FileSink describes the delivery of events by appending
+// JSON lines to a file.
+type FileSink struct {
+	// Path is the path of the file relative to the file directory
+	// configured for the notifier.
+	Path string `json:"path"`
+}
+
+// ExecSink describes the delivery of events by executing a
+// command. The payload is passed on stdin.
+type ExecSink struct {
+	Command []string `json:"command"`
+	// TimeoutSeconds is the timeout for the command execution.
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
+}
+
+// SinkSpec describes the sink of a notification.
+// Exactly one sink must be specified.
+type SinkSpec struct {
+	Webhook *WebhookSink `json:"webhook,omitempty"`
+	File    *FileSink    `json:"file,omitempty"`
+	Exec    *ExecSink    `json:"exec,omitempty"`
+}
+
+// Spec describes a notification.
+type Spec struct {
+	Selector Selector `json:"selector"`
+	Sink     SinkSpec `json:"sink"`
+}
+
+// Status describes the delivery state of a notification.
+type Status struct {
+	// State is the delivery state. It is empty for
+	// notifications not yet observed by the notifier.
+	State   string `json:"state,omitempty"`
+	Message string `json:"message,omitempty"`
+	// Cursor is the sequence number of the last processed event.
+	Cursor int64 `json:"cursor"`
+	// Failures is the number of consecutive failed delivery attempts.
+	Failures int `json:"failures,omitempty"`
+	// LastDelivery is the time of the last successful delivery.
+	LastDelivery *utils.Timestamp `json:"lastDelivery,omitempty"`
+}
+
+// Notification is the interface of database objects used
+// to configure notifications.
+type Notification interface {
+	database.Object
+
+	GetNotificationSpec() Spec
+	GetNotificationStatus() Status
+	SetNotificationStatus(Status)
+}
+
+// Record is an element event kept for delivery.
+type Record struct {
+	// Sequence is the sequence number of the event. It defines
+	// the delivery order.
+	Sequence  int64           `json:"sequence"`
+	Timestamp utils.Timestamp `json:"timestamp"`
+	Event     elemwatch.Event `json:"event"`
+}
+
+// Name provides the name of the journal object for the record.
+func (r *Record) Name() string {
+	return ObjectName(r.Sequence)
+}
+
+// ObjectName provides the name of a journal object for a sequence
+// number. The names are ordered like the sequence numbers.
+// Object names must start with a letter, therefore the
+// sequence number is prefixed.
+func ObjectName(seq int64) string {
+	return fmt.Sprintf("e%016d", seq)
+}
+
+// Journal is the interface of database objects used to
+// persist the events until they are delivered by all
+// notifications.
+type Journal interface {
+	database.Object
+
+	GetRecord() Record
+	SetRecord(Record)
+}
+
+// Delivery is the payload passed to the sinks.
+type Delivery struct {
+	// Notification is the namespace and name of the notification.
+	Notification string          `json:"notification"`
+	Sequence     int64           `json:"sequence"`
+	Timestamp    utils.Timestamp `json:"timestamp"`
+	Event        elemwatch.Event `json:"event"`
+}
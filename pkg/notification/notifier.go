@@ -0,0 +1,529 @@
+package notification
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/processing/model"
+	elemwatch "github.com/mandelsoft/engine/pkg/processing/watch"
+	"github.com/mandelsoft/engine/pkg/service"
+	"github.com/mandelsoft/engine/pkg/utils"
+	"github.com/mandelsoft/engine/pkg/watch"
+	"github.com/mandelsoft/goutils/maputils"
+	"github.com/mandelsoft/logging"
+)
+
+var REALM = logging.DefineRealm("engine/notification", "notifications")
+
+// DEFAULT_RETRY_INTERVAL is the default interval used to
+// retry failed deliveries.
+const DEFAULT_RETRY_INTERVAL = 30 * time.Second
+
+// DEFAULT_BACKOFF is the default initial backoff used
+// for retries of webhook requests.
+const DEFAULT_BACKOFF = time.Second
+
+// Options describes the configuration of a Notifier.
+type Options struct {
+	// FileDirectory is the base directory for the files used by
+	// file sinks. If empty, file sinks are disabled.
+	FileDirectory string
+	// Exec enables exec sinks. Because notifications are
+	// configured by database objects, exec sinks should only
+	// be enabled, if the database access is restricted.
+	Exec bool
+	// RetryInterval is the interval used to retry failed deliveries.
+	RetryInterval time.Duration
+	// Backoff is the initial backoff used for retries of webhook requests.
+	Backoff time.Duration
+}
+
+// EventSource is the source of element events, for example
+// the processor.
+type EventSource interface {
+	RegisterHandler(h watch.EventHandler[elemwatch.Event], current bool, kind string, closure bool, ns string)
+	UnregisterHandler(h watch.EventHandler[elemwatch.Event], kind string, closure bool, ns string)
+}
+
+type notification struct {
+	id     database.ObjectId
+	name   string
+	spec   Spec
+	status Status
+	sink   Sink
+	// retry is the earliest time for the next delivery
+	// attempt after a failed delivery.
+	retry time.Time
+}
+
+func (n *notification) isValid() bool {
+	return n.sink != nil
+}
+
+// Notifier delivers element events to the sinks configured by
+// notification objects. Only status transitions of elements
+// are considered. Events selected by at least one notification
+// are persisted as journal objects in the root namespace before
+// they are delivered. Every notification keeps the sequence number
+// of the last processed event as cursor in its status, which is
+// updated after every delivery. Therefore, events are delivered at
+// least once and in order, even if the engine is restarted.
+// If a delivery fails, the delivery for this notification stops and
+// is retried after the retry interval or after a change of its spec. Journal objects are deleted
+// after they have been processed by all valid notifications.
+type Notifier[O database.Object] struct {
+	lock    sync.Mutex
+	log     logging.Logger
+	db      database.Database[O]
+	source  EventSource
+	ntyp    string
+	jtyp    string
+	options Options
+
+	// wlock serializes the journal writes in the order
+	// of their sequence numbers.
+	wlock sync.Mutex
+	// pending are the records, which could not be journaled, yet.
+	// They are kept in order and stored before newer records to
+	// keep the journal free of gaps. It is guarded by wlock.
+	pending       []*Record
+	seq           int64
+	last          map[elemwatch.Id]string
+	notifications map[string]*notification
+	// dirty is set by the handler for notification objects.
+	// It is called synchronously by status updates done while
+	// holding the lock, therefore it must not use the lock.
+	dirty atomic.Bool
+
+	trigger chan struct{}
+	handler *objectHandler[O]
+	syncher service.Syncher
+}
+
+var _ service.Service = (*Notifier[database.Object])(nil)
+
+// New creates a Notifier for the events of source. Notification
+// and journal objects use the types of the given proto objects.
+func New[O database.Object](lctx logging.AttributionContextProvider, db database.Database[O], source EventSource, nproto, jproto database.ObjectId, opts Options) *Notifier[O] {
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = DEFAULT_RETRY_INTERVAL
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = DEFAULT_BACKOFF
+	}
+	n := &Notifier[O]{
+		log:           lctx.AttributionContext().Logger(REALM),
+		db:            db,
+		source:        source,
+		ntyp:          nproto.GetType(),
+		jtyp:          jproto.GetType(),
+		options:       opts,
+		last:          map[elemwatch.Id]string{},
+		notifications: map[string]*notification{},
+		trigger:       make(chan struct{}, 1),
+	}
+	n.handler = &objectHandler[O]{n}
+	return n
+}
+
+// Start loads the notifications and starts the delivery of the
+// journaled and new events.
+func (n *Notifier[O]) Start(ctx context.Context) (service.Syncher, service.Syncher, error) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	ready := service.SyncTrigger()
+	ready.Trigger()
+	if n.syncher != nil {
+		return ready, n.syncher, nil
+	}
+
+	records, err := n.journal()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(records) > 0 {
+		n.seq = records[len(records)-1].Sequence
+	}
+	err = n.load()
+	if err != nil {
+		return nil, nil, err
+	}
+	n.log.Info("starting notifier with {{amount}} notifications at sequence {{sequence}}", "amount", len(n.notifications), "sequence", n.seq)
+
+	n.db.RegisterHandler(n.handler, false, n.ntyp, true, "")
+	n.source.RegisterHandler(n, false, "", true, "")
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	n.syncher = service.Sync(wg)
+	go func() {
+		defer wg.Done()
+		n.run(ctx)
+	}()
+	return ready, n.syncher, nil
+}
+
+func (n *Notifier[O]) Wait() error {
+	n.lock.Lock()
+	sy := n.syncher
+	n.lock.Unlock()
+
+	if sy == nil {
+		return nil
+	}
+	return sy.Wait()
+}
+
+// HandleEvent journals an element event, if it describes a status
+// transition selected by a notification.
+// The journal object is written without holding the lock. The
+// writes are serialized to keep the journal free of gaps.
+// Records, which cannot be written, are kept and retried
+// before newer records are written.
+func (n *Notifier[O]) HandleEvent(e elemwatch.Event) {
+	n.lock.Lock()
+	if s, ok := n.last[e.Node]; ok && s == e.Status {
+		n.lock.Unlock()
+		return
+	}
+	if e.Status == string(model.STATUS_DELETED) {
+		delete(n.last, e.Node)
+	} else {
+		n.last[e.Node] = e.Status
+	}
+	if !n.selected(&e) {
+		n.lock.Unlock()
+		return
+	}
+
+	n.seq++
+	r := &Record{
+		Sequence:  n.seq,
+		Timestamp: utils.NewTimestamp(),
+		Event:     e,
+	}
+	n.wlock.Lock()
+	defer n.wlock.Unlock()
+	n.lock.Unlock()
+
+	n.pending = append(n.pending, r)
+	if n.flush() {
+		n.notify()
+	}
+}
+
+// flush journals the pending records in order. It stops at
+// the first failure and keeps the remaining records for a retry.
+// It reports whether records have been written.
+// It must be called with the write lock held.
+func (n *Notifier[O]) flush() bool {
+	stored := false
+	for len(n.pending) > 0 {
+		r := n.pending[0]
+		err := n.store(r)
+		if err != nil {
+			n.log.LogError(err, "cannot journal event {{sequence}} for {{element}}", "sequence", r.Sequence, "element", r.Event.Node.String())
+			break
+		}
+		n.log.Debug("journaled event {{sequence}} for {{element}} ({{status}})", "sequence", r.Sequence, "element", r.Event.Node.String(), "status", r.Event.Status)
+		n.pending = n.pending[1:]
+		stored = true
+	}
+	return stored
+}
+
+func (n *Notifier[O]) selected(e *elemwatch.Event) bool {
+	for _, nt := range n.notifications {
+		if nt.isValid() && nt.spec.Selector.Match(e) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *Notifier[O]) notify() {
+	select {
+	case n.trigger <- struct{}{}:
+	default:
+	}
+}
+
+func (n *Notifier[O]) run(ctx context.Context) {
+	defer func() {
+		n.source.UnregisterHandler(n, "", true, "")
+		n.db.UnregisterHandler(n.handler, n.ntyp, true, "")
+	}()
+	for {
+		n.process(ctx)
+		select {
+		case <-ctx.Done():
+			n.log.Info("stopping notifier")
+			return
+		case <-n.trigger:
+		case <-time.After(n.options.RetryInterval):
+		}
+	}
+}
+
+// process delivers the pending events of all notifications
+// and removes the journal objects processed by all of them.
+// Events, which could not be journaled, yet, are retried first.
+func (n *Notifier[O]) process(ctx context.Context) {
+	n.wlock.Lock()
+	n.flush()
+	n.wlock.Unlock()
+
+	n.lock.Lock()
+	if n.dirty.Swap(false) {
+		err := n.load()
+		if err != nil {
+			n.log.LogError(err, "cannot load notifications")
+			n.dirty.Store(true)
+		}
+	}
+	var list []*notification
+	for _, k := range maputils.OrderedKeys(n.notifications) {
+		if nt := n.notifications[k]; nt.isValid() && !time.Now().Before(nt.retry) {
+			list = append(list, nt)
+		}
+	}
+	n.lock.Unlock()
+
+	records, err := n.journal()
+	if err != nil {
+		n.log.LogError(err, "cannot read journal")
+		return
+	}
+	for _, nt := range list {
+		if ctx.Err() != nil {
+			return
+		}
+		n.deliver(ctx, nt, records)
+	}
+	n.prune(records)
+}
+
+// deliver delivers the pending events of a notification in order.
+func (n *Notifier[O]) deliver(ctx context.Context, nt *notification, records []*Record) {
+	cursor := nt.status.Cursor
+	for _, r := range records {
+		if r.Sequence <= cursor {
+			continue
+		}
+		if nt.spec.Selector.Match(&r.Event) {
+			d := &Delivery{
+				Notification: nt.name,
+				Sequence:     r.Sequence,
+				Timestamp:    r.Timestamp,
+				Event:        r.Event,
+			}
+			payload, err := json.Marshal(d)
+			if err == nil {
+				err = nt.sink.Deliver(ctx, d, payload)
+			}
+			if err != nil {
+				n.log.LogError(err, "delivery of event {{sequence}} for notification {{notification}} failed", "sequence", r.Sequence, "notification", nt.name)
+				n.lock.Lock()
+				nt.retry = time.Now().Add(n.options.RetryInterval)
+				n.lock.Unlock()
+				n.setStatus(nt, func(s *Status) {
+					s.Cursor = cursor
+					s.State = STATE_FAILING
+					s.Message = fmt.Sprintf("delivery of event %d failed: %s", r.Sequence, err)
+					s.Failures++
+				})
+				return
+			}
+			n.log.Debug("delivered event {{sequence}} for notification {{notification}}", "sequence", r.Sequence, "notification", nt.name)
+			cursor = r.Sequence
+			n.setStatus(nt, func(s *Status) {
+				s.Cursor = cursor
+				s.State = STATE_ACTIVE
+				s.Message = ""
+				s.Failures = 0
+				s.LastDelivery = utils.NewTimestampP()
+			})
+			continue
+		}
+		cursor = r.Sequence
+	}
+	if cursor != nt.status.Cursor || nt.status.State != STATE_ACTIVE {
+		n.setStatus(nt, func(s *Status) {
+			s.Cursor = cursor
+			s.State = STATE_ACTIVE
+			s.Message = ""
+			s.Failures = 0
+		})
+	}
+}
+
+// prune removes the journal objects processed by all
+// valid notifications.
+func (n *Notifier[O]) prune(records []*Record) {
+	if len(records) == 0 {
+		return
+	}
+	n.lock.Lock()
+	min := records[len(records)-1].Sequence
+	for _, nt := range n.notifications {
+		if nt.isValid() && nt.status.Cursor < min {
+			min = nt.status.Cursor
+		}
+	}
+	n.lock.Unlock()
+
+	for _, r := range records {
+		if r.Sequence > min {
+			break
+		}
+		_, err := n.db.DeleteObject(database.NewObjectId(n.jtyp, "", r.Name()))
+		if err != nil && !errors.Is(err, database.ErrNotExist) {
+			n.log.LogError(err, "cannot delete journal entry {{sequence}}", "sequence", r.Sequence)
+		}
+	}
+}
+
+// load (re-)reads the notification objects. New notifications
+// start with the actual sequence number, so that only events
+// observed after their creation are delivered.
+func (n *Notifier[O]) load() error {
+	list, err := n.db.ListObjects(n.ntyp, true, "")
+	if err != nil {
+		return err
+	}
+
+	notifications := map[string]*notification{}
+	for _, o := range list {
+		no, ok := any(o).(Notification)
+		if !ok {
+			return fmt.Errorf("type %q is no notification type", n.ntyp)
+		}
+		nt := &notification{
+			id:     database.NewObjectIdFor(o),
+			name:   strings.TrimPrefix(o.GetNamespace()+"/"+o.GetName(), "/"),
+			spec:   no.GetNotificationSpec(),
+			status: no.GetNotificationStatus(),
+		}
+		if old := n.notifications[nt.name]; old != nil && reflect.DeepEqual(old.spec, nt.spec) {
+			// status updates must not bypass the retry interval.
+			nt.retry = old.retry
+		}
+		notifications[nt.name] = nt
+
+		if nt.status.Cursor > n.seq {
+			n.seq = nt.status.Cursor
+		}
+		sink, err := newSink(&nt.spec.Sink, &n.options)
+		if err != nil {
+			if nt.status.State != STATE_INVALID || nt.status.Message != err.Error() {
+				n.log.Info("invalid notification {{notification}}: {{error}}", "notification", nt.name, "error", err.Error())
+				n.setStatus(nt, func(s *Status) {
+					s.State = STATE_INVALID
+					s.Message = err.Error()
+				})
+			}
+			continue
+		}
+		nt.sink = sink
+		switch nt.status.State {
+		case "":
+			n.log.Info("new notification {{notification}}", "notification", nt.name)
+			n.setStatus(nt, func(s *Status) {
+				s.State = STATE_ACTIVE
+				s.Cursor = n.seq
+			})
+		case STATE_INVALID:
+			n.setStatus(nt, func(s *Status) {
+				s.State = STATE_ACTIVE
+				s.Message = ""
+			})
+		}
+	}
+	n.notifications = notifications
+	return nil
+}
+
+// setStatus updates the status of a notification object.
+// If the object has been deleted meanwhile, only the
+// cached status is updated.
+func (n *Notifier[O]) setStatus(nt *notification, mod func(s *Status)) {
+	mod(&nt.status)
+	for {
+		o, err := n.db.GetObject(nt.id)
+		if err != nil {
+			if !errors.Is(err, database.ErrNotExist) {
+				n.log.LogError(err, "cannot get notification {{notification}}", "notification", nt.name)
+			}
+			return
+		}
+		no := any(o).(Notification)
+		s := no.GetNotificationStatus()
+		mod(&s)
+		no.SetNotificationStatus(s)
+		err = n.db.SetObject(o)
+		if !errors.Is(err, database.ErrModified) {
+			if err != nil {
+				n.log.LogError(err, "cannot update status of notification {{notification}}", "notification", nt.name)
+			}
+			return
+		}
+	}
+}
+
+func (n *Notifier[O]) store(r *Record) error {
+	id := database.NewObjectId(n.jtyp, "", r.Name())
+	o, err := n.db.SchemeTypes().CreateObject(id.GetType(), database.SetObjectNameFromId[O](id))
+	if err != nil {
+		return err
+	}
+	jo, ok := any(o).(Journal)
+	if !ok {
+		return fmt.Errorf("type %q is no journal type", id.GetType())
+	}
+	jo.SetRecord(*r)
+	return n.db.SetObject(o)
+}
+
+// journal provides the journaled events ordered by their
+// sequence numbers.
+func (n *Notifier[O]) journal() ([]*Record, error) {
+	list, err := n.db.ListObjects(n.jtyp, false, "")
+	if err != nil {
+		return nil, err
+	}
+	var result []*Record
+	for _, o := range list {
+		jo, ok := any(o).(Journal)
+		if !ok {
+			return nil, fmt.Errorf("type %q is no journal type", n.jtyp)
+		}
+		r := jo.GetRecord()
+		result = append(result, &r)
+	}
+	slices.SortFunc(result, func(a, b *Record) int { return cmp.Compare(a.Sequence, b.Sequence) })
+	return result, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// objectHandler triggers the reload of the notifications
+// for changed notification objects.
+type objectHandler[O database.Object] struct {
+	n *Notifier[O]
+}
+
+func (h *objectHandler[O]) HandleEvent(id database.ObjectId) {
+	h.n.dirty.Store(true)
+	h.n.notify()
+}
@@ -0,0 +1,366 @@
+package notification_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/impl/database/filesystem"
+	"github.com/mandelsoft/engine/pkg/notification"
+	"github.com/mandelsoft/engine/pkg/processing/model/support/db"
+	elemwatch "github.com/mandelsoft/engine/pkg/processing/watch"
+	"github.com/mandelsoft/engine/pkg/watch"
+	"github.com/mandelsoft/logging"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+)
+
+type source struct {
+	lock     sync.Mutex
+	handlers []watch.EventHandler[elemwatch.Event]
+}
+
+func (s *source) RegisterHandler(h watch.EventHandler[elemwatch.Event], current bool, kind string, closure bool, ns string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.handlers = append(s.handlers, h)
+}
+
+func (s *source) UnregisterHandler(h watch.EventHandler[elemwatch.Event], kind string, closure bool, ns string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for i, e := range s.handlers {
+		if e == h {
+			s.handlers = append(s.handlers[:i], s.handlers[i+1:]...)
+			return
+		}
+	}
+}
+
+func (s *source) Trigger(typ, ns, name, status string) {
+	s.lock.Lock()
+	handlers := s.handlers
+	s.lock.Unlock()
+	e := elemwatch.Event{
+		Node:   elemwatch.Id{Kind: typ, Namespace: ns, Name: name, Phase: "Phase"},
+		Status: status,
+	}
+	for _, h := range handlers {
+		h.HandleEvent(e)
+	}
+}
+
+// failingDatabase fails to write journal objects on request.
+type failingDatabase struct {
+	database.Database[db.Object]
+	lock sync.Mutex
+	fail bool
+}
+
+func (d *failingDatabase) SetFail(b bool) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.fail = b
+}
+
+func (d *failingDatabase) SetObject(o db.Object) error {
+	d.lock.Lock()
+	fail := d.fail && o.GetType() == db.TYPE_NOTIFICATION_EVENT
+	d.lock.Unlock()
+	if fail {
+		return fmt.Errorf("journal not writable")
+	}
+	return d.Database.SetObject(o)
+}
+
+type webhook struct {
+	lock       sync.Mutex
+	fail       bool
+	deliveries []notification.Delivery
+	headers    []http.Header
+	payloads   [][]byte
+}
+
+func (w *webhook) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.fail {
+		rw.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	data, _ := io.ReadAll(req.Body)
+	// requests canceled by the client may still be processed,
+	// therefore repeated deliveries are ignored.
+	for _, h := range w.headers {
+		if h.Get(notification.HEADER_DELIVERY) == req.Header.Get(notification.HEADER_DELIVERY) {
+			rw.WriteHeader(http.StatusOK)
+			return
+		}
+	}
+	var d notification.Delivery
+	if json.Unmarshal(data, &d) != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	w.deliveries = append(w.deliveries, d)
+	w.headers = append(w.headers, req.Header)
+	w.payloads = append(w.payloads, data)
+	rw.WriteHeader(http.StatusOK)
+}
+
+func (w *webhook) SetFail(b bool) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.fail = b
+}
+
+func (w *webhook) Elements() []string {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	var r []string
+	for _, d := range w.deliveries {
+		r = append(r, d.Event.Node.Name+":"+d.Event.Status)
+	}
+	return r
+}
+
+var _ = Describe("notifier", func() {
+	var odb database.Database[db.Object]
+	var src *source
+	var hook *webhook
+	var srv *httptest.Server
+	var ctx context.Context
+	var cancel context.CancelFunc
+
+	opts := notification.Options{
+		RetryInterval: 100 * time.Millisecond,
+		Backoff:       10 * time.Millisecond,
+	}
+
+	newNotifier := func(o notification.Options) *notification.Notifier[db.Object] {
+		return notification.New(logging.DefaultContext(), odb, src, db.NewNotification("", ""), db.NewNotificationEvent("", ""), o)
+	}
+
+	status := func(ns, name string) notification.Status {
+		o := Must(odb.GetObject(database.NewObjectId(db.TYPE_NOTIFICATION, ns, name)))
+		return o.(*db.Notification).Status
+	}
+
+	journal := func() int {
+		return len(Must(odb.ListObjects(db.TYPE_NOTIFICATION_EVENT, false, "")))
+	}
+
+	BeforeEach(func() {
+		scheme := db.NewScheme[db.Object]()
+		database.MustRegisterType[db.Notification, db.Object](scheme)
+		database.MustRegisterType[db.NotificationEvent, db.Object](scheme)
+		odb = Must(filesystem.New[db.Object](scheme, "/db", memoryfs.New()))
+		src = &source{}
+		hook = &webhook{}
+		srv = httptest.NewServer(hook)
+		ctx, cancel = context.WithCancel(context.Background())
+	})
+
+	AfterEach(func() {
+		cancel()
+		srv.Close()
+	})
+
+	It("delivers selected status transitions to webhooks", func() {
+		n := db.NewNotification("ns", "failed")
+		n.Spec.Selector = notification.Selector{
+			Types:     []string{"OperatorState"},
+			Namespace: "ns",
+			Closure:   true,
+			Status:    []string{"Failed", "Completed"},
+		}
+		n.Spec.Sink.Webhook = &notification.WebhookSink{URL: srv.URL, Secret: "secret"}
+		MustBeSuccessful(odb.SetObject(n))
+
+		notifier := newNotifier(opts)
+		Must2(notifier.Start(ctx))
+		Expect(status("ns", "failed").State).To(Equal(notification.STATE_ACTIVE))
+
+		src.Trigger("OperatorState", "ns", "A", "Processing")
+		src.Trigger("OperatorState", "ns", "A", "Failed")
+		src.Trigger("OperatorState", "ns", "A", "Failed")
+		src.Trigger("ValueState", "ns", "B", "Failed")
+		src.Trigger("OperatorState", "other", "C", "Failed")
+		src.Trigger("OperatorState", "ns/sub", "D", "Completed")
+
+		Eventually(hook.Elements).Should(Equal([]string{"A:Failed", "D:Completed"}))
+		Expect(hook.deliveries[0].Notification).To(Equal("ns/failed"))
+		Expect(hook.headers[0].Get(notification.HEADER_SIGNATURE)).To(Equal(notification.Sign("secret", hook.payloads[0])))
+		Expect(hook.headers[0].Get(notification.HEADER_DELIVERY)).To(Equal("ns/failed/1"))
+
+		Eventually(func() int64 { return status("ns", "failed").Cursor }).Should(Equal(int64(2)))
+		Eventually(journal).Should(Equal(0))
+	})
+
+	It("keeps undelivered events across restarts", func() {
+		n := db.NewNotification("", "all")
+		n.Spec.Selector = notification.Selector{Closure: true}
+		n.Spec.Sink.Webhook = &notification.WebhookSink{URL: srv.URL, Retries: -1}
+		MustBeSuccessful(odb.SetObject(n))
+
+		hook.SetFail(true)
+		notifier := newNotifier(opts)
+		Must2(notifier.Start(ctx))
+
+		src.Trigger("ValueState", "ns", "A", "Completed")
+		src.Trigger("ValueState", "ns", "B", "Completed")
+		Eventually(func() string { return status("", "all").State }).Should(Equal(notification.STATE_FAILING))
+		Expect(status("", "all").Failures).To(BeNumerically(">", 0))
+		Expect(journal()).To(Equal(2))
+
+		cancel()
+		MustBeSuccessful(notifier.Wait())
+
+		hook.SetFail(false)
+		ctx, cancel = context.WithCancel(context.Background())
+		notifier = newNotifier(opts)
+		Must2(notifier.Start(ctx))
+
+		Eventually(hook.Elements).Should(Equal([]string{"A:Completed", "B:Completed"}))
+		src.Trigger("ValueState", "ns", "C", "Completed")
+		Eventually(hook.Elements).Should(Equal([]string{"A:Completed", "B:Completed", "C:Completed"}))
+		Eventually(func() notification.Status { return status("", "all") }).Should(
+			And(HaveField("State", notification.STATE_ACTIVE), HaveField("Cursor", int64(3)), HaveField("Failures", 0)))
+		Eventually(journal).Should(Equal(0))
+	})
+
+	It("retries events, which could not be journaled", func() {
+		n := db.NewNotification("", "all")
+		n.Spec.Selector = notification.Selector{Closure: true}
+		n.Spec.Sink.Webhook = &notification.WebhookSink{URL: srv.URL}
+		MustBeSuccessful(odb.SetObject(n))
+
+		fdb := &failingDatabase{Database: odb}
+		notifier := notification.New(logging.DefaultContext(), fdb, src, db.NewNotification("", ""), db.NewNotificationEvent("", ""), opts)
+		Must2(notifier.Start(ctx))
+
+		fdb.SetFail(true)
+		src.Trigger("ValueState", "ns", "A", "Completed")
+		src.Trigger("ValueState", "ns", "B", "Completed")
+		Expect(journal()).To(Equal(0))
+
+		fdb.SetFail(false)
+		src.Trigger("ValueState", "ns", "C", "Completed")
+		Eventually(hook.Elements).Should(Equal([]string{"A:Completed", "B:Completed", "C:Completed"}))
+		Eventually(func() int64 { return status("", "all").Cursor }).Should(Equal(int64(3)))
+	})
+
+	It("starts new notifications with actual events", func() {
+		notifier := newNotifier(opts)
+		Must2(notifier.Start(ctx))
+		src.Trigger("ValueState", "ns", "A", "Completed")
+
+		n := db.NewNotification("", "late")
+		n.Spec.Sink.Webhook = &notification.WebhookSink{URL: srv.URL}
+		n.Spec.Selector.Closure = true
+		MustBeSuccessful(odb.SetObject(n))
+		Eventually(func() string { return status("", "late").State }).Should(Equal(notification.STATE_ACTIVE))
+
+		src.Trigger("ValueState", "ns", "B", "Completed")
+		Eventually(hook.Elements).Should(Equal([]string{"B:Completed"}))
+	})
+
+	It("forgets the status of deleted elements", func() {
+		n := db.NewNotification("", "all")
+		n.Spec.Selector = notification.Selector{Closure: true}
+		n.Spec.Sink.Webhook = &notification.WebhookSink{URL: srv.URL}
+		MustBeSuccessful(odb.SetObject(n))
+
+		notifier := newNotifier(opts)
+		Must2(notifier.Start(ctx))
+
+		src.Trigger("ValueState", "ns", "A", "Completed")
+		src.Trigger("ValueState", "ns", "A", "Deleted")
+		src.Trigger("ValueState", "ns", "A", "Completed")
+		Eventually(hook.Elements).Should(Equal([]string{"A:Completed", "A:Deleted", "A:Completed"}))
+	})
+
+	It("appends to files", func() {
+		dir := GinkgoT().TempDir()
+
+		n := db.NewNotification("", "file")
+		n.Spec.Selector.Closure = true
+		n.Spec.Sink.File = &notification.FileSink{Path: "events/log.jsonl"}
+		MustBeSuccessful(odb.SetObject(n))
+
+		o := opts
+		o.FileDirectory = dir
+		notifier := newNotifier(o)
+		Must2(notifier.Start(ctx))
+
+		src.Trigger("ValueState", "ns", "A", "Completed")
+		src.Trigger("ValueState", "ns", "B", "Failed")
+
+		file := filepath.Join(dir, "events", "log.jsonl")
+		Eventually(func() int {
+			data, _ := os.ReadFile(file)
+			return strings.Count(string(data), "\n")
+		}).Should(Equal(2))
+		lines := strings.Split(strings.TrimSpace(string(Must(os.ReadFile(file)))), "\n")
+		var d notification.Delivery
+		MustBeSuccessful(json.Unmarshal([]byte(lines[1]), &d))
+		Expect(d.Sequence).To(Equal(int64(2)))
+		Expect(d.Event.Node.Name).To(Equal("B"))
+	})
+
+	It("executes commands", func() {
+		dir := GinkgoT().TempDir()
+		out := filepath.Join(dir, "out")
+
+		n := db.NewNotification("", "exec")
+		n.Spec.Selector.Closure = true
+		n.Spec.Sink.Exec = &notification.ExecSink{Command: []string{"sh", "-c", `echo "$ENGINE_ELEMENT $ENGINE_STATUS" >>` + out}}
+		MustBeSuccessful(odb.SetObject(n))
+
+		o := opts
+		o.Exec = true
+		notifier := newNotifier(o)
+		Must2(notifier.Start(ctx))
+
+		src.Trigger("ValueState", "ns", "A", "Completed")
+		Eventually(func() string {
+			data, _ := os.ReadFile(out)
+			return string(data)
+		}).Should(Equal("ValueState/ns/A:Phase Completed\n"))
+	})
+
+	It("rejects disabled sinks", func() {
+		n := db.NewNotification("", "exec")
+		n.Spec.Sink.Exec = &notification.ExecSink{Command: []string{"true"}}
+		MustBeSuccessful(odb.SetObject(n))
+		n = db.NewNotification("", "file")
+		n.Spec.Sink.File = &notification.FileSink{Path: "../log"}
+		MustBeSuccessful(odb.SetObject(n))
+		n = db.NewNotification("", "none")
+		MustBeSuccessful(odb.SetObject(n))
+
+		o := opts
+		o.FileDirectory = GinkgoT().TempDir()
+		notifier := newNotifier(o)
+		Must2(notifier.Start(ctx))
+
+		Expect(status("", "exec")).To(And(HaveField("State", notification.STATE_INVALID), HaveField("Message", "exec sinks not enabled")))
+		Expect(status("", "file")).To(And(HaveField("State", notification.STATE_INVALID), HaveField("Message", `invalid file path "../log"`)))
+		Expect(status("", "none")).To(And(HaveField("State", notification.STATE_INVALID), HaveField("Message", "exactly one sink required")))
+
+		src.Trigger("ValueState", "ns", "A", "Completed")
+		Consistently(journal, 200*time.Millisecond).Should(Equal(0))
+	})
+})
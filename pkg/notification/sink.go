@@ -0,0 +1,205 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DEFAULT_RETRIES is the default number of retries for
+// failed webhook requests.
+const DEFAULT_RETRIES = 3
+
+// DEFAULT_TIMEOUT is the default timeout for a webhook
+// request or a command execution.
+const DEFAULT_TIMEOUT = 30 * time.Second
+
+// Headers used for webhook requests.
+const (
+	// HEADER_SIGNATURE is the header used to pass the HMAC-SHA256
+	// signature of the payload (sha256=<hex digest>).
+	HEADER_SIGNATURE = "X-Engine-Signature"
+	// HEADER_DELIVERY is the header used to pass the unique
+	// delivery id (<notification>/<sequence>). It can be used
+	// to detect repeated deliveries.
+	HEADER_DELIVERY = "X-Engine-Delivery"
+)
+
+// Sink delivers the payload for an event.
+type Sink interface {
+	Deliver(ctx context.Context, d *Delivery, payload []byte) error
+}
+
+// Sign provides the signature of a payload passed
+// with the header HEADER_SIGNATURE.
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func timeout(seconds int) time.Duration {
+	if seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return DEFAULT_TIMEOUT
+}
+
+// newSink creates the sink for a sink spec.
+// File and exec sinks are only possible, if enabled
+// by the options.
+func newSink(spec *SinkSpec, opts *Options) (Sink, error) {
+	n := 0
+	var s Sink
+	if spec.Webhook != nil {
+		n++
+		if spec.Webhook.URL == "" {
+			return nil, fmt.Errorf("webhook url required")
+		}
+		s = &webhookSink{spec: *spec.Webhook, backoff: opts.Backoff}
+	}
+	if spec.File != nil {
+		n++
+		if opts.FileDirectory == "" {
+			return nil, fmt.Errorf("file sinks not enabled")
+		}
+		p := filepath.Clean(spec.File.Path)
+		if spec.File.Path == "" || filepath.IsAbs(p) || p == ".." || strings.HasPrefix(p, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("invalid file path %q", spec.File.Path)
+		}
+		s = &fileSink{path: filepath.Join(opts.FileDirectory, p)}
+	}
+	if spec.Exec != nil {
+		n++
+		if !opts.Exec {
+			return nil, fmt.Errorf("exec sinks not enabled")
+		}
+		if len(spec.Exec.Command) == 0 {
+			return nil, fmt.Errorf("command required")
+		}
+		s = &execSink{spec: *spec.Exec}
+	}
+	if n != 1 {
+		return nil, fmt.Errorf("exactly one sink required")
+	}
+	return s, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type webhookSink struct {
+	spec    WebhookSink
+	backoff time.Duration
+}
+
+// Deliver posts the payload. Failed requests are retried
+// with an exponential backoff.
+func (s *webhookSink) Deliver(ctx context.Context, d *Delivery, payload []byte) error {
+	retries := s.spec.Retries
+	if retries == 0 {
+		retries = DEFAULT_RETRIES
+	}
+	backoff := s.backoff
+	for i := 0; ; i++ {
+		err := s.post(ctx, d, payload)
+		if err == nil || i >= retries {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (s *webhookSink) post(ctx context.Context, d *Delivery, payload []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout(s.spec.TimeoutSeconds))
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.spec.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HEADER_DELIVERY, fmt.Sprintf("%s/%d", d.Notification, d.Sequence))
+	if s.spec.Secret != "" {
+		req.Header.Set(HEADER_SIGNATURE, Sign(s.spec.Secret, payload))
+	}
+	r, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	r.Body.Close()
+	if r.StatusCode < 200 || r.StatusCode >= 300 {
+		return fmt.Errorf("webhook request failed with status %s", r.Status)
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type fileSink struct {
+	path string
+}
+
+// Deliver appends the payload as JSON line.
+func (s *fileSink) Deliver(ctx context.Context, d *Delivery, payload []byte) error {
+	err := os.MkdirAll(filepath.Dir(s.path), 0o755)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(payload, '\n'))
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type execSink struct {
+	spec ExecSink
+}
+
+// Deliver executes the command with the payload on stdin.
+// Additionally, the notification, the element and its status
+// are passed by environment variables.
+func (s *execSink) Deliver(ctx context.Context, d *Delivery, payload []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout(s.spec.TimeoutSeconds))
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, s.spec.Command[0], s.spec.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(),
+		"ENGINE_NOTIFICATION="+d.Notification,
+		"ENGINE_ELEMENT="+d.Event.Node.String(),
+		"ENGINE_STATUS="+d.Event.Status,
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		msg := strings.TrimSpace(string(out))
+		if msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package pool
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/mandelsoft/engine/pkg/database"
@@ -53,7 +54,17 @@ func (l actions) add(a Action) actions {
 	return append(l, a)
 }
 
+func (l actions) remove(a Action) actions {
+	for i, r := range l {
+		if r == a {
+			return append(l[:i:i], l[i+1:]...)
+		}
+	}
+	return l
+}
+
 type actionMapping struct {
+	lock     sync.RWMutex
 	values   map[interface{}]actions
 	matchers map[utils.Matcher]actions
 }
@@ -66,6 +77,9 @@ func newActionMapping() *actionMapping {
 }
 
 func (am *actionMapping) getAction(key interface{}) actions {
+	am.lock.RLock()
+	defer am.lock.RUnlock()
+
 	i := am.values[key]
 	if i == nil {
 		cmd, ok := key.(Command)
@@ -82,6 +96,9 @@ func (am *actionMapping) getAction(key interface{}) actions {
 }
 
 func (am *actionMapping) addAction(key ActionTargetSpec, a Action) {
+	am.lock.Lock()
+	defer am.lock.Unlock()
+
 	switch k := key.(type) {
 	case utils.StringMatcher:
 		am.values[string(k)] = am.values[string(k)].add(a)
@@ -91,3 +108,35 @@ func (am *actionMapping) addAction(key ActionTargetSpec, a Action) {
 		am.values[k] = am.values[k].add(a)
 	}
 }
+
+// removeAction removes an action for a key. If no action is
+// given, all actions for the key are removed.
+func (am *actionMapping) removeAction(key ActionTargetSpec, a Action) {
+	am.lock.Lock()
+	defer am.lock.Unlock()
+
+	switch k := key.(type) {
+	case utils.StringMatcher:
+		am.values[string(k)] = removeFrom(am.values[string(k)], a)
+		if len(am.values[string(k)]) == 0 {
+			delete(am.values, string(k))
+		}
+	case utils.Matcher:
+		am.matchers[k] = removeFrom(am.matchers[k], a)
+		if len(am.matchers[k]) == 0 {
+			delete(am.matchers, k)
+		}
+	default:
+		am.values[k] = removeFrom(am.values[k], a)
+		if len(am.values[k]) == 0 {
+			delete(am.values, k)
+		}
+	}
+}
+
+func removeFrom(l actions, a Action) actions {
+	if a == nil {
+		return nil
+	}
+	return l.remove(a)
+}
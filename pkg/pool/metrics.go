@@ -0,0 +1,69 @@
+package pool
+
+import (
+	"strings"
+	"time"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/metrics"
+)
+
+var (
+	queueDepth = metrics.NewGaugeVec("engine_workqueue_depth",
+		"Current number of queued requests.", "pool")
+	queueAdds = metrics.NewCounterVec("engine_workqueue_adds_total",
+		"Total number of requests added to the work queue.", "pool")
+	queueLatency = metrics.NewHistogramVec("engine_workqueue_queue_duration_seconds",
+		"Time a request is queued before being processed.", nil, "pool")
+	workDuration = metrics.NewHistogramVec("engine_workqueue_work_duration_seconds",
+		"Time required to process a request.", nil, "pool")
+
+	reconcileDuration = metrics.NewHistogramVec("engine_reconcile_duration_seconds",
+		"Duration of reconcilations per command type.", nil, "pool", "command")
+	reconcileTotal = metrics.NewCounterVec("engine_reconcile_total",
+		"Number of reconcilations per command type and result.", "pool", "command", "result")
+)
+
+func init() {
+	metrics.MustRegister(queueDepth, queueAdds, queueLatency, workDuration, reconcileDuration, reconcileTotal)
+}
+
+const (
+	RESULT_SUCCEEDED = "succeeded"
+	RESULT_DELAYED   = "delayed"
+	RESULT_FAILED    = "failed"
+	RESULT_REDO      = "redo"
+)
+
+// commandType provides the metrics label for a request.
+// For commands, it is the command name without arguments,
+// for objects it is the object type.
+func commandType(cmd Command, id database.ObjectId) string {
+	if id != nil {
+		return id.GetType()
+	}
+	c := string(cmd)
+	if i := strings.Index(c, ":"); i >= 0 {
+		return c[:i]
+	}
+	return c
+}
+
+func result(ok bool, err error) string {
+	switch {
+	case ok && err == nil:
+		return RESULT_SUCCEEDED
+	case ok:
+		return RESULT_DELAYED
+	case err != nil:
+		return RESULT_FAILED
+	default:
+		return RESULT_REDO
+	}
+}
+
+func observeReconcile(pool string, cmd Command, id database.ObjectId, start time.Time, ok bool, err error) {
+	typ := commandType(cmd, id)
+	reconcileDuration.WithLabelValues(pool, typ).Observe(time.Since(start).Seconds())
+	reconcileTotal.WithLabelValues(pool, typ, result(ok, err)).Inc()
+}
@@ -25,9 +25,18 @@ type Pool interface {
 	Period() time.Duration
 
 	AddAction(key ActionTargetSpec, a Action)
+	// RemoveAction removes an action for a key. If no action
+	// is given, all actions for the key are removed.
+	RemoveAction(key ActionTargetSpec, a Action)
 	GetActions(key interface{}) []Action
 
+	// SetClassifier sets the classifier used to determine the
+	// priority and tenant of queued requests. By default, all requests
+	// are handled with the default priority for a single tenant.
+	SetClassifier(c Classifier)
+
 	EnqueueCommand(cmd Command)
+	EnqueueCommandWithPriority(cmd Command, prio Priority)
 	EnqueueCommandRateLimited(cmd Command)
 	EnqueueCommandAfter(cmd Command, duration time.Duration)
 
@@ -46,6 +55,7 @@ type pool struct {
 	lctx       logging.AttributionContext
 	period     time.Duration
 	workqueue  Queue
+	queue      *fairQueue
 	actions    *actionMapping
 	useKeyName bool
 	key        string
@@ -55,6 +65,7 @@ type pool struct {
 
 func NewPool(lctxp logging.AttributionContextProvider, name string, size int, period time.Duration, useKeyName ...bool) Pool {
 	lctx := lctxp.AttributionContext().WithContext(REALM, logging.NewAttribute("pool", name)).WithName(name)
+	queue := newFairQueue(name)
 	pool := &pool{
 		UnboundLogger: logging.DynamicLogger(lctx),
 		name:          name,
@@ -63,8 +74,13 @@ func NewPool(lctxp logging.AttributionContextProvider, name string, size int, pe
 		lctx:          lctx.AttributionContext(),
 		useKeyName:    general.Optional(useKeyName...),
 		key:           fmt.Sprintf("pool %s", name),
+		queue:         queue,
 		workqueue: workqueue.NewRateLimitingQueueWithConfig(workqueue.DefaultControllerRateLimiter(), workqueue.RateLimitingQueueConfig{
 			Name: name,
+			DelayingQueue: workqueue.NewDelayingQueueWithConfig(workqueue.DelayingQueueConfig{
+				Name:  name,
+				Queue: queue,
+			}),
 		}),
 		actions: newActionMapping(),
 	}
@@ -84,10 +100,19 @@ func (p *pool) AddAction(key ActionTargetSpec, a Action) {
 	p.actions.addAction(key, a)
 }
 
+func (p *pool) RemoveAction(key ActionTargetSpec, a Action) {
+	p.Info("removing action", "type", fmt.Sprintf("%T", a), "key", key.String())
+	p.actions.removeAction(key, a)
+}
+
 func (p *pool) GetActions(key interface{}) []Action {
 	return p.actions.getAction(key)
 }
 
+func (p *pool) SetClassifier(c Classifier) {
+	p.queue.SetClassifier(c)
+}
+
 func (p *pool) GetName() string {
 	return p.name
 }
@@ -164,6 +189,9 @@ func (p *pool) startWorker(number int, stopCh <-chan struct{}) {
 func (p *pool) EnqueueCommand(cmd Command) {
 	p.enqueueCommand(cmd, p.workqueue.Add)
 }
+func (p *pool) EnqueueCommandWithPriority(cmd Command, prio Priority) {
+	p.enqueueCommand(cmd, func(key interface{}) { p.queue.AddWithPriority(key, prio) })
+}
 func (p *pool) EnqueueCommandRateLimited(name Command) {
 	p.enqueueCommand(name, p.workqueue.AddRateLimited)
 }
@@ -155,5 +155,18 @@ var _ = Describe("version", func() {
 			Expect(a.ids).To(ConsistOf(id))
 			Expect(a.actions).To(BeNil())
 		})
+
+		It("ignores id after removing action", func() {
+			a := &action{}
+			pool.AddAction(me.ObjectType("type"), a)
+			pool.RemoveAction(me.ObjectType("type"), a)
+			Expect(pool.GetActions(me.ObjectType("type"))).To(BeEmpty())
+
+			id := database.NewObjectId("type", "ns", "object")
+			pool.EnqueueKey(id)
+
+			time.Sleep(2 * time.Second)
+			Expect(a.ids).To(BeNil())
+		})
 	})
 })
@@ -0,0 +1,50 @@
+package pool
+
+import (
+	"github.com/mandelsoft/engine/pkg/database"
+)
+
+// Priority is the scheduling priority of a queued request.
+// Requests with a higher priority are always handled before
+// requests with a lower priority.
+type Priority int
+
+const (
+	PRIORITY_LOW     = Priority(-100)
+	PRIORITY_DEFAULT = Priority(0)
+	PRIORITY_HIGH    = Priority(100)
+)
+
+// Class describes the scheduling class of a queued request.
+// Requests with the same priority are handled round-robin
+// between the tenants. Requests of the same tenant are handled
+// in the order they have been queued.
+type Class struct {
+	Priority Priority
+	Tenant   string
+}
+
+// Classifier determines the scheduling class for a request.
+// Either the command or the object id is set.
+type Classifier func(cmd Command, id database.ObjectId) Class
+
+func defaultClassifier(key string) Class {
+	return Class{}
+}
+
+// classifierForKeys maps a Classifier to a classifier for queue keys.
+func classifierForKeys(c Classifier) func(key string) Class {
+	if c == nil {
+		return defaultClassifier
+	}
+	return func(key string) Class {
+		if key == tickCmd {
+			return Class{}
+		}
+		cmd, id, err := DecodeKey(key)
+		if err != nil {
+			return Class{}
+		}
+		return c(cmd, id)
+	}
+}
@@ -0,0 +1,401 @@
+package pool
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/mandelsoft/engine/pkg/metrics"
+)
+
+type t = interface{}
+
+// fairQueue is a workqueue.Interface implementation supporting
+// priority classes and fair scheduling between tenants.
+// Requests are handled strictly by priority. Requests with the same
+// priority are handled round-robin between the tenants, so that a
+// tenant with many queued requests cannot starve another tenant.
+//
+// Like the client-go workqueue, it guarantees that a request is
+// never processed concurrently and that it is queued at most once.
+// It is used as base queue for the client-go delaying and rate limiting
+// queues, which therefore keep their per-key behaviour.
+type fairQueue struct {
+	cond *sync.Cond
+
+	classifier func(key string) Class
+
+	// levels contains the queued requests per priority.
+	levels map[Priority]*priorityLevel
+	// priorities contains the priorities of the non-empty levels
+	// in descending order.
+	priorities []Priority
+	// queued contains the classes of all queued requests.
+	queued map[t]Class
+	// classes contains the last classification
+	// of requests not yet handed out.
+	classes map[t]Class
+	// requested contains explicitly requested priorities
+	// for requests not yet handed out.
+	requested map[t]Priority
+
+	dirty      map[t]struct{}
+	processing map[t]struct{}
+
+	// added and started keep the timestamps used for the
+	// queue metrics.
+	added   map[t]time.Time
+	started map[t]time.Time
+
+	depth    metrics.Gauge
+	adds     metrics.Counter
+	latency  metrics.Observer
+	duration metrics.Observer
+
+	shuttingDown bool
+	drain        bool
+}
+
+var _ workqueue.Interface = (*fairQueue)(nil)
+
+func newFairQueue(name string) *fairQueue {
+	return &fairQueue{
+		added:      map[t]time.Time{},
+		started:    map[t]time.Time{},
+		depth:      queueDepth.WithLabelValues(name),
+		adds:       queueAdds.WithLabelValues(name),
+		latency:    queueLatency.WithLabelValues(name),
+		duration:   workDuration.WithLabelValues(name),
+		cond:       sync.NewCond(&sync.Mutex{}),
+		classifier: defaultClassifier,
+		levels:     map[Priority]*priorityLevel{},
+		queued:     map[t]Class{},
+		classes:    map[t]Class{},
+		requested:  map[t]Priority{},
+		dirty:      map[t]struct{}{},
+		processing: map[t]struct{}{},
+	}
+}
+
+func (q *fairQueue) SetClassifier(c Classifier) {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	q.classifier = classifierForKeys(c)
+}
+
+func (q *fairQueue) Add(item t) {
+	q.add(item, nil)
+}
+
+// AddWithPriority adds a request with a minimum priority.
+// The effective priority is the maximum of the requested
+// priority and the priority provided by the classifier.
+func (q *fairQueue) AddWithPriority(item t, prio Priority) {
+	q.add(item, &prio)
+}
+
+func (q *fairQueue) add(item t, prio *Priority) {
+	// the classifier might be expensive, so it is called without lock.
+	q.cond.L.Lock()
+	classifier := q.classifier
+	q.cond.L.Unlock()
+
+	var c Class
+	if key, ok := item.(string); ok {
+		c = classifier(key)
+	}
+
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	if q.shuttingDown {
+		return
+	}
+	if prio != nil {
+		if p, ok := q.requested[item]; !ok || p < *prio {
+			q.requested[item] = *prio
+		}
+	}
+	q.classes[item] = c
+	if _, ok := q.dirty[item]; ok {
+		if o, ok := q.queued[item]; ok {
+			// raise priority of already queued request
+			if n := q.effective(item); n.Priority > o.Priority {
+				q.remove(item, o)
+				q.push(item, n)
+			}
+		}
+		return
+	}
+
+	q.adds.Inc()
+	q.dirty[item] = struct{}{}
+	q.added[item] = time.Now()
+	if _, ok := q.processing[item]; ok {
+		return
+	}
+	q.push(item, q.effective(item))
+	q.cond.Signal()
+}
+
+// effective provides the effective class of a dirty request
+// based on the last classification and the requested priority.
+func (q *fairQueue) effective(item t) Class {
+	c := q.classes[item]
+	if p, ok := q.requested[item]; ok && p > c.Priority {
+		c.Priority = p
+	}
+	return c
+}
+
+func (q *fairQueue) push(item t, c Class) {
+	l := q.levels[c.Priority]
+	if l == nil {
+		l = newPriorityLevel()
+		q.levels[c.Priority] = l
+		i, _ := slices.BinarySearchFunc(q.priorities, c.Priority, comparePriority)
+		q.priorities = slices.Insert(q.priorities, i, c.Priority)
+	}
+	l.push(item, c.Tenant)
+	q.queued[item] = c
+	q.depth.Inc()
+}
+
+func (q *fairQueue) remove(item t, c Class) {
+	l := q.levels[c.Priority]
+	l.remove(item, c.Tenant)
+	delete(q.queued, item)
+	q.depth.Dec()
+	q.cleanupLevel(c.Priority, l)
+}
+
+func (q *fairQueue) cleanupLevel(p Priority, l *priorityLevel) {
+	if l.len() == 0 {
+		delete(q.levels, p)
+		q.priorities = slices.DeleteFunc(q.priorities, func(e Priority) bool { return e == p })
+	}
+}
+
+func (q *fairQueue) Len() int {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	return len(q.queued)
+}
+
+func (q *fairQueue) Get() (item t, shutdown bool) {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	for len(q.queued) == 0 && !q.shuttingDown {
+		q.cond.Wait()
+	}
+	if len(q.queued) == 0 {
+		// shutting down
+		return nil, true
+	}
+
+	p := q.priorities[0]
+	l := q.levels[p]
+	item = l.pop()
+	q.cleanupLevel(p, l)
+
+	delete(q.queued, item)
+	delete(q.requested, item)
+	delete(q.classes, item)
+	delete(q.dirty, item)
+	q.processing[item] = struct{}{}
+
+	now := time.Now()
+	q.depth.Dec()
+	q.latency.Observe(now.Sub(q.added[item]).Seconds())
+	delete(q.added, item)
+	q.started[item] = now
+	return item, false
+}
+
+func (q *fairQueue) Done(item t) {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	delete(q.processing, item)
+	if start, ok := q.started[item]; ok {
+		q.duration.Observe(time.Since(start).Seconds())
+		delete(q.started, item)
+	}
+	if _, ok := q.dirty[item]; ok {
+		q.push(item, q.effective(item))
+		q.cond.Signal()
+	} else if len(q.processing) == 0 {
+		q.cond.Signal()
+	}
+}
+
+func (q *fairQueue) ShutDown() {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	q.drain = false
+	q.shuttingDown = true
+	q.cond.Broadcast()
+}
+
+func (q *fairQueue) ShutDownWithDrain() {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	q.drain = true
+	q.shuttingDown = true
+	q.cond.Broadcast()
+
+	for len(q.processing) != 0 && q.drain {
+		q.cond.Wait()
+	}
+}
+
+func (q *fairQueue) ShuttingDown() bool {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	return q.shuttingDown
+}
+
+func comparePriority(a, b Priority) int {
+	// descending order
+	return int(b) - int(a)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// priorityLevel keeps the queued requests of a priority
+// and hands them out round-robin between the tenants.
+type priorityLevel struct {
+	tenants map[string]*tenantQueue
+	// ring is the round-robin order of the tenants with queued requests.
+	ring []string
+	next int
+}
+
+func newPriorityLevel() *priorityLevel {
+	return &priorityLevel{
+		tenants: map[string]*tenantQueue{},
+	}
+}
+
+func (l *priorityLevel) len() int {
+	return len(l.ring)
+}
+
+func (l *priorityLevel) push(item t, tenant string) {
+	q := l.tenants[tenant]
+	if q == nil {
+		// new tenants are served last in the actual round.
+		q = newTenantQueue()
+		l.tenants[tenant] = q
+		l.ring = append(l.ring, tenant)
+	}
+	q.push(item)
+}
+
+func (l *priorityLevel) pop() t {
+	tenant := l.ring[l.next]
+	q := l.tenants[tenant]
+	item := q.pop()
+	if q.len() == 0 {
+		l.removeTenant(l.next)
+	} else {
+		l.next++
+	}
+	if l.next >= len(l.ring) {
+		l.next = 0
+	}
+	return item
+}
+
+func (l *priorityLevel) remove(item t, tenant string) {
+	q := l.tenants[tenant]
+	if q == nil || !q.remove(item) {
+		return
+	}
+	if q.len() == 0 {
+		l.removeTenant(slices.Index(l.ring, tenant))
+	}
+}
+
+func (l *priorityLevel) removeTenant(i int) {
+	delete(l.tenants, l.ring[i])
+	l.ring = slices.Delete(l.ring, i, i+1)
+	if i < l.next {
+		l.next--
+	}
+	if l.next >= len(l.ring) {
+		l.next = 0
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// tenantQueue is the FIFO of the queued requests of a tenant.
+// Removed requests are only dropped from the index and skipped
+// when handed out, so that a removal does not require to search
+// the queue.
+type tenantQueue struct {
+	items []t
+	// first is the sequence number of the first entry of items.
+	first int
+	// index maps the queued requests to their sequence number.
+	index map[t]int
+}
+
+func newTenantQueue() *tenantQueue {
+	return &tenantQueue{
+		index: map[t]int{},
+	}
+}
+
+func (q *tenantQueue) len() int {
+	return len(q.index)
+}
+
+func (q *tenantQueue) push(item t) {
+	q.index[item] = q.first + len(q.items)
+	q.items = append(q.items, item)
+}
+
+func (q *tenantQueue) pop() t {
+	for {
+		item := q.items[0]
+		seq := q.first
+		q.items[0] = nil
+		q.items = q.items[1:]
+		q.first++
+		if s, ok := q.index[item]; ok && s == seq {
+			delete(q.index, item)
+			return item
+		}
+	}
+}
+
+func (q *tenantQueue) remove(item t) bool {
+	if _, ok := q.index[item]; !ok {
+		return false
+	}
+	delete(q.index, item)
+	if len(q.items) > 2*len(q.index)+32 {
+		q.compact()
+	}
+	return true
+}
+
+// compact drops the entries of removed requests.
+func (q *tenantQueue) compact() {
+	items := make([]t, 0, len(q.index))
+	for i, item := range q.items {
+		if s, ok := q.index[item]; ok && s == q.first+i {
+			q.index[item] = q.first + len(items)
+			items = append(items, item)
+		}
+	}
+	q.items = items
+}
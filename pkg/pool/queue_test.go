@@ -0,0 +1,220 @@
+package pool
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// tenantClassifier uses the namespace as tenant and
+// the prefix of the name as priority indicator.
+func tenantClassifier(cmd Command, id database.ObjectId) Class {
+	if id == nil {
+		return Class{}
+	}
+	c := Class{Tenant: id.GetNamespace()}
+	if strings.HasPrefix(id.GetName(), "high") {
+		c.Priority = PRIORITY_HIGH
+	}
+	return c
+}
+
+func newTestQueue() *fairQueue {
+	q := newFairQueue("test")
+	q.SetClassifier(tenantClassifier)
+	return q
+}
+
+func drain(q workqueue.Interface) []string {
+	var result []string
+	for q.Len() > 0 {
+		item, _ := q.Get()
+		result = append(result, item.(string))
+		q.Done(item)
+	}
+	return result
+}
+
+func key(ns, name string) string {
+	return EncodeObjectKey("type", ns, name)
+}
+
+var _ = Describe("fair queue", func() {
+	var q *fairQueue
+
+	BeforeEach(func() {
+		q = newTestQueue()
+	})
+
+	It("keeps order for single tenant", func() {
+		q.Add(key("a", "1"))
+		q.Add(key("a", "2"))
+		q.Add(key("a", "3"))
+		Expect(drain(q)).To(Equal([]string{key("a", "1"), key("a", "2"), key("a", "3")}))
+	})
+
+	It("queues requests only once", func() {
+		q.Add(key("a", "1"))
+		q.Add(key("a", "2"))
+		q.Add(key("a", "1"))
+		Expect(q.Len()).To(Equal(2))
+		Expect(drain(q)).To(Equal([]string{key("a", "1"), key("a", "2")}))
+	})
+
+	It("handles tenants round-robin", func() {
+		q.Add(key("a", "1"))
+		q.Add(key("a", "2"))
+		q.Add(key("a", "3"))
+		q.Add(key("b", "1"))
+		q.Add(key("c", "1"))
+		q.Add(key("c", "2"))
+		Expect(drain(q)).To(Equal([]string{
+			key("a", "1"), key("b", "1"), key("c", "1"),
+			key("a", "2"), key("c", "2"),
+			key("a", "3"),
+		}))
+	})
+
+	It("serves new tenant at the end of the actual round", func() {
+		q.Add(key("a", "1"))
+		q.Add(key("a", "2"))
+		q.Add(key("b", "1"))
+		q.Add(key("b", "2"))
+
+		item, _ := q.Get()
+		Expect(item).To(Equal(key("a", "1")))
+		q.Done(item)
+
+		q.Add(key("c", "1"))
+		Expect(drain(q)).To(Equal([]string{
+			key("b", "1"), key("c", "1"),
+			key("a", "2"), key("b", "2"),
+		}))
+	})
+
+	It("prefers higher priorities", func() {
+		q.Add(key("a", "1"))
+		q.Add(key("b", "1"))
+		q.Add(key("b", "high"))
+		Expect(drain(q)).To(Equal([]string{key("b", "high"), key("a", "1"), key("b", "1")}))
+	})
+
+	It("raises priority of queued requests", func() {
+		q.Add(key("a", "1"))
+		q.Add(key("a", "2"))
+		q.AddWithPriority(key("a", "2"), PRIORITY_HIGH)
+		Expect(q.Len()).To(Equal(2))
+		Expect(drain(q)).To(Equal([]string{key("a", "2"), key("a", "1")}))
+	})
+
+	It("keeps order of remaining requests after raising priorities", func() {
+		var expected []string
+		for i := 0; i < 100; i++ {
+			q.Add(key("a", fmt.Sprintf("%d", i)))
+		}
+		for i := 0; i < 100; i += 2 {
+			q.AddWithPriority(key("a", fmt.Sprintf("%d", i)), PRIORITY_HIGH)
+			expected = append(expected, key("a", fmt.Sprintf("%d", i)))
+		}
+		for i := 1; i < 100; i += 2 {
+			expected = append(expected, key("a", fmt.Sprintf("%d", i)))
+		}
+		Expect(q.Len()).To(Equal(100))
+		Expect(drain(q)).To(Equal(expected))
+	})
+
+	It("does not lower priority of queued requests", func() {
+		q.AddWithPriority(key("a", "1"), PRIORITY_HIGH)
+		q.Add(key("a", "2"))
+		q.AddWithPriority(key("a", "1"), PRIORITY_LOW)
+		Expect(drain(q)).To(Equal([]string{key("a", "1"), key("a", "2")}))
+	})
+
+	It("requeues requests added during processing", func() {
+		q.Add(key("a", "1"))
+		q.Add(key("a", "2"))
+		item, _ := q.Get()
+		q.AddWithPriority(item, PRIORITY_HIGH)
+		Expect(q.Len()).To(Equal(1))
+		q.Done(item)
+		Expect(drain(q)).To(Equal([]string{key("a", "1"), key("a", "2")}))
+	})
+
+	It("shuts down", func() {
+		q.Add(key("a", "1"))
+		q.ShutDown()
+		q.Add(key("a", "2"))
+		Expect(q.ShuttingDown()).To(BeTrue())
+		item, shutdown := q.Get()
+		Expect(shutdown).To(BeFalse())
+		Expect(item).To(Equal(key("a", "1")))
+		q.Done(item)
+		_, shutdown = q.Get()
+		Expect(shutdown).To(BeTrue())
+	})
+})
+
+////////////////////////////////////////////////////////////////////////////////
+
+// benchmarkFairness queues the requests of a large tenant before
+// the requests of a small tenant, which is triggered later, and reports
+// the average number of requests handed out before the small tenant
+// has been completely served.
+func benchmarkFairness(b *testing.B, create func() workqueue.Interface, large, small int) {
+	total := 0
+	for n := 0; n < b.N; n++ {
+		q := create()
+		for i := 0; i < large; i++ {
+			q.Add(key("large", fmt.Sprintf("%d", i)))
+		}
+		for i := 0; i < small; i++ {
+			q.Add(key("small", fmt.Sprintf("%d", i)))
+		}
+		pending := small
+		for count := 1; pending > 0; count++ {
+			item, _ := q.Get()
+			if strings.HasPrefix(item.(string), key("small", "")) {
+				pending--
+				if pending == 0 {
+					total += count
+				}
+			}
+			q.Done(item)
+		}
+		q.ShutDown()
+	}
+	b.ReportMetric(float64(total)/float64(b.N), "requests-until-small-served")
+}
+
+func BenchmarkFairnessFIFO(b *testing.B) {
+	benchmarkFairness(b, func() workqueue.Interface { return workqueue.New() }, 10000, 10)
+}
+
+func BenchmarkFairnessFair(b *testing.B) {
+	benchmarkFairness(b, func() workqueue.Interface { return newTestQueue() }, 10000, 10)
+}
+
+func benchmarkThroughput(b *testing.B, q workqueue.Interface) {
+	tenants := []string{"a", "b", "c", "d"}
+	for n := 0; n < b.N; n++ {
+		q.Add(key(tenants[n%len(tenants)], fmt.Sprintf("%d", n)))
+	}
+	for n := 0; n < b.N; n++ {
+		item, _ := q.Get()
+		q.Done(item)
+	}
+}
+
+func BenchmarkThroughputFIFO(b *testing.B) {
+	benchmarkThroughput(b, workqueue.New())
+}
+
+func BenchmarkThroughputFair(b *testing.B) {
+	benchmarkThroughput(b, newTestQueue())
+}
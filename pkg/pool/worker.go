@@ -105,6 +105,7 @@ func (w *worker) processNextWorkItem() bool {
 	}
 
 	ok = true
+	start := time.Now()
 	var reschedule time.Duration = -1
 	if cmd != "" {
 		actions := w.pool.GetActions(cmd)
@@ -151,6 +152,7 @@ func (w *worker) processNextWorkItem() bool {
 		}
 
 	}
+	observeReconcile(w.pool.name, cmd, rkey, start, ok, err)
 
 	if err != nil {
 		if ok && reschedule < 0 {
@@ -0,0 +1,254 @@
+// Package api describes the request and response types
+// of the engine API provided by the processor.
+package api
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/mandelsoft/engine/pkg/history"
+	"github.com/mandelsoft/engine/pkg/recorder"
+	"github.com/mandelsoft/engine/pkg/version"
+)
+
+const (
+	// CMD_CANCEL is the API command used to abort runs.
+	// Path: <prefix>/cancel/<type>/<namespace>/<name>[?phase=<phase>]
+	CMD_CANCEL = "cancel"
+	// CMD_APPROVE is the API command used to approve runs awaiting approval.
+	// Path: <prefix>/approve/<type>/<namespace>/<name>?runid=<runid>&version=<input version>[&phase=<phase>][&message=<text>]
+	CMD_APPROVE = "approve"
+	// CMD_REJECT is the API command used to reject runs awaiting approval.
+	// Path: <prefix>/reject/<type>/<namespace>/<name>?runid=<runid>&version=<input version>[&phase=<phase>][&message=<text>]
+	CMD_REJECT = "reject"
+	// CMD_RUNS is the API command used to get the recorded runs of an object.
+	// Path: <prefix>/runs/<type>/<namespace>/<name>[?phase=<phase>]
+	CMD_RUNS = "runs"
+	// CMD_RUN is the API command used to get the recorded element runs for a run id.
+	// Path: <prefix>/run/<runid>[?namespace=<namespace>]
+	CMD_RUN = "run"
+	// CMD_PLAN is the API command used to predict the elements
+	// reprocessed by proposed external object changes.
+	// The changed objects are passed as PlanRequest.
+	// Path: <prefix>/plan
+	CMD_PLAN = "plan"
+	// CMD_EXPLAIN is the API command used to explain the formal versions of an object.
+	// Path: <prefix>/explain/<type>/<namespace>/<name>[?phase=<phase>]
+	CMD_EXPLAIN = "explain"
+	// CMD_EVENTS is the API command used to get the recorded events
+	// of a namespace closure or an object.
+	// Path: <prefix>/events[/<type>/<namespace>/<name>][?namespace=<namespace>]
+	CMD_EVENTS = "events"
+	// CMD_DESCRIBE is the API command used to describe the processing
+	// state of an object.
+	// Path: <prefix>/describe/<type>/<namespace>/<name>[?limit=<recent events and runs>]
+	CMD_DESCRIBE = "describe"
+	// CMD_TYPES is the API command used to discover the types
+	// of the metamodel handled by the processor.
+	// Path: <prefix>/types
+	CMD_TYPES = "types"
+	// CMD_STUCK is the API command used to list objects deleting
+	// longer than a threshold (default 5m).
+	// Path: <prefix>/stuck[?threshold=<duration>]
+	CMD_STUCK = "stuck"
+	// CMD_FORCE_DELETE is the privileged API command used to enforce
+	// the deletion of an object. It is served by the admin API.
+	// Path: <admin prefix>/force-delete/<type>/<namespace>/<name>
+	CMD_FORCE_DELETE = "force-delete"
+	// CMD_METAMODEL is the privileged API command used to apply a new
+	// version of the metamodel to a running engine. The specification
+	// is passed as JSON representation of a metamodel.MetaModelSpecification.
+	// It is served by the admin API.
+	// Path: <admin prefix>/metamodel
+	CMD_METAMODEL = "metamodel"
+)
+
+const (
+	PARAM_PHASE   = "phase"
+	PARAM_RUNID   = "runid"
+	PARAM_VERSION = "version"
+	PARAM_MESSAGE = "message"
+
+	PARAM_NAMESPACE = "namespace"
+	PARAM_LIMIT     = "limit"
+	PARAM_THRESHOLD = "threshold"
+)
+
+// CancelResult describes the runs cancelled by a cancel request.
+type CancelResult struct {
+	Cancelled []CancelledRun `json:"cancelled"`
+}
+
+type CancelledRun struct {
+	Element string `json:"element"`
+	RunId   string `json:"runid"`
+}
+
+// ApprovalResult describes the decision taken by an approval request.
+type ApprovalResult struct {
+	Element  string `json:"element"`
+	RunId    string `json:"runid"`
+	Decision string `json:"decision"`
+}
+
+// RunList describes recorded element runs.
+type RunList struct {
+	Runs []history.Record `json:"runs"`
+}
+
+// EventList describes recorded events.
+type EventList struct {
+	Events []recorder.Record `json:"events"`
+}
+
+// PlanRequest describes the proposed external object changes.
+type PlanRequest struct {
+	Objects []json.RawMessage `json:"objects"`
+}
+
+// PlanResult describes the elements affected by a PlanRequest.
+type PlanResult struct {
+	Elements []PlannedElement `json:"elements"`
+}
+
+type PlannedElement struct {
+	Element string `json:"element"`
+	// Trigger is the proposed object triggering the element.
+	Trigger string `json:"trigger,omitempty"`
+	// Action is the predicted action, one of create, run or none.
+	Action     string `json:"action"`
+	OldVersion string `json:"oldVersion,omitempty"`
+	NewVersion string `json:"newVersion,omitempty"`
+}
+
+// ExplainResult describes the formal versions of the phases of an object.
+type ExplainResult struct {
+	Elements []ElementExplanation `json:"elements"`
+}
+
+type ElementExplanation struct {
+	Element string `json:"element"`
+	// Current is the composed structure of the committed formal version.
+	Current string `json:"current,omitempty"`
+	// Target is the composed structure of the formal version
+	// of the active or next run.
+	Target  string           `json:"target"`
+	Changes []version.Change `json:"changes,omitempty"`
+}
+
+// DescribeResult describes the processing state of an object.
+type DescribeResult struct {
+	// Internal is the id of the internal object.
+	Internal   string             `json:"internal"`
+	Finalizers []string           `json:"finalizers,omitempty"`
+	Phases     []PhaseDescription `json:"phases"`
+	// Slaves are the elements assured by the phases of the object.
+	Slaves []string          `json:"slaves,omitempty"`
+	Events []recorder.Record `json:"events,omitempty"`
+	Runs   []history.Record  `json:"runs,omitempty"`
+}
+
+type PhaseDescription struct {
+	Element  string `json:"element"`
+	Status   string `json:"status"`
+	RunId    string `json:"runid,omitempty"`
+	Deletion bool   `json:"deletion,omitempty"`
+	// Current describes the committed state.
+	Current *StateDescription `json:"current,omitempty"`
+	// Target describes the target state of an active run.
+	Target     *StateDescription `json:"target,omitempty"`
+	Upstream   []Dependency      `json:"upstream,omitempty"`
+	Downstream []Dependency      `json:"downstream,omitempty"`
+}
+
+type StateDescription struct {
+	ObjectVersion string   `json:"objectVersion,omitempty"`
+	InputVersion  string   `json:"inputVersion,omitempty"`
+	OutputVersion string   `json:"outputVersion,omitempty"`
+	FormalVersion string   `json:"formalVersion,omitempty"`
+	Links         []string `json:"links,omitempty"`
+}
+
+// Dependency describes an element in a dependency tree.
+type Dependency struct {
+	Element      string       `json:"element"`
+	Status       string       `json:"status,omitempty"`
+	Dependencies []Dependency `json:"dependencies,omitempty"`
+}
+
+// TypesResult describes the types of the metamodel of a processor.
+type TypesResult struct {
+	// Model is the name of the metamodel.
+	Model             string             `json:"model"`
+	NamespaceType     string             `json:"namespaceType"`
+	UpdateRequestType string             `json:"updateRequestType,omitempty"`
+	ExternalTypes     []ExternalTypeInfo `json:"externalTypes"`
+	InternalTypes     []InternalTypeInfo `json:"internalTypes"`
+}
+
+type ExternalTypeInfo struct {
+	Name string `json:"name"`
+	// Trigger is the element type triggered by the external type.
+	Trigger string `json:"trigger"`
+}
+
+type InternalTypeInfo struct {
+	Name   string   `json:"name"`
+	Phases []string `json:"phases"`
+}
+
+// StuckResult describes the objects deleting longer than a threshold.
+type StuckResult struct {
+	Objects []StuckObject `json:"objects"`
+}
+
+type StuckObject struct {
+	Object       string      `json:"object"`
+	DeletionTime time.Time   `json:"deletionTime"`
+	Finalizers   []Finalizer `json:"finalizers,omitempty"`
+	// Locks are the active runs of the elements of the object.
+	Locks []ActiveRun `json:"locks,omitempty"`
+}
+
+type ActiveRun struct {
+	Element string `json:"element"`
+	RunId   string `json:"runid"`
+}
+
+type Finalizer struct {
+	Name string `json:"name"`
+	// Controller is the controller owning the finalizer, if known.
+	Controller string `json:"controller,omitempty"`
+}
+
+// ForceDeleteResult describes the elements cleaned up
+// by a force deletion request.
+type ForceDeleteResult struct {
+	Elements []string `json:"elements"`
+}
+
+// MetaModelChanges describes the changes applied by
+// a metamodel update request.
+type MetaModelChanges struct {
+	ExternalTypes []string `json:"externalTypes,omitempty"`
+	InternalTypes []string `json:"internalTypes,omitempty"`
+	// Phases are the phases added to existing internal types.
+	Phases               map[string][]string `json:"phases,omitempty"`
+	UpdateRequestType    string              `json:"updateRequestType,omitempty"`
+	RemovedExternalTypes []string            `json:"removedExternalTypes,omitempty"`
+	// ModifiedTypes are the existing internal types with added
+	// phases or changed dependencies.
+	ModifiedTypes []string `json:"modifiedTypes,omitempty"`
+}
+
+// ModelList describes the models hosted by an engine.
+type ModelList struct {
+	Models []string `json:"models"`
+	// Default is the model served without model prefix.
+	Default string `json:"default,omitempty"`
+}
+
+// Error is the error response of an API request.
+type Error struct {
+	Error string `json:"error"`
+}
@@ -0,0 +1,38 @@
+package internal
+
+import (
+	. "github.com/mandelsoft/engine/pkg/processing/mmids"
+)
+
+// ApprovalDecision is the decision about a requested
+// approval of a run.
+type ApprovalDecision string
+
+const (
+	APPROVAL_PENDING  = ApprovalDecision("Pending")
+	APPROVAL_APPROVED = ApprovalDecision("Approved")
+	APPROVAL_REJECTED = ApprovalDecision("Rejected")
+)
+
+// ApprovalState is the persisted approval information of a phase
+// for the actual run. An approval is always bound to the input
+// version of the run it has been requested for.
+type ApprovalState struct {
+	// RunId is the run the approval is requested for.
+	RunId RunId `json:"runid"`
+	// InputVersion is the input version of the run to be approved.
+	InputVersion string `json:"inputVersion"`
+	// Decision is the actual decision for the approval request.
+	Decision ApprovalDecision `json:"decision"`
+	// Message is an optional explanation of the decision.
+	Message string `json:"message,omitempty"`
+}
+
+// DecisionFor provides the decision for the given run and input version.
+// If there is no approval request for it, an empty decision is returned.
+func (s *ApprovalState) DecisionFor(id RunId, version string) ApprovalDecision {
+	if s == nil || s.RunId != id || s.InputVersion != version {
+		return ""
+	}
+	return s.Decision
+}
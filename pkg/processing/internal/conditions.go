@@ -0,0 +1,39 @@
+package internal
+
+import (
+	"github.com/mandelsoft/engine/pkg/utils"
+)
+
+type ConditionStatus string
+
+// Condition describes an aspect of the processing state
+// of an external object. There is one condition per phase
+// and an overall Ready condition.
+type Condition struct {
+	Type   string          `json:"type"`
+	Status ConditionStatus `json:"status"`
+	// Reason is the processing status causing the condition status.
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+	// LastTransitionTime is the time the status of the condition changed.
+	LastTransitionTime utils.Timestamp `json:"lastTransitionTime"`
+	// ObservedGeneration is the object generation the condition is based on.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// ObservedDigest is the content digest of the object generation
+	// the condition is based on. Because status updates increase the
+	// generation, it is used to check whether the object has been changed
+	// since it has been observed.
+	ObservedDigest string `json:"observedDigest,omitempty"`
+}
+
+type Conditions []Condition
+
+// Get provides the condition with the given type or nil.
+func (c Conditions) Get(typ string) *Condition {
+	for i := range c {
+		if c[i].Type == typ {
+			return &c[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package internal
+
+import (
+	"github.com/mandelsoft/engine/pkg/database"
+)
+
+// DeletionPolicy describes how the deletion of an external
+// object is propagated to the elements depending on its
+// elements.
+type DeletionPolicy = database.DeletionPolicy
+
+const (
+	DELETION_POLICY_BLOCK   = database.DELETION_POLICY_BLOCK
+	DELETION_POLICY_CASCADE = database.DELETION_POLICY_CASCADE
+	DELETION_POLICY_ORPHAN  = database.DELETION_POLICY_ORPHAN
+)
+
+// DEFAULT_DELETION_POLICY is used for external types
+// without explicit deletion policy.
+const DEFAULT_DELETION_POLICY = DELETION_POLICY_BLOCK
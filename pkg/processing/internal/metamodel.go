@@ -2,6 +2,7 @@ package internal
 
 import (
 	"io"
+	"time"
 
 	. "github.com/mandelsoft/engine/pkg/processing/mmids"
 )
@@ -10,6 +11,9 @@ type ExternalObjectType interface {
 	Name() string
 	Trigger() ElementType
 	IsForeignControlled() bool
+	// DeletionPolicy provides the default deletion policy
+	// for objects of the type.
+	DeletionPolicy() DeletionPolicy
 }
 
 type InternalObjectType interface {
@@ -26,6 +30,26 @@ type ElementType interface {
 	TriggeredBy() *string
 	HasDependency(name TypeId) bool
 	HasLocalDependency(name TypeId) bool
+	// HasCrossNamespaceDependency indicates whether elements of the
+	// given type may be linked in other namespaces.
+	HasCrossNamespaceDependency(name TypeId) bool
+
+	// RetryPolicy provides the retry policy for failed
+	// processing steps, or nil, if there is none.
+	RetryPolicy() *RetryPolicy
+	// Timeout provides the deadline for runs of the element type.
+	// 0 means no deadline.
+	Timeout() time.Duration
+	// RequiresApproval indicates whether runs of the element type
+	// must be approved before the processing step is executed.
+	RequiresApproval() bool
+	// Memoized indicates whether the results of processing steps
+	// of the element type are cached and reused for identical
+	// object and input versions.
+	Memoized() bool
+	// Priority provides the scheduling priority for the
+	// processing of elements of the element type.
+	Priority() int
 }
 
 type MetaModel interface {
@@ -48,6 +72,9 @@ type MetaModel interface {
 	GetInternalType(name string) InternalObjectType
 	GetElementType(name TypeId) ElementType
 	HasDependency(s, d TypeId) bool
+	// HasCrossNamespaceDependencies indicates whether there are
+	// element types linking elements of other namespaces.
+	HasCrossNamespaceDependencies() bool
 
 	GetDependentTypePhases(name TypeId) (all []Phase, leafs []Phase)
 	GetPhaseFor(ext string) *TypeId
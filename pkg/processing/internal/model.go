@@ -1,10 +1,12 @@
 package internal
 
 import (
+	"context"
 	"slices"
 
 	"github.com/mandelsoft/engine/pkg/database"
 	. "github.com/mandelsoft/engine/pkg/processing/mmids"
+	"github.com/mandelsoft/engine/pkg/utils"
 	"github.com/mandelsoft/goutils/general"
 )
 
@@ -17,6 +19,9 @@ type NamespaceObject interface {
 	GetNamespaceName() string
 
 	GetLock() RunId
+	// GetPriority provides the scheduling priority for
+	// elements of the namespace.
+	GetPriority() int
 
 	ClearLock(ob Objectbase, id RunId) (bool, error)
 	TryLock(db Objectbase, id RunId) (bool, error)
@@ -39,6 +44,11 @@ type UpdateRequestObject interface {
 type UpdateAction struct {
 	Action  string                    `json:"action"`
 	Objects []database.LocalObjectRef `json:"objects"`
+	// Lease is an optional duration (for example 5m) limiting the
+	// time the locks of the request are held without a change of the
+	// requested action. If it expires, the locks are released
+	// automatically. Every action change renews the lease.
+	Lease string `json:"lease,omitempty"`
 }
 
 func (a UpdateAction) Copy() *UpdateAction {
@@ -54,6 +64,8 @@ type UpdateStatus struct {
 	Status          string `json:"status"`
 	Message         string `json:"message,omitempty"`
 	ObservedVersion string `json:"observedVersion,omitempty"`
+	// Expiry is the time the lease of the request expires.
+	Expiry *utils.Timestamp `json:"expiry,omitempty"`
 }
 
 type ExternalObject interface {
@@ -81,10 +93,28 @@ type InternalObject interface {
 
 	GetStatus(Phase) Status
 	SetStatus(ob Objectbase, phase Phase, status Status) (bool, error)
+	// GetStatusTime provides the time of the last status change.
+	GetStatusTime(Phase) *utils.Timestamp
+
+	GetRetryState(Phase) *RetryState
+	SetRetryState(ob Objectbase, phase Phase, state *RetryState) (bool, error)
+
+	GetApprovalState(Phase) *ApprovalState
+	SetApprovalState(ob Objectbase, phase Phase, state *ApprovalState) (bool, error)
 
 	MarkPhasesForDeletion(ob Objectbase, phases ...Phase) (bool, error)
 	IsMarkedForDeletion(phase Phase) bool
 
+	// GetSlaveInfo provides the element assuring a phase of the object as slave, if any.
+	GetSlaveInfo(phase Phase) *SlaveInfo
+	// SetMaster records the element assuring a phase of the object as slave.
+	SetMaster(ob Objectbase, phase Phase, master ElementId) (bool, error)
+
+	// IsForcedDeletion checks whether the deletion of a phase has been enforced.
+	IsForcedDeletion(phase Phase) bool
+	// MarkForcedDeletion records the enforced deletion of a phase.
+	MarkForcedDeletion(ob Objectbase, phase Phase) (bool, error)
+
 	AcceptExternalState(lctx Logging, ob Objectbase, ph Phase, ext ExternalState) (AcceptStatus, error)
 	Process(Request) ProcessingResult
 	PrepareDeletion(lctx Logging, mgmt SlaveManagement, phase Phase) error
@@ -117,6 +147,12 @@ type CurrentState interface {
 	GetOutput() OutputState
 }
 
+// FormalGraphState is an optional interface of a CurrentState
+// providing the composed structure of the committed formal version.
+type FormalGraphState interface {
+	GetFormalGraph() string
+}
+
 type Inputs = map[ElementId]OutputState
 
 type TargetState interface {
@@ -135,6 +171,8 @@ type OutputState interface {
 type CommitInfo struct {
 	// FormalVersion is the formal (graph) version, which is committed.
 	FormalVersion string
+	// FormalGraph is the composed structure of the formal version.
+	FormalGraph string
 	// InputVersion is the version of the inputs used for this commit.
 	InputVersion string
 	// ObjectVersion is an optional modified object version, which should be
@@ -175,6 +213,10 @@ type SlaveManagement interface {
 }
 
 type Request struct {
+	// Context is cancelled if the run is aborted or its
+	// deadline is exceeded. Process implementations should
+	// stop their work, if the context is done.
+	Context         context.Context
 	Logging         Logging
 	Model           ProcessingModel
 	Delete          bool
@@ -231,4 +273,18 @@ type StatusUpdate struct {
 	ExternalState ExternalState
 	// ResultState is some state info provided by the internal object.
 	ResultState OutputState
+	// Conditions are the conditions for the phase and the
+	// Ready condition for the complete object.
+	Conditions []Condition
+	// ObservedGeneration is the generation of the external object
+	// read by the engine to provide this update. An update without
+	// conditions reports a generation checked by the engine without
+	// requiring further processing, it applies to all conditions.
+	ObservedGeneration *int64
+}
+
+// IsObservationReport checks whether the update only reports the
+// observed generation. Such an update must not affect other status fields.
+func (s StatusUpdate) IsObservationReport() bool {
+	return len(s.Conditions) == 0 && s.ObservedGeneration != nil
 }
@@ -20,4 +20,8 @@ type Object interface {
 	HasFinalizer(f string) bool
 
 	IsDeleting() bool
+	IsSuspended() bool
+	// GetDeletionPolicy provides the deletion policy
+	// requested for the object.
+	GetDeletionPolicy() DeletionPolicy
 }
@@ -0,0 +1,98 @@
+package internal
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	. "github.com/mandelsoft/engine/pkg/processing/mmids"
+	"github.com/mandelsoft/engine/pkg/utils"
+)
+
+// RetryableFunc classifies an error provided by a processing step.
+// It returns true, if a failed run should be retried.
+type RetryableFunc func(err error) bool
+
+// RetryPolicy describes how often and how fast a failed
+// processing step of an element type should be retried.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of processing attempts
+	// for a run (including the first one). After exhaustion the
+	// run settles in status Failed.
+	// A value <= 0 means unlimited attempts.
+	MaxAttempts int
+	// InitialDelay is the delay before the first retry.
+	InitialDelay time.Duration
+	// MaxDelay is an upper bound for the delay between two attempts,
+	// including the jitter. A value <= 0 means no limit.
+	MaxDelay time.Duration
+	// Factor is the multiplier applied to the delay for
+	// every further attempt. Values < 1 are treated as 1.
+	Factor float64
+	// Jitter is the maximal relative deviation randomly
+	// added to the calculated delay (0..1).
+	Jitter float64
+	// Retryable is an optional error classification.
+	// If not set, all errors not marked as non-temporary are retryable.
+	Retryable RetryableFunc `json:"-"`
+}
+
+// Exhausted checks whether the given number of failed attempts
+// exhausts the policy.
+func (p *RetryPolicy) Exhausted(attempts int) bool {
+	return p.MaxAttempts > 0 && attempts >= p.MaxAttempts
+}
+
+// Delay provides the delay before the next attempt
+// after the given number of failed attempts.
+func (p *RetryPolicy) Delay(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	f := p.Factor
+	if f < 1 {
+		f = 1
+	}
+	d := float64(p.InitialDelay) * math.Pow(f, float64(attempts-1))
+	if p.Jitter > 0 {
+		d += d * p.Jitter * rand.Float64()
+	}
+	if p.MaxDelay > 0 && d > float64(p.MaxDelay) {
+		d = float64(p.MaxDelay)
+	}
+	return time.Duration(d)
+}
+
+// IsRetryable checks whether an error should be retried.
+// The default function is used, if the policy does not provide
+// its own classification.
+func (p *RetryPolicy) IsRetryable(err error, def RetryableFunc) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	if def != nil {
+		return def(err)
+	}
+	return true
+}
+
+// RetryState is the persisted retry information of a phase
+// for the actual run.
+type RetryState struct {
+	// RunId is the run the attempts are counted for.
+	RunId RunId `json:"runid"`
+	// Attempts is the number of failed processing attempts.
+	Attempts int `json:"attempts"`
+	// NextRetry is the point in time of the next scheduled attempt.
+	NextRetry *utils.Timestamp `json:"nextRetry,omitempty"`
+	// LastError is the error message of the last failed attempt.
+	LastError string `json:"lastError,omitempty"`
+}
+
+// AttemptsFor provides the number of failed attempts for the given run.
+func (s *RetryState) AttemptsFor(id RunId) int {
+	if s == nil || s.RunId != id {
+		return 0
+	}
+	return s.Attempts
+}
@@ -0,0 +1,35 @@
+package internal
+
+import (
+	. "github.com/mandelsoft/engine/pkg/processing/mmids"
+)
+
+// SlaveInfo is the persisted information about the element
+// assuring a phase of an internal object as slave. It is kept
+// per phase, because different phases of an object may be
+// assured by different masters. The master element always
+// belongs to the namespace of the slave object.
+type SlaveInfo struct {
+	// MasterType is the type of the master element.
+	MasterType string `json:"masterType"`
+	// MasterName is the name of the master element.
+	MasterName string `json:"masterName"`
+	// MasterPhase is the phase of the master element.
+	MasterPhase Phase `json:"masterPhase"`
+}
+
+// NewSlaveInfo provides the slave information for a phase
+// assured by the given master element.
+func NewSlaveInfo(master ElementId) *SlaveInfo {
+	return &SlaveInfo{
+		MasterType:  master.GetType(),
+		MasterName:  master.GetName(),
+		MasterPhase: master.GetPhase(),
+	}
+}
+
+// Master provides the master element for a slave object
+// in the given namespace.
+func (s *SlaveInfo) Master(ns string) ElementId {
+	return NewElementId(s.MasterType, ns, s.MasterName, s.MasterPhase)
+}
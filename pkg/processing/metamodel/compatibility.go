@@ -0,0 +1,164 @@
+package metamodel
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	. "github.com/mandelsoft/engine/pkg/processing/mmids"
+)
+
+// UsageFunc checks whether objects of the given type exist.
+type UsageFunc func(typ string) (bool, error)
+
+// Changes describes the extensions of a new version of a metamodel
+// compared to the actual one.
+type Changes struct {
+	// ExternalTypes are the added external types.
+	ExternalTypes []string
+	// InternalTypes are the added internal types.
+	InternalTypes []string
+	// Phases are the phases added to existing internal types.
+	Phases map[string][]Phase
+	// UpdateRequestType is the added update request type.
+	UpdateRequestType string
+	// RemovedExternalTypes are the removed (unused) external types.
+	RemovedExternalTypes []string
+	// ModifiedTypes are the existing internal types with added
+	// phases or changed dependencies.
+	ModifiedTypes []string
+}
+
+// IsEmpty checks whether there are no extensions.
+func (c *Changes) IsEmpty() bool {
+	return len(c.ExternalTypes) == 0 && len(c.InternalTypes) == 0 && len(c.Phases) == 0 && c.UpdateRequestType == "" &&
+		len(c.RemovedExternalTypes) == 0 && len(c.ModifiedTypes) == 0
+}
+
+// IncompatibleChangesError reports the changes of a new version
+// of a metamodel preventing its application to a running engine.
+type IncompatibleChangesError struct {
+	Problems []string
+}
+
+func (e *IncompatibleChangesError) Error() string {
+	return fmt.Sprintf("incompatible metamodel changes: %s", strings.Join(e.Problems, "; "))
+}
+
+// Compare checks whether a metamodel can be replaced by a new version
+// on a running engine and provides the extensions of the new version.
+// A new version must keep the namespace and update request type, the
+// triggers of the external types and the phases of the internal types.
+// New phases may only be appended, existing phases must not depend on them.
+// Types may only be removed, if there are no objects of those types.
+// All violations are reported by an IncompatibleChangesError.
+func Compare(old, new MetaModel, used UsageFunc) (*Changes, error) {
+	var problems []string
+	changes := &Changes{Phases: map[string][]Phase{}}
+
+	if old.NamespaceType() != new.NamespaceType() {
+		problems = append(problems, fmt.Sprintf("namespace type changed from %q to %q", old.NamespaceType(), new.NamespaceType()))
+	}
+	if old.UpdateRequestType() != new.UpdateRequestType() {
+		if old.UpdateRequestType() == "" {
+			changes.UpdateRequestType = new.UpdateRequestType()
+		} else {
+			problems = append(problems, fmt.Sprintf("update request type changed from %q to %q", old.UpdateRequestType(), new.UpdateRequestType()))
+		}
+	}
+
+	for _, n := range old.ExternalTypes() {
+		if !new.IsExternalType(n) {
+			ok, err := used(n)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				problems = append(problems, fmt.Sprintf("external type %q removed, but objects still exist", n))
+			} else {
+				changes.RemovedExternalTypes = append(changes.RemovedExternalTypes, n)
+			}
+			continue
+		}
+		o, t := old.GetExternalType(n).Trigger().Id(), new.GetExternalType(n).Trigger().Id()
+		if o != t {
+			problems = append(problems, fmt.Sprintf("trigger of external type %q changed from %s to %s", n, o, t))
+		}
+	}
+	for _, n := range new.ExternalTypes() {
+		if !old.IsExternalType(n) {
+			changes.ExternalTypes = append(changes.ExternalTypes, n)
+		}
+	}
+
+	for _, n := range old.InternalTypes() {
+		if !new.IsInternalType(n) {
+			ok, err := used(n)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				problems = append(problems, fmt.Sprintf("internal type %q removed, but objects still exist", n))
+			}
+			continue
+		}
+		oldphases := old.Phases(n)
+		newphases := new.Phases(n)
+		for _, ph := range oldphases {
+			if !slices.Contains(newphases, ph) {
+				problems = append(problems, fmt.Sprintf("phase %q of internal type %q removed", ph, n))
+			}
+		}
+		var added []Phase
+		for _, ph := range newphases {
+			if !slices.Contains(oldphases, ph) {
+				added = append(added, ph)
+			}
+		}
+		if len(added) == 0 {
+			if dependenciesChanged(old, new, n) {
+				changes.ModifiedTypes = append(changes.ModifiedTypes, n)
+			}
+			continue
+		}
+		changes.Phases[n] = added
+		changes.ModifiedTypes = append(changes.ModifiedTypes, n)
+		for _, ph := range oldphases {
+			if !slices.Contains(newphases, ph) {
+				continue
+			}
+			e := new.GetElementType(NewTypeId(n, ph))
+			for _, d := range e.Dependencies() {
+				if d.Id().GetType() == n && slices.Contains(added, d.Id().GetPhase()) && e.HasLocalDependency(d.Id()) {
+					problems = append(problems, fmt.Sprintf("phase %q of internal type %q inserted before existing phase %q", d.Id().GetPhase(), n, ph))
+				}
+			}
+		}
+	}
+	for _, n := range new.InternalTypes() {
+		if !old.IsInternalType(n) {
+			changes.InternalTypes = append(changes.InternalTypes, n)
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, &IncompatibleChangesError{Problems: problems}
+	}
+	return changes, nil
+}
+
+// dependenciesChanged checks whether the dependencies of the
+// phases of an internal type differ.
+func dependenciesChanged(old, new MetaModel, typ string) bool {
+	for _, ph := range old.Phases(typ) {
+		if !slices.Contains(new.Phases(typ), ph) {
+			continue
+		}
+		id := NewTypeId(typ, ph)
+		o, n := old.GetElementType(id).Dependencies(), new.GetElementType(id).Dependencies()
+		if !slices.EqualFunc(o, n, func(a, b ElementType) bool { return a.Id() == b.Id() }) {
+			return true
+		}
+	}
+	return false
+}
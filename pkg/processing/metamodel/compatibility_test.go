@@ -0,0 +1,107 @@
+package metamodel_test
+
+import (
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/engine/pkg/processing/metamodel"
+	. "github.com/mandelsoft/engine/pkg/processing/mmids"
+)
+
+var _ = Describe("metamodel compatibility", func() {
+	var used map[string]bool
+
+	usage := func(typ string) (bool, error) {
+		return used[typ], nil
+	}
+
+	base := func() metamodel.MetaModelSpecification {
+		return metamodel.MetaModelSpecification{
+			NamespaceType: "Namespace",
+			ExternalTypes: []metamodel.ExternalTypeSpecification{
+				metamodel.ExtSpec("A", "AState", "Phase1"),
+				metamodel.ExtSpec("B", "BState", "Phase1"),
+			},
+			InternalTypes: []metamodel.InternalTypeSpecification{
+				metamodel.IntSpec("AState",
+					metamodel.PhaseSpec("Phase1"),
+				),
+				metamodel.IntSpec("BState",
+					metamodel.PhaseSpec("Phase1", metamodel.Dep("AState", "Phase1")),
+				),
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		used = map[string]bool{"A": true, "AState": true}
+	})
+
+	It("accepts unchanged metamodel", func() {
+		old := Must(metamodel.NewMetaModel("test", base()))
+		changes := Must(metamodel.Compare(old, Must(metamodel.NewMetaModel("test", base())), usage))
+		Expect(changes.IsEmpty()).To(BeTrue())
+	})
+
+	It("accepts extensions", func() {
+		old := Must(metamodel.NewMetaModel("test", base()))
+
+		spec := base()
+		spec.UpdateRequestType = "UpdateRequest"
+		spec.ExternalTypes = append(spec.ExternalTypes, metamodel.ExtSpec("C", "CState", "Phase1"))
+		spec.InternalTypes[0] = metamodel.IntSpec("AState",
+			metamodel.PhaseSpec("Phase1"),
+			metamodel.PhaseSpec("Phase2", metamodel.LocalDep("Phase1")),
+		)
+		spec.InternalTypes = append(spec.InternalTypes, metamodel.IntSpec("CState",
+			metamodel.PhaseSpec("Phase1", metamodel.Dep("AState", "Phase2")),
+		))
+
+		changes := Must(metamodel.Compare(old, Must(metamodel.NewMetaModel("test", spec)), usage))
+		Expect(changes).To(Equal(&metamodel.Changes{
+			ExternalTypes:     []string{"C"},
+			InternalTypes:     []string{"CState"},
+			Phases:            map[string][]Phase{"AState": {"Phase2"}},
+			UpdateRequestType: "UpdateRequest",
+			ModifiedTypes:     []string{"AState"},
+		}))
+	})
+
+	It("accepts removal of unused types", func() {
+		old := Must(metamodel.NewMetaModel("test", base()))
+
+		spec := base()
+		spec.ExternalTypes = spec.ExternalTypes[:1]
+		spec.InternalTypes = spec.InternalTypes[:1]
+
+		changes := Must(metamodel.Compare(old, Must(metamodel.NewMetaModel("test", spec)), usage))
+		Expect(changes.RemovedExternalTypes).To(Equal([]string{"B"}))
+	})
+
+	It("rejects incompatible changes", func() {
+		old := Must(metamodel.NewMetaModel("test", base()))
+
+		spec := base()
+		spec.NamespaceType = "Other"
+		spec.ExternalTypes = []metamodel.ExternalTypeSpecification{
+			metamodel.ExtSpec("B", "BState", "Phase0"),
+		}
+		spec.InternalTypes = []metamodel.InternalTypeSpecification{
+			metamodel.IntSpec("BState",
+				metamodel.PhaseSpec("Phase0"),
+				metamodel.PhaseSpec("Phase1", metamodel.LocalDep("Phase0")),
+			),
+		}
+
+		_, err := metamodel.Compare(old, Must(metamodel.NewMetaModel("test", spec)), usage)
+		Expect(err).To(BeAssignableToTypeOf(&metamodel.IncompatibleChangesError{}))
+		Expect(err.(*metamodel.IncompatibleChangesError).Problems).To(Equal([]string{
+			`namespace type changed from "Namespace" to "Other"`,
+			`external type "A" removed, but objects still exist`,
+			`trigger of external type "B" changed from BState:Phase1 to BState:Phase0`,
+			`internal type "AState" removed, but objects still exist`,
+			`phase "Phase0" of internal type "BState" inserted before existing phase "Phase1"`,
+		}))
+	})
+})
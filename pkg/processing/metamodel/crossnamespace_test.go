@@ -0,0 +1,66 @@
+package metamodel_test
+
+import (
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/engine/pkg/processing/metamodel"
+	. "github.com/mandelsoft/engine/pkg/processing/mmids"
+)
+
+var _ = Describe("cross namespace dependencies", func() {
+	spec := metamodel.MetaModelSpecification{
+		NamespaceType: "Namespace",
+		ExternalTypes: []metamodel.ExternalTypeSpecification{
+			metamodel.ExtSpec("A", "AState", "Phase1"),
+			metamodel.ExtSpec("B", "BState", "Phase1"),
+		},
+		InternalTypes: []metamodel.InternalTypeSpecification{
+			metamodel.IntSpec("AState",
+				metamodel.PhaseSpec("Phase1", metamodel.CrossNamespaceDep("BState", "Phase1")),
+				metamodel.PhaseSpec("Phase2", metamodel.LocalDep("Phase1")),
+			),
+			metamodel.IntSpec("BState",
+				metamodel.PhaseSpec("Phase1", metamodel.Dep("AState", "Phase2")),
+			),
+		},
+	}
+
+	It("detects cross namespace dependencies", func() {
+		mm := Must(metamodel.NewMetaModel("test", spec))
+		Expect(mm.HasCrossNamespaceDependencies()).To(BeTrue())
+		Expect(mm.GetElementType(NewTypeId("AState", "Phase1")).HasCrossNamespaceDependency(NewTypeId("BState", "Phase1"))).To(BeTrue())
+		Expect(mm.GetElementType(NewTypeId("BState", "Phase1")).HasCrossNamespaceDependency(NewTypeId("AState", "Phase2"))).To(BeFalse())
+	})
+
+	It("verifies links", func() {
+		mm := Must(metamodel.NewMetaModel("test", spec))
+
+		a := NewElementId("AState", "ns", "a", "Phase1")
+		b := NewElementId("BState", "ns", "b", "Phase1")
+		Expect(mm.VerifyLink(a, b)).To(Succeed())
+		Expect(mm.VerifyLink(a, NewElementId("BState", "other", "b", "Phase1"))).To(Succeed())
+
+		Expect(mm.VerifyLink(b, NewElementId("AState", "ns", "a", "Phase2"))).To(Succeed())
+		Expect(mm.VerifyLink(b, NewElementId("AState", "other", "a", "Phase2"))).To(
+			MatchError(`from "BState/ns/b:Phase1" to "AState/other/a:Phase2": links to phase "AState:Phase2" in other namespaces not possible`))
+	})
+
+	It("rejects local cross namespace dependencies", func() {
+		spec := metamodel.MetaModelSpecification{
+			NamespaceType: "Namespace",
+			ExternalTypes: []metamodel.ExternalTypeSpecification{
+				metamodel.ExtSpec("A", "AState", "Phase1"),
+			},
+			InternalTypes: []metamodel.InternalTypeSpecification{
+				metamodel.IntSpec("AState",
+					metamodel.PhaseSpec("Phase1"),
+					metamodel.PhaseSpec("Phase2", metamodel.DependencyTypeSpecification{Phase: "Phase1", CrossNamespace: true}),
+				),
+			},
+		}
+		_, err := metamodel.NewMetaModel("test", spec)
+		Expect(err).To(MatchError(`dependency ":Phase1" of phase "Phase2" of internal type "AState": local dependency cannot be cross namespace`))
+	})
+})
@@ -13,3 +13,14 @@ type InternalObjectType = internal.InternalObjectType
 type ExternalObjectType = internal.ExternalObjectType
 type ElementType = internal.ElementType
 type MetaModel = internal.MetaModel
+type RetryPolicy = internal.RetryPolicy
+type RetryableFunc = internal.RetryableFunc
+type DeletionPolicy = internal.DeletionPolicy
+
+const (
+	DELETION_POLICY_BLOCK   = internal.DELETION_POLICY_BLOCK
+	DELETION_POLICY_CASCADE = internal.DELETION_POLICY_CASCADE
+	DELETION_POLICY_ORPHAN  = internal.DELETION_POLICY_ORPHAN
+
+	DEFAULT_DELETION_POLICY = internal.DEFAULT_DELETION_POLICY
+)
@@ -6,6 +6,7 @@ import (
 	"slices"
 	"sort"
 
+	"github.com/mandelsoft/engine/pkg/database"
 	. "github.com/mandelsoft/engine/pkg/processing/mmids"
 	"github.com/mandelsoft/goutils/general"
 	"github.com/mandelsoft/goutils/generics"
@@ -23,6 +24,8 @@ type metaModel struct {
 	external      map[string]*externalObjectType
 	namespace     string
 	updateRequest string
+
+	crossNamespace bool
 }
 
 var _ MetaModel = (*metaModel)(nil)
@@ -49,7 +52,17 @@ func NewMetaModel(name string, spec MetaModelSpecification) (MetaModel, error) {
 		}
 
 		for _, p := range i.Phases {
-			e := newElementType(i.Name, p.Name)
+			retry := i.RetryPolicy
+			if p.RetryPolicy != nil {
+				retry = p.RetryPolicy
+			}
+			if retry != nil && (retry.InitialDelay < 0 || retry.Jitter < 0 || retry.Jitter > 1) {
+				return nil, fmt.Errorf("invalid retry policy for phase %q of internal type %q", p.Name, i.Name)
+			}
+			if p.Timeout < 0 {
+				return nil, fmt.Errorf("invalid timeout for phase %q of internal type %q", p.Name, i.Name)
+			}
+			e := newElementType(i.Name, p, retry, i.Priority)
 			m.elements[e.id] = e
 			def.phases[p.Name] = e
 		}
@@ -66,7 +79,12 @@ func NewMetaModel(name string, spec MetaModelSpecification) (MetaModel, error) {
 					return nil, fmt.Errorf("dependency \"%s:%s\" of phase %q of internal type %q: %w",
 						d.Type, d.Phase, p.Name, i.Name, err)
 				}
-				e.addDependency(t, local)
+				if local && d.CrossNamespace {
+					return nil, fmt.Errorf("dependency \"%s:%s\" of phase %q of internal type %q: local dependency cannot be cross namespace",
+						d.Type, d.Phase, p.Name, i.Name)
+				}
+				e.addDependency(t, local, d.CrossNamespace)
+				m.crossNamespace = m.crossNamespace || d.CrossNamespace
 			}
 		}
 	}
@@ -83,8 +101,13 @@ func NewMetaModel(name string, spec MetaModelSpecification) (MetaModel, error) {
 			return nil, fmt.Errorf("trigger \"%s:%s\" of external type %q: already triggered by %q",
 				d.Type, d.Phase, e.Name, *t.TriggeredBy())
 		}
+		if e.DeletionPolicy != "" {
+			if _, err := database.ParseDeletionPolicy(string(e.DeletionPolicy)); err != nil {
+				return nil, fmt.Errorf("external type %q: %w", e.Name, err)
+			}
+		}
 		t.setTrigger(e.Name)
-		m.external[e.Name] = newExternalObjectType(e.Name, t, e.ForeignControlled)
+		m.external[e.Name] = newExternalObjectType(e.Name, t, e.ForeignControlled, e.DeletionPolicy)
 	}
 
 	for _, i := range m.internal {
@@ -233,6 +256,10 @@ func (m *metaModel) HasDependency(s, d TypeId) bool {
 	return src.HasDependency(d)
 }
 
+func (m *metaModel) HasCrossNamespaceDependencies() bool {
+	return m.crossNamespace
+}
+
 func (m *metaModel) GetPhaseFor(ext string) *TypeId {
 	i := m.external[ext]
 	if i == nil {
@@ -294,7 +321,7 @@ func (m *metaModel) VerifyLink(from, to ElementId) error {
 		return fmt.Errorf("from %q: type not defined", from)
 	}
 	p := e.phases[from.GetPhase()]
-	if e == nil {
+	if p == nil {
 		return fmt.Errorf("from %q: phase not defined", from)
 	}
 	for _, d := range p.dependencies {
@@ -304,6 +331,9 @@ func (m *metaModel) VerifyLink(from, to ElementId) error {
 					return fmt.Errorf("from %q to %q: only links to local phase possible", from, to)
 				}
 			}
+			if !d.crossNamespace && to.GetNamespace() != from.GetNamespace() {
+				return fmt.Errorf("from %q to %q: links to phase %q in other namespaces not possible", from, to, to.TypeId())
+			}
 			return nil
 		}
 	}
@@ -339,10 +369,28 @@ func (m *metaModel) Dump(w io.Writer) {
 		if t := i.TriggeredBy(); t != nil {
 			fmt.Fprintf(w, "  triggered by: %s\n", *t)
 		}
+		if r := i.RetryPolicy(); r != nil {
+			fmt.Fprintf(w, "  retry: max attempts %d, delay %s (factor %g, max %s, jitter %g)\n",
+				r.MaxAttempts, r.InitialDelay, r.Factor, r.MaxDelay, r.Jitter)
+		}
+		if t := i.Timeout(); t > 0 {
+			fmt.Fprintf(w, "  timeout: %s\n", t)
+		}
+		if i.RequiresApproval() {
+			fmt.Fprintf(w, "  approval required\n")
+		}
+		if i.Memoized() {
+			fmt.Fprintf(w, "  memoized\n")
+		}
+		if p := i.Priority(); p != 0 {
+			fmt.Fprintf(w, "  priority: %d\n", p)
+		}
 		fmt.Fprintf(w, "  dependencies:\n")
 		for _, d := range i.dependencies {
 			if d.local {
 				fmt.Fprintf(w, "  - %s (local)\n", d.Id())
+			} else if d.crossNamespace {
+				fmt.Fprintf(w, "  - %s (cross namespace)\n", d.Id())
 			} else {
 				fmt.Fprintf(w, "  - %s\n", d.Id())
 			}
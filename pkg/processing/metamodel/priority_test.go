@@ -0,0 +1,35 @@
+package metamodel_test
+
+import (
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/engine/pkg/processing/metamodel"
+	. "github.com/mandelsoft/engine/pkg/processing/mmids"
+)
+
+var _ = Describe("priority", func() {
+	It("assigns priorities to element types", func() {
+		spec := metamodel.MetaModelSpecification{
+			NamespaceType: "Namespace",
+			ExternalTypes: []metamodel.ExternalTypeSpecification{
+				metamodel.ExtSpec("A", "AState", "Phase1"),
+				metamodel.ExtSpec("B", "BState", "Phase1"),
+			},
+			InternalTypes: []metamodel.InternalTypeSpecification{
+				metamodel.IntSpec("AState",
+					metamodel.PhaseSpec("Phase1"),
+					metamodel.PhaseSpec("Phase2", metamodel.LocalDep("Phase1")),
+				).WithPriority(10),
+				metamodel.IntSpec("BState",
+					metamodel.PhaseSpec("Phase1"),
+				),
+			},
+		}
+		mm := Must(metamodel.NewMetaModel("test", spec))
+		Expect(mm.GetElementType(NewTypeId("AState", "Phase1")).Priority()).To(Equal(10))
+		Expect(mm.GetElementType(NewTypeId("AState", "Phase2")).Priority()).To(Equal(10))
+		Expect(mm.GetElementType(NewTypeId("BState", "Phase1")).Priority()).To(Equal(0))
+	})
+})
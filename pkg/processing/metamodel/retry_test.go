@@ -0,0 +1,93 @@
+package metamodel_test
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/engine/pkg/processing/metamodel"
+	. "github.com/mandelsoft/engine/pkg/processing/mmids"
+)
+
+var _ = Describe("retry policy", func() {
+	policy := metamodel.RetryPolicy{
+		MaxAttempts:  3,
+		InitialDelay: time.Second,
+		MaxDelay:     3 * time.Second,
+		Factor:       2,
+	}
+
+	It("calculates exponential backoff", func() {
+		Expect(policy.Delay(1)).To(Equal(time.Second))
+		Expect(policy.Delay(2)).To(Equal(2 * time.Second))
+		Expect(policy.Delay(3)).To(Equal(3 * time.Second))
+	})
+
+	It("adds jitter", func() {
+		p := policy
+		p.Jitter = 0.5
+		for i := 0; i < 10; i++ {
+			d := p.Delay(1)
+			Expect(d >= time.Second && d <= 1500*time.Millisecond).To(BeTrue())
+		}
+	})
+
+	It("limits jitter by the maximum delay", func() {
+		p := policy
+		p.Jitter = 0.5
+		for i := 0; i < 10; i++ {
+			Expect(p.Delay(3)).To(Equal(3 * time.Second))
+		}
+	})
+
+	It("detects exhaustion", func() {
+		Expect(policy.Exhausted(2)).To(BeFalse())
+		Expect(policy.Exhausted(3)).To(BeTrue())
+		Expect((&metamodel.RetryPolicy{}).Exhausted(100)).To(BeFalse())
+	})
+
+	It("classifies errors", func() {
+		p := policy
+		Expect(p.IsRetryable(fmt.Errorf("test"), nil)).To(BeTrue())
+		Expect(p.IsRetryable(fmt.Errorf("test"), func(error) bool { return false })).To(BeFalse())
+		p.Retryable = func(error) bool { return true }
+		Expect(p.IsRetryable(fmt.Errorf("test"), func(error) bool { return false })).To(BeTrue())
+	})
+
+	It("assigns policies to element types", func() {
+		spec := metamodel.MetaModelSpecification{
+			NamespaceType: "Namespace",
+			ExternalTypes: []metamodel.ExternalTypeSpecification{
+				metamodel.ExtSpec("A", "AState", "Phase1"),
+			},
+			InternalTypes: []metamodel.InternalTypeSpecification{
+				metamodel.IntSpec("AState",
+					metamodel.PhaseSpec("Phase1"),
+					metamodel.PhaseSpec("Phase2", metamodel.LocalDep("Phase1")).WithRetry(metamodel.RetryPolicy{MaxAttempts: 5}),
+				).WithRetry(policy),
+			},
+		}
+		mm := Must(metamodel.NewMetaModel("test", spec))
+		Expect(mm.GetElementType(NewTypeId("AState", "Phase1")).RetryPolicy().MaxAttempts).To(Equal(3))
+		Expect(mm.GetElementType(NewTypeId("AState", "Phase2")).RetryPolicy().MaxAttempts).To(Equal(5))
+	})
+
+	It("rejects invalid policies", func() {
+		spec := metamodel.MetaModelSpecification{
+			NamespaceType: "Namespace",
+			ExternalTypes: []metamodel.ExternalTypeSpecification{
+				metamodel.ExtSpec("A", "AState", "Phase1"),
+			},
+			InternalTypes: []metamodel.InternalTypeSpecification{
+				metamodel.IntSpec("AState",
+					metamodel.PhaseSpec("Phase1").WithRetry(metamodel.RetryPolicy{Jitter: 2}),
+				),
+			},
+		}
+		_, err := metamodel.NewMetaModel("test", spec)
+		Expect(err).To(MatchError(`invalid retry policy for phase "Phase1" of internal type "AState"`))
+	})
+})
@@ -2,6 +2,7 @@ package metamodel
 
 import (
 	"slices"
+	"time"
 
 	. "github.com/mandelsoft/engine/pkg/processing/mmids"
 )
@@ -13,11 +14,70 @@ type TypeSpecification struct {
 type PhaseSpecification struct {
 	Name         Phase
 	Dependencies []DependencyTypeSpecification
+	// RetryPolicy is an optional retry policy for failed
+	// processing steps of this phase. It overwrites the
+	// policy of the internal type.
+	RetryPolicy *RetryPolicy
+	// Timeout is an optional deadline for a run of this phase.
+	// It limits the time spent in status Processing or Waiting.
+	Timeout time.Duration
+	// Approval requires a manual approval of the target state
+	// of a run, before the processing step is executed.
+	Approval bool
+	// Memoize enables the result cache for the phase.
+	// If a processing step is requested for an object and input
+	// version already processed before, the cached output state is
+	// committed without processing the step again. It should
+	// only be used for phases without side effects on other
+	// objects.
+	Memoize bool
+}
+
+// WithApproval requires a manual approval for runs of the phase.
+func (s PhaseSpecification) WithApproval() PhaseSpecification {
+	s.Approval = true
+	return s
+}
+
+// WithMemoization enables the result cache for the phase.
+func (s PhaseSpecification) WithMemoization() PhaseSpecification {
+	s.Memoize = true
+	return s
+}
+
+// WithTimeout sets a deadline for runs of the phase.
+func (s PhaseSpecification) WithTimeout(d time.Duration) PhaseSpecification {
+	s.Timeout = d
+	return s
+}
+
+// WithRetry sets a dedicated retry policy for the phase.
+func (s PhaseSpecification) WithRetry(p RetryPolicy) PhaseSpecification {
+	s.RetryPolicy = &p
+	return s
 }
 
 type InternalTypeSpecification struct {
 	TypeSpecification
 	Phases []PhaseSpecification
+	// RetryPolicy is an optional default retry policy
+	// for all phases of the type.
+	RetryPolicy *RetryPolicy
+	// Priority is the scheduling priority for the processing
+	// of elements of the type. Higher values are preferred.
+	Priority int
+}
+
+// WithRetry sets a default retry policy for all phases of the type.
+func (s InternalTypeSpecification) WithRetry(p RetryPolicy) InternalTypeSpecification {
+	s.RetryPolicy = &p
+	return s
+}
+
+// WithPriority sets the scheduling priority for all phases of the type.
+func (s InternalTypeSpecification) WithPriority(p int) InternalTypeSpecification {
+	s.Priority = p
+	return s
 }
 
 func PhaseSpec(name Phase, deps ...DependencyTypeSpecification) PhaseSpecification {
@@ -39,17 +99,27 @@ func IntSpec(tname string, phase PhaseSpecification, phases ...PhaseSpecificatio
 type DependencyTypeSpecification struct {
 	Type  string
 	Phase Phase
+	// CrossNamespace allows links to elements of other namespaces.
+	// Otherwise, links are restricted to the namespace of the
+	// linking element.
+	CrossNamespace bool
 }
 
 // Dep describes a dependency to a phase of another object,
 // which might have the same type.
 func Dep(typ string, phase Phase) DependencyTypeSpecification {
-	return DependencyTypeSpecification{typ, phase}
+	return DependencyTypeSpecification{Type: typ, Phase: phase}
+}
+
+// CrossNamespaceDep describes a dependency to a phase of another object,
+// which might be located in another namespace.
+func CrossNamespaceDep(typ string, phase Phase) DependencyTypeSpecification {
+	return DependencyTypeSpecification{Type: typ, Phase: phase, CrossNamespace: true}
 }
 
 // LocalDep describes a dependency to another ahe of the same object.
 func LocalDep(phase Phase) DependencyTypeSpecification {
-	return DependencyTypeSpecification{"", phase}
+	return DependencyTypeSpecification{Phase: phase}
 }
 
 type ExternalTypeSpecification struct {
@@ -65,6 +135,11 @@ type ExternalTypeSpecification struct {
 	// This will be evaluated by the implementation of the internal object
 	// implementing the triggered phase.
 	ForeignControlled bool
+	// DeletionPolicy is the default policy used to propagate the
+	// deletion of objects of this type to dependent elements.
+	// It can be overwritten by the deleted object.
+	// The default is DELETION_POLICY_BLOCK.
+	DeletionPolicy DeletionPolicy
 }
 
 func ExtSpec(tname string, inttype string, phase Phase) ExternalTypeSpecification {
@@ -85,6 +160,12 @@ func (s ExternalTypeSpecification) Foreign() ExternalTypeSpecification {
 	return s
 }
 
+// WithDeletionPolicy sets the default deletion policy for objects of the type.
+func (s ExternalTypeSpecification) WithDeletionPolicy(p DeletionPolicy) ExternalTypeSpecification {
+	s.DeletionPolicy = p
+	return s
+}
+
 type MetaModelSpecification struct {
 	NamespaceType     string
 	UpdateRequestType string
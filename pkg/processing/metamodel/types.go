@@ -3,6 +3,7 @@ package metamodel
 import (
 	"slices"
 	"strings"
+	"time"
 
 	. "github.com/mandelsoft/engine/pkg/processing/mmids"
 	"github.com/mandelsoft/goutils/generics"
@@ -16,7 +17,8 @@ const DEFAULT_PHASE = Phase("PhaseUpdating")
 
 type dependency struct {
 	*elementType
-	local bool
+	local          bool
+	crossNamespace bool
 }
 
 type elementType struct {
@@ -24,14 +26,24 @@ type elementType struct {
 	dependencies []dependency
 	trigger      *string
 	states       []string
+	retry        *RetryPolicy
+	timeout      time.Duration
+	approval     bool
+	memoize      bool
+	priority     int
 }
 
 var _ ElementType = (*elementType)(nil)
 var _elementType = generics.CastPointer[ElementType, elementType]
 
-func newElementType(objtype string, phase Phase) *elementType {
+func newElementType(objtype string, spec PhaseSpecification, retry *RetryPolicy, priority int) *elementType {
 	return &elementType{
-		id: NewTypeId(objtype, phase),
+		id:       NewTypeId(objtype, spec.Name),
+		retry:    retry,
+		timeout:  spec.Timeout,
+		approval: spec.Approval,
+		memoize:  spec.Memoize,
+		priority: priority,
 	}
 }
 
@@ -44,12 +56,13 @@ func matchDep(e *elementType) func(d dependency) bool {
 		return d.elementType == e
 	}
 }
-func (e *elementType) addDependency(d *elementType, local bool) {
+func (e *elementType) addDependency(d *elementType, local bool, crossNamespace bool) {
 	m := matchDep(d)
 	if !slices.ContainsFunc(e.dependencies, m) {
 		e.dependencies = append(e.dependencies, dependency{
-			elementType: d,
-			local:       local,
+			elementType:    d,
+			local:          local,
+			crossNamespace: crossNamespace,
 		})
 		slices.SortFunc(e.dependencies, compareDependency)
 	}
@@ -83,10 +96,39 @@ func (e *elementType) HasLocalDependency(name TypeId) bool {
 	return false
 }
 
+func (e *elementType) HasCrossNamespaceDependency(name TypeId) bool {
+	for _, d := range e.dependencies {
+		if d.Id() == name {
+			return d.crossNamespace
+		}
+	}
+	return false
+}
+
 func (e *elementType) TriggeredBy() *string {
 	return e.trigger
 }
 
+func (e *elementType) RetryPolicy() *RetryPolicy {
+	return e.retry
+}
+
+func (e *elementType) Timeout() time.Duration {
+	return e.timeout
+}
+
+func (e *elementType) RequiresApproval() bool {
+	return e.approval
+}
+
+func (e *elementType) Memoized() bool {
+	return e.memoize
+}
+
+func (e *elementType) Priority() int {
+	return e.priority
+}
+
 func (e *elementType) ExternalStates() []string {
 	return slices.Clone(e.states)
 }
@@ -106,19 +148,24 @@ func compareDependency(a, b dependency) int {
 ////////////////////////////////////////////////////////////////////////////////
 
 type externalObjectType struct {
-	name    string
-	trigger *elementType
-	foreign bool
+	name     string
+	trigger  *elementType
+	foreign  bool
+	deletion DeletionPolicy
 }
 
 var _ ExternalObjectType = (*externalObjectType)(nil)
 var _externalObjectType = generics.CastPointer[ExternalObjectType, externalObjectType]
 
-func newExternalObjectType(name string, trigger *elementType, foreign bool) *externalObjectType {
+func newExternalObjectType(name string, trigger *elementType, foreign bool, deletion DeletionPolicy) *externalObjectType {
+	if deletion == "" {
+		deletion = DEFAULT_DELETION_POLICY
+	}
 	return &externalObjectType{
-		name:    name,
-		trigger: trigger,
-		foreign: foreign,
+		name:     name,
+		trigger:  trigger,
+		foreign:  foreign,
+		deletion: deletion,
 	}
 }
 
@@ -134,6 +181,10 @@ func (o *externalObjectType) IsForeignControlled() bool {
 	return o.foreign
 }
 
+func (o *externalObjectType) DeletionPolicy() DeletionPolicy {
+	return o.deletion
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 
 type internalObjectType struct {
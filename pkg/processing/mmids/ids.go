@@ -163,3 +163,25 @@ func CompareElementId(a, b ElementId) int {
 	}
 	return d
 }
+
+////////////////////////////////////////////////////////////////////////////////
+
+// QualifiedName provides the name of an object in namespace ns as seen from
+// namespace ref. Names of objects in other namespaces are qualified
+// by their namespace (<namespace>/<name>).
+func QualifiedName(ref, ns, name string) string {
+	if ns == ref {
+		return name
+	}
+	return ns + "/" + name
+}
+
+// ParseQualifiedName provides the namespace and name for a name used in
+// namespace ref, which might be qualified by a namespace (<namespace>/<name>).
+func ParseQualifiedName(ref, name string) (string, string) {
+	i := strings.LastIndex(name, "/")
+	if i < 0 {
+		return ref, name
+	}
+	return name[:i], name[i+1:]
+}
@@ -13,4 +13,24 @@ var _ = Describe("version", func() {
 		Expect(fmt.Sprintf("%s", id)).To(Equal("a/b/c"))
 	})
 
+	It("qualifies names", func() {
+		Expect(QualifiedName("ns", "ns", "a")).To(Equal("a"))
+		Expect(QualifiedName("ns", "other/ns", "a")).To(Equal("other/ns/a"))
+		Expect(QualifiedName("ns", "", "a")).To(Equal("/a"))
+	})
+
+	It("parses qualified names", func() {
+		ns, n := ParseQualifiedName("ns", "a")
+		Expect(ns).To(Equal("ns"))
+		Expect(n).To(Equal("a"))
+
+		ns, n = ParseQualifiedName("ns", "other/ns/a")
+		Expect(ns).To(Equal("other/ns"))
+		Expect(n).To(Equal("a"))
+
+		ns, n = ParseQualifiedName("ns", "/a")
+		Expect(ns).To(Equal(""))
+		Expect(n).To(Equal("a"))
+	})
+
 })
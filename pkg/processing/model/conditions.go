@@ -0,0 +1,44 @@
+package model
+
+import (
+	"github.com/mandelsoft/engine/pkg/processing/internal"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+type Condition = internal.Condition
+type Conditions = internal.Conditions
+type ConditionStatus = internal.ConditionStatus
+
+const (
+	CONDITION_TRUE    = ConditionStatus("True")
+	CONDITION_FALSE   = ConditionStatus("False")
+	CONDITION_UNKNOWN = ConditionStatus("Unknown")
+)
+
+// CONDITION_READY is the type of the condition
+// describing whether all phases are completed.
+const CONDITION_READY = "Ready"
+
+var failed = sets.Set[Status]{}.Insert(STATUS_FAILED, STATUS_INVALID, STATUS_BLOCKED)
+
+// ConditionStatusFor maps a processing status to a condition status.
+// Completed phases are true, failed, invalid and blocked phases
+// are false. For all other states the outcome is still unknown.
+func ConditionStatusFor(s Status) ConditionStatus {
+	switch {
+	case s == STATUS_COMPLETED:
+		return CONDITION_TRUE
+	case failed.Has(s):
+		return CONDITION_FALSE
+	default:
+		return CONDITION_UNKNOWN
+	}
+}
+
+// ConditionReasonFor provides the condition reason for a processing status.
+func ConditionReasonFor(s Status) string {
+	if s == STATUS_INITIAL {
+		return "Initial"
+	}
+	return string(s)
+}
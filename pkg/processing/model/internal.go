@@ -32,6 +32,16 @@ type StatusUpdate = internal.StatusUpdate
 type StatusSource = internal.StatusSource
 type Status = internal.Status
 type Inputs = internal.Inputs
+type FormalGraphState = internal.FormalGraphState
+type RetryState = internal.RetryState
+type SlaveInfo = internal.SlaveInfo
+type RetryPolicy = internal.RetryPolicy
+type RetryableFunc = internal.RetryableFunc
+type ApprovalState = internal.ApprovalState
+type ApprovalDecision = internal.ApprovalDecision
+type DeletionPolicy = internal.DeletionPolicy
+
+var NewSlaveInfo = internal.NewSlaveInfo
 
 type Logging = internal.Logging
 
@@ -47,6 +57,7 @@ const (
 
 	REQ_STATUS_PENDING = "Pending"
 	REQ_STATUS_INVALID = "Invalid"
+	REQ_STATUS_EXPIRED = "Expired"
 )
 
 const (
@@ -55,17 +66,33 @@ const (
 )
 
 const (
-	STATUS_INITIAL    = Status("")
-	STATUS_PENDING    = Status("Pending")
-	STATUS_INVALID    = Status("Invalid")
-	STATUS_BLOCKED    = Status("Blocked")
-	STATUS_PREPARING  = Status("Preparing")
-	STATUS_PROCESSING = Status("Processing")
-	STATUS_DELETING   = Status("Deleting")
-	STATUS_WAITING    = Status("Waiting")
-	STATUS_COMPLETED  = Status("Completed")
-	STATUS_FAILED     = Status("Failed")
-	STATUS_DELETED    = Status("Deleted")
+	STATUS_INITIAL           = Status("")
+	STATUS_PENDING           = Status("Pending")
+	STATUS_INVALID           = Status("Invalid")
+	STATUS_BLOCKED           = Status("Blocked")
+	STATUS_PREPARING         = Status("Preparing")
+	STATUS_PROCESSING        = Status("Processing")
+	STATUS_DELETING          = Status("Deleting")
+	STATUS_WAITING           = Status("Waiting")
+	STATUS_COMPLETED         = Status("Completed")
+	STATUS_FAILED            = Status("Failed")
+	STATUS_DELETED           = Status("Deleted")
+	STATUS_SUSPENDED         = Status("Suspended")
+	STATUS_AWAITING_APPROVAL = Status("AwaitingApproval")
+)
+
+const (
+	DELETION_POLICY_BLOCK   = internal.DELETION_POLICY_BLOCK
+	DELETION_POLICY_CASCADE = internal.DELETION_POLICY_CASCADE
+	DELETION_POLICY_ORPHAN  = internal.DELETION_POLICY_ORPHAN
+
+	DEFAULT_DELETION_POLICY = internal.DEFAULT_DELETION_POLICY
+)
+
+const (
+	APPROVAL_PENDING  = internal.APPROVAL_PENDING
+	APPROVAL_APPROVED = internal.APPROVAL_APPROVED
+	APPROVAL_REJECTED = internal.APPROVAL_REJECTED
 )
 
 func StatusFailed(err error) ProcessingResult {
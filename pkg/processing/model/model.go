@@ -122,6 +122,14 @@ func (r *rootNamespace) IsDeleting() bool {
 	return false
 }
 
+func (r *rootNamespace) IsSuspended() bool {
+	return false
+}
+
+func (r *rootNamespace) GetDeletionPolicy() internal.DeletionPolicy {
+	return ""
+}
+
 func (r *rootNamespace) GetFinalizers() []string {
 	return r.finalizable.GetFinalizers()
 }
@@ -142,6 +150,10 @@ func (r rootNamespace) GetLock() mmids.RunId {
 	return ""
 }
 
+func (r rootNamespace) GetPriority() int {
+	return 0
+}
+
 func (r rootNamespace) ClearLock(ob internal.Objectbase, id mmids.RunId) (bool, error) {
 	return false, nil
 }
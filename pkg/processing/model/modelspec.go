@@ -28,12 +28,16 @@ func (s *ModelSpecification) GetMetaModel() (metamodel.MetaModel, error) {
 }
 
 func (s *ModelSpecification) Validate() error {
-	enc := s.Objectbase.SchemeTypes()
-
 	m, err := metamodel.NewMetaModel(s.Name, s.MetaModel)
 	if err != nil {
 		return err
 	}
+	return ValidateTypes(m, s.Objectbase.SchemeTypes())
+}
+
+// ValidateTypes validates the encodings of the types
+// of a metamodel provided by the given scheme types.
+func ValidateTypes(m metamodel.MetaModel, enc objectbase.SchemeTypes) error {
 	for _, n := range m.ExternalTypes() {
 		o, err := enc.CreateObject(n)
 		if err != nil {
@@ -12,124 +12,172 @@ func IsFinalStatus(s Status) bool {
 
 var statusmerge = map[Status]map[Status]Status{
 	STATUS_INITIAL: {
-		STATUS_INITIAL:    STATUS_INITIAL,
-		STATUS_COMPLETED:  STATUS_COMPLETED,
-		STATUS_BLOCKED:    STATUS_BLOCKED,
-		STATUS_FAILED:     STATUS_FAILED,
-		STATUS_INVALID:    STATUS_INVALID,
-		STATUS_PENDING:    STATUS_PENDING,
-		STATUS_PREPARING:  STATUS_PREPARING,
-		STATUS_PROCESSING: STATUS_PROCESSING,
-		STATUS_WAITING:    STATUS_WAITING,
-		STATUS_DELETED:    STATUS_DELETED,
+		STATUS_INITIAL:           STATUS_INITIAL,
+		STATUS_COMPLETED:         STATUS_COMPLETED,
+		STATUS_BLOCKED:           STATUS_BLOCKED,
+		STATUS_FAILED:            STATUS_FAILED,
+		STATUS_INVALID:           STATUS_INVALID,
+		STATUS_PENDING:           STATUS_PENDING,
+		STATUS_PREPARING:         STATUS_PREPARING,
+		STATUS_PROCESSING:        STATUS_PROCESSING,
+		STATUS_WAITING:           STATUS_WAITING,
+		STATUS_AWAITING_APPROVAL: STATUS_AWAITING_APPROVAL,
+		STATUS_SUSPENDED:         STATUS_SUSPENDED,
+		STATUS_DELETED:           STATUS_DELETED,
 	},
 	STATUS_COMPLETED: {
-		STATUS_INITIAL:    STATUS_COMPLETED,
-		STATUS_COMPLETED:  STATUS_COMPLETED,
-		STATUS_BLOCKED:    STATUS_BLOCKED,
-		STATUS_FAILED:     STATUS_FAILED,
-		STATUS_INVALID:    STATUS_INVALID,
-		STATUS_PENDING:    STATUS_PENDING,
-		STATUS_PREPARING:  STATUS_PREPARING,
-		STATUS_PROCESSING: STATUS_PROCESSING,
-		STATUS_WAITING:    STATUS_WAITING,
-		STATUS_DELETED:    STATUS_COMPLETED,
+		STATUS_INITIAL:           STATUS_COMPLETED,
+		STATUS_COMPLETED:         STATUS_COMPLETED,
+		STATUS_BLOCKED:           STATUS_BLOCKED,
+		STATUS_FAILED:            STATUS_FAILED,
+		STATUS_INVALID:           STATUS_INVALID,
+		STATUS_PENDING:           STATUS_PENDING,
+		STATUS_PREPARING:         STATUS_PREPARING,
+		STATUS_PROCESSING:        STATUS_PROCESSING,
+		STATUS_WAITING:           STATUS_WAITING,
+		STATUS_AWAITING_APPROVAL: STATUS_AWAITING_APPROVAL,
+		STATUS_SUSPENDED:         STATUS_SUSPENDED,
+		STATUS_DELETED:           STATUS_COMPLETED,
 	},
 	STATUS_BLOCKED: {
-		STATUS_INITIAL:    STATUS_BLOCKED,
-		STATUS_COMPLETED:  STATUS_BLOCKED,
-		STATUS_BLOCKED:    STATUS_BLOCKED,
-		STATUS_FAILED:     STATUS_BLOCKED,
-		STATUS_INVALID:    STATUS_BLOCKED,
-		STATUS_PENDING:    STATUS_PENDING,
-		STATUS_PREPARING:  STATUS_PREPARING,
-		STATUS_PROCESSING: STATUS_PROCESSING,
-		STATUS_WAITING:    STATUS_WAITING,
-		STATUS_DELETED:    STATUS_DELETED,
+		STATUS_INITIAL:           STATUS_BLOCKED,
+		STATUS_COMPLETED:         STATUS_BLOCKED,
+		STATUS_BLOCKED:           STATUS_BLOCKED,
+		STATUS_FAILED:            STATUS_BLOCKED,
+		STATUS_INVALID:           STATUS_BLOCKED,
+		STATUS_PENDING:           STATUS_PENDING,
+		STATUS_PREPARING:         STATUS_PREPARING,
+		STATUS_PROCESSING:        STATUS_PROCESSING,
+		STATUS_WAITING:           STATUS_WAITING,
+		STATUS_AWAITING_APPROVAL: STATUS_AWAITING_APPROVAL,
+		STATUS_SUSPENDED:         STATUS_SUSPENDED,
+		STATUS_DELETED:           STATUS_DELETED,
 	},
 	STATUS_INVALID: {
-		STATUS_INITIAL:    STATUS_INVALID,
-		STATUS_COMPLETED:  STATUS_INVALID,
-		STATUS_BLOCKED:    STATUS_BLOCKED,
-		STATUS_FAILED:     STATUS_INVALID,
-		STATUS_INVALID:    STATUS_INVALID,
-		STATUS_PENDING:    STATUS_PENDING,
-		STATUS_PREPARING:  STATUS_PREPARING,
-		STATUS_PROCESSING: STATUS_PROCESSING,
-		STATUS_WAITING:    STATUS_WAITING,
-		STATUS_DELETED:    STATUS_DELETED,
+		STATUS_INITIAL:           STATUS_INVALID,
+		STATUS_COMPLETED:         STATUS_INVALID,
+		STATUS_BLOCKED:           STATUS_BLOCKED,
+		STATUS_FAILED:            STATUS_INVALID,
+		STATUS_INVALID:           STATUS_INVALID,
+		STATUS_PENDING:           STATUS_PENDING,
+		STATUS_PREPARING:         STATUS_PREPARING,
+		STATUS_PROCESSING:        STATUS_PROCESSING,
+		STATUS_WAITING:           STATUS_WAITING,
+		STATUS_AWAITING_APPROVAL: STATUS_AWAITING_APPROVAL,
+		STATUS_SUSPENDED:         STATUS_SUSPENDED,
+		STATUS_DELETED:           STATUS_DELETED,
 	},
 	STATUS_FAILED: {
-		STATUS_INITIAL:    STATUS_FAILED,
-		STATUS_COMPLETED:  STATUS_FAILED,
-		STATUS_BLOCKED:    STATUS_BLOCKED,
-		STATUS_FAILED:     STATUS_FAILED,
-		STATUS_INVALID:    STATUS_INVALID,
-		STATUS_PENDING:    STATUS_PENDING,
-		STATUS_PREPARING:  STATUS_PREPARING,
-		STATUS_PROCESSING: STATUS_PROCESSING,
-		STATUS_WAITING:    STATUS_WAITING,
-		STATUS_DELETED:    STATUS_DELETED,
+		STATUS_INITIAL:           STATUS_FAILED,
+		STATUS_COMPLETED:         STATUS_FAILED,
+		STATUS_BLOCKED:           STATUS_BLOCKED,
+		STATUS_FAILED:            STATUS_FAILED,
+		STATUS_INVALID:           STATUS_INVALID,
+		STATUS_PENDING:           STATUS_PENDING,
+		STATUS_PREPARING:         STATUS_PREPARING,
+		STATUS_PROCESSING:        STATUS_PROCESSING,
+		STATUS_WAITING:           STATUS_WAITING,
+		STATUS_AWAITING_APPROVAL: STATUS_AWAITING_APPROVAL,
+		STATUS_SUSPENDED:         STATUS_SUSPENDED,
+		STATUS_DELETED:           STATUS_DELETED,
 	},
 	STATUS_PENDING: {
-		STATUS_INITIAL:    STATUS_PENDING,
-		STATUS_COMPLETED:  STATUS_PENDING,
-		STATUS_BLOCKED:    STATUS_PENDING,
-		STATUS_FAILED:     STATUS_PENDING,
-		STATUS_INVALID:    STATUS_PENDING,
-		STATUS_PENDING:    STATUS_PENDING,
-		STATUS_PREPARING:  STATUS_PREPARING,
-		STATUS_PROCESSING: STATUS_PROCESSING,
-		STATUS_WAITING:    STATUS_WAITING,
-		STATUS_DELETED:    STATUS_DELETED,
+		STATUS_INITIAL:           STATUS_PENDING,
+		STATUS_COMPLETED:         STATUS_PENDING,
+		STATUS_BLOCKED:           STATUS_PENDING,
+		STATUS_FAILED:            STATUS_PENDING,
+		STATUS_INVALID:           STATUS_PENDING,
+		STATUS_PENDING:           STATUS_PENDING,
+		STATUS_PREPARING:         STATUS_PREPARING,
+		STATUS_PROCESSING:        STATUS_PROCESSING,
+		STATUS_WAITING:           STATUS_WAITING,
+		STATUS_AWAITING_APPROVAL: STATUS_AWAITING_APPROVAL,
+		STATUS_SUSPENDED:         STATUS_SUSPENDED,
+		STATUS_DELETED:           STATUS_DELETED,
 	},
 	STATUS_PREPARING: {
-		STATUS_INITIAL:    STATUS_PREPARING,
-		STATUS_COMPLETED:  STATUS_PREPARING,
-		STATUS_BLOCKED:    STATUS_PREPARING,
-		STATUS_FAILED:     STATUS_PREPARING,
-		STATUS_INVALID:    STATUS_PREPARING,
-		STATUS_PENDING:    STATUS_PREPARING,
-		STATUS_PREPARING:  STATUS_PREPARING,
-		STATUS_PROCESSING: STATUS_PROCESSING,
-		STATUS_WAITING:    STATUS_WAITING,
-		STATUS_DELETED:    STATUS_DELETED,
+		STATUS_INITIAL:           STATUS_PREPARING,
+		STATUS_COMPLETED:         STATUS_PREPARING,
+		STATUS_BLOCKED:           STATUS_PREPARING,
+		STATUS_FAILED:            STATUS_PREPARING,
+		STATUS_INVALID:           STATUS_PREPARING,
+		STATUS_PENDING:           STATUS_PREPARING,
+		STATUS_PREPARING:         STATUS_PREPARING,
+		STATUS_PROCESSING:        STATUS_PROCESSING,
+		STATUS_WAITING:           STATUS_WAITING,
+		STATUS_AWAITING_APPROVAL: STATUS_AWAITING_APPROVAL,
+		STATUS_SUSPENDED:         STATUS_SUSPENDED,
+		STATUS_DELETED:           STATUS_DELETED,
 	},
 	STATUS_WAITING: {
-		STATUS_INITIAL:    STATUS_WAITING,
-		STATUS_COMPLETED:  STATUS_WAITING,
-		STATUS_BLOCKED:    STATUS_WAITING,
-		STATUS_FAILED:     STATUS_WAITING,
-		STATUS_INVALID:    STATUS_WAITING,
-		STATUS_PENDING:    STATUS_WAITING,
-		STATUS_PREPARING:  STATUS_WAITING,
-		STATUS_PROCESSING: STATUS_PROCESSING,
-		STATUS_WAITING:    STATUS_WAITING,
-		STATUS_DELETED:    STATUS_DELETED,
+		STATUS_INITIAL:           STATUS_WAITING,
+		STATUS_COMPLETED:         STATUS_WAITING,
+		STATUS_BLOCKED:           STATUS_WAITING,
+		STATUS_FAILED:            STATUS_WAITING,
+		STATUS_INVALID:           STATUS_WAITING,
+		STATUS_PENDING:           STATUS_WAITING,
+		STATUS_PREPARING:         STATUS_WAITING,
+		STATUS_PROCESSING:        STATUS_PROCESSING,
+		STATUS_WAITING:           STATUS_WAITING,
+		STATUS_AWAITING_APPROVAL: STATUS_AWAITING_APPROVAL,
+		STATUS_SUSPENDED:         STATUS_SUSPENDED,
+		STATUS_DELETED:           STATUS_DELETED,
+	},
+	STATUS_AWAITING_APPROVAL: {
+		STATUS_INITIAL:           STATUS_AWAITING_APPROVAL,
+		STATUS_COMPLETED:         STATUS_AWAITING_APPROVAL,
+		STATUS_BLOCKED:           STATUS_AWAITING_APPROVAL,
+		STATUS_FAILED:            STATUS_AWAITING_APPROVAL,
+		STATUS_INVALID:           STATUS_AWAITING_APPROVAL,
+		STATUS_PENDING:           STATUS_AWAITING_APPROVAL,
+		STATUS_PREPARING:         STATUS_AWAITING_APPROVAL,
+		STATUS_PROCESSING:        STATUS_PROCESSING,
+		STATUS_WAITING:           STATUS_AWAITING_APPROVAL,
+		STATUS_AWAITING_APPROVAL: STATUS_AWAITING_APPROVAL,
+		STATUS_SUSPENDED:         STATUS_SUSPENDED,
+		STATUS_DELETED:           STATUS_DELETED,
+	},
+	STATUS_SUSPENDED: {
+		STATUS_INITIAL:           STATUS_SUSPENDED,
+		STATUS_COMPLETED:         STATUS_SUSPENDED,
+		STATUS_BLOCKED:           STATUS_SUSPENDED,
+		STATUS_FAILED:            STATUS_SUSPENDED,
+		STATUS_INVALID:           STATUS_SUSPENDED,
+		STATUS_PENDING:           STATUS_SUSPENDED,
+		STATUS_PREPARING:         STATUS_SUSPENDED,
+		STATUS_PROCESSING:        STATUS_PROCESSING,
+		STATUS_WAITING:           STATUS_SUSPENDED,
+		STATUS_AWAITING_APPROVAL: STATUS_SUSPENDED,
+		STATUS_SUSPENDED:         STATUS_SUSPENDED,
+		STATUS_DELETED:           STATUS_SUSPENDED,
 	},
 	STATUS_PROCESSING: {
-		STATUS_INITIAL:    STATUS_PROCESSING,
-		STATUS_COMPLETED:  STATUS_PROCESSING,
-		STATUS_BLOCKED:    STATUS_PROCESSING,
-		STATUS_FAILED:     STATUS_PROCESSING,
-		STATUS_INVALID:    STATUS_PROCESSING,
-		STATUS_PENDING:    STATUS_PROCESSING,
-		STATUS_PREPARING:  STATUS_PROCESSING,
-		STATUS_PROCESSING: STATUS_PROCESSING,
-		STATUS_WAITING:    STATUS_PROCESSING,
-		STATUS_DELETED:    STATUS_PROCESSING,
+		STATUS_INITIAL:           STATUS_PROCESSING,
+		STATUS_COMPLETED:         STATUS_PROCESSING,
+		STATUS_BLOCKED:           STATUS_PROCESSING,
+		STATUS_FAILED:            STATUS_PROCESSING,
+		STATUS_INVALID:           STATUS_PROCESSING,
+		STATUS_PENDING:           STATUS_PROCESSING,
+		STATUS_PREPARING:         STATUS_PROCESSING,
+		STATUS_PROCESSING:        STATUS_PROCESSING,
+		STATUS_WAITING:           STATUS_PROCESSING,
+		STATUS_AWAITING_APPROVAL: STATUS_PROCESSING,
+		STATUS_SUSPENDED:         STATUS_PROCESSING,
+		STATUS_DELETED:           STATUS_PROCESSING,
 	},
 	STATUS_DELETED: {
-		STATUS_INITIAL:    STATUS_DELETED,
-		STATUS_COMPLETED:  STATUS_COMPLETED,
-		STATUS_BLOCKED:    STATUS_BLOCKED,
-		STATUS_FAILED:     STATUS_FAILED,
-		STATUS_INVALID:    STATUS_FAILED,
-		STATUS_PENDING:    STATUS_PENDING,
-		STATUS_PREPARING:  STATUS_PREPARING,
-		STATUS_PROCESSING: STATUS_PROCESSING,
-		STATUS_WAITING:    STATUS_WAITING,
-		STATUS_DELETED:    STATUS_DELETED,
+		STATUS_INITIAL:           STATUS_DELETED,
+		STATUS_COMPLETED:         STATUS_COMPLETED,
+		STATUS_BLOCKED:           STATUS_BLOCKED,
+		STATUS_FAILED:            STATUS_FAILED,
+		STATUS_INVALID:           STATUS_FAILED,
+		STATUS_PENDING:           STATUS_PENDING,
+		STATUS_PREPARING:         STATUS_PREPARING,
+		STATUS_PROCESSING:        STATUS_PROCESSING,
+		STATUS_WAITING:           STATUS_WAITING,
+		STATUS_AWAITING_APPROVAL: STATUS_AWAITING_APPROVAL,
+		STATUS_SUSPENDED:         STATUS_SUSPENDED,
+		STATUS_DELETED:           STATUS_DELETED,
 	},
 }
 
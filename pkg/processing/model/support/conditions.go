@@ -0,0 +1,107 @@
+package support
+
+import (
+	"reflect"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/processing/model"
+	"github.com/mandelsoft/engine/pkg/processing/model/support/db"
+	"github.com/mandelsoft/engine/pkg/utils"
+)
+
+// UpdateConditions updates the conditions of an external object with
+// the conditions provided by a status update. The transition time
+// is only changed if the status of a condition changes.
+// If the update reports the generation of the object read by the
+// engine, the provided phase conditions are based on this generation.
+// Because status updates increase the generation, additionally the
+// content digest of the object is kept, if the object has not been
+// changed since it has been read. Conditions already based on the
+// same content keep their observed generation. Therefore, reporting
+// an observation does not cause endless update cycles, although every
+// status update increases the generation. An update without conditions
+// applies the observed generation to all conditions. The Ready condition
+// uses the oldest generation observed by the phase conditions.
+func UpdateConditions(field *model.Conditions, o database.Object, update model.StatusUpdate, mod ...*bool) bool {
+	if len(update.Conditions) == 0 && update.ObservedGeneration == nil {
+		return false
+	}
+
+	gen, digest := observed(o, update)
+	conditions := append(model.Conditions{}, *field...)
+	for _, c := range update.Conditions {
+		old := conditions.Get(c.Type)
+		if old != nil {
+			c.LastTransitionTime = old.LastTransitionTime
+			c.ObservedGeneration = old.ObservedGeneration
+			c.ObservedDigest = old.ObservedDigest
+		}
+		if old == nil || old.Status != c.Status {
+			c.LastTransitionTime = utils.NewTimestamp()
+		}
+		if c.Type != model.CONDITION_READY {
+			observe(&c, gen, digest)
+		}
+		if old != nil {
+			*old = c
+		} else {
+			conditions = append(conditions, c)
+		}
+	}
+	if len(update.Conditions) == 0 {
+		for i := range conditions {
+			if conditions[i].Type != model.CONDITION_READY {
+				observe(&conditions[i], gen, digest)
+			}
+		}
+	}
+
+	if ready := conditions.Get(model.CONDITION_READY); ready != nil {
+		ready.ObservedGeneration = 0
+		ready.ObservedDigest = ""
+		for _, c := range conditions {
+			if c.Type != model.CONDITION_READY && c.ObservedGeneration != 0 && (ready.ObservedGeneration == 0 || c.ObservedGeneration < ready.ObservedGeneration) {
+				ready.ObservedGeneration = c.ObservedGeneration
+				ready.ObservedDigest = c.ObservedDigest
+			}
+		}
+	}
+
+	if reflect.DeepEqual(*field, conditions) {
+		return false
+	}
+	*field = conditions
+	if len(mod) > 0 {
+		*mod[0] = true
+	}
+	return true
+}
+
+// observed provides the object generation and content digest
+// observed by a status update. The digest is only known, if the
+// object has not been changed since it has been read.
+func observed(o database.Object, update model.StatusUpdate) (int64, string) {
+	if update.ObservedGeneration == nil {
+		return 0, ""
+	}
+	gen := *update.ObservedGeneration
+	if database.GetGeneration(o) != gen {
+		return gen, ""
+	}
+	return gen, db.ContentDigest(o)
+}
+
+// observe sets the observed generation and digest of a condition,
+// if it is not already based on the same or a newer generation.
+func observe(c *model.Condition, gen int64, digest string) {
+	switch {
+	case gen == 0 || c.ObservedGeneration > gen:
+		return
+	case digest != "" && c.ObservedDigest == digest:
+		return
+	case c.ObservedGeneration == gen && digest == "":
+		return
+	}
+	c.ObservedGeneration = gen
+	c.ObservedDigest = digest
+}
@@ -1,6 +1,8 @@
 package db
 
 import (
+	"reflect"
+
 	"github.com/mandelsoft/engine/pkg/processing/mmids"
 	"github.com/mandelsoft/engine/pkg/processing/model"
 	"github.com/mandelsoft/engine/pkg/utils"
@@ -27,6 +29,7 @@ type PhaseState interface {
 
 	GetStatus() model.Status
 	SetStatus(model.Status) bool
+	GetStatusTime() *utils.Timestamp
 	GetCurrent() CurrentState
 	ClearTarget() bool
 	GetTarget() TargetState
@@ -34,6 +37,12 @@ type PhaseState interface {
 
 	MarkForDeletion(t utils.Timestamp) bool
 	IsDeletionRequested() bool
+
+	GetRetryState() *model.RetryState
+	SetRetryState(*model.RetryState) bool
+
+	GetApprovalState() *model.ApprovalState
+	SetApprovalState(*model.ApprovalState) bool
 }
 
 type CommonState interface {
@@ -47,6 +56,9 @@ type CurrentState interface {
 	GetFormalVersion() string
 	SetFormalVersion(v string) bool
 
+	GetFormalGraph() string
+	SetFormalGraph(v string) bool
+
 	GetObservedVersion() string
 	SetObservedVersion(v string) bool
 
@@ -90,11 +102,14 @@ type currentpointer[P any] interface {
 // Type parameters are required for the struct and the
 // pointer type.
 type DefaultPhaseState[C any, T any, CP currentpointer[C], TP targetpointer[T]] struct {
-	RunId             mmids.RunId      `json:"runid"`
-	Status            model.Status     `json:"status"`
-	DeletionRequested *utils.Timestamp `json:"deletionRequested,omitempty"`
-	Current           C                `json:"current,omitempty"`
-	Target            TP               `json:"target,omitempty"`
+	RunId             mmids.RunId          `json:"runid"`
+	Status            model.Status         `json:"status"`
+	StatusTime        *utils.Timestamp     `json:"statusTime,omitempty"`
+	DeletionRequested *utils.Timestamp     `json:"deletionRequested,omitempty"`
+	Retry             *model.RetryState    `json:"retry,omitempty"`
+	Approval          *model.ApprovalState `json:"approval,omitempty"`
+	Current           C                    `json:"current,omitempty"`
+	Target            TP                   `json:"target,omitempty"`
 }
 
 var _ PhaseState = (*DefaultPhaseState[StandardCurrentState, StandardTargetState, *StandardCurrentState, *StandardTargetState])(nil)
@@ -133,9 +148,14 @@ func (n *DefaultPhaseState[C, T, CP, TP]) SetStatus(s model.Status) bool {
 		return false
 	}
 	n.Status = s
+	n.StatusTime = utils.NewTimestampP()
 	return true
 }
 
+func (n *DefaultPhaseState[C, T, CP, TP]) GetStatusTime() *utils.Timestamp {
+	return n.StatusTime
+}
+
 func (n *DefaultPhaseState[C, T, CP, TP]) GetCurrent() CurrentState {
 	return CP(&n.Current)
 }
@@ -171,3 +191,35 @@ func (n *DefaultPhaseState[C, T, CP, TP]) MarkForDeletion(t utils.Timestamp) boo
 func (n *DefaultPhaseState[C, T, CP, TP]) IsDeletionRequested() bool {
 	return n.DeletionRequested != nil
 }
+
+func (n *DefaultPhaseState[C, T, CP, TP]) GetRetryState() *model.RetryState {
+	return n.Retry
+}
+
+func (n *DefaultPhaseState[C, T, CP, TP]) SetRetryState(s *model.RetryState) bool {
+	if reflect.DeepEqual(n.Retry, s) {
+		return false
+	}
+	if s != nil {
+		c := *s
+		s = &c
+	}
+	n.Retry = s
+	return true
+}
+
+func (n *DefaultPhaseState[C, T, CP, TP]) GetApprovalState() *model.ApprovalState {
+	return n.Approval
+}
+
+func (n *DefaultPhaseState[C, T, CP, TP]) SetApprovalState(s *model.ApprovalState) bool {
+	if reflect.DeepEqual(n.Approval, s) {
+		return false
+	}
+	if s != nil {
+		c := *s
+		s = &c
+	}
+	n.Approval = s
+	return true
+}
@@ -8,4 +8,5 @@ type DBNamespace interface {
 	Object
 	GetRunLock() mmids.RunId
 	SetRunLock(id mmids.RunId)
+	GetPriority() int
 }
@@ -2,8 +2,14 @@ package db
 
 import (
 	"encoding/json"
+	"reflect"
+	"slices"
+	"time"
 
 	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/processing/mmids"
+	"github.com/mandelsoft/engine/pkg/processing/model"
+	"github.com/mandelsoft/engine/pkg/tracing"
 )
 
 const APIVERSION = "engine/v1"
@@ -12,6 +18,33 @@ type ObjectMetaAccessor interface {
 	database.Object
 	database.GenerationAccess
 	database.Finalizable
+	database.DeletionPolicyAccess
+	database.DeletionTimeAccess
+	Suspendable
+	SlaveAccess
+	ForcedDeletionAccess
+	tracing.Carrier
+}
+
+// Suspendable is implemented by objects, which can
+// suspend the processing of elements depending on them.
+type Suspendable interface {
+	IsSuspended() bool
+	SetSuspended(bool) bool
+}
+
+// SlaveAccess is implemented by objects, which can
+// be assured as slave by an element.
+type SlaveAccess interface {
+	GetSlaveInfo(phase mmids.Phase) *model.SlaveInfo
+	SetSlaveInfo(phase mmids.Phase, s *model.SlaveInfo) bool
+}
+
+// ForcedDeletionAccess is implemented by objects, which
+// record the enforced deletion of their phases.
+type ForcedDeletionAccess interface {
+	IsForcedDeletion(phase mmids.Phase) bool
+	SetForcedDeletion(phase mmids.Phase) bool
 }
 
 type Object interface {
@@ -55,12 +88,72 @@ func (o *ObjectMeta) IsDeleting() bool {
 	return o.MetaData.IsDeleting()
 }
 
+// deletionInfo additionally preserves the deletion policy
+// of a deleting object.
+type deletionInfo struct {
+	info   database.DeletionInfo
+	policy database.DeletionPolicy
+}
+
 func (o *ObjectMeta) GetDeletionInfo() database.DeletionInfo {
-	return o.MetaData.GetDeletionInfo()
+	return &deletionInfo{o.MetaData.GetDeletionInfo(), o.MetaData.DeletionPolicy}
 }
 
 func (g *ObjectMeta) PreserveDeletion(info database.DeletionInfo) {
-	g.MetaData.PreserveDeletion(info)
+	d, ok := info.(*deletionInfo)
+	if !ok {
+		g.MetaData.PreserveDeletion(info)
+		return
+	}
+	deleting := g.MetaData.IsDeleting()
+	g.MetaData.PreserveDeletion(d.info)
+	if !deleting && g.MetaData.IsDeleting() && g.MetaData.DeletionPolicy == "" {
+		g.MetaData.DeletionPolicy = d.policy
+	}
+}
+
+func (o *ObjectMeta) GetDeletionTime() *time.Time {
+	return o.MetaData.GetDeletionTime()
+}
+
+func (o *ObjectMeta) GetDeletionPolicy() database.DeletionPolicy {
+	return o.MetaData.GetDeletionPolicy()
+}
+
+func (o *ObjectMeta) SetDeletionPolicy(p database.DeletionPolicy) bool {
+	return o.MetaData.SetDeletionPolicy(p)
+}
+
+func (o *ObjectMeta) IsSuspended() bool {
+	return o.MetaData.IsSuspended()
+}
+
+func (o *ObjectMeta) SetSuspended(b bool) bool {
+	return o.MetaData.SetSuspended(b)
+}
+
+func (o *ObjectMeta) GetSlaveInfo(phase mmids.Phase) *model.SlaveInfo {
+	return o.MetaData.GetSlaveInfo(phase)
+}
+
+func (o *ObjectMeta) SetSlaveInfo(phase mmids.Phase, s *model.SlaveInfo) bool {
+	return o.MetaData.SetSlaveInfo(phase, s)
+}
+
+func (o *ObjectMeta) IsForcedDeletion(phase mmids.Phase) bool {
+	return o.MetaData.IsForcedDeletion(phase)
+}
+
+func (o *ObjectMeta) SetForcedDeletion(phase mmids.Phase) bool {
+	return o.MetaData.SetForcedDeletion(phase)
+}
+
+func (o *ObjectMeta) GetTraceParent() string {
+	return o.MetaData.GetTraceParent()
+}
+
+func (o *ObjectMeta) SetTraceParent(s string) bool {
+	return o.MetaData.SetTraceParent(s)
 }
 
 func (o *ObjectMeta) SetName(s string) {
@@ -117,6 +210,93 @@ type MetaData struct {
 	database.Named         `json:",inline"`
 	database.Generation    `json:",inline"`
 	database.FinalizedMeta `json:",inline"`
+
+	// Suspended suspends the processing of all elements
+	// depending on this object.
+	Suspended bool `json:"suspended,omitempty"`
+
+	// DeletionPolicy describes how the deletion of the object
+	// is propagated to dependent objects.
+	DeletionPolicy database.DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// Slaves describes the elements assuring
+	// the phases of the object as slave.
+	Slaves map[mmids.Phase]*model.SlaveInfo `json:"slaves,omitempty"`
+
+	// ForcedDeletion are the phases, whose deletion has
+	// been enforced.
+	ForcedDeletion []mmids.Phase `json:"forcedDeletion,omitempty"`
+
+	// TraceParent is the trace context of the run, which
+	// lastly updated the object.
+	TraceParent string `json:"traceParent,omitempty"`
+}
+
+func (m *MetaData) IsSuspended() bool {
+	return m.Suspended
+}
+
+func (m *MetaData) SetSuspended(b bool) bool {
+	if m.Suspended == b {
+		return false
+	}
+	m.Suspended = b
+	return true
+}
+
+func (m *MetaData) GetDeletionPolicy() database.DeletionPolicy {
+	return m.DeletionPolicy
+}
+
+func (m *MetaData) SetDeletionPolicy(p database.DeletionPolicy) bool {
+	if m.DeletionPolicy == p {
+		return false
+	}
+	m.DeletionPolicy = p
+	return true
+}
+
+func (m *MetaData) GetSlaveInfo(phase mmids.Phase) *model.SlaveInfo {
+	return m.Slaves[phase]
+}
+
+func (m *MetaData) SetSlaveInfo(phase mmids.Phase, s *model.SlaveInfo) bool {
+	if reflect.DeepEqual(m.Slaves[phase], s) {
+		return false
+	}
+	if s == nil {
+		delete(m.Slaves, phase)
+		return true
+	}
+	if m.Slaves == nil {
+		m.Slaves = map[mmids.Phase]*model.SlaveInfo{}
+	}
+	m.Slaves[phase] = s
+	return true
+}
+
+func (m *MetaData) IsForcedDeletion(phase mmids.Phase) bool {
+	return slices.Contains(m.ForcedDeletion, phase)
+}
+
+func (m *MetaData) SetForcedDeletion(phase mmids.Phase) bool {
+	if m.IsForcedDeletion(phase) {
+		return false
+	}
+	m.ForcedDeletion = append(m.ForcedDeletion, phase)
+	return true
+}
+
+func (m *MetaData) GetTraceParent() string {
+	return m.TraceParent
+}
+
+func (m *MetaData) SetTraceParent(s string) bool {
+	if m.TraceParent == s {
+		return false
+	}
+	m.TraceParent = s
+	return true
 }
 
 func NewObjectMeta(ty string, ns string, name string) ObjectMeta {
@@ -3,6 +3,9 @@ package db
 type StandardCurrentState struct {
 	ObservedVersion string `json:"observedVersion,omitempty"`
 	FormalVersion   string `json:"formalVersion,omitempty"`
+	// FormalGraph is the composed structure of the formal version,
+	// independent of the composer used to calculate the formal version.
+	FormalGraph string `json:"formalGraph,omitempty"`
 
 	InputVersion  string `json:"inputVersion,omitempty"`
 	ObjectVersion string `json:"objectVersion,omitempty"`
@@ -23,6 +26,18 @@ func (d *StandardCurrentState) SetFormalVersion(v string) bool {
 	return true
 }
 
+func (d *StandardCurrentState) GetFormalGraph() string {
+	return d.FormalGraph
+}
+
+func (d *StandardCurrentState) SetFormalGraph(v string) bool {
+	if d.FormalGraph == v {
+		return false
+	}
+	d.FormalGraph = v
+	return true
+}
+
 func (d *StandardCurrentState) GetObservedVersion() string {
 	return d.ObservedVersion
 }
@@ -0,0 +1,38 @@
+package db
+
+import (
+	"github.com/mandelsoft/engine/pkg/recorder"
+)
+
+// TYPE_EVENT is the type name of event objects
+// used by the event recorder.
+const TYPE_EVENT = "Event"
+
+type Event struct {
+	ObjectMeta `json:",inline"`
+
+	Spec   recorder.EventSpec   `json:"spec"`
+	Status recorder.EventStatus `json:"status"`
+}
+
+var _ recorder.Event = (*Event)(nil)
+var _ Object = (*Event)(nil)
+
+func NewEvent(ns, name string) *Event {
+	return &Event{
+		ObjectMeta: NewObjectMeta(TYPE_EVENT, ns, name),
+	}
+}
+
+func (e *Event) GetEventRecord() recorder.Record {
+	return recorder.Record{Spec: e.Spec, Status: e.Status}
+}
+
+func (e *Event) SetEventRecord(rec recorder.Record) {
+	e.Spec = rec.Spec
+	e.Status = rec.Status
+}
+
+func (e *Event) GetStatusValue() string {
+	return e.Spec.Reason
+}
@@ -0,0 +1,38 @@
+package db
+
+import (
+	"github.com/mandelsoft/engine/pkg/election"
+)
+
+// TYPE_LEASE is the type name of lease objects
+// used for the leader election.
+const TYPE_LEASE = "Lease"
+
+type Lease struct {
+	ObjectMeta `json:",inline"`
+
+	Spec election.LeaseSpec `json:"spec"`
+}
+
+var _ election.Lease = (*Lease)(nil)
+
+func NewLease(ns, name string) *Lease {
+	return &Lease{
+		ObjectMeta: NewObjectMeta(TYPE_LEASE, ns, name),
+	}
+}
+
+func (l *Lease) GetLeaseSpec() election.LeaseSpec {
+	return l.Spec
+}
+
+func (l *Lease) SetLeaseSpec(spec election.LeaseSpec) {
+	l.Spec = spec
+}
+
+func (l *Lease) GetStatusValue() string {
+	if l.Spec.Holder != "" {
+		return "Held"
+	}
+	return "Released"
+}
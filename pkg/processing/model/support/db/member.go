@@ -0,0 +1,35 @@
+package db
+
+import (
+	"github.com/mandelsoft/engine/pkg/sharding"
+)
+
+// TYPE_SHARD_MEMBER is the type name of member objects
+// used for the namespace sharding.
+const TYPE_SHARD_MEMBER = "ShardMember"
+
+type ShardMember struct {
+	ObjectMeta `json:",inline"`
+
+	Spec sharding.MemberSpec `json:"spec"`
+}
+
+var _ sharding.Member = (*ShardMember)(nil)
+
+func NewShardMember(ns, name string) *ShardMember {
+	return &ShardMember{
+		ObjectMeta: NewObjectMeta(TYPE_SHARD_MEMBER, ns, name),
+	}
+}
+
+func (m *ShardMember) GetMemberSpec() sharding.MemberSpec {
+	return m.Spec
+}
+
+func (m *ShardMember) SetMemberSpec(spec sharding.MemberSpec) {
+	m.Spec = spec
+}
+
+func (m *ShardMember) GetStatusValue() string {
+	return "Active"
+}
@@ -8,6 +8,9 @@ type Namespace struct {
 	ObjectMeta `json:",inline"`
 
 	RunLock mmids.RunId `json:"runLock"`
+	// Priority is the scheduling priority used for
+	// the processing of elements in the namespace.
+	Priority int `json:"priority,omitempty"`
 }
 
 var _ DBNamespace = (*Namespace)(nil)
@@ -20,6 +23,10 @@ func (n *Namespace) SetRunLock(id mmids.RunId) {
 	n.RunLock = id
 }
 
+func (n *Namespace) GetPriority() int {
+	return n.Priority
+}
+
 func (n *Namespace) GetStatusValue() string {
 	if n.RunLock != "" {
 		return "Locked"
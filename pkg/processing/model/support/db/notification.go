@@ -0,0 +1,72 @@
+package db
+
+import (
+	"github.com/mandelsoft/engine/pkg/notification"
+)
+
+// TYPE_NOTIFICATION is the type name of notification objects
+// used to configure the notifier.
+const TYPE_NOTIFICATION = "Notification"
+
+// TYPE_NOTIFICATION_EVENT is the type name of the journal objects
+// used by the notifier to persist events until they are delivered.
+const TYPE_NOTIFICATION_EVENT = "NotificationEvent"
+
+type Notification struct {
+	ObjectMeta `json:",inline"`
+
+	Spec   notification.Spec   `json:"spec"`
+	Status notification.Status `json:"status"`
+}
+
+var _ notification.Notification = (*Notification)(nil)
+var _ Object = (*Notification)(nil)
+
+func NewNotification(ns, name string) *Notification {
+	return &Notification{
+		ObjectMeta: NewObjectMeta(TYPE_NOTIFICATION, ns, name),
+	}
+}
+
+func (n *Notification) GetNotificationSpec() notification.Spec {
+	return n.Spec
+}
+
+func (n *Notification) GetNotificationStatus() notification.Status {
+	return n.Status
+}
+
+func (n *Notification) SetNotificationStatus(s notification.Status) {
+	n.Status = s
+}
+
+func (n *Notification) GetStatusValue() string {
+	return n.Status.State
+}
+
+type NotificationEvent struct {
+	ObjectMeta `json:",inline"`
+
+	Spec notification.Record `json:"spec"`
+}
+
+var _ notification.Journal = (*NotificationEvent)(nil)
+var _ Object = (*NotificationEvent)(nil)
+
+func NewNotificationEvent(ns, name string) *NotificationEvent {
+	return &NotificationEvent{
+		ObjectMeta: NewObjectMeta(TYPE_NOTIFICATION_EVENT, ns, name),
+	}
+}
+
+func (e *NotificationEvent) GetRecord() notification.Record {
+	return e.Spec
+}
+
+func (e *NotificationEvent) SetRecord(r notification.Record) {
+	e.Spec = r
+}
+
+func (e *NotificationEvent) GetStatusValue() string {
+	return e.Spec.Event.Status
+}
@@ -0,0 +1,37 @@
+package db
+
+import (
+	"github.com/mandelsoft/engine/pkg/history"
+)
+
+// TYPE_RUN is the type name of run objects
+// used for the run history.
+const TYPE_RUN = "Run"
+
+type Run struct {
+	ObjectMeta `json:",inline"`
+
+	Spec   history.RunSpec   `json:"spec"`
+	Status history.RunStatus `json:"status"`
+}
+
+var _ history.Run = (*Run)(nil)
+
+func NewRun(ns, name string) *Run {
+	return &Run{
+		ObjectMeta: NewObjectMeta(TYPE_RUN, ns, name),
+	}
+}
+
+func (r *Run) GetRunRecord() history.Record {
+	return history.Record{Spec: r.Spec, Status: r.Status}
+}
+
+func (r *Run) SetRunRecord(rec history.Record) {
+	r.Spec = rec.Spec
+	r.Status = rec.Status
+}
+
+func (r *Run) GetStatusValue() string {
+	return r.Status.Status
+}
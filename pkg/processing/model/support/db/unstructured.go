@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/mandelsoft/engine/pkg/processing/model"
+
 	"sigs.k8s.io/yaml"
 )
 
@@ -45,6 +47,36 @@ func (u *Unstructured) GetStatusValue() string {
 	return ""
 }
 
+func (u *Unstructured) GetStatusMessage() string {
+	status := u.Other["status"]
+	if status == nil {
+		return ""
+	}
+	if m, ok := status.(map[string]interface{}); ok {
+		if s, ok := m["message"].(string); ok {
+			return s
+		}
+	}
+	return ""
+}
+
+// GetConditions provides the conditions found in the status.
+func (u *Unstructured) GetConditions() model.Conditions {
+	status, ok := u.Other["status"].(map[string]interface{})
+	if !ok || status["conditions"] == nil {
+		return nil
+	}
+	data, err := json.Marshal(status["conditions"])
+	if err != nil {
+		return nil
+	}
+	var conditions model.Conditions
+	if json.Unmarshal(data, &conditions) != nil {
+		return nil
+	}
+	return conditions
+}
+
 func (u *Unstructured) IsDeleting() bool {
 	meta := u.Other["metadata"]
 	if meta == nil {
@@ -1,6 +1,10 @@
 package db
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
 	"github.com/mandelsoft/engine/pkg/database"
 	"github.com/mandelsoft/goutils/generics"
 )
@@ -18,3 +22,29 @@ func RemoveFinalizer[O database.Object](odb database.Database[Object], o *O, f s
 		return m, m
 	})
 }
+
+// ContentDigest provides a digest for the content of an object
+// without its status and generation. It is only changed by
+// modifications of the object other than status updates.
+// Typed and unstructured representations of an object
+// provide the same digest.
+func ContentDigest(o database.Object) string {
+	data, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	}
+	var m map[string]interface{}
+	if json.Unmarshal(data, &m) != nil {
+		return ""
+	}
+	delete(m, "status")
+	if meta, ok := m["metadata"].(map[string]interface{}); ok {
+		delete(meta, "generation")
+	}
+	data, err = json.Marshal(m)
+	if err != nil {
+		return ""
+	}
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
@@ -110,6 +110,12 @@ func (n *InternalObjectSupport[I]) GetStatus(phase mmids.Phase) model.Status {
 	return n.GetPhaseState(phase).GetStatus()
 }
 
+func (n *InternalObjectSupport[I]) GetStatusTime(phase mmids.Phase) *utils.Timestamp {
+	n.Lock.Lock()
+	defer n.Lock.Unlock()
+	return n.GetPhaseState(phase).GetStatusTime()
+}
+
 func (n *InternalObjectSupport[I]) SetStatus(ob objectbase.Objectbase, phase mmids.Phase, status model.Status) (bool, error) {
 	n.Lock.Lock()
 	defer n.Lock.Unlock()
@@ -138,6 +144,74 @@ func (n *InternalObjectSupport[I]) TryLock(ob objectbase.Objectbase, phase mmids
 	return wrapped.Modify(ob, n, mod)
 }
 
+func (n *InternalObjectSupport[I]) GetRetryState(phase mmids.Phase) *model.RetryState {
+	n.Lock.Lock()
+	defer n.Lock.Unlock()
+	return n.GetPhaseState(phase).GetRetryState()
+}
+
+func (n *InternalObjectSupport[I]) SetRetryState(ob objectbase.Objectbase, phase mmids.Phase, state *model.RetryState) (bool, error) {
+	n.Lock.Lock()
+	defer n.Lock.Unlock()
+
+	mod := func(o db.Object) (bool, bool) {
+		b := n.GetPhaseState(phase).SetRetryState(state)
+		return b, b
+	}
+	return wrapped.Modify(ob, n, mod)
+}
+
+func (n *InternalObjectSupport[I]) GetApprovalState(phase mmids.Phase) *model.ApprovalState {
+	n.Lock.Lock()
+	defer n.Lock.Unlock()
+	return n.GetPhaseState(phase).GetApprovalState()
+}
+
+func (n *InternalObjectSupport[I]) SetApprovalState(ob objectbase.Objectbase, phase mmids.Phase, state *model.ApprovalState) (bool, error) {
+	n.Lock.Lock()
+	defer n.Lock.Unlock()
+
+	mod := func(o db.Object) (bool, bool) {
+		b := n.GetPhaseState(phase).SetApprovalState(state)
+		return b, b
+	}
+	return wrapped.Modify(ob, n, mod)
+}
+
+func (n *InternalObjectSupport[I]) GetSlaveInfo(phase mmids.Phase) *model.SlaveInfo {
+	n.Lock.Lock()
+	defer n.Lock.Unlock()
+	return n.GetBase().GetSlaveInfo(phase)
+}
+
+func (n *InternalObjectSupport[I]) SetMaster(ob objectbase.Objectbase, phase mmids.Phase, master mmids.ElementId) (bool, error) {
+	n.Lock.Lock()
+	defer n.Lock.Unlock()
+
+	mod := func(o db.Object) (bool, bool) {
+		b := o.SetSlaveInfo(phase, model.NewSlaveInfo(master))
+		return b, b
+	}
+	return wrapped.Modify(ob, n, mod)
+}
+
+func (n *InternalObjectSupport[I]) IsForcedDeletion(phase mmids.Phase) bool {
+	n.Lock.Lock()
+	defer n.Lock.Unlock()
+	return n.GetBase().IsForcedDeletion(phase)
+}
+
+func (n *InternalObjectSupport[I]) MarkForcedDeletion(ob objectbase.Objectbase, phase mmids.Phase) (bool, error) {
+	n.Lock.Lock()
+	defer n.Lock.Unlock()
+
+	mod := func(o db.Object) (bool, bool) {
+		b := o.SetForcedDeletion(phase)
+		return b, b
+	}
+	return wrapped.Modify(ob, n, mod)
+}
+
 type Rollbacker[P any] interface {
 	DBRollback(lctx model.Logging, o P, phase mmids.Phase)
 }
@@ -240,9 +314,15 @@ func (n *InternalObjectSupport[I]) HandleCommit(lctx model.Logging, ob objectbas
 				v = commit.OutputState.GetOutputVersion()
 				log.Info("  output version {{output}}", "output", v)
 				c.SetOutputVersion(v)
-				v = commit.OutputState.GetFormalVersion()
+				v = commit.FormalVersion
+				if v == "" {
+					v = commit.OutputState.GetFormalVersion()
+				}
 				log.Info("  formal version {{formal}}", "formal", v)
 				c.SetFormalVersion(v)
+				if commit.FormalGraph != "" {
+					c.SetFormalGraph(commit.FormalGraph)
+				}
 			}
 			if committer != nil {
 				committer.DBCommit(lctx, o, phase, commit)
@@ -338,6 +418,10 @@ func (c *CurrentStateSupport[I, C]) GetInputVersion() string {
 	return c.Get().GetInputVersion()
 }
 
+func (c *CurrentStateSupport[I, C]) GetFormalGraph() string {
+	return c.Get().GetFormalGraph()
+}
+
 func (c *CurrentStateSupport[I, C]) GetObjectVersion() string {
 	return c.Get().GetObjectVersion()
 }
@@ -394,10 +478,15 @@ func NewDefaultObservedStateForTypePhase(v string, typ string, namespace string,
 	return &defaultObservedState{v, LinksForTypePhase(typ, namespace, phase, names...)}
 }
 
+// LinksForTypePhase provides the links to the given phase of the
+// objects of the given type and names used in a namespace.
+// Names may be qualified by a namespace (<namespace>/<name>) to
+// link elements of other namespaces.
 func LinksForTypePhase(typ string, namespace string, phase mmids.Phase, names ...string) []mmids.ElementId {
 	var links []mmids.ElementId
 	for _, l := range sliceutils.Filter(names, matcher.NotInitial[string]) {
-		links = append(links, mmids.NewElementId(typ, namespace, l, phase))
+		ns, n := mmids.ParseQualifiedName(namespace, l)
+		links = append(links, mmids.NewElementId(typ, ns, n, phase))
 	}
 	return links
 }
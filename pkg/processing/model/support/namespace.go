@@ -33,6 +33,10 @@ func (n *Namespace) GetLock() mmids.RunId {
 	return generics.Cast[db.DBNamespace](n.GetBase()).GetRunLock()
 }
 
+func (n *Namespace) GetPriority() int {
+	return generics.Cast[db.DBNamespace](n.GetBase()).GetPriority()
+}
+
 func (n *Namespace) ClearLock(ob objectbase2.Objectbase, id mmids.RunId) (bool, error) {
 	n.Lock.Lock()
 	defer n.Lock.Unlock()
@@ -87,9 +87,14 @@ func UpdateSlave[I db.InternalDBObject, R any](ob objectbase.Objectbase, eid mmi
 		i = _i.(model.InternalObject)
 	}
 
+	parent := objectbase.GetTraceParent(ob)
 	r, err := wrapped.Modify(ob, i.(wrapper.Object[db.Object]), func(_o db.Object) (R, bool) {
 		o := _o.(I)
-		return mod(o)
+		r, m := mod(o)
+		if m && parent != "" {
+			o.SetTraceParent(parent)
+		}
+		return r, m
 	})
 	return r, i.(InternalObject), err
 }
@@ -103,9 +108,13 @@ func UpdateExternal[E db.ExternalDBObject](ob objectbase.Objectbase, oid databas
 		e = _e.(model.ExternalObject)
 	}
 
+	parent := objectbase.GetTraceParent(ob)
 	r, err := wrapped.Modify(ob, e.(wrapper.Object[db.Object]), func(_o db.Object) (bool, bool) {
 		o := _o.(E)
 		m := mod(o)
+		if m && parent != "" {
+			o.SetTraceParent(parent)
+		}
 		return m, m
 	})
 	return r, e.(model.ExternalObject), err
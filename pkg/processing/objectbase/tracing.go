@@ -0,0 +1,43 @@
+package objectbase
+
+import (
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/database/wrapper"
+)
+
+// traced is an Objectbase carrying the trace context of
+// the actual run. It is passed to model functions creating
+// or updating slave objects, to propagate the trace context
+// into those objects.
+type traced struct {
+	Objectbase
+	parent string
+}
+
+// WithTraceParent provides an Objectbase carrying the given
+// trace context. It is used for all operations of the original
+// Objectbase.
+func WithTraceParent(ob Objectbase, parent string) Objectbase {
+	if parent == "" {
+		return ob
+	}
+	if t, ok := ob.(*traced); ok {
+		ob = t.Objectbase
+	}
+	return &traced{ob, parent}
+}
+
+// GetTraceParent provides the trace context carried by an Objectbase.
+func GetTraceParent(ob Objectbase) string {
+	if t, ok := ob.(*traced); ok {
+		return t.parent
+	}
+	return ""
+}
+
+func (t *traced) GetDatabase() database.Database[Object] {
+	if w, ok := t.Objectbase.(wrapper.Wrapped[Object]); ok {
+		return w.GetDatabase()
+	}
+	return t.Objectbase
+}
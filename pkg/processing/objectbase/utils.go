@@ -1,9 +1,30 @@
 package objectbase
 
 import (
+	"fmt"
+
 	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/database/wrapper"
+	"github.com/mandelsoft/engine/pkg/runtime"
 )
 
 func SetObjectName(ns, n string) Initializer {
 	return database.SetObjectName[Object](ns, n)
 }
+
+// DecodeObject decodes a serialized object into an object
+// of the Objectbase without storing it.
+func DecodeObject(ob Objectbase, data []byte) (Object, error) {
+	if d, ok := ob.(wrapper.Decoder[Object]); ok {
+		return d.DecodeObject(data)
+	}
+	if w, ok := ob.(wrapper.Wrapped[Object]); ok {
+		if d, ok := w.GetDatabase().(wrapper.Decoder[Object]); ok {
+			return d.DecodeObject(data)
+		}
+	}
+	if e, ok := ob.SchemeTypes().(runtime.Encoding[Object]); ok {
+		return e.Decode(data)
+	}
+	return nil, fmt.Errorf("objectbase does not support decoding")
+}
@@ -0,0 +1,120 @@
+package processor
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/processing/api"
+	"github.com/mandelsoft/engine/pkg/processing/metamodel"
+	"github.com/mandelsoft/engine/pkg/server"
+)
+
+// adminHandler provides the privileged engine API for a processor.
+// It is served separately from the regular engine API, so that
+// the access to it can be restricted separately.
+type adminHandler struct {
+	controller *Controller
+	prefix     string
+}
+
+// RegisterAdminHandler registers the privileged engine API
+// at the given path prefix.
+func (p *Controller) RegisterAdminHandler(s *server.Server, prefix string) {
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	s.Handle(prefix, &adminHandler{p, prefix})
+}
+
+func (a *adminHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	path := req.URL.Path[len(a.prefix):]
+	comps := strings.Split(path, "/")
+
+	var result interface{}
+	var status int
+
+	switch comps[0] {
+	case api.CMD_FORCE_DELETE:
+		result, status = a.forceDelete(req, comps[1:])
+	case api.CMD_METAMODEL:
+		result, status = a.metamodel(req, comps[1:])
+	default:
+		result, status = &api.Error{Error: "unknown command " + comps[0]}, http.StatusNotFound
+	}
+	respond(w, result, status)
+}
+
+func (a *adminHandler) forceDelete(req *http.Request, comps []string) (interface{}, int) {
+	if req.Method != http.MethodPost {
+		return &api.Error{Error: "method not allowed"}, http.StatusMethodNotAllowed
+	}
+	oid, ok := objectIdFor(comps)
+	if !ok {
+		return &api.Error{Error: "invalid path"}, http.StatusBadRequest
+	}
+
+	ids, err := a.controller.ForceDelete(oid)
+	if err != nil {
+		switch {
+		case errors.Is(err, database.ErrNotExist):
+			return &api.Error{Error: err.Error()}, http.StatusNotFound
+		case errors.Is(err, ErrForceDeletionDisabled):
+			return &api.Error{Error: err.Error()}, http.StatusForbidden
+		case errors.Is(err, ErrNotResponsible):
+			return &api.Error{Error: err.Error()}, http.StatusMisdirectedRequest
+		}
+		return &api.Error{Error: err.Error()}, http.StatusBadRequest
+	}
+	result := &api.ForceDeleteResult{Elements: []string{}}
+	for _, id := range ids {
+		result.Elements = append(result.Elements, id.String())
+	}
+	return result, http.StatusOK
+}
+
+func (a *adminHandler) metamodel(req *http.Request, comps []string) (interface{}, int) {
+	if req.Method != http.MethodPost {
+		return &api.Error{Error: "method not allowed"}, http.StatusMethodNotAllowed
+	}
+	if len(comps) > 1 || len(comps) == 1 && comps[0] != "" {
+		return &api.Error{Error: "invalid path"}, http.StatusBadRequest
+	}
+
+	var spec metamodel.MetaModelSpecification
+	data, err := io.ReadAll(req.Body)
+	if err == nil {
+		err = json.Unmarshal(data, &spec)
+	}
+	if err != nil {
+		return &api.Error{Error: err.Error()}, http.StatusBadRequest
+	}
+
+	changes, err := a.controller.UpdateMetaModel(spec)
+	if err != nil {
+		var incompatible *metamodel.IncompatibleChangesError
+		if errors.As(err, &incompatible) {
+			return &api.Error{Error: err.Error()}, http.StatusConflict
+		}
+		return &api.Error{Error: err.Error()}, http.StatusBadRequest
+	}
+	result := &api.MetaModelChanges{
+		ExternalTypes:        changes.ExternalTypes,
+		InternalTypes:        changes.InternalTypes,
+		UpdateRequestType:    changes.UpdateRequestType,
+		RemovedExternalTypes: changes.RemovedExternalTypes,
+		ModifiedTypes:        changes.ModifiedTypes,
+	}
+	for t, phases := range changes.Phases {
+		if result.Phases == nil {
+			result.Phases = map[string][]string{}
+		}
+		for _, ph := range phases {
+			result.Phases[t] = append(result.Phases[t], string(ph))
+		}
+	}
+	return result, http.StatusOK
+}
@@ -0,0 +1,444 @@
+package processor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/history"
+	"github.com/mandelsoft/engine/pkg/processing/api"
+	. "github.com/mandelsoft/engine/pkg/processing/mmids"
+	"github.com/mandelsoft/engine/pkg/processing/model"
+	"github.com/mandelsoft/engine/pkg/processing/objectbase"
+	"github.com/mandelsoft/engine/pkg/recorder"
+	"github.com/mandelsoft/engine/pkg/server"
+	"github.com/mandelsoft/goutils/maputils"
+)
+
+// apiHandler provides the engine API for a processor.
+type apiHandler struct {
+	controller *Controller
+	prefix     string
+}
+
+// RegisterAPIHandler registers the engine API at the given path prefix.
+func (p *Controller) RegisterAPIHandler(s *server.Server, prefix string) {
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	s.Handle(prefix, &apiHandler{p, prefix})
+}
+
+func (a *apiHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	path := req.URL.Path[len(a.prefix):]
+	comps := strings.Split(path, "/")
+
+	var result interface{}
+	var status int
+
+	switch comps[0] {
+	case api.CMD_CANCEL:
+		result, status = a.cancel(req, comps[1:])
+	case api.CMD_APPROVE:
+		result, status = a.decide(req, comps[1:], model.APPROVAL_APPROVED)
+	case api.CMD_REJECT:
+		result, status = a.decide(req, comps[1:], model.APPROVAL_REJECTED)
+	case api.CMD_RUNS:
+		result, status = a.runs(req, comps[1:])
+	case api.CMD_RUN:
+		result, status = a.run(req, comps[1:])
+	case api.CMD_EXPLAIN:
+		result, status = a.explain(req, comps[1:])
+	case api.CMD_PLAN:
+		result, status = a.plan(req, comps[1:])
+	case api.CMD_EVENTS:
+		result, status = a.events(req, comps[1:])
+	case api.CMD_DESCRIBE:
+		result, status = a.describe(req, comps[1:])
+	case api.CMD_TYPES:
+		result, status = a.types(req, comps[1:])
+	case api.CMD_STUCK:
+		result, status = a.stuck(req, comps[1:])
+	default:
+		result, status = &api.Error{Error: "unknown command " + comps[0]}, http.StatusNotFound
+	}
+
+	respond(w, result, status)
+}
+
+// respond writes the JSON representation of an API result.
+func respond(w http.ResponseWriter, result interface{}, status int) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		data, _ = json.Marshal(&api.Error{Error: err.Error()})
+		status = http.StatusInternalServerError
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+// objectIdFor decodes an object id from path components
+// of the form <type>/<namespace>/<name>.
+func objectIdFor(comps []string) (database.ObjectId, bool) {
+	if len(comps) < 2 || comps[0] == "" || comps[len(comps)-1] == "" {
+		return nil, false
+	}
+	name := comps[len(comps)-1]
+	ns := strings.Join(comps[1:len(comps)-1], "/")
+	return database.NewObjectId(comps[0], ns, name), true
+}
+
+func (a *apiHandler) cancel(req *http.Request, comps []string) (interface{}, int) {
+	if req.Method != http.MethodPost {
+		return &api.Error{Error: "method not allowed"}, http.StatusMethodNotAllowed
+	}
+	oid, ok := objectIdFor(comps)
+	if !ok {
+		return &api.Error{Error: "invalid path"}, http.StatusBadRequest
+	}
+
+	runs, err := a.controller.CancelObject(oid, Phase(req.URL.Query().Get(api.PARAM_PHASE)))
+	if err != nil {
+		return &api.Error{Error: err.Error()}, http.StatusBadRequest
+	}
+
+	result := &api.CancelResult{Cancelled: []api.CancelledRun{}}
+	for _, id := range maputils.Keys(runs, CompareElementId) {
+		result.Cancelled = append(result.Cancelled, api.CancelledRun{
+			Element: id.String(),
+			RunId:   string(runs[id]),
+		})
+	}
+	return result, http.StatusOK
+}
+
+func (a *apiHandler) decide(req *http.Request, comps []string, decision model.ApprovalDecision) (interface{}, int) {
+	if req.Method != http.MethodPost {
+		return &api.Error{Error: "method not allowed"}, http.StatusMethodNotAllowed
+	}
+	oid, ok := objectIdFor(comps)
+	if !ok {
+		return &api.Error{Error: "invalid path"}, http.StatusBadRequest
+	}
+	q := req.URL.Query()
+	runid := RunId(q.Get(api.PARAM_RUNID))
+	if runid == "" {
+		return &api.Error{Error: "run id required"}, http.StatusBadRequest
+	}
+	version := q.Get(api.PARAM_VERSION)
+	if version == "" {
+		return &api.Error{Error: "input version required"}, http.StatusBadRequest
+	}
+
+	id, err := a.controller.DecideObject(oid, Phase(q.Get(api.PARAM_PHASE)), runid, version, decision, q.Get(api.PARAM_MESSAGE))
+	if err != nil {
+		return &api.Error{Error: err.Error()}, http.StatusBadRequest
+	}
+	return &api.ApprovalResult{
+		Element:  id.String(),
+		RunId:    string(runid),
+		Decision: string(decision),
+	}, http.StatusOK
+}
+
+func (a *apiHandler) runs(req *http.Request, comps []string) (interface{}, int) {
+	if req.Method != http.MethodGet {
+		return &api.Error{Error: "method not allowed"}, http.StatusMethodNotAllowed
+	}
+	oid, ok := objectIdFor(comps)
+	if !ok {
+		return &api.Error{Error: "invalid path"}, http.StatusBadRequest
+	}
+
+	runs, err := a.controller.ObjectRuns(oid, Phase(req.URL.Query().Get(api.PARAM_PHASE)))
+	if err != nil {
+		return &api.Error{Error: err.Error()}, http.StatusBadRequest
+	}
+	return runList(runs), http.StatusOK
+}
+
+func (a *apiHandler) run(req *http.Request, comps []string) (interface{}, int) {
+	if req.Method != http.MethodGet {
+		return &api.Error{Error: "method not allowed"}, http.StatusMethodNotAllowed
+	}
+	if len(comps) != 1 || comps[0] == "" {
+		return &api.Error{Error: "invalid path"}, http.StatusBadRequest
+	}
+
+	runs, err := a.controller.Run(req.URL.Query().Get(api.PARAM_NAMESPACE), RunId(comps[0]))
+	if err != nil {
+		return &api.Error{Error: err.Error()}, http.StatusBadRequest
+	}
+	if len(runs) == 0 {
+		return &api.Error{Error: "run " + comps[0] + " not found"}, http.StatusNotFound
+	}
+	return runList(runs), http.StatusOK
+}
+
+func (a *apiHandler) explain(req *http.Request, comps []string) (interface{}, int) {
+	if req.Method != http.MethodGet {
+		return &api.Error{Error: "method not allowed"}, http.StatusMethodNotAllowed
+	}
+	oid, ok := objectIdFor(comps)
+	if !ok {
+		return &api.Error{Error: "invalid path"}, http.StatusBadRequest
+	}
+
+	list, err := a.controller.Explain(oid, Phase(req.URL.Query().Get(api.PARAM_PHASE)))
+	if err != nil {
+		if errors.Is(err, database.ErrNotExist) {
+			return &api.Error{Error: err.Error()}, http.StatusNotFound
+		}
+		return &api.Error{Error: err.Error()}, http.StatusBadRequest
+	}
+	result := &api.ExplainResult{Elements: []api.ElementExplanation{}}
+	for _, x := range list {
+		result.Elements = append(result.Elements, api.ElementExplanation{
+			Element: x.Id.String(),
+			Current: x.Current,
+			Target:  x.Target,
+			Changes: x.Changes,
+		})
+	}
+	return result, http.StatusOK
+}
+
+func runList(runs []*history.Record) *api.RunList {
+	result := &api.RunList{Runs: []history.Record{}}
+	for _, r := range runs {
+		result.Runs = append(result.Runs, *r)
+	}
+	return result
+}
+
+func (a *apiHandler) plan(req *http.Request, comps []string) (interface{}, int) {
+	if req.Method != http.MethodPost {
+		return &api.Error{Error: "method not allowed"}, http.StatusMethodNotAllowed
+	}
+	if len(comps) > 1 || len(comps) == 1 && comps[0] != "" {
+		return &api.Error{Error: "invalid path"}, http.StatusBadRequest
+	}
+
+	var preq api.PlanRequest
+	data, err := io.ReadAll(req.Body)
+	if err == nil {
+		err = json.Unmarshal(data, &preq)
+	}
+	if err != nil {
+		return &api.Error{Error: err.Error()}, http.StatusBadRequest
+	}
+
+	var objs []model.ExternalObject
+	for i, d := range preq.Objects {
+		o, err := objectbase.DecodeObject(a.controller.Objectbase(), d)
+		if err != nil {
+			return &api.Error{Error: fmt.Sprintf("object %d: %s", i+1, err)}, http.StatusBadRequest
+		}
+		e, ok := o.(model.ExternalObject)
+		if !ok || !a.controller.MetaModel().IsExternalType(o.GetType()) {
+			return &api.Error{Error: fmt.Sprintf("%s: no external object", database.StringId(o))}, http.StatusBadRequest
+		}
+		objs = append(objs, e)
+	}
+
+	elems, err := a.controller.Plan(objs...)
+	if err != nil {
+		return &api.Error{Error: err.Error()}, http.StatusBadRequest
+	}
+	result := &api.PlanResult{Elements: []api.PlannedElement{}}
+	for _, e := range elems {
+		p := api.PlannedElement{
+			Element:    e.Id.String(),
+			Action:     string(e.Action),
+			OldVersion: e.OldVersion,
+			NewVersion: e.NewVersion,
+		}
+		if e.Trigger != nil {
+			p.Trigger = database.StringId(e.Trigger)
+		}
+		result.Elements = append(result.Elements, p)
+	}
+	return result, http.StatusOK
+}
+
+func (a *apiHandler) events(req *http.Request, comps []string) (interface{}, int) {
+	if req.Method != http.MethodGet {
+		return &api.Error{Error: "method not allowed"}, http.StatusMethodNotAllowed
+	}
+	var oid database.ObjectId
+	if len(comps) > 0 && !(len(comps) == 1 && comps[0] == "") {
+		var ok bool
+		oid, ok = objectIdFor(comps)
+		if !ok {
+			return &api.Error{Error: "invalid path"}, http.StatusBadRequest
+		}
+	}
+
+	events, err := a.controller.Events(req.URL.Query().Get(api.PARAM_NAMESPACE), oid)
+	if err != nil {
+		return &api.Error{Error: err.Error()}, http.StatusBadRequest
+	}
+	result := &api.EventList{Events: []recorder.Record{}}
+	for _, e := range events {
+		result.Events = append(result.Events, *e)
+	}
+	return result, http.StatusOK
+}
+
+func (a *apiHandler) describe(req *http.Request, comps []string) (interface{}, int) {
+	if req.Method != http.MethodGet {
+		return &api.Error{Error: "method not allowed"}, http.StatusMethodNotAllowed
+	}
+	oid, ok := objectIdFor(comps)
+	if !ok {
+		return &api.Error{Error: "invalid path"}, http.StatusBadRequest
+	}
+	limit := DEFAULT_DESCRIBE_LIMIT
+	if l := req.URL.Query().Get(api.PARAM_LIMIT); l != "" {
+		var err error
+		limit, err = strconv.Atoi(l)
+		if err != nil {
+			return &api.Error{Error: "invalid limit: " + err.Error()}, http.StatusBadRequest
+		}
+	}
+
+	d, err := a.controller.Describe(oid, limit)
+	if err != nil {
+		if errors.Is(err, database.ErrNotExist) {
+			return &api.Error{Error: err.Error()}, http.StatusNotFound
+		}
+		return &api.Error{Error: err.Error()}, http.StatusBadRequest
+	}
+
+	result := &api.DescribeResult{
+		Internal:   d.Id.String(),
+		Finalizers: d.Finalizers,
+		Phases:     []api.PhaseDescription{},
+	}
+	for _, ph := range d.Phases {
+		result.Phases = append(result.Phases, api.PhaseDescription{
+			Element:    ph.Id.String(),
+			Status:     string(ph.Status),
+			RunId:      string(ph.Lock),
+			Deletion:   ph.Deletion,
+			Current:    stateDescription(ph.Current),
+			Target:     stateDescription(ph.Target),
+			Upstream:   dependencies(ph.Upstream),
+			Downstream: dependencies(ph.Downstream),
+		})
+	}
+	for _, s := range d.Slaves {
+		result.Slaves = append(result.Slaves, s.String())
+	}
+	for _, e := range d.Events {
+		result.Events = append(result.Events, *e)
+	}
+	for _, r := range d.Runs {
+		result.Runs = append(result.Runs, *r)
+	}
+	return result, http.StatusOK
+}
+
+func stateDescription(s *StateDescription) *api.StateDescription {
+	if s == nil {
+		return nil
+	}
+	d := &api.StateDescription{
+		ObjectVersion: s.ObjectVersion,
+		InputVersion:  s.InputVersion,
+		OutputVersion: s.OutputVersion,
+		FormalVersion: s.FormalVersion,
+	}
+	for _, l := range s.Links {
+		d.Links = append(d.Links, l.String())
+	}
+	return d
+}
+
+func dependencies(deps []*Dependency) []api.Dependency {
+	var result []api.Dependency
+	for _, d := range deps {
+		result = append(result, api.Dependency{
+			Element:      d.Id.String(),
+			Status:       string(d.Status),
+			Dependencies: dependencies(d.Dependencies),
+		})
+	}
+	return result
+}
+
+func (a *apiHandler) types(req *http.Request, comps []string) (interface{}, int) {
+	if req.Method != http.MethodGet {
+		return &api.Error{Error: "method not allowed"}, http.StatusMethodNotAllowed
+	}
+	if len(comps) > 1 || len(comps) == 1 && comps[0] != "" {
+		return &api.Error{Error: "invalid path"}, http.StatusBadRequest
+	}
+
+	mm := a.controller.MetaModel()
+	result := &api.TypesResult{
+		Model:             mm.Name(),
+		NamespaceType:     mm.NamespaceType(),
+		UpdateRequestType: mm.UpdateRequestType(),
+		ExternalTypes:     []api.ExternalTypeInfo{},
+		InternalTypes:     []api.InternalTypeInfo{},
+	}
+	for _, n := range mm.ExternalTypes() {
+		result.ExternalTypes = append(result.ExternalTypes, api.ExternalTypeInfo{
+			Name:    n,
+			Trigger: mm.GetExternalType(n).Trigger().Id().String(),
+		})
+	}
+	for _, n := range mm.InternalTypes() {
+		info := api.InternalTypeInfo{Name: n, Phases: []string{}}
+		for _, ph := range mm.Phases(n) {
+			info.Phases = append(info.Phases, string(ph))
+		}
+		result.InternalTypes = append(result.InternalTypes, info)
+	}
+	return result, http.StatusOK
+}
+
+func (a *apiHandler) stuck(req *http.Request, comps []string) (interface{}, int) {
+	if req.Method != http.MethodGet {
+		return &api.Error{Error: "method not allowed"}, http.StatusMethodNotAllowed
+	}
+	if len(comps) > 1 || len(comps) == 1 && comps[0] != "" {
+		return &api.Error{Error: "invalid path"}, http.StatusBadRequest
+	}
+	threshold := DEFAULT_STUCK_THRESHOLD
+	if v := req.URL.Query().Get(api.PARAM_THRESHOLD); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return &api.Error{Error: fmt.Sprintf("invalid threshold %q", v)}, http.StatusBadRequest
+		}
+		threshold = d
+	}
+
+	list, err := a.controller.StuckDeletions(threshold)
+	if err != nil {
+		return &api.Error{Error: err.Error()}, http.StatusInternalServerError
+	}
+	result := &api.StuckResult{Objects: []api.StuckObject{}}
+	for _, s := range list {
+		o := api.StuckObject{
+			Object:       database.StringId(s.Id),
+			DeletionTime: s.DeletionTime,
+		}
+		for _, f := range s.Finalizers {
+			o.Finalizers = append(o.Finalizers, api.Finalizer{Name: f.Finalizer, Controller: f.Controller})
+		}
+		for _, id := range stuckLocks(s) {
+			o.Locks = append(o.Locks, api.ActiveRun{Element: id.String(), RunId: string(s.Locks[id])})
+		}
+		result.Objects = append(result.Objects, o)
+	}
+	return result, http.StatusOK
+}
@@ -0,0 +1,167 @@
+package processor
+
+import (
+	"fmt"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/pool"
+	. "github.com/mandelsoft/engine/pkg/processing/mmids"
+	"github.com/mandelsoft/engine/pkg/processing/model"
+)
+
+var ErrRunRejected = fmt.Errorf("run rejected")
+
+func (r *elementRunReconcilation) requiresApproval() bool {
+	t := r.MetaModel().GetElementType(r.Id().TypeId())
+	return t != nil && t.RequiresApproval()
+}
+
+// checkApproval checks the approval gate of the element type for the
+// actual run and input version. It returns true, if the processing step
+// can be executed. Otherwise, the element is parked in status
+// AwaitingApproval, or the run fails, if it has been rejected.
+func (r *elementRunReconcilation) checkApproval(version string, formal string) (pool.Status, bool) {
+	if !r.requiresApproval() {
+		return pool.Status{}, true
+	}
+
+	runid := r.GetLock()
+	state := r.GetApprovalState()
+	switch state.DecisionFor(runid, version) {
+	case model.APPROVAL_APPROVED:
+		r.Info("run {{runid}} approved for input version {{version}}", "runid", runid, "version", version)
+		return pool.Status{}, true
+	case model.APPROVAL_REJECTED:
+		r.Info("run {{runid}} rejected for input version {{version}}", "runid", runid, "version", version)
+		return r.fail(false, rejectionError(state), formal), false
+	case model.APPROVAL_PENDING:
+		if r.GetStatus() == model.STATUS_AWAITING_APPROVAL {
+			r.Info("still awaiting approval of run {{runid}} for input version {{version}}", "runid", runid, "version", version)
+			return pool.StatusCompleted(), false
+		}
+	}
+
+	r.Info("run {{runid}} requires approval for input version {{version}}", "runid", runid, "version", version)
+	_, err := r.SetApprovalState(r.Objectbase(), &model.ApprovalState{
+		RunId:        runid,
+		InputVersion: version,
+		Decision:     model.APPROVAL_PENDING,
+	})
+	if err != nil {
+		return pool.StatusCompleted(err), false
+	}
+	err = r.updateStatus(model.STATUS_AWAITING_APPROVAL, approvalMessage(r.GetApprovalState()), runid, FormalVersion(formal))
+	if err != nil {
+		return pool.StatusCompleted(err), false
+	}
+	err = r.setStatus(r, r._Element, model.STATUS_AWAITING_APPROVAL)
+	return pool.StatusCompleted(err), false
+}
+
+func rejectionError(state *model.ApprovalState) error {
+	if state.Message == "" {
+		return fmt.Errorf("%w: %s", ErrRunRejected, state.RunId)
+	}
+	return fmt.Errorf("%w: %s: %s", ErrRunRejected, state.RunId, state.Message)
+}
+
+// approvalMessage provides a describing text for an approval state.
+func approvalMessage(state *model.ApprovalState) string {
+	if state == nil {
+		return ""
+	}
+	switch state.Decision {
+	case model.APPROVAL_PENDING:
+		return fmt.Sprintf("approval required for run %s (input version %s)", state.RunId, state.InputVersion)
+	case model.APPROVAL_APPROVED:
+		return fmt.Sprintf("run %s approved", state.RunId)
+	case model.APPROVAL_REJECTED:
+		return rejectionError(state).Error()
+	}
+	return ""
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Decide approves or rejects the pending approval request of an element.
+// The request must match the actual run and its input version.
+func (p *Controller) Decide(id ElementId, runid RunId, version string, decision model.ApprovalDecision, msg string) error {
+	if decision != model.APPROVAL_APPROVED && decision != model.APPROVAL_REJECTED {
+		return fmt.Errorf("invalid approval decision %q", decision)
+	}
+	e := p.processingModel._GetElement(id)
+	if e == nil {
+		return fmt.Errorf("unknown element %s", id)
+	}
+	if e.GetStatus() != model.STATUS_AWAITING_APPROVAL {
+		return fmt.Errorf("element %s is not awaiting approval (status %s)", id, e.GetStatus())
+	}
+	state := e.GetApprovalState()
+	if state.DecisionFor(runid, version) != model.APPROVAL_PENDING {
+		return fmt.Errorf("no pending approval for run %s with input version %s for element %s", runid, version, id)
+	}
+
+	state = &model.ApprovalState{
+		RunId:        runid,
+		InputVersion: version,
+		Decision:     decision,
+		Message:      msg,
+	}
+	_, err := e.SetApprovalState(p.Objectbase(), state)
+	if err != nil {
+		return err
+	}
+	log := p.logging.Logger()
+	log.Info("approval for run {{runid}} of {{element}}: {{decision}}", "runid", runid, "element", id, "decision", decision)
+	err = p.updateExternalStatus(p.logging.AttributionContext(), id, model.STATUS_AWAITING_APPROVAL, approvalMessage(state))
+	if err != nil {
+		log.LogError(err, "cannot update status for {{element}}", "element", id)
+	}
+	p.events.TriggerElementEvent(e)
+	// a decision is an interactive request, which should not wait
+	// for the processing of other pending elements.
+	p.EnqueueKeyWithPriority(CMD_ELEM, id, pool.PRIORITY_HIGH)
+	return nil
+}
+
+// DecideObject approves or rejects the pending approval request
+// for a phase of an object. The type may be an internal type or
+// an external type triggering an internal type. If no phase is given,
+// the phase awaiting approval for the given run and input version
+// is used.
+func (p *Controller) DecideObject(oid database.ObjectId, phase Phase, runid RunId, version string, decision model.ApprovalDecision, msg string) (ElementId, error) {
+	ids, err := p.elementIdsFor(oid, phase)
+	if err != nil {
+		return ElementId{}, err
+	}
+	if phase == "" {
+		var found []ElementId
+		for _, id := range ids {
+			e := p.processingModel._GetElement(id)
+			if e != nil && e.GetStatus() == model.STATUS_AWAITING_APPROVAL &&
+				e.GetApprovalState().DecisionFor(runid, version) == model.APPROVAL_PENDING {
+				found = append(found, id)
+			}
+		}
+		switch len(found) {
+		case 0:
+			return ElementId{}, fmt.Errorf("no pending approval for run %s with input version %s for object %s", runid, version, database.StringId(oid))
+		case 1:
+			ids = found
+		default:
+			return ElementId{}, fmt.Errorf("multiple phases of object %s awaiting approval -> phase required", database.StringId(oid))
+		}
+	}
+	return ids[0], p.Decide(ids[0], runid, version, decision, msg)
+}
+
+// Approve approves the pending approval request of an element.
+func (p *Controller) Approve(id ElementId, runid RunId, version string, msg string) error {
+	return p.Decide(id, runid, version, model.APPROVAL_APPROVED, msg)
+}
+
+// Reject rejects the pending approval request of an element.
+// The run fails.
+func (p *Controller) Reject(id ElementId, runid RunId, version string, msg string) error {
+	return p.Decide(id, runid, version, model.APPROVAL_REJECTED, msg)
+}
@@ -0,0 +1,68 @@
+package processor
+
+import (
+	"fmt"
+
+	. "github.com/mandelsoft/engine/pkg/processing/mmids"
+	"github.com/mandelsoft/engine/pkg/processing/model"
+)
+
+// setConditions adds the conditions for a status update of
+// an element. The phase condition reflects the new status of the
+// element, the Ready condition the status of all phases of its
+// internal object.
+func (p *Controller) setConditions(id ElementId, update *model.StatusUpdate) {
+	if update.Status == nil {
+		return
+	}
+	status := *update.Status
+	message := ""
+	if update.Message != nil {
+		message = *update.Message
+	}
+
+	update.Conditions = []model.Condition{{
+		Type:    string(id.GetPhase()),
+		Status:  model.ConditionStatusFor(status),
+		Reason:  model.ConditionReasonFor(status),
+		Message: message,
+	}}
+
+	it := p.processingModel.MetaModel().GetInternalType(id.GetType())
+	if it == nil {
+		return
+	}
+	var o model.InternalObject
+	if _o, err := p.Objectbase().GetObject(id.ObjectId()); err == nil {
+		o, _ = _o.(model.InternalObject)
+	}
+
+	ready := model.Condition{
+		Type:    model.CONDITION_READY,
+		Status:  model.CONDITION_TRUE,
+		Reason:  model.ConditionReasonFor(model.STATUS_COMPLETED),
+		Message: "all phases completed",
+	}
+	for _, ph := range it.Phases() {
+		s := status
+		if ph != id.GetPhase() {
+			s = model.STATUS_INITIAL
+			if o != nil {
+				s = o.GetStatus(ph)
+			}
+		}
+		c := model.ConditionStatusFor(s)
+		if c == model.CONDITION_TRUE || ready.Status == model.CONDITION_FALSE {
+			continue
+		}
+		if c == model.CONDITION_FALSE || ready.Status == model.CONDITION_TRUE {
+			ready.Status = c
+			ready.Reason = model.ConditionReasonFor(s)
+			ready.Message = fmt.Sprintf("phase %s is %s", ph, ready.Reason)
+			if ph == id.GetPhase() && message != "" {
+				ready.Message += ": " + message
+			}
+		}
+	}
+	update.Conditions = append(update.Conditions, ready)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"slices"
+	"sync"
 	"time"
 
 	"github.com/mandelsoft/engine/pkg/database"
@@ -16,6 +17,8 @@ import (
 	elemwatch "github.com/mandelsoft/engine/pkg/processing/watch"
 	"github.com/mandelsoft/engine/pkg/server"
 	"github.com/mandelsoft/engine/pkg/service"
+	"github.com/mandelsoft/engine/pkg/sharding"
+	"github.com/mandelsoft/engine/pkg/tracing"
 	"github.com/mandelsoft/engine/pkg/utils"
 	"github.com/mandelsoft/engine/pkg/version"
 	"github.com/mandelsoft/engine/pkg/watch"
@@ -30,6 +33,7 @@ const FINALIZER = "engine"
 const CMD_EXT = "ext"
 const CMD_ELEM = "elem"
 const CMD_NS = "ns"
+const CMD_ORPHAN = "orphan"
 
 type Controller struct {
 	lctx   logging.Context
@@ -45,27 +49,73 @@ type Controller struct {
 	ready   service.Trigger
 	handler database.EventHandler
 
+	// modelLock serializes the setup of the handlers and
+	// pool actions for the types of the metamodel.
+	modelLock    sync.Mutex
+	registry     database.HandlerRegistry
+	extReconcile pool.Action
+
 	events  *EventManager
 	pending PendingCounter
 
 	delay time.Duration
+
+	runs             *runRegistry
+	watchdogInterval time.Duration
+
+	orphanScanInterval time.Duration
+	orphanDeletion     bool
+
+	tracer *tracing.Tracer
+	traces *runTraces
+
+	records  *runRecords
+	recorder *eventRecorder
+
+	suspended  *suspensionRegistry
+	forced     *forceRegistry
+	priorities *priorityCache
+	results    *resultCache
+
+	sharding sharding.Sharding
+	shards   *shardState
+	watches  *elemwatch.Aggregator
 }
 
+// DEFAULT_WATCHDOG_INTERVAL is the default interval used to check
+// runs for exceeded deadlines.
+const DEFAULT_WATCHDOG_INTERVAL = 10 * time.Second
+
 var _ service.Service = (*Controller)(nil)
 
 func NewController(lctx logging.Context, m model.Model, worker int, cmps ...version.Composer) (*Controller, error) {
 	p := &Controller{
-		pool:            pool.NewPool(lctx, m.MetaModel().Name(), worker, 0, false),
-		logging:         lctx.WithContext(REALM),
-		processingModel: newProcessingModel(m),
-		composer:        general.OptionalDefaulted[version.Composer](version.Composed, cmps...),
+		pool:               pool.NewPool(lctx, m.MetaModel().Name(), worker, 0, false),
+		logging:            lctx.WithContext(REALM),
+		processingModel:    newProcessingModel(m),
+		composer:           general.OptionalDefaulted[version.Composer](version.Composed, cmps...),
+		ctx:                context.Background(),
+		runs:               newRunRegistry(),
+		tracer:             tracing.Default,
+		traces:             newRunTraces(),
+		records:            newRunRecords(),
+		recorder:           newEventRecorder(),
+		watchdogInterval:   DEFAULT_WATCHDOG_INTERVAL,
+		orphanScanInterval: DEFAULT_ORPHAN_SCAN_INTERVAL,
+		suspended:          newSuspensionRegistry(),
+		forced:             newForceRegistry(),
+		priorities:         newPriorityCache(),
+		results:            newResultCache(DEFAULT_RESULT_CACHE_SIZE),
+		shards:             newShardState(),
 	}
 	p.events = newEventManager(p.processingModel)
+	p.watches = elemwatch.NewAggregator(lctx, p.events.registry)
+	p.pool.SetClassifier(p.classify)
 	return p, nil
 }
 
 func (p *Controller) RegisterWatchHandler(s *server.Server, pattern string) {
-	s.Handle(pattern, watch.WatchHttpHandler[elemwatch.Request, elemwatch.Event](p.events.registry))
+	s.Handle(pattern, watch.WatchHttpHandler[elemwatch.Request, elemwatch.Event](p.watches))
 }
 
 func (p *Controller) RegisterHandler(handler EventHandler, current bool, kind string, closure bool, ns string) {
@@ -101,6 +151,12 @@ func (p *Controller) SetDelay(d time.Duration) {
 	p.delay = d
 }
 
+// SetWatchdogInterval sets the interval used to check runs
+// for exceeded deadlines. A value <= 0 disables the watchdog.
+func (p *Controller) SetWatchdogInterval(d time.Duration) {
+	p.watchdogInterval = d
+}
+
 func (p *Controller) MetaModel() metamodel.MetaModel {
 	return p.processingModel.MetaModel()
 }
@@ -114,17 +170,25 @@ func (p *Controller) Start(ctx context.Context) (service.Syncher, service.Synche
 		return p.ready, p.syncher, nil
 	}
 	log := p.logging.Logger().WithName("setup")
+	p.ctx = ctx
 
+	p.modelLock.Lock()
+	defer p.modelLock.Unlock()
+
+	p.initSharding()
 	err := p.setupElements(p.logging.AttributionContext(), log)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	p.handler = newHandler(p.pool)
+	p.handler = newHandler(p)
 
 	extReconcile := newExternalObjectReconciler(p)
 	reg := database.NewHandlerRegistry(p.processingModel.ObjectBase())
+	p.registry = reg
+	p.extReconcile = extReconcile
 	reg.RegisterHandler(p.handler, false, p.processingModel.MetaModel().NamespaceType(), true, "/")
+	p.pool.AddAction(pool.ObjectType(p.processingModel.MetaModel().NamespaceType()), newNamespaceObjectReconciler(p))
 	for _, t := range p.processingModel.MetaModel().ExternalTypes() {
 		log.Debug("register handler for external type {{exttype}}", "exttype", t)
 		reg.RegisterHandler(p.handler, false, t, true, "/")
@@ -141,6 +205,7 @@ func (p *Controller) Start(ctx context.Context) (service.Syncher, service.Synche
 	p.pool.AddAction(utils.NewStringGlobMatcher(CMD_NS+":*"), newNamespaceReconciler(p))
 	p.pool.AddAction(utils.NewStringGlobMatcher(CMD_ELEM+":*"), elemReconcile)
 	p.pool.AddAction(utils.NewStringGlobMatcher(CMD_EXT+":*"), elemReconcile)
+	p.pool.AddAction(utils.NewStringGlobMatcher(CMD_ORPHAN+":*"), elemReconcile)
 
 	p.processingModel.ObjectBase().RegisterHandler(reg, true, "", true, "")
 
@@ -157,6 +222,11 @@ func (p *Controller) Start(ctx context.Context) (service.Syncher, service.Synche
 
 	p.ready = service.SyncTrigger()
 
+	p.registerMetrics(ctx)
+	go p.watchdog(ctx)
+	go p.orphanScanner(ctx)
+	go p.shardManager(ctx, p.logging.AttributionContext())
+
 	go func() {
 		log.Info("triggering all elements")
 		c := 0
@@ -190,6 +260,31 @@ func (s *setup) GetNamespace() string {
 }
 
 func (p *Controller) setupElements(lctx model.Logging, log logging.Logger) error {
+	// step 1 and 2: setup elements of the namespaces of own shards
+	err := p.setupNamespaces(lctx, log, p.isResponsible)
+	if err != nil {
+		return err
+	}
+
+	// step 3: validate links
+	log.Info("validating linḱs...")
+	for _, ns := range p.processingModel.namespaces {
+		for _, e := range ns.elements {
+			for _, l := range e.GetCurrentState().GetLinks() {
+				if ns.elements[l] == nil {
+					log.Warn("element {{element}} has unknown linked element {{link}}", "element", e.Id(), "link", l)
+				}
+			}
+			// target state must not already be linked
+		}
+	}
+
+	return nil
+}
+
+// setupNamespaces sets up the elements for the namespaces
+// matching the given filter.
+func (p *Controller) setupNamespaces(lctx model.Logging, log logging.Logger, filter func(ns string) bool) error {
 	// step 1: create processing elements and cleanup pending locks
 	log.Info("setup internal objects...")
 
@@ -201,6 +296,9 @@ func (p *Controller) setupElements(lctx model.Logging, log logging.Logger) error
 		}
 
 		for _, _o := range objs {
+			if !filter(_o.GetNamespace()) {
+				continue
+			}
 			log.Debug("    found {{intid}}", "intid", database.NewObjectIdFor(_o))
 			o := _o.(model.InternalObject)
 			r := &setup{
@@ -219,6 +317,12 @@ func (p *Controller) setupElements(lctx model.Logging, log logging.Logger) error
 			for _, ph := range p.MetaModel().Phases(o.GetType()) {
 				log.Debug("      found phase {{phase}}", "phase", ph)
 				e := ni._AddElement(o, ph)
+				p.processingModel.addLinks(e.Id(), e.GetCurrentState().GetLinks()...)
+				if o.IsForcedDeletion(ph) {
+					log.Info("resuming enforced deletion of {{element}}", "element", e.Id())
+					p.forced.Add(e.Id())
+					p.EnqueueKey(CMD_ELEM, e.Id())
+				}
 				if curlock != "" {
 					if owner := IsObjectLock(curlock); owner != nil {
 						id := (*owner).Id(o.GetNamespace(), p.processingModel.MetaModel())
@@ -239,19 +343,34 @@ func (p *Controller) setupElements(lctx model.Logging, log logging.Logger) error
 		}
 	}
 
-	// step 2: validate links
-	log.Info("validating linḱs...")
-	for _, ns := range p.processingModel.namespaces {
-		for _, e := range ns.elements {
-			for _, l := range e.GetCurrentState().GetLinks() {
-				if ns.elements[l] == nil {
-					log.Warn("element {{element}} has unknown linked element {{link}}", "element", e.Id(), "link", l)
-				}
-			}
-			// target state must not already be linked
+	// step 2: release stale namespace locks of incompletely locked runs
+	// left by a previous (crashed) processor for this object space.
+	log.Info("recovering stale namespace locks...")
+	nsobjs, err := p.Objectbase().ListObjects(p.MetaModel().NamespaceType(), true, "")
+	if err != nil {
+		return err
+	}
+	for _, o := range nsobjs {
+		rid := o.(model.NamespaceObject).GetLock()
+		if rid == "" || IsObjectLock(rid) != nil || !filter(o.(model.NamespaceObject).GetNamespaceName()) {
+			continue
+		}
+		r := &setup{
+			reconciler: reconciler{controller: p},
+			Logger:     log,
+			lctx:       lctx,
+			namespace:  o.(model.NamespaceObject).GetNamespaceName(),
+		}
+		ni, err := p.processingModel.AssureNamespace(r, r.GetNamespace(), false)
+		if err != nil {
+			return err
+		}
+		log.Info("releasing stale lock {{runid}} for namespace {{namespace}}", "runid", rid, "namespace", r.GetNamespace())
+		err = ni.clearLock(r, rid)
+		if err != nil {
+			return err
 		}
 	}
-
 	return nil
 }
 
@@ -281,6 +400,20 @@ func (p *Controller) EnqueueKey(cmd string, id ElementId) {
 	p.pool.EnqueueCommand(k)
 }
 
+// EnqueueKeyWithPriority enqueues an element command with
+// a minimum priority. It is used for interactive requests, which
+// should be preferred over the priority derived from the element
+// type and the namespace.
+func (p *Controller) EnqueueKeyWithPriority(cmd string, id ElementId, prio pool.Priority) {
+	k := EncodeElement(cmd, id)
+	p.pool.EnqueueCommandWithPriority(k, prio)
+}
+
+func (p *Controller) EnqueueKeyAfter(cmd string, id ElementId, d time.Duration) {
+	k := EncodeElement(cmd, id)
+	p.pool.EnqueueCommandAfter(k, d)
+}
+
 func (p *Controller) Enqueue(cmd string, e Element) {
 	k := EncodeElement(cmd, e.Id())
 	p.pool.EnqueueCommand(k)
@@ -305,6 +438,7 @@ func (p *Controller) setStatus(log logging.Logger, e _Element, status model.Stat
 	}
 	if ok {
 		log.Info("status updated to {{status}} for {{element}}", "status", status, "element", e.Id())
+		p.records.Transition(e.Id(), status)
 	}
 	if ok || general.Optional(trigger...) {
 		p.events.TriggerStatusEvent(log, e)
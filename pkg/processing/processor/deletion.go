@@ -0,0 +1,191 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/pool"
+	. "github.com/mandelsoft/engine/pkg/processing/mmids"
+	"github.com/mandelsoft/engine/pkg/processing/model"
+	"github.com/mandelsoft/goutils/stringutils"
+	"github.com/mandelsoft/logging"
+)
+
+// deletionPolicy determines the policy used to propagate the
+// deletion of an element to the elements depending on it.
+// The policy requested by a deleting triggering external object
+// of its internal object overwrites the default policy of the
+// external type.
+func (p *Controller) deletionPolicy(id ElementId) (model.DeletionPolicy, error) {
+	mm := p.processingModel.MetaModel()
+	for _, t := range mm.GetTriggeringTypesForInternalType(id.GetType()) {
+		o, err := p.Objectbase().GetObject(database.NewObjectId(t, id.GetNamespace(), id.GetName()))
+		if err != nil {
+			if errors.Is(err, database.ErrNotExist) {
+				continue
+			}
+			return "", err
+		}
+		if !o.IsDeleting() {
+			continue
+		}
+		if policy := o.GetDeletionPolicy(); policy != "" {
+			return policy, nil
+		}
+		return mm.GetExternalType(t).DeletionPolicy(), nil
+	}
+	return model.DEFAULT_DELETION_POLICY, nil
+}
+
+// dependants provides the elements of other internal objects
+// depending on an element, which are not marked for deletion.
+func (p *Controller) dependants(id ElementId, children []Element) []Element {
+	var deps []Element
+	for _, c := range children {
+		if c.Id().ObjectId() == id.ObjectId() || c.IsMarkedForDeletion() {
+			continue
+		}
+		deps = append(deps, c)
+	}
+	return deps
+}
+
+// cascadeDeletion requests the deletion of the triggering external
+// objects of the given elements. Without explicit deletion policy,
+// the policy Cascade is used for them, too.
+func (p *Controller) cascadeDeletion(log logging.Logger, deps []Element) error {
+	var errs []error
+	for _, c := range deps {
+		for _, t := range p.processingModel.MetaModel().GetTriggeringTypesForInternalType(c.GetType()) {
+			oid := database.NewObjectId(t, c.GetNamespace(), c.GetName())
+			o, err := p.Objectbase().GetObject(oid)
+			if err != nil {
+				if !errors.Is(err, database.ErrNotExist) {
+					errs = append(errs, err)
+				}
+				continue
+			}
+			if o.IsDeleting() {
+				continue
+			}
+			if a, ok := o.(database.DeletionPolicyAccess); ok && a.GetDeletionPolicy() == "" {
+				a.SetDeletionPolicy(model.DELETION_POLICY_CASCADE)
+				err = p.Objectbase().SetObject(o)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("cannot set deletion policy for %s: %w", oid, err))
+					continue
+				}
+			}
+			log.Info(" - deleting dependent external object {{extid}}", "extid", oid)
+			_, err = p.Objectbase().DeleteObject(oid)
+			if err != nil && !errors.Is(err, database.ErrNotExist) {
+				errs = append(errs, fmt.Errorf("cannot delete %s: %w", oid, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// propagateDeletion applies the deletion policy to the children
+// of a deleting element. It returns the children the deletion
+// has to wait for.
+func (r *elementRunReconcilation) propagateDeletion(children []Element) ([]Element, error) {
+	deps := r.Controller().dependants(r.eid, children)
+	if len(deps) == 0 {
+		return children, nil
+	}
+	policy, err := r.Controller().deletionPolicy(r.eid)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []ElementId
+	for _, d := range deps {
+		ids = append(ids, d.Id())
+	}
+	switch policy {
+	case model.DELETION_POLICY_ORPHAN:
+		r.Info("deletion policy {{policy}}: orphaning dependent elements {{dependants}}", "policy", policy, "dependants", stringutils.Join(ids))
+		var wait []Element
+		for _, c := range children {
+			if c.Id().ObjectId() == r.eid.ObjectId() || c.IsMarkedForDeletion() {
+				wait = append(wait, c)
+			}
+		}
+		return wait, nil
+	case model.DELETION_POLICY_CASCADE:
+		r.Info("deletion policy {{policy}}: deleting dependent elements {{dependants}}", "policy", policy, "dependants", stringutils.Join(ids))
+		return children, r.Controller().cascadeDeletion(r, deps)
+	default:
+		msg := fmt.Sprintf("deletion blocked by dependent elements %s", stringutils.Join(ids))
+		r.Info("deletion policy {{policy}}: {{message}}", "policy", policy, "message", msg)
+		r.recorder.Warning(r.eid, REASON_DELETION_BLOCKED, msg)
+		return children, r.Controller().updateExternalStatus(r.lctx, r.eid, model.STATUS_DELETING, msg)
+	}
+}
+
+// deletedLink provides a linked element of the current state,
+// which does not exist anymore. Because the deletion of an element
+// waits for the elements depending on it unless the deletion policy
+// Orphan is used, such an element is orphaned.
+// The state is derived from the links, therefore it survives
+// restarts.
+func (r *elementRunReconcilation) deletedLink() (ElementId, bool, error) {
+	for _, l := range r.GetCurrentState().GetLinks() {
+		deleted, err := r.Controller().isDeleted(l)
+		if err != nil {
+			return ElementId{}, false, err
+		}
+		if deleted {
+			return l, true, nil
+		}
+	}
+	return ElementId{}, false, nil
+}
+
+// isDeleted checks whether an element has been deleted.
+// An element unknown to the processing model might just not be
+// loaded, yet, or it might be handled by another shard. Therefore,
+// in this case the database is consulted: the element is deleted,
+// if its internal object does not exist anymore or if it is being
+// deleted with deletion policy Orphan, which does not wait for the
+// elements depending on it.
+func (p *Controller) isDeleted(id ElementId) (bool, error) {
+	if e := p.processingModel._GetElement(id); e != nil {
+		return e.GetStatus() == model.STATUS_DELETED, nil
+	}
+	o, err := p.Objectbase().GetObject(id.ObjectId())
+	if err != nil {
+		if errors.Is(err, database.ErrNotExist) {
+			return true, nil
+		}
+		return false, err
+	}
+	if !o.IsDeleting() {
+		return false, nil
+	}
+	policy, err := p.deletionPolicy(id)
+	if err != nil {
+		return false, err
+	}
+	return policy == model.DELETION_POLICY_ORPHAN, nil
+}
+
+// orphaned fails an element whose dependency has been deleted
+// with deletion policy Orphan.
+func (r *elementRunReconcilation) orphaned(dep ElementId) pool.Status {
+	msg := fmt.Sprintf("dependency %s deleted", dep)
+	r.Info("element {{element}} orphaned: {{message}}", "message", msg)
+	err := r.Controller().updateExternalStatus(r.lctx, r.eid, model.STATUS_FAILED, msg)
+	if err != nil {
+		return pool.StatusCompleted(err)
+	}
+	err = r.setStatus(r, r._Element, model.STATUS_FAILED)
+	if err != nil {
+		return pool.StatusCompleted(err)
+	}
+	r.records.Transition(r.eid, model.STATUS_FAILED, msg)
+	r.recorder.Warning(r.eid, REASON_ORPHANED, msg)
+	return pool.StatusCompleted()
+}
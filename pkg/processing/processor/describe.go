@@ -0,0 +1,192 @@
+package processor
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/history"
+	. "github.com/mandelsoft/engine/pkg/processing/mmids"
+	"github.com/mandelsoft/engine/pkg/processing/model"
+	"github.com/mandelsoft/engine/pkg/recorder"
+)
+
+// DEFAULT_DESCRIBE_LIMIT is the default number of recent
+// events and runs provided by a description.
+const DEFAULT_DESCRIBE_LIMIT = 10
+
+// Description describes the processing state of an object.
+type Description struct {
+	// Id is the id of the internal object.
+	Id ObjectId
+	// Finalizers are the finalizers of the internal object.
+	Finalizers []string
+	Phases     []*PhaseDescription
+	// Slaves are the elements assured by the phases of the object
+	// since the engine has been started.
+	Slaves []ElementId
+	// Events are the recent events recorded for the object.
+	Events []*recorder.Record
+	// Runs are the recent runs recorded for the object.
+	Runs []*history.Record
+}
+
+// PhaseDescription describes the processing state of an element.
+type PhaseDescription struct {
+	Id       ElementId
+	Status   model.Status
+	Lock     RunId
+	Deletion bool
+	Current  *StateDescription
+	// Target describes the target state of an active run.
+	Target *StateDescription
+	// Upstream is the tree of elements the element depends on.
+	Upstream []*Dependency
+	// Downstream is the tree of elements depending on the element.
+	Downstream []*Dependency
+}
+
+// StateDescription describes the versions and links of
+// a current or target state of an element.
+type StateDescription struct {
+	ObjectVersion string
+	InputVersion  string
+	OutputVersion string
+	FormalVersion string
+	Links         []ElementId
+}
+
+// Dependency describes an element in a dependency tree.
+type Dependency struct {
+	Id           ElementId
+	Status       model.Status
+	Dependencies []*Dependency
+}
+
+// Describe provides the description of the processing state of an
+// object. The type may be an internal type or an external type triggering
+// an internal type. At most limit recent events and runs are provided.
+func (p *Controller) Describe(oid database.ObjectId, limit int) (*Description, error) {
+	ids, err := p.elementIdsFor(oid, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var d *Description
+	for _, id := range ids {
+		e := p.processingModel._GetElement(id)
+		if e == nil {
+			continue
+		}
+		if d == nil {
+			d = &Description{
+				Id:         e.Id().ObjectId(),
+				Finalizers: e.GetObject().GetFinalizers(),
+			}
+		}
+		d.Phases = append(d.Phases, p.describePhase(e))
+		if ni := p.processingModel._getNamespaceInfo(id.GetNamespace()); ni != nil {
+			d.Slaves = append(d.Slaves, ni.getSlaves(id)...)
+		}
+	}
+	if d == nil {
+		return nil, database.ErrNotExist
+	}
+
+	events, err := p.Events(oid.GetNamespace(), oid)
+	if err != nil && !errors.Is(err, ErrNoEventRecorder) {
+		return nil, err
+	}
+	d.Events = recent(events, limit)
+
+	runs, err := p.ObjectRuns(oid, "")
+	if err != nil && !errors.Is(err, ErrNoRunHistory) {
+		return nil, err
+	}
+	d.Runs = recent(runs, limit)
+	return d, nil
+}
+
+func (p *Controller) describePhase(e _Element) *PhaseDescription {
+	d := &PhaseDescription{
+		Id:       e.Id(),
+		Status:   e.GetStatus(),
+		Lock:     e.GetLock(),
+		Deletion: e.IsMarkedForDeletion(),
+	}
+	if c := e.GetCurrentState(); c != nil {
+		d.Current = &StateDescription{
+			ObjectVersion: c.GetObjectVersion(),
+			InputVersion:  c.GetInputVersion(),
+			OutputVersion: c.GetOutputVersion(),
+			FormalVersion: c.GetFormalVersion(),
+			Links:         c.GetLinks(),
+		}
+	}
+	if t := e.GetProcessingState(); t != nil {
+		d.Target = &StateDescription{
+			ObjectVersion: t.GetObjectVersion(),
+			FormalVersion: t.GetFormalObjectVersion(),
+			Links:         t.GetLinks(),
+		}
+	}
+	d.Upstream = p.upstream(e, map[ElementId]bool{e.Id(): true})
+	d.Downstream = p.downstream(e.Id(), map[ElementId]bool{e.Id(): true})
+	return d
+}
+
+// upstream provides the dependency tree of the elements
+// an element depends on. Cycles are cut.
+func (p *Controller) upstream(e _Element, visited map[ElementId]bool) []*Dependency {
+	var links []ElementId
+	if t := e.GetProcessingState(); t != nil {
+		links = t.GetLinks()
+	} else if c := e.GetCurrentState(); c != nil {
+		links = c.GetLinks()
+	}
+	links = slices.Clone(links)
+	slices.SortFunc(links, CompareElementId)
+
+	var deps []*Dependency
+	for _, l := range links {
+		d := &Dependency{Id: l}
+		if u := p.processingModel._GetElement(l); u != nil {
+			d.Status = u.GetStatus()
+			if !visited[l] {
+				visited[l] = true
+				d.Dependencies = p.upstream(u, visited)
+				delete(visited, l)
+			}
+		}
+		deps = append(deps, d)
+	}
+	return deps
+}
+
+// downstream provides the dependency tree of the elements
+// depending on an element. Cycles are cut.
+func (p *Controller) downstream(id ElementId, visited map[ElementId]bool) []*Dependency {
+	children := p.processingModel.GetChildren(id)
+	slices.SortFunc(children, func(a, b Element) int { return CompareElementId(a.Id(), b.Id()) })
+
+	var deps []*Dependency
+	for _, c := range children {
+		d := &Dependency{Id: c.Id(), Status: c.GetStatus()}
+		if !visited[c.Id()] {
+			visited[c.Id()] = true
+			d.Dependencies = p.downstream(c.Id(), visited)
+			delete(visited, c.Id())
+		}
+		deps = append(deps, d)
+	}
+	return deps
+}
+
+// recent provides the last limit entries of a list.
+// A limit <= 0 provides all entries.
+func recent[T any](list []T, limit int) []T {
+	if limit > 0 && len(list) > limit {
+		return list[len(list)-limit:]
+	}
+	return list
+}
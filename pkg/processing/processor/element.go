@@ -3,6 +3,7 @@ package processor
 import (
 	. "github.com/mandelsoft/engine/pkg/processing/mmids"
 	"github.com/mandelsoft/engine/pkg/processing/objectbase"
+	"github.com/mandelsoft/engine/pkg/utils"
 	"github.com/mandelsoft/goutils/general"
 	"github.com/mandelsoft/goutils/generics"
 
@@ -17,6 +18,11 @@ type _Element interface {
 	// GetLock() RunId
 
 	SetStatus(ob objectbase.Objectbase, s model.Status) (bool, error)
+	GetStatusTime() *utils.Timestamp
+	GetRetryState() *model.RetryState
+	SetRetryState(ob objectbase.Objectbase, s *model.RetryState) (bool, error)
+	GetApprovalState() *model.ApprovalState
+	SetApprovalState(ob objectbase.Objectbase, s *model.ApprovalState) (bool, error)
 	GetCurrentState() model.CurrentState
 	GetTargetState() model.TargetState
 	GetProcessingState() ProcessingState
@@ -76,6 +82,26 @@ func (e *element) SetStatus(ob objectbase.Objectbase, s model.Status) (bool, err
 	return e.object.SetStatus(ob, e.GetPhase(), s)
 }
 
+func (e *element) GetStatusTime() *utils.Timestamp {
+	return e.object.GetStatusTime(e.GetPhase())
+}
+
+func (e *element) GetRetryState() *model.RetryState {
+	return e.object.GetRetryState(e.GetPhase())
+}
+
+func (e *element) SetRetryState(ob objectbase.Objectbase, s *model.RetryState) (bool, error) {
+	return e.object.SetRetryState(ob, e.GetPhase(), s)
+}
+
+func (e *element) GetApprovalState() *model.ApprovalState {
+	return e.object.GetApprovalState(e.GetPhase())
+}
+
+func (e *element) SetApprovalState(ob objectbase.Objectbase, s *model.ApprovalState) (bool, error) {
+	return e.object.SetApprovalState(ob, e.GetPhase(), s)
+}
+
 func (e *element) GetObject() model.InternalObject {
 	return e.object
 }
@@ -16,6 +16,11 @@ func (e *nonTemporaryError) Unwrap() error {
 	return e.error
 }
 
+func (e *nonTemporaryError) Is(err error) bool {
+	_, ok := err.(*nonTemporaryError)
+	return ok
+}
+
 var protoNonTemp = &nonTemporaryError{}
 
 func IsNonTemporary(err error) bool {
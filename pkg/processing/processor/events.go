@@ -63,6 +63,12 @@ func (p *PendingCounter) Add(delta int64) {
 	}
 }
 
+func (p *PendingCounter) Get() int64 {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.pending
+}
+
 func (p *PendingCounter) Wait(ctx context.Context) bool {
 	p.lock.Lock()
 
@@ -0,0 +1,138 @@
+package processor
+
+import (
+	"slices"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	. "github.com/mandelsoft/engine/pkg/processing/mmids"
+	"github.com/mandelsoft/engine/pkg/processing/model"
+	"github.com/mandelsoft/engine/pkg/version"
+	"github.com/mandelsoft/goutils/maputils"
+)
+
+// Explanation describes the composed structure of the formal
+// versions of an element and the differences between them.
+type Explanation struct {
+	Id ElementId
+	// Current is the composed structure of the committed formal version.
+	Current string
+	// Target is the composed structure of the formal version
+	// of an active run, or the one a new run would use based
+	// on the actual inputs.
+	Target string
+	// Changes describes the input nodes and object versions
+	// changed from the current to the target formal version.
+	Changes []version.Change
+}
+
+// Explain provides the explanations for the phases of an object.
+// The type may be an internal type or an external type triggering
+// an internal type. If no phase is given, all phases are explained.
+func (p *Controller) Explain(oid database.ObjectId, phase Phase) ([]*Explanation, error) {
+	ids, err := p.elementIdsFor(oid, phase)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*Explanation
+	for _, id := range ids {
+		e := p.processingModel._GetElement(id)
+		if e == nil {
+			if phase != "" {
+				return nil, database.ErrNotExist
+			}
+			continue
+		}
+		x, err := p.explain(e)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, x)
+	}
+	if len(result) == 0 {
+		return nil, database.ErrNotExist
+	}
+	return result, nil
+}
+
+func (p *Controller) explain(e _Element) (*Explanation, error) {
+	x := &Explanation{
+		Id:      e.Id(),
+		Current: p.currentFormalGraph(e.Id()),
+	}
+
+	var formal string
+	var links []ElementId
+	if t := e.GetProcessingState(); t != nil {
+		formal = t.GetFormalObjectVersion()
+		links = t.GetLinks()
+	} else {
+		// without active run the object version is unchanged.
+		if n, err := version.Parse(x.Current); err == nil {
+			formal = n.GetVersion()
+		}
+		links = e.GetCurrentState().GetLinks()
+	}
+	x.Target = p.formalGraph(e.Id(), formal, links...)
+
+	if x.Current == p.emptyFormalGraph(e.Id()) {
+		// not yet processed
+		x.Current = ""
+	}
+	changes, err := version.Diff(x.Current, x.Target)
+	if err != nil {
+		return nil, err
+	}
+	x.Changes = changes
+	return x, nil
+}
+
+// formalGraph provides the composed structure of a formal version
+// for an element based on the committed formal graphs of its inputs.
+// In contrast to the formal version, it does not depend on the
+// composer configured for the processor.
+func (p *Controller) formalGraph(id ElementId, formal string, links ...ElementId) string {
+	links = slices.Clone(links)
+	slices.SortFunc(links, func(a, b ElementId) int {
+		return version.CompareId(versionIdIn(id.GetNamespace(), a), versionIdIn(id.GetNamespace(), b))
+	})
+
+	var nested []string
+	for _, l := range links {
+		nested = append(nested, p.currentFormalGraph(l))
+	}
+	return version.Compose(version.NewNodeById(versionId(id), formal), nested...)
+}
+
+// currentFormalGraph provides the composed structure of the committed
+// formal version of an element. If it is not available, the formal version
+// is used as version of a single node.
+func (p *Controller) currentFormalGraph(id ElementId) string {
+	e := p.processingModel._GetElement(id)
+	if e == nil {
+		return p.emptyFormalGraph(id)
+	}
+	c := e.GetCurrentState()
+	if s, ok := c.(model.FormalGraphState); ok && s.GetFormalGraph() != "" {
+		return s.GetFormalGraph()
+	}
+	v := c.GetFormalVersion()
+	if v == "" {
+		return p.emptyFormalGraph(id)
+	}
+	if _, err := version.Parse(v); err == nil {
+		// composed formal version
+		return v
+	}
+	return version.Compose(version.NewNodeById(versionId(id), v))
+}
+
+func (p *Controller) emptyFormalGraph(id ElementId) string {
+	return version.Compose(version.NewNodeById(versionId(id), ""))
+}
+
+// formalGraph provides the composed structure of the formal version
+// of the actual run.
+func (r *elementRunReconcilation) formalGraph(inputs model.Inputs) string {
+	return r.Controller().formalGraph(r.Id(), r.GetTargetState().GetFormalObjectVersion(), maputils.Keys(inputs)...)
+}
@@ -0,0 +1,290 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/pool"
+	. "github.com/mandelsoft/engine/pkg/processing/mmids"
+	"github.com/mandelsoft/engine/pkg/processing/model"
+	"github.com/mandelsoft/engine/pkg/processing/objectbase"
+	"github.com/mandelsoft/goutils/maputils"
+)
+
+// DEFAULT_STUCK_THRESHOLD is the default duration an object
+// must be deleting to be reported as stuck.
+const DEFAULT_STUCK_THRESHOLD = 5 * time.Minute
+
+var ErrForceDeletionDisabled = errors.New("force deletion not enabled")
+var ErrForceDeleted = errors.New("deletion enforced")
+
+// StuckDeletion describes an object deleting longer than
+// a threshold.
+type StuckDeletion struct {
+	Id           database.ObjectId
+	DeletionTime time.Time
+	// Finalizers are the remaining finalizers together with
+	// the controllers owning them.
+	Finalizers []FinalizerOwner
+	// Locks are the active runs of the elements of the object.
+	Locks map[ElementId]RunId
+}
+
+// FinalizerOwner describes the controller responsible
+// for a finalizer.
+type FinalizerOwner struct {
+	Finalizer string
+	// Controller is the owning controller or empty,
+	// if it is not known.
+	Controller string
+}
+
+// forceRegistry keeps track of elements, whose deletion
+// has been enforced. They are cleaned up by their next
+// reconcilation. The enforced deletion is persisted by the
+// internal objects, the registry is rebuilt from them
+// by the setup of the namespaces.
+type forceRegistry struct {
+	lock       sync.Mutex
+	elements   map[ElementId]struct{}
+	finalizers map[string]string
+	enabled    bool
+}
+
+func newForceRegistry() *forceRegistry {
+	return &forceRegistry{
+		elements:   map[ElementId]struct{}{},
+		finalizers: map[string]string{FINALIZER: "processor"},
+	}
+}
+
+func (f *forceRegistry) Add(id ElementId) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.elements[id] = struct{}{}
+}
+
+// Has checks whether the deletion of an element has been enforced.
+func (f *forceRegistry) Has(id ElementId) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	_, ok := f.elements[id]
+	return ok
+}
+
+// Remove unregisters an element after it has been cleaned up.
+func (f *forceRegistry) Remove(id ElementId) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	delete(f.elements, id)
+}
+
+func (f *forceRegistry) Owner(finalizer string) string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.finalizers[finalizer]
+}
+
+// finalizerAccess is used to replace the finalizers
+// of an object.
+type finalizerAccess interface {
+	HasFinalizer(f string) bool
+	GetFinalizers() []string
+	SetFinalizers(f []string)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// RegisterFinalizer registers the controller owning a finalizer.
+// It is used to report the responsible controllers for
+// stuck deletions.
+func (p *Controller) RegisterFinalizer(finalizer, controller string) {
+	p.forced.lock.Lock()
+	defer p.forced.lock.Unlock()
+	p.forced.finalizers[finalizer] = controller
+}
+
+// StuckDeletions provides the objects of the metamodel types,
+// which are deleting longer than the given threshold.
+func (p *Controller) StuckDeletions(threshold time.Duration) ([]*StuckDeletion, error) {
+	mm := p.MetaModel()
+	now := time.Now()
+
+	var result []*StuckDeletion
+	for _, t := range append(mm.ExternalTypes(), mm.InternalTypes()...) {
+		list, err := p.Objectbase().ListObjects(t, true, "")
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range list {
+			a, ok := o.(database.DeletionTimeAccess)
+			if !ok || a.GetDeletionTime() == nil || now.Sub(*a.GetDeletionTime()) < threshold {
+				continue
+			}
+			s := &StuckDeletion{
+				Id:           database.NewObjectIdFor(o),
+				DeletionTime: *a.GetDeletionTime(),
+			}
+			for _, f := range o.GetFinalizers() {
+				s.Finalizers = append(s.Finalizers, FinalizerOwner{f, p.forced.Owner(f)})
+			}
+			ids, err := p.elementIdsFor(s.Id, "")
+			if err != nil {
+				return nil, err
+			}
+			for _, id := range ids {
+				if e := p.processingModel._GetElement(id); e != nil && e.GetLock() != "" {
+					if s.Locks == nil {
+						s.Locks = map[ElementId]RunId{}
+					}
+					s.Locks[id] = e.GetLock()
+				}
+			}
+			result = append(result, s)
+		}
+	}
+	slices.SortFunc(result, func(a, b *StuckDeletion) int { return database.CompareObjectId(a.Id, b.Id) })
+	return result, nil
+}
+
+// EnableForceDeletion enables the forced deletion of objects.
+// Because it bypasses the regular deletion flow, it is
+// disabled by default.
+func (p *Controller) EnableForceDeletion(b bool) {
+	p.forced.lock.Lock()
+	defer p.forced.lock.Unlock()
+	p.forced.enabled = b
+}
+
+// ForceDelete enforces the deletion of an object. The type may be
+// an internal type or an external type triggering an internal type.
+// The deletion is requested, if not yet done, and the finalizers
+// of foreign controllers are removed. The elements of the object
+// are cleaned up by the engine: their active runs are rolled back,
+// and they are removed from the processing model together
+// with the engine finalizers. The enforced deletion is recorded
+// in the internal objects, so that it is resumed after a restart.
+// It provides the ids of the elements cleaned up by the engine.
+// If the object does not exist, database.ErrNotExist is returned.
+// Because it bypasses the regular deletion flow, it must be enabled
+// (see EnableForceDeletion) and is only offered by the privileged
+// API (see RegisterAdminHandler).
+func (p *Controller) ForceDelete(oid database.ObjectId) ([]ElementId, error) {
+	p.forced.lock.Lock()
+	enabled := p.forced.enabled
+	p.forced.lock.Unlock()
+	if !enabled {
+		return nil, ErrForceDeletionDisabled
+	}
+	if !p.isResponsibleFor(oid) {
+		return nil, fmt.Errorf("%w for %s", ErrNotResponsible, oid)
+	}
+
+	ids, err := p.elementIdsFor(oid, "")
+	if err != nil {
+		return nil, err
+	}
+	o, err := p.Objectbase().GetObject(oid)
+	if err != nil {
+		return nil, err
+	}
+	log := p.logging.Logger()
+	if !o.IsDeleting() {
+		log.Info("requesting deletion for {{oid}}", "oid", oid)
+		_, err = p.Objectbase().DeleteObject(oid)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// the elements to clean up are determined by the persisted
+	// internal objects, the processing model might not be
+	// up to date.
+	var elems []ElementId
+	for _, id := range ids {
+		_i, err := p.Objectbase().GetObject(id.ObjectId())
+		if err != nil {
+			if errors.Is(err, database.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		i := _i.(model.InternalObject)
+		if e := p.processingModel._GetElement(id); e != nil {
+			i = e.GetObject()
+		}
+		_, err = i.MarkForcedDeletion(p.processingModel.ObjectBase(), id.GetPhase())
+		if err != nil && !errors.Is(err, database.ErrNotExist) {
+			return nil, err
+		}
+		elems = append(elems, id)
+	}
+
+	// the engine finalizer is removed by the cleanup of the elements.
+	keep := len(elems) > 0
+	mod := func(o objectbase.Object) (bool, bool) {
+		f, ok := o.(finalizerAccess)
+		if !ok {
+			return false, false
+		}
+		var finalizers []string
+		if keep && f.HasFinalizer(FINALIZER) {
+			finalizers = append(finalizers, FINALIZER)
+		}
+		if len(finalizers) == len(f.GetFinalizers()) {
+			return false, false
+		}
+		log.Info("removing finalizers {{finalizers}} for {{oid}}", "oid", oid, "finalizers", f.GetFinalizers())
+		f.SetFinalizers(finalizers)
+		return true, true
+	}
+	_, err = database.Modify(p.Objectbase(), &o, mod)
+	if err != nil && !errors.Is(err, database.ErrNotExist) {
+		return nil, err
+	}
+
+	for _, id := range elems {
+		log.Info("enforcing deletion of element {{element}}", "element", id)
+		p.forced.Add(id)
+		if p.CancelRun(id, ErrForceDeleted) == "" {
+			p.EnqueueKey(CMD_ELEM, id)
+		}
+	}
+	return elems, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// forceDeletion cleans up an element, whose deletion has been
+// enforced. An active run is failed and the element is
+// removed from the processing model. Elements depending on it
+// are orphaned. The element stays registered for the enforced
+// deletion until the cleanup succeeded.
+func (r *elementRunReconcilation) forceDeletion() pool.Status {
+	r.Info("deletion of element {{element}} enforced")
+	if r.GetLock() != "" {
+		r.Info("failing active run {{runid}}")
+		err := r.abort(false, ErrForceDeleted)
+		if err != nil {
+			return pool.StatusCompleted(err)
+		}
+	}
+	r.recorder.Warning(r.eid, REASON_FORCE_DELETED, "%s", ErrForceDeleted)
+	err := r.phaseDeleted()
+	if err != nil {
+		return pool.StatusCompleted(err)
+	}
+	r.Controller().forced.Remove(r.eid)
+	return pool.StatusCompleted()
+}
+
+// stuckLocks provides the locked elements of a stuck deletion
+// in a stable order.
+func stuckLocks(s *StuckDeletion) []ElementId {
+	return maputils.Keys(s.Locks, CompareElementId)
+}
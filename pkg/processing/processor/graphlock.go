@@ -0,0 +1,189 @@
+package processor
+
+import (
+	"github.com/mandelsoft/goutils/maputils"
+
+	. "github.com/mandelsoft/engine/pkg/processing/mmids"
+)
+
+// graphLock locks the graph of elements depending on a set of
+// elements for a run. For metamodels with cross namespace dependencies
+// the graph may span several namespaces.
+//
+// To avoid deadlocks between concurrent lock attempts starting in
+// different namespaces, the involved namespace infos are never waited for.
+// They are acquired with TryLock, and the complete attempt is given up
+// (and has to be retried later), if one of them is busy.
+// Every namespace contributing elements to the graph is locked for the
+// run id, too. Those namespace locks acquired by the graph lock are
+// released again by release.
+type graphLock struct {
+	r     Reconcilation
+	runid RunId
+
+	// infos are the namespace infos considered for the graph.
+	infos map[string]*namespaceInfo
+	// held are the namespace infos locked by the graph lock.
+	held []*namespaceInfo
+	// locked are the namespaces locked for the run id.
+	locked map[string]*namespaceInfo
+	// acquired are the namespaces locked for the run id by the graph lock.
+	acquired []*namespaceInfo
+}
+
+func newGraphLock(r Reconcilation, runid RunId) *graphLock {
+	return &graphLock{
+		r:      r,
+		runid:  runid,
+		infos:  map[string]*namespaceInfo{},
+		locked: map[string]*namespaceInfo{},
+	}
+}
+
+// use adds a namespace info already locked by the caller for
+// the run id.
+func (g *graphLock) use(ni *namespaceInfo) {
+	g.infos[ni.GetNamespaceName()] = ni
+	g.locked[ni.GetNamespaceName()] = ni
+}
+
+// acquire locks the given namespace infos without waiting.
+// If one of them is busy, all namespace infos are released again.
+func (g *graphLock) acquire(infos ...*namespaceInfo) bool {
+	for _, ni := range infos {
+		if g.infos[ni.GetNamespaceName()] != nil {
+			continue
+		}
+		if !ni.lock.TryLock() {
+			g.r.Info("namespace {{busy}} busy", "busy", ni.GetNamespaceName())
+			g.release()
+			return false
+		}
+		g.infos[ni.GetNamespaceName()] = ni
+		g.held = append(g.held, ni)
+	}
+	return true
+}
+
+// lockNamespace locks a namespace for the run id.
+func (g *graphLock) lockNamespace(ni *namespaceInfo) (bool, error) {
+	if g.locked[ni.GetNamespaceName()] != nil {
+		return true, nil
+	}
+	ok, err := ni.tryLock(g.r, g.runid)
+	if ok {
+		g.locked[ni.GetNamespaceName()] = ni
+		g.acquired = append(g.acquired, ni)
+	}
+	return ok, err
+}
+
+// release clears the pending element locks and the namespace locks
+// acquired for the run id and unlocks the namespace infos.
+func (g *graphLock) release() {
+	for _, ni := range g.acquired {
+		err := ni.clearLocks(g.r)
+		if err != nil {
+			g.r.Error("cannot clear namespace lock for {{lockednamespace}} -> requeue", "lockednamespace", ni.GetNamespaceName(), "error", err)
+			g.r.Controller().EnqueueNamespace(ni.GetNamespaceName())
+		}
+	}
+	g.acquired = nil
+	for _, ni := range g.held {
+		delete(g.infos, ni.GetNamespaceName())
+		delete(g.locked, ni.GetNamespaceName())
+		ni.lock.Unlock()
+	}
+	g.held = nil
+}
+
+// children provides the elements depending on the given
+// element in the considered namespaces.
+func (g *graphLock) children(id ElementId) []Element {
+	var r []Element
+	for _, n := range maputils.OrderedKeys(g.infos) {
+		r = append(r, g.infos[n].getChildren(id)...)
+	}
+	return r
+}
+
+// lockGraph locks the graphs of the given candidates for the run id.
+// Elements of other namespaces are only considered, if their namespace
+// can be locked for the run id, too.
+func (g *graphLock) lockGraph(keep bool, candidates ..._Element) (bool, error) {
+	elems := NewOrderedElementSet()
+	for _, elem := range candidates {
+		ok, err := g.tryLockGraph(elem, elems)
+		if !ok || err != nil {
+			return false, err
+		}
+		ok, err = g.lockElements(keep, elems)
+		if !ok || err != nil {
+			return ok, err
+		}
+	}
+	return true, nil
+}
+
+func (g *graphLock) tryLockGraph(elem _Element, elems OrderedElementSet) (bool, error) {
+	if !elems.Has(elem.Id()) {
+		cur := elem.GetLock()
+		if cur != "" && cur != g.runid {
+			g.r.Info("element {{candidate}} already locked for {{lock}}", "candidate", elem.Id(), "lock", cur)
+			return false, nil
+		}
+		ni := g.infos[elem.Id().GetNamespace()]
+		if ni == nil {
+			g.r.Info("namespace of element {{candidate}} not available", "candidate", elem.Id())
+			return false, nil
+		}
+		ok, err := g.lockNamespace(ni)
+		if !ok || err != nil {
+			if err == nil {
+				g.r.Info("cannot lock namespace {{lockednamespace}} of element {{candidate}} already locked for {{current}}",
+					"lockednamespace", ni.GetNamespaceName(), "candidate", elem.Id(), "current", ni.namespace.GetLock())
+			}
+			return false, err
+		}
+		elems.Add(elem)
+
+		for _, d := range g.children(elem.Id()) {
+			ok, err := g.tryLockGraph(d.(_Element), elems)
+			if !ok || err != nil {
+				return false, err
+			}
+		}
+	}
+	return true, nil
+}
+
+func (g *graphLock) lockElements(keep bool, elems OrderedElementSet) (bool, error) {
+	var ok bool
+	var err error
+
+	for _, ni := range g.locked {
+		ni.pendingElements = map[ElementId]_Element{}
+	}
+
+	g.r.Debug("found {{amount}} elements in graph", "amount", elems.Size())
+	for _, elem := range elems.Order() {
+		g.r.Debug("locking {{nestedelem}}", "nestedelem", elem.Id())
+		ok, err = elem.TryLock(g.r.Objectbase(), g.runid)
+		if err != nil {
+			g.r.Debug("locking failed for {{nestedelem}}", "nestedelem", elem.Id(), "error", err)
+			return false, err
+		}
+		if !keep {
+			g.locked[elem.Id().GetNamespace()].pendingElements[elem.Id()] = elem
+		}
+		if ok {
+			// log.Debug("successfully locked {{nestedelem}}", "nestedelem", elem.Id())
+			g.r.Controller().events.TriggerElementEvent(elem)
+			g.r.Controller().pending.Add(1)
+		}
+	}
+	for _, ni := range g.locked {
+		ni.pendingElements = nil
+	}
+	return true, nil
+}
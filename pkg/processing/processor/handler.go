@@ -7,16 +7,22 @@ import (
 
 var _ database.EventHandler = (*Handler)(nil)
 
+// Handler enqueues database events for objects
+// belonging to the namespace shards of the controller.
 type Handler struct {
-	pool pool.Pool
+	controller *Controller
+	pool       pool.Pool
 }
 
-func newHandler(p pool.Pool) database.EventHandler {
+func newHandler(c *Controller) database.EventHandler {
 	return &Handler{
-		p,
+		c,
+		c.pool,
 	}
 }
 
 func (h *Handler) HandleEvent(id database.ObjectId) {
-	h.pool.EnqueueKey(id)
+	if h.controller.isResponsibleFor(id) {
+		h.pool.EnqueueKey(id)
+	}
 }
@@ -0,0 +1,269 @@
+package processor
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/history"
+	. "github.com/mandelsoft/engine/pkg/processing/mmids"
+	"github.com/mandelsoft/engine/pkg/processing/model"
+	"github.com/mandelsoft/logging"
+)
+
+var ErrNoRunHistory = fmt.Errorf("no run history configured")
+
+// RunHistory persists the records of element runs.
+type RunHistory interface {
+	Record(r *history.Record) error
+	List(ns string, closure bool, match func(r *history.Record) bool) ([]*history.Record, error)
+}
+
+type runRecord struct {
+	runid  RunId
+	record *history.Record
+}
+
+// runRecords keeps track of the records of active element runs
+// and passes every change to the run history.
+type runRecords struct {
+	lock    sync.Mutex
+	log     logging.Logger
+	history RunHistory
+	records map[ElementId]*runRecord
+}
+
+func newRunRecords() *runRecords {
+	return &runRecords{records: map[ElementId]*runRecord{}}
+}
+
+func (h *runRecords) set(log logging.Logger, hist RunHistory) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.log = log
+	h.history = hist
+}
+
+// Start provides the record of an element run. If there is no record
+// for the run, yet, a new one is created. A record of a previous run
+// of the element is finished.
+func (h *runRecords) Start(e _Element, runid RunId) {
+	h.lock.Lock()
+	if h.history == nil {
+		h.lock.Unlock()
+		return
+	}
+
+	var records []*history.Record
+	id := e.Id()
+	if r := h.records[id]; r != nil {
+		if r.runid == runid {
+			h.lock.Unlock()
+			return
+		}
+		records = append(records, h.finish(r, string(e.GetStatus()), "superseded by run "+string(runid), ""))
+		delete(h.records, id)
+	}
+
+	now := time.Now()
+	status := string(e.GetStatus())
+	r := &runRecord{
+		runid: runid,
+		record: &history.Record{
+			Spec: history.RunSpec{
+				RunId:     string(runid),
+				Type:      id.GetType(),
+				Namespace: id.GetNamespace(),
+				Name:      id.GetName(),
+				Phase:     string(id.GetPhase()),
+			},
+			Status: history.RunStatus{
+				Status:      status,
+				StartTime:   now,
+				Transitions: []history.Transition{{Status: status, Time: now}},
+			},
+		},
+	}
+	h.records[id] = r
+	records = append(records, r.record.Copy())
+	h.lock.Unlock()
+
+	h.store(records...)
+}
+
+// Update modifies the record of an active element run.
+func (h *runRecords) Update(id ElementId, runid RunId, mod func(r *history.Record)) {
+	h.lock.Lock()
+	r := h.records[id]
+	if h.history == nil || r == nil || r.runid != runid {
+		h.lock.Unlock()
+		return
+	}
+	mod(r.record)
+	rec := r.record.Copy()
+	h.lock.Unlock()
+
+	h.store(rec)
+}
+
+// Transition records a status change for the active run of an element.
+// An optional message is recorded as error message of the run.
+func (h *runRecords) Transition(id ElementId, status model.Status, msg ...string) {
+	h.lock.Lock()
+	r := h.records[id]
+	if h.history == nil || r == nil {
+		h.lock.Unlock()
+		return
+	}
+	if !h.transition(r.record, string(status), msg...) {
+		h.lock.Unlock()
+		return
+	}
+	rec := r.record.Copy()
+	h.lock.Unlock()
+
+	h.store(rec)
+}
+
+// End finishes the record of an element run.
+func (h *runRecords) End(id ElementId, runid RunId, status model.Status, err error, output string) {
+	h.lock.Lock()
+	r := h.records[id]
+	if h.history == nil || r == nil || r.runid != runid {
+		h.lock.Unlock()
+		return
+	}
+	msg := ""
+	if err != nil {
+		msg = err.Error()
+	}
+	rec := h.finish(r, string(status), msg, output)
+	delete(h.records, id)
+	h.lock.Unlock()
+
+	h.store(rec)
+}
+
+func (h *runRecords) finish(r *runRecord, status string, msg string, output string) *history.Record {
+	h.transition(r.record, status, msg)
+	now := time.Now()
+	r.record.Status.EndTime = &now
+	r.record.Status.OutputVersion = output
+	return r.record
+}
+
+func (h *runRecords) transition(r *history.Record, status string, msg ...string) bool {
+	m := ""
+	if len(msg) > 0 {
+		m = msg[0]
+	}
+	r.Status.Status = status
+	if m != "" {
+		r.Status.Message = m
+	}
+	n := len(r.Status.Transitions)
+	if n > 0 && r.Status.Transitions[n-1].Status == status {
+		if m == "" || r.Status.Transitions[n-1].Message == m {
+			return false
+		}
+		r.Status.Transitions[n-1].Message = m
+		return true
+	}
+	r.Status.Transitions = append(r.Status.Transitions, history.Transition{Status: status, Time: time.Now(), Message: m})
+	return true
+}
+
+func (h *runRecords) store(records ...*history.Record) {
+	for _, r := range records {
+		err := h.history.Record(r)
+		if err != nil {
+			h.log.LogError(err, "cannot record run {{runid}} for {{element}}", "runid", r.Spec.RunId, "element", r.Spec.Element())
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// SetRunHistory sets the history used to record element runs.
+// By default, no runs are recorded.
+func (p *Controller) SetRunHistory(h RunHistory) {
+	p.records.set(p.logging.Logger().WithName("history"), h)
+}
+
+func (p *Controller) runHistory() RunHistory {
+	p.records.lock.Lock()
+	defer p.records.lock.Unlock()
+	return p.records.history
+}
+
+// ObjectRuns provides the recorded runs for the phases of an object.
+// The type may be an internal type or an external type triggering
+// an internal type. If no phase is given, the runs of all phases
+// are returned.
+func (p *Controller) ObjectRuns(oid database.ObjectId, phase Phase) ([]*history.Record, error) {
+	h := p.runHistory()
+	if h == nil {
+		return nil, ErrNoRunHistory
+	}
+	ids, err := p.elementIdsFor(oid, phase)
+	if err != nil {
+		return nil, err
+	}
+	return h.List(oid.GetNamespace(), false, func(r *history.Record) bool {
+		for _, id := range ids {
+			if r.Spec.Type == id.GetType() && r.Spec.Name == id.GetName() && r.Spec.Phase == string(id.GetPhase()) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Run provides the recorded element runs for a run id
+// in a namespace closure.
+func (p *Controller) Run(ns string, runid RunId) ([]*history.Record, error) {
+	h := p.runHistory()
+	if h == nil {
+		return nil, ErrNoRunHistory
+	}
+	return h.List(ns, true, func(r *history.Record) bool {
+		return r.Spec.RunId == string(runid)
+	})
+}
+
+// startRun assures the trace and the record for the actual
+// run of the element.
+func (r *elementRunReconcilation) startRun() {
+	r.runSpan()
+	r.records.Start(r._Element, r.runid)
+}
+
+// endRun finishes the trace and the record for the actual
+// run of the element.
+func (r *elementRunReconcilation) endRun(status model.Status, err error) {
+	output := ""
+	if status == model.STATUS_COMPLETED {
+		output = r.GetCurrentState().GetOutputVersion()
+	}
+	r.runs.Discard(r.eid, r.runid)
+	r.traces.End(r.eid, r.runid, status, err)
+	r.records.End(r.eid, r.runid, status, err, output)
+}
+
+// recordInputs records the inputs used by the actual run.
+func (r *elementRunReconcilation) recordInputs(inputs model.Inputs, formal string) {
+	r.records.Update(r.eid, r.runid, func(rec *history.Record) {
+		rec.Spec.Inputs = nil
+		for id, s := range inputs {
+			if rec.Spec.Inputs == nil {
+				rec.Spec.Inputs = map[string]string{}
+			}
+			rec.Spec.Inputs[id.String()] = s.GetOutputVersion()
+		}
+		if t := r.GetProcessingState(); t != nil {
+			rec.Spec.InputVersion = t.GetInputVersion(inputs)
+		}
+		rec.Spec.FormalVersion = formal
+	})
+}
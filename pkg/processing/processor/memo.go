@@ -0,0 +1,151 @@
+package processor
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/mandelsoft/engine/pkg/metrics"
+	. "github.com/mandelsoft/engine/pkg/processing/mmids"
+	"github.com/mandelsoft/engine/pkg/processing/model"
+)
+
+// DEFAULT_RESULT_CACHE_SIZE is the default number of
+// processing results kept for memoized phases.
+const DEFAULT_RESULT_CACHE_SIZE = 1000
+
+var resultCacheRequests = metrics.NewCounterVec("engine_result_cache_requests_total",
+	"Number of result cache lookups for memoized phases.", "processor", "type", "phase", "result")
+
+func init() {
+	metrics.MustRegister(resultCacheRequests)
+}
+
+// resultKey is the content address of a processing result.
+type resultKey struct {
+	typ           TypeId
+	objectVersion string
+	inputVersion  string
+}
+
+type resultEntry struct {
+	key    resultKey
+	result model.ProcessingResult
+}
+
+// resultCache is a size bounded cache for the results of
+// processing steps. If the cache is full, the least recently
+// used entry is evicted.
+type resultCache struct {
+	lock    sync.Mutex
+	size    int
+	order   *list.List
+	entries map[resultKey]*list.Element
+}
+
+func newResultCache(size int) *resultCache {
+	return &resultCache{
+		size:    size,
+		order:   list.New(),
+		entries: map[resultKey]*list.Element{},
+	}
+}
+
+func (c *resultCache) SetSize(size int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.size = size
+	c.evict()
+}
+
+func (c *resultCache) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.order.Len()
+}
+
+func (c *resultCache) Get(key resultKey) (model.ProcessingResult, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	e := c.entries[key]
+	if e == nil {
+		return model.ProcessingResult{}, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*resultEntry).result, true
+}
+
+func (c *resultCache) Set(key resultKey, result model.ProcessingResult) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.size <= 0 {
+		return
+	}
+	if e := c.entries[key]; e != nil {
+		e.Value.(*resultEntry).result = result
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&resultEntry{key, result})
+	c.evict()
+}
+
+func (c *resultCache) evict() {
+	for c.order.Len() > 0 && c.order.Len() > c.size {
+		e := c.order.Back()
+		c.order.Remove(e)
+		delete(c.entries, e.Value.(*resultEntry).key)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// SetResultCacheSize sets the maximal number of results kept
+// for memoized phases. A size <= 0 disables the cache.
+func (p *Controller) SetResultCacheSize(size int) {
+	p.results.SetSize(size)
+}
+
+func (r *elementRunReconcilation) isMemoized() bool {
+	t := r.MetaModel().GetElementType(r.Id().TypeId())
+	return t != nil && t.Memoized()
+}
+
+func (r *elementRunReconcilation) resultKey(inputs model.Inputs) resultKey {
+	target := r.GetProcessingState()
+	return resultKey{
+		typ:           r.Id().TypeId(),
+		objectVersion: target.GetObjectVersion(),
+		inputVersion:  target.GetInputVersion(inputs),
+	}
+}
+
+// cachedResult provides the result of a former processing step
+// for the same object and input version, if the phase is memoized.
+func (r *elementRunReconcilation) cachedResult(inputs model.Inputs) (model.ProcessingResult, bool) {
+	if !r.isMemoized() || r.GetProcessingState() == nil {
+		return model.ProcessingResult{}, false
+	}
+	result, ok := r.results.Get(r.resultKey(inputs))
+	label := "miss"
+	if ok {
+		label = "hit"
+	}
+	resultCacheRequests.WithLabelValues(r.MetaModel().Name(), r.Id().GetType(), string(r.GetPhase()), label).Inc()
+	return result, ok
+}
+
+// cacheResult remembers the result of a successfully completed
+// processing step, if the phase is memoized.
+func (r *elementRunReconcilation) cacheResult(inputs model.Inputs, result model.ProcessingResult) {
+	if !r.isMemoized() || r.GetProcessingState() == nil {
+		return
+	}
+	if result.Error != nil || result.Status != model.STATUS_COMPLETED || result.ResultState == nil {
+		return
+	}
+	r.results.Set(r.resultKey(inputs), result)
+}
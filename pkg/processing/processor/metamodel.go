@@ -0,0 +1,124 @@
+package processor
+
+import (
+	"slices"
+
+	"github.com/mandelsoft/logging"
+
+	"github.com/mandelsoft/engine/pkg/pool"
+	"github.com/mandelsoft/engine/pkg/processing/metamodel"
+	"github.com/mandelsoft/engine/pkg/processing/mmids"
+	"github.com/mandelsoft/engine/pkg/processing/model"
+)
+
+// UpdateMetaModel applies a new version of the metamodel to the engine.
+// The new version must be compatible with the actual one (see metamodel.Compare),
+// incompatible changes are rejected with a metamodel.IncompatibleChangesError
+// reporting all problems. The encodings of the types must be provided by the
+// scheme of the object base.
+// For a running engine, the handlers and pool actions for added
+// external and update request types are registered, the ones for
+// removed external types are unregistered, the elements
+// for added phases of existing internal objects are set up and
+// the elements of modified internal types are triggered.
+func (p *Controller) UpdateMetaModel(spec metamodel.MetaModelSpecification) (*metamodel.Changes, error) {
+	p.modelLock.Lock()
+	defer p.modelLock.Unlock()
+
+	log := p.logging.Logger().WithName("metamodel")
+
+	old := p.MetaModel()
+	mm, err := metamodel.NewMetaModel(old.Name(), spec)
+	if err != nil {
+		return nil, err
+	}
+	err = model.ValidateTypes(mm, p.Objectbase().SchemeTypes())
+	if err != nil {
+		return nil, err
+	}
+	changes, err := metamodel.Compare(old, mm, p.hasObjects)
+	if err != nil {
+		log.Info("metamodel update rejected", "error", err)
+		return nil, err
+	}
+
+	p.processingModel.setMetaModel(mm)
+	log.Info("metamodel updated")
+	if p.handler == nil {
+		// handlers are registered by Start.
+		return changes, nil
+	}
+
+	for _, t := range changes.RemovedExternalTypes {
+		log.Info("unregister handler for removed external type {{exttype}}", "exttype", t)
+		p.registry.UnregisterHandler(p.handler, t, true, "/")
+		p.pool.RemoveAction(pool.ObjectType(t), p.extReconcile)
+	}
+	for _, t := range changes.ExternalTypes {
+		log.Info("register handler for added external type {{exttype}}", "exttype", t)
+		p.pool.AddAction(pool.ObjectType(t), p.extReconcile)
+		p.registry.RegisterHandler(p.handler, true, t, true, "/")
+	}
+	if req := changes.UpdateRequestType; req != "" {
+		log.Info("register handler for added update request type {{reqtype}}", "reqtype", req)
+		p.pool.AddAction(pool.ObjectType(req), newUpdateRequestReconciler(p))
+		p.registry.RegisterHandler(p.handler, true, req, true, "/")
+	}
+
+	err = p.setupPhases(log, changes.Phases)
+	if err != nil {
+		return changes, err
+	}
+
+	c := 0
+	for _, n := range p.processingModel.Namespaces() {
+		ns := p.processingModel.GetNamespace(n)
+		if ns == nil {
+			continue
+		}
+		for _, id := range ns.Elements() {
+			if slices.Contains(changes.ModifiedTypes, id.GetType()) {
+				p.EnqueueKey(CMD_ELEM, id)
+				c++
+			}
+		}
+	}
+	log.Info("{{amount}} elements of modified types triggered", "amount", c)
+	return changes, nil
+}
+
+// hasObjects checks whether there are objects of the given type.
+func (p *Controller) hasObjects(typ string) (bool, error) {
+	objs, err := p.Objectbase().ListObjects(typ, true, "")
+	if err != nil {
+		return false, err
+	}
+	return len(objs) > 0, nil
+}
+
+// setupPhases sets up the elements for added phases
+// of existing internal objects.
+func (p *Controller) setupPhases(log logging.Logger, phases map[string][]mmids.Phase) error {
+	for t, added := range phases {
+		log.Info("setup added phases {{phases}} for internal type {{inttype}}", "inttype", t, "phases", added)
+		objs, err := p.Objectbase().ListObjects(t, true, "")
+		if err != nil {
+			return err
+		}
+		for _, _o := range objs {
+			if !p.isResponsible(_o.GetNamespace()) {
+				continue
+			}
+			ni, err := p.processingModel.AssureNamespace(log, _o.GetNamespace(), true)
+			if err != nil {
+				return err
+			}
+			o := _o.(model.InternalObject)
+			for _, ph := range added {
+				e := ni._AddElement(o, ph)
+				log.Debug("  added element {{element}}", "element", e.Id())
+			}
+		}
+	}
+	return nil
+}
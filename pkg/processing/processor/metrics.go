@@ -0,0 +1,81 @@
+package processor
+
+import (
+	"context"
+	"time"
+
+	"github.com/mandelsoft/engine/pkg/metrics"
+	. "github.com/mandelsoft/engine/pkg/processing/mmids"
+	"github.com/mandelsoft/engine/pkg/processing/model"
+	"github.com/mandelsoft/goutils/maputils"
+)
+
+var phaseDuration = metrics.NewHistogramVec("engine_phase_run_duration_seconds",
+	"Duration of the processing step of a phase.", nil, "processor", "type", "phase")
+
+func init() {
+	metrics.MustRegister(phaseDuration)
+}
+
+func observePhaseRun(processor string, id ElementId, start time.Time) {
+	phaseDuration.WithLabelValues(processor, id.GetType(), string(id.GetPhase())).Observe(time.Since(start).Seconds())
+}
+
+// controllerMetrics provides the metrics describing the
+// state of a controller. They are calculated at collection time.
+type controllerMetrics struct {
+	controller *Controller
+}
+
+var _ metrics.Collector = (*controllerMetrics)(nil)
+
+func (c *controllerMetrics) Collect() []metrics.Family {
+	p := c.controller
+	name := p.MetaModel().Name()
+
+	elements := metrics.Family{
+		Name: "engine_elements",
+		Help: "Number of elements per namespace and status.",
+		Type: metrics.TYPE_GAUGE,
+	}
+	for _, n := range p.processingModel.Namespaces() {
+		ns := p.processingModel.GetNamespace(n)
+		if ns == nil {
+			continue
+		}
+		count := map[model.Status]int{}
+		for _, id := range ns.Elements() {
+			if e := ns.GetElement(id); e != nil {
+				count[e.GetStatus()]++
+			}
+		}
+		for _, s := range maputils.OrderedKeys(count) {
+			elements.Samples = append(elements.Samples, metrics.Sample{
+				Labels: metrics.NewLabels("processor", name, "namespace", n, "status", string(s)),
+				Value:  float64(count[s]),
+			})
+		}
+	}
+
+	pending := metrics.Family{
+		Name: "engine_pending_elements",
+		Help: "Number of elements with pending processing.",
+		Type: metrics.TYPE_GAUGE,
+		Samples: []metrics.Sample{{
+			Labels: metrics.NewLabels("processor", name),
+			Value:  float64(p.pending.Get()),
+		}},
+	}
+	return []metrics.Family{elements, pending}
+}
+
+// registerMetrics registers the controller metrics
+// for the lifetime of the given context.
+func (p *Controller) registerMetrics(ctx context.Context) {
+	c := &controllerMetrics{p}
+	metrics.MustRegister(c)
+	go func() {
+		<-ctx.Done()
+		metrics.Unregister(c)
+	}()
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 
 	. "github.com/mandelsoft/engine/pkg/processing/mmids"
 	"github.com/mandelsoft/goutils/generics"
@@ -22,21 +23,26 @@ type processingModel struct {
 	lock sync.Mutex
 
 	m  model.Model
-	mm metamodel.MetaModel
+	mm atomic.Pointer[metamodel.MetaModel]
 	ob objectbase.Objectbase
 
 	namespaces map[string]*namespaceInfo
+	// linked maps namespaces to the other namespaces hosting
+	// elements with links to elements of the namespace.
+	linked map[string]map[string]struct{}
 }
 
 var _ ProcessingModel = (*processingModel)(nil)
 
 func newProcessingModel(m model.Model) *processingModel {
-	return &processingModel{
+	p := &processingModel{
 		m:          m,
-		mm:         m.MetaModel(),
 		ob:         m.Objectbase(),
 		namespaces: map[string]*namespaceInfo{"": newNamespaceInfo(model.NewRootNamespace(m.MetaModel().NamespaceType()))},
+		linked:     map[string]map[string]struct{}{},
 	}
+	p.setMetaModel(m.MetaModel())
+	return p
 }
 
 func (p *processingModel) ObjectBase() objectbase.Objectbase {
@@ -44,7 +50,12 @@ func (p *processingModel) ObjectBase() objectbase.Objectbase {
 }
 
 func (p *processingModel) MetaModel() metamodel.MetaModel {
-	return p.mm
+	return *p.mm.Load()
+}
+
+// setMetaModel replaces the metamodel used for processing.
+func (p *processingModel) setMetaModel(mm metamodel.MetaModel) {
+	p.mm.Store(&mm)
 }
 
 func (p *processingModel) SchemeTypes() objectbase.SchemeTypes {
@@ -83,6 +94,81 @@ func (p *processingModel) getElement(id ElementId) _Element {
 	return ni._GetElement(id)
 }
 
+// _getNamespaceInfo provides the info for a namespace
+// without creating it.
+func (m *processingModel) _getNamespaceInfo(name string) *namespaceInfo {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.namespaces[name]
+}
+
+// childNamespaces provides the namespace infos, which may host
+// elements depending on elements of the given namespace.
+// Other namespaces are only relevant for metamodels with
+// cross namespace dependencies. They are only provided, if they
+// host elements linking elements of the given namespace, and
+// they are ordered by name.
+func (m *processingModel) childNamespaces(ni *namespaceInfo) []*namespaceInfo {
+	if !m.MetaModel().HasCrossNamespaceDependencies() {
+		return []*namespaceInfo{ni}
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	result := []*namespaceInfo{ni}
+	for _, n := range maputils.OrderedKeys(m.linked[ni.GetNamespaceName()]) {
+		if o := m.namespaces[n]; o != nil && o != ni {
+			result = append(result, o)
+		}
+	}
+	return result
+}
+
+// addLinks registers the namespaces of elements of other namespaces
+// linked by the given element.
+func (m *processingModel) addLinks(id ElementId, links ...ElementId) {
+	if !m.MetaModel().HasCrossNamespaceDependencies() {
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for _, l := range links {
+		if l.GetNamespace() == id.GetNamespace() {
+			continue
+		}
+		s := m.linked[l.GetNamespace()]
+		if s == nil {
+			s = map[string]struct{}{}
+			m.linked[l.GetNamespace()] = s
+		}
+		s[id.GetNamespace()] = struct{}{}
+	}
+}
+
+// removeLinks forgets the registered links for a removed namespace.
+func (m *processingModel) removeLinks(name string) {
+	delete(m.linked, name)
+	for _, s := range m.linked {
+		delete(s, name)
+	}
+}
+
+// GetChildren provides the elements depending on the given element
+// in all namespaces.
+// It must not be called while holding a namespace info lock.
+func (m *processingModel) GetChildren(id ElementId) []Element {
+	ni := m._getNamespaceInfo(id.GetNamespace())
+	if ni == nil {
+		return nil
+	}
+	var r []Element
+	for _, c := range m.childNamespaces(ni) {
+		r = append(r, c.GetChildren(id)...)
+	}
+	return r
+}
+
 func (m *processingModel) AssureNamespace(log logging.Logger, name string, create bool) (*namespaceInfo, error) {
 	m.lock.Lock()
 	defer m.lock.Unlock()
@@ -94,14 +180,14 @@ func (m *processingModel) assureNamespace(log logging.Logger, name string, creat
 	ni := m.namespaces[name]
 	if ni == nil {
 		nns, nn := NamespaceId(name)
-		b, err := m.ob.GetObject(database.NewObjectId(m.mm.NamespaceType(), nns, nn))
+		b, err := m.ob.GetObject(database.NewObjectId(m.MetaModel().NamespaceType(), nns, nn))
 		if err != nil {
 			if !errors.Is(err, database.ErrNotExist) || !create {
 				log.Error("cannot get namespace object for {{namespace}}", "namespace", name)
 				return nil, err
 			}
 			log.Info("creating namespace object for {{namespace}}", "namespace", name)
-			b, err = m.ob.SchemeTypes().CreateObject(m.mm.NamespaceType(), objectbase.SetObjectName(nns, nn))
+			b, err = m.ob.SchemeTypes().CreateObject(m.MetaModel().NamespaceType(), objectbase.SetObjectName(nns, nn))
 			if err != nil {
 				log.Error("cannot create namespace object for {{namespace}}", "namespace", name)
 				return nil, err
@@ -129,14 +215,38 @@ func (m *processingModel) RemoveNamespace(log logging.Logger, ni *namespaceInfo)
 	// Only relevant for UI
 	m.ob.DeleteObject(ni.namespace)
 	delete(m.namespaces, ni.GetNamespaceName())
+	m.removeLinks(ni.GetNamespaceName())
 	return true
 }
 
+// DropNamespaces removes the namespaces matching the filter from
+// the processing model without touching their database objects.
+// It is used to hand over namespaces to another processor.
+func (m *processingModel) DropNamespaces(filter func(ns string) bool) []string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	var dropped []string
+	for name, ni := range m.namespaces {
+		if !filter(name) {
+			continue
+		}
+		if name == "" {
+			m.namespaces[name] = newNamespaceInfo(ni.namespace)
+		} else {
+			delete(m.namespaces, name)
+		}
+		m.removeLinks(name)
+		dropped = append(dropped, name)
+	}
+	return dropped
+}
+
 func (m *processingModel) AssureElementObjectFor(log logging.Logger, e model.ExternalObject) (Element, error) {
 	m.lock.Lock()
 	defer m.lock.Unlock()
 
-	t := m.mm.GetPhaseFor(e.GetType())
+	t := m.MetaModel().GetPhaseFor(e.GetType())
 	if t == nil {
 		return nil, NonTemporaryError(fmt.Errorf("external object type %q not configured", e.GetType()))
 	}
@@ -166,7 +276,7 @@ func (m *processingModel) AssureElementObjectFor(log logging.Logger, e model.Ext
 		}
 
 		ns.internal[mmids.NewObjectIdFor(i)] = i
-		for _, ph := range m.mm.GetInternalType(t.GetType()).Phases() {
+		for _, ph := range m.MetaModel().GetInternalType(t.GetType()).Phases() {
 			id := mmids.NewElementId(t.GetType(), e.GetNamespace(), e.GetName(), ph)
 			pe := newElement(ph, i)
 			ns.elements[id] = pe
@@ -13,6 +13,7 @@ import (
 	"github.com/mandelsoft/engine/pkg/processing/internal"
 	"github.com/mandelsoft/engine/pkg/processing/mmids"
 	"github.com/mandelsoft/engine/pkg/processing/model"
+	"github.com/mandelsoft/engine/pkg/processing/objectbase"
 	"github.com/mandelsoft/logging"
 )
 
@@ -112,6 +113,8 @@ func (ni *namespaceInfo) clearElementLock(r Reconcilation, elem _Element, rid Ru
 		r.TriggerElementEvent(elem)
 		r.Controller().pending.Add(-1)
 	}
+	r.Controller().traces.End(elem.Id(), rid, elem.GetStatus(), fmt.Errorf("lock released"))
+	r.Controller().records.End(elem.Id(), rid, elem.GetStatus(), fmt.Errorf("lock released"), "")
 	return nil
 }
 
@@ -185,6 +188,23 @@ func (ni *namespaceInfo) getChildren(id ElementId) []Element {
 	return r
 }
 
+// getSlaves provides the existing slave elements assured by
+// the given element.
+func (ni *namespaceInfo) getSlaves(master ElementId) []ElementId {
+	ni.lock.Lock()
+	defer ni.lock.Unlock()
+
+	var r []ElementId
+	for eid, e := range ni.elements {
+		s := e.GetObject().GetSlaveInfo(eid.GetPhase())
+		if s != nil && s.Master(eid.GetNamespace()) == master {
+			r = append(r, eid)
+		}
+	}
+	slices.SortFunc(r, CompareElementId)
+	return r
+}
+
 func (ni *namespaceInfo) list(typ string) []ElementId {
 	ni.lock.Lock()
 	defer ni.lock.Unlock()
@@ -199,37 +219,45 @@ func (ni *namespaceInfo) list(typ string) []ElementId {
 	return list
 }
 
-func (ni *namespaceInfo) assureSlaves(log logging.Logger, p *Controller, check model.SlaveCheckFunction, update model.SlaveUpdateFunction, runid RunId, eids ...ElementId) error {
+// assureSlaves assures the given slave elements and provides
+// the ids of the newly created ones.
+func (ni *namespaceInfo) assureSlaves(log logging.Logger, p *Controller, ob objectbase.Objectbase, check model.SlaveCheckFunction, update model.SlaveUpdateFunction, master ElementId, runid RunId, eids ...ElementId) ([]ElementId, error) {
 	ni.lock.Lock()
 	defer ni.lock.Unlock()
 
 	// first, check existing objects
 	for _, eid := range eids {
 		if !p.processingModel.MetaModel().HasElementType(eid.TypeId()) {
-			return fmt.Errorf("unknown element type %q for slave", eid.TypeId())
+			return nil, fmt.Errorf("unknown element type %q for slave", eid.TypeId())
 		}
 		e := ni.elements[eid]
 		if e != nil && check != nil {
 			err := check(e.GetObject())
 			if err != nil {
-				return err
+				return nil, err
 			}
 		}
 	}
 
 	// second, update/create required objects
+	var created []ElementId
 	for _, eid := range eids {
 		e := ni.elements[eid]
 		if e == nil {
-			i, err := update(p.processingModel.ObjectBase(), eid, nil)
+			i, err := update(ob, eid, nil)
 			if err != nil {
-				return err
+				return created, err
 			}
 			_, err = i.AddFinalizer(p.processingModel.ObjectBase(), FINALIZER)
 			if err != nil {
-				return err
+				return created, err
 			}
 			e = ni.setupElements(log, p, i, eid.GetPhase(), runid)
+			created = append(created, eid)
+		}
+		_, err := e.GetObject().SetMaster(ob, eid.GetPhase(), master)
+		if err != nil {
+			return created, err
 		}
 		// always trigger new elements, because they typically have no correct current state dependencies.
 		// Those dependencies are configured in form of a state change.
@@ -237,7 +265,7 @@ func (ni *namespaceInfo) assureSlaves(log logging.Logger, p *Controller, check m
 		// of the external object)
 		p.Enqueue(CMD_ELEM, e)
 	}
-	return nil
+	return created, nil
 }
 
 func (ni *namespaceInfo) setupElements(log logging.Logger, p *Controller, i model.InternalObject, phase Phase, runid RunId) _Element {
@@ -274,16 +302,21 @@ func (ni *namespaceInfo) RemoveInternal(log logging.Logger, m *processingModel,
 	return len(ni.internal) == 0
 }
 
+// LockGraph locks the graph of elements depending on the given element
+// for a new run id. For metamodels with cross namespace dependencies,
+// the graph may include elements of other namespaces.
+// If the graph cannot be locked, yet, no run id is returned.
 func (ni *namespaceInfo) LockGraph(r Reconcilation, elem _Element) (*RunId, error) {
 	id := NewRunId()
 
-	if !ni.lock.TryLock() {
+	g := newGraphLock(r, id)
+	if !g.acquire(r.Controller().processingModel.childNamespaces(ni)...) {
 		return nil, nil
 	}
-	defer ni.lock.Unlock()
+	defer g.release()
 
 	log := r.WithValues("runid", id)
-	ok, err := ni.tryLock(r, id)
+	ok, err := g.lockNamespace(ni)
 	if err != nil {
 		log.Info("locking namespace {{namespace}} for new runid {{runid}} failed", "error", err)
 		return nil, err
@@ -293,78 +326,24 @@ func (ni *namespaceInfo) LockGraph(r Reconcilation, elem _Element) (*RunId, erro
 		return nil, nil
 	}
 	log.Info("namespace {{namespace}} locked for new runid {{runid}}")
-	defer func() {
-		err := ni.clearLocks(r)
-		if err != nil {
-			log.Error("cannot clear namespace lock for {{namespace}} -> requeue", "error", err)
-			r.Controller().EnqueueNamespace(ni.GetNamespaceName())
-		}
-	}()
 
-	ok, err = ni.doLockGraph(r, id, false, elem)
+	ok, err = g.lockGraph(false, elem)
 	if !ok || err != nil {
 		return nil, err
 	}
 	return &id, nil
 }
 
+// doLockGraph locks the graphs of the given candidates for a run id
+// the namespace is already locked for. The namespace info must be locked
+// by the caller. Children in other namespaces are considered, too,
+// if their namespace infos are not busy.
 func (ni *namespaceInfo) doLockGraph(r Reconcilation, runid RunId, keep bool, candidates ..._Element) (bool, error) {
-	elems := NewOrderedElementSet()
-	for _, elem := range candidates {
-		ok, err := ni._tryLockGraph(r, runid, elem, elems)
-		if !ok || err != nil {
-			return false, err
-		}
-		ok, err = ni._lockGraph(r, runid, keep, elems)
-		if !ok || err != nil {
-			return ok, err
-		}
-	}
-	return true, nil
-}
-
-func (ni *namespaceInfo) _tryLockGraph(r Reconcilation, runid RunId, elem _Element, elems OrderedElementSet) (bool, error) {
-	if !elems.Has(elem.Id()) {
-		cur := elem.GetLock()
-		if cur != "" && cur != runid {
-			r.Info("element {{candidate}} already locked for {{lock}}", "candidate", elem.Id(), "lock", cur)
-			return false, nil
-		}
-		elems.Add(elem)
-
-		for _, d := range ni.getChildren(elem.Id()) {
-			ok, err := ni._tryLockGraph(r, runid, d.(_Element), elems)
-			if !ok || err != nil {
-				return false, err
-			}
-		}
-	}
-	return true, nil
-}
-
-func (ni *namespaceInfo) _lockGraph(r Reconcilation, id RunId, keep bool, elems OrderedElementSet) (bool, error) {
-	var ok bool
-	var err error
-
-	ni.pendingElements = map[ElementId]_Element{}
-
-	r.Debug("found {{amount}} elements in graph", "amount", elems.Size())
-	for _, elem := range elems.Order() {
-		r.Debug("locking {{nestedelem}}", "nestedelem", elem.Id())
-		ok, err = elem.TryLock(r.Objectbase(), id)
-		if err != nil {
-			r.Debug("locking failed for {{nestedelem}}", "nestedelem", elem.Id(), "error", err)
-			return false, err
-		}
-		if !keep {
-			ni.pendingElements[elem.Id()] = elem
-		}
-		if ok {
-			// log.Debug("successfully locked {{nestedelem}}", "nestedelem", elem.Id())
-			r.Controller().events.TriggerElementEvent(elem)
-			r.Controller().pending.Add(1)
-		}
+	g := newGraphLock(r, runid)
+	g.use(ni)
+	if !g.acquire(r.Controller().processingModel.childNamespaces(ni)...) {
+		return false, fmt.Errorf("namespaces of dependent elements busy")
 	}
-	ni.pendingElements = nil
-	return true, nil
+	defer g.release()
+	return g.lockGraph(keep, candidates...)
 }
@@ -0,0 +1,229 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/pool"
+	. "github.com/mandelsoft/engine/pkg/processing/mmids"
+	"github.com/mandelsoft/engine/pkg/processing/model"
+	"github.com/mandelsoft/goutils/maputils"
+)
+
+// DEFAULT_ORPHAN_SCAN_INTERVAL is the default interval used to
+// scan for orphaned internal objects.
+const DEFAULT_ORPHAN_SCAN_INTERVAL = 5 * time.Minute
+
+// SetOrphanScan configures the periodic scan for orphaned internal
+// objects. Orphans are internal objects without triggering external
+// object and without dependent elements. They are reported, and,
+// if delete is set, their phases are marked for deletion.
+// An interval <= 0 disables the scan.
+func (p *Controller) SetOrphanScan(interval time.Duration, delete bool) {
+	p.orphanScanInterval = interval
+	p.orphanDeletion = delete
+}
+
+// Orphans provides the ids of all orphaned internal objects.
+func (p *Controller) Orphans() ([]ObjectId, error) {
+	var result []ObjectId
+	for _, n := range p.processingModel.Namespaces() {
+		ni := p.processingModel._getNamespaceInfo(n)
+		if ni == nil {
+			continue
+		}
+		for _, i := range ni.internalObjects() {
+			ok, err := p.isOrphan(ni, i)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				result = append(result, NewObjectIdFor(i))
+			}
+		}
+	}
+	return result, nil
+}
+
+// DeleteOrphans requests the deletion of all orphaned internal objects.
+// The phases are marked for deletion by the element reconciler, which
+// checks the orphan state again, and the regular deletion flow is triggered.
+// It provides the ids of the affected objects.
+func (p *Controller) DeleteOrphans() ([]ObjectId, error) {
+	list, err := p.Orphans()
+	if err != nil {
+		return nil, err
+	}
+	for _, oid := range list {
+		p.deleteOrphan(oid)
+	}
+	return list, nil
+}
+
+func (p *Controller) isOrphan(ni *namespaceInfo, i model.InternalObject) (bool, error) {
+	mm := p.MetaModel()
+	triggers := mm.GetTriggeringTypesForInternalType(i.GetType())
+	if len(triggers) == 0 {
+		// pure slave types cannot be judged
+		return false, nil
+	}
+
+	for _, ph := range mm.GetInternalType(i.GetType()).Phases() {
+		e := ni._GetElement(NewElementIdForPhase(i, ph))
+		if e != nil && (e.GetLock() != "" || e.IsMarkedForDeletion()) {
+			return false, nil
+		}
+	}
+
+	for _, t := range triggers {
+		_, err := p.Objectbase().GetObject(database.NewObjectId(t, i.GetNamespace(), i.GetName()))
+		if err == nil {
+			return false, nil
+		}
+		if !errors.Is(err, database.ErrNotExist) {
+			return false, err
+		}
+	}
+	return !p.processingModel.hasDependants(ni, NewObjectIdFor(i)), nil
+}
+
+// deleteOrphan enqueues the triggered elements of an orphaned
+// internal object for deletion.
+func (p *Controller) deleteOrphan(oid ObjectId) {
+	for _, t := range p.MetaModel().GetTriggeringTypesForInternalType(oid.GetType()) {
+		tid := p.MetaModel().GetPhaseFor(t)
+		if tid == nil {
+			continue
+		}
+		p.EnqueueKey(CMD_ORPHAN, NewElementIdForObject(*tid, oid))
+	}
+}
+
+// reconcileOrphan marks an element of an orphaned internal object
+// for deletion. The orphan state is checked again, because it
+// might have changed since the scan.
+func (r *elementReconciler) reconcileOrphan(ctx model.Logging, eid ElementId) pool.Status {
+	p := r.Controller()
+	log := ctx.Logger().WithValues("element", eid)
+
+	ni := p.processingModel._getNamespaceInfo(eid.GetNamespace())
+	if ni == nil {
+		return pool.StatusCompleted()
+	}
+	i := ni.internalObject(eid.ObjectId())
+	if i == nil {
+		return pool.StatusCompleted()
+	}
+	ok, err := p.isOrphan(ni, i)
+	if err != nil || !ok {
+		if err == nil {
+			log.Info("internal object of {{element}} is not orphaned anymore")
+		}
+		return pool.StatusCompleted(err)
+	}
+	e := ni._GetElement(eid)
+	if e == nil || e.IsMarkedForDeletion() {
+		return pool.StatusCompleted()
+	}
+
+	log.Info("mark orphaned element {{element}} for deletion")
+	_, _, leafs, err := e.MarkForDeletion(p.processingModel)
+	if err != nil {
+		return pool.StatusCompleted(err)
+	}
+	log.Info("triggering leaf phases {{phases}} for deletion", "phases", leafs)
+	for _, phase := range leafs {
+		p.EnqueueKey(CMD_ELEM, NewElementIdForPhase(e, phase))
+	}
+	return pool.StatusCompleted()
+}
+
+// orphanScanner periodically checks for orphaned internal objects.
+func (p *Controller) orphanScanner(ctx context.Context) {
+	if p.orphanScanInterval <= 0 {
+		return
+	}
+	log := p.logging.Logger().WithName("orphans")
+	log.Info("starting orphan scanner with interval {{interval}} (deletion {{deletion}})", "interval", p.orphanScanInterval, "deletion", p.orphanDeletion)
+	ticker := time.NewTicker(p.orphanScanInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("stopping orphan scanner")
+			return
+		case <-ticker.C:
+			var list []ObjectId
+			var err error
+			if p.orphanDeletion {
+				list, err = p.DeleteOrphans()
+			} else {
+				list, err = p.Orphans()
+			}
+			if err != nil {
+				log.LogError(err, "orphan scan failed")
+				continue
+			}
+			for _, oid := range list {
+				log.Info("found orphaned internal object {{object}}", "object", oid)
+			}
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func (ni *namespaceInfo) internalObject(oid ObjectId) model.InternalObject {
+	ni.lock.Lock()
+	defer ni.lock.Unlock()
+
+	return ni.internal[oid]
+}
+
+func (ni *namespaceInfo) internalObjects() []model.InternalObject {
+	ni.lock.Lock()
+	defer ni.lock.Unlock()
+
+	return maputils.Values(ni.internal)
+}
+
+// hasDependants checks whether elements of other internal objects
+// in the namespace or in namespaces linking elements of the namespace
+// are linked to elements of the given internal object.
+func (m *processingModel) hasDependants(ni *namespaceInfo, oid ObjectId) bool {
+	for _, c := range m.childNamespaces(ni) {
+		if c.hasDependants(oid) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasDependants checks whether elements of other internal objects
+// of the namespace are linked to elements of the given internal object.
+func (ni *namespaceInfo) hasDependants(oid ObjectId) bool {
+	ni.lock.Lock()
+	defer ni.lock.Unlock()
+
+	for id, e := range ni.elements {
+		if id.ObjectId() == oid || e.GetStatus() == model.STATUS_DELETED {
+			continue
+		}
+		var links []ElementId
+		if s := e.GetCurrentState(); s != nil {
+			links = s.GetLinks()
+		}
+		if t := e.GetProcessingState(); t != nil {
+			links = append(slices.Clone(links), t.GetLinks()...)
+		}
+		for _, l := range links {
+			if l.ObjectId() == oid {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,209 @@
+package processor
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	. "github.com/mandelsoft/engine/pkg/processing/mmids"
+	"github.com/mandelsoft/engine/pkg/processing/model"
+	"github.com/mandelsoft/engine/pkg/version"
+)
+
+// PlanAction describes the predicted effect of
+// a change on an element.
+type PlanAction string
+
+const (
+	// PLAN_CREATE indicates a new element for a new external object.
+	PLAN_CREATE PlanAction = "create"
+	// PLAN_RUN indicates an element, which will be processed.
+	PLAN_RUN PlanAction = "run"
+	// PLAN_NONE indicates an element, which will be skipped,
+	// because its effective version is not changed.
+	PLAN_NONE PlanAction = "none"
+)
+
+// PlannedElement describes the predicted effect of
+// proposed changes on an element.
+type PlannedElement struct {
+	Id ElementId
+	// Trigger is the id of the proposed external object
+	// triggering the element, if the element is
+	// directly affected by a proposed change.
+	Trigger database.ObjectId
+	Action  PlanAction
+
+	// OldVersion and NewVersion are the formal versions
+	// of the element before and after the change.
+	OldVersion string
+	NewVersion string
+}
+
+// Plan predicts which elements are reprocessed if the given external
+// objects are applied. The target state assignment and the version
+// propagation is simulated on the current element graph without
+// locking or persisting anything.
+// The formal versions are evaluated on the object versions
+// of the elements, where the object version of a triggered element
+// is taken from the external state of the proposed object.
+// Because the links of the elements are taken from their current
+// state, changed dependencies of proposed objects are not considered.
+func (p *Controller) Plan(objs ...model.ExternalObject) ([]*PlannedElement, error) {
+	mm := p.MetaModel()
+
+	pl := &plan{
+		controller: p,
+		namespace:  planNamespace(objs),
+		proposed:   map[ElementId]string{},
+		triggers:   map[ElementId]database.ObjectId{},
+		elements:   map[ElementId]_Element{},
+	}
+
+	var affected []ElementId
+	for _, o := range objs {
+		t := mm.GetTriggedElementType(o.GetType())
+		if t == nil {
+			return nil, fmt.Errorf("%s: external object type %q not configured", database.StringId(o), o.GetType())
+		}
+		id := NewElementIdForType(t.Id(), o.GetNamespace(), o.GetName())
+		if _, ok := pl.triggers[id]; ok {
+			return nil, fmt.Errorf("%s: multiple changes for element %s", database.StringId(o), id)
+		}
+		pl.triggers[id] = database.NewObjectIdFor(o)
+
+		var state model.ExternalState
+		if e := pl.element(id); e != nil {
+			state = e.GetExternalState(o)
+		} else {
+			state = o.GetState()
+		}
+		pl.proposed[id] = state.GetVersion()
+		affected = append(affected, id)
+	}
+
+	// determine all elements depending on the triggered ones.
+	for i := 0; i < len(affected); i++ {
+		for _, c := range p.processingModel.GetChildren(affected[i]) {
+			if !slices.Contains(affected, c.Id()) {
+				affected = append(affected, c.Id())
+			}
+		}
+	}
+
+	oldg, err := pl.evaluate(affected, false)
+	if err != nil {
+		return nil, err
+	}
+	newg, err := pl.evaluate(affected, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*PlannedElement
+	for _, id := range affected {
+		vid := versionIdIn(pl.namespace, id)
+		e := &PlannedElement{
+			Id:         id,
+			Trigger:    pl.triggers[id],
+			NewVersion: newg.FormalVersion(vid),
+		}
+		switch {
+		case pl.element(id) == nil:
+			e.Action = PLAN_CREATE
+		default:
+			e.OldVersion = oldg.FormalVersion(vid)
+			if e.OldVersion != e.NewVersion {
+				e.Action = PLAN_RUN
+			} else {
+				e.Action = PLAN_NONE
+			}
+		}
+		result = append(result, e)
+	}
+	return result, nil
+}
+
+type plan struct {
+	controller *Controller
+	// namespace is the namespace the version node ids
+	// are qualified for.
+	namespace string
+	proposed  map[ElementId]string
+	triggers  map[ElementId]database.ObjectId
+	elements  map[ElementId]_Element
+}
+
+// planNamespace provides the namespace of the first proposed object.
+func planNamespace(objs []model.ExternalObject) string {
+	if len(objs) == 0 {
+		return ""
+	}
+	return objs[0].GetNamespace()
+}
+
+func (p *plan) element(id ElementId) _Element {
+	if e, ok := p.elements[id]; ok {
+		return e
+	}
+	e := p.controller.processingModel._GetElement(id)
+	p.elements[id] = e
+	return e
+}
+
+// objectVersion provides the object version of an element.
+// The target object version of an element with an active run
+// is preferred over the one of its current state.
+func (p *plan) objectVersion(e _Element) string {
+	if t := e.GetProcessingState(); t != nil {
+		if v := t.GetObjectVersion(); v != "" {
+			return v
+		}
+	}
+	if c := e.GetCurrentState(); c != nil {
+		return c.GetObjectVersion()
+	}
+	return ""
+}
+
+// evaluate evaluates the formal versions for a set of elements
+// using their current object versions, or the proposed
+// ones for triggered elements.
+func (p *plan) evaluate(ids []ElementId, proposed bool) (version.EvaluatedGraph, error) {
+	g := version.NewGraph()
+	done := map[ElementId]bool{}
+
+	var add func(id ElementId)
+	add = func(id ElementId) {
+		if done[id] {
+			return
+		}
+		done[id] = true
+
+		v := ""
+		var links []ElementId
+		e := p.element(id)
+		if e != nil {
+			v = p.objectVersion(e)
+			if c := e.GetCurrentState(); c != nil {
+				links = c.GetLinks()
+			}
+		}
+		if n, ok := p.proposed[id]; ok && proposed {
+			v = n
+		}
+		var deps []version.Id
+		for _, l := range links {
+			deps = append(deps, versionIdIn(p.namespace, l))
+		}
+		g.AddNode(version.NewNodeById(versionIdIn(p.namespace, id), v, deps...))
+		for _, l := range links {
+			add(l)
+		}
+	}
+
+	for _, id := range ids {
+		add(id)
+	}
+	return version.EvaluateGraph(g, p.controller.composer)
+}
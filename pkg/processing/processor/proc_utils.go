@@ -119,19 +119,46 @@ func (p *Controller) isDeleting(objs ...model.ExternalObject) bool {
 	return false
 }
 
+// verifyLinks checks the links of an element against the metamodel.
+// Links to elements of other namespaces are only possible, if those
+// namespaces are handled by the same shard. Valid links are registered
+// for the processing model.
 func (p *Controller) verifyLinks(e _Element, links ...ElementId) error {
 	for _, l := range links {
 		if err := p.processingModel.MetaModel().VerifyLink(e.Id(), l); err != nil {
 			return err
 		}
+		if l.GetNamespace() != e.Id().GetNamespace() && !p.isResponsible(l.GetNamespace()) {
+			return fmt.Errorf("from %q to %q: namespace %q is handled by another shard", e.Id(), l, l.GetNamespace())
+		}
 	}
+	p.processingModel.addLinks(e.Id(), links...)
 	return nil
 }
 
-func formalInputVersions(inputs model.Inputs) []string {
-	return maputils.Values(maputils.Transform(inputs, mapInputsToVersions), version.CompareId)
+// formalInputVersions provides the formal versions of the inputs
+// of an element in namespace ns ordered by their version ids.
+func formalInputVersions(ns string, inputs model.Inputs) []string {
+	return maputils.Values(maputils.Transform(inputs, mapInputsToVersions(ns)), version.CompareId)
+}
+
+// versionId provides the id of the formal version node of an element.
+func versionId(id ElementId) version.Id {
+	return version.NewId(id.TypeId(), id.GetName())
 }
 
-func mapInputsToVersions(id ElementId, state model.OutputState) (version.Id, string) {
-	return version.NewIdFor(id), state.GetFormalVersion()
+// versionIdIn provides the id of the formal version node of an element
+// as seen from namespace ns. Nodes of elements in other namespaces
+// use qualified names to keep them unique.
+func versionIdIn(ns string, id ElementId) version.Id {
+	return version.NewId(id.TypeId(), QualifiedName(ns, id.GetNamespace(), id.GetName()))
+}
+
+func mapInputsToVersions(ns string) func(id ElementId, state model.OutputState) (version.Id, string) {
+	return func(id ElementId, state model.OutputState) (version.Id, string) {
+		if id.GetNamespace() != ns {
+			return version.NewId(id.GetType(), QualifiedName(ns, id.GetNamespace(), id.GetName())), state.GetFormalVersion()
+		}
+		return version.NewIdFor(id), state.GetFormalVersion()
+	}
 }
@@ -26,6 +26,12 @@ func (r *elementReconciler) Command(_ pool.Pool, ctx pool.MessageContext, comman
 	ctx = ctx.WithContext(REALM)
 	cmd, _, id := DecodeCommand(command)
 	if id != nil {
+		done, ok := r.Controller().enterNamespace(id.GetNamespace())
+		if !ok {
+			// namespace handed over to another shard
+			return pool.StatusCompleted()
+		}
+		defer done()
 		if r.Controller().delay > 0 {
 			time.Sleep(r.Controller().delay)
 		}
@@ -40,6 +46,8 @@ func (r *elementReconciler) Command(_ pool.Pool, ctx pool.MessageContext, comman
 			return newElementExtReconcilation(r, ctx, *id).Reconcile()
 		case CMD_ELEM:
 			return newElementRunReconcilation(r, ctx, *id).Reconcile()
+		case CMD_ORPHAN:
+			return r.reconcileOrphan(ctx, *id)
 		}
 	}
 	return pool.StatusFailed(fmt.Errorf("invalid element command %q", command))
@@ -99,11 +107,17 @@ func (r *elementReconcilation) isReTriggerable(e _Element, ext ...string) bool {
 
 func (r *elementReconcilation) initiateNewRun() pool.Status {
 	r.Info("trying to initiate new run for {{element}}")
+	if ok, err := r.suspended(); ok || err != nil {
+		return pool.StatusCompleted(err)
+	}
 	ni := r.getNamespaceInfo(r.GetNamespace())
 	rid, err := ni.LockGraph(r, r._Element)
 	if err == nil {
 		if rid != nil {
 			r.Info("starting run {{runid}}", "runid", *rid)
+			r.traces.Start(r.tracer, r._Element, *rid)
+			r.records.Start(r._Element, *rid)
+			r.recorder.Normal(r.eid, REASON_RUN_STARTED, "run %s started", *rid)
 			r.EnqueueKey(CMD_ELEM, r.eid)
 		} else {
 			err = fmt.Errorf("delay initiation of new run")
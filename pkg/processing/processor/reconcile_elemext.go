@@ -6,6 +6,7 @@ import (
 	. "github.com/mandelsoft/engine/pkg/processing/mmids"
 	"github.com/mandelsoft/goutils/errors"
 	"github.com/mandelsoft/goutils/general"
+	"github.com/mandelsoft/goutils/generics"
 
 	"github.com/mandelsoft/engine/pkg/database"
 	"github.com/mandelsoft/engine/pkg/pool"
@@ -146,6 +147,16 @@ func (r *elementExtReconcilation) handleExternalChange() pool.Status {
 
 	if changed == nil && !deleting {
 		r.Info("no external object state change found for {{element}}")
+		if isFinal(r._Element) && r.GetLock() == "" {
+			// report the actual object generation as observed, to enable
+			// clients to detect stale states. Conditions already based on
+			// the actual object content are not updated again.
+			err := o.UpdateStatus(r.lctx, r.processingModel.ObjectBase(), r.eid, model.StatusUpdate{ObservedGeneration: generics.Pointer(o.GetGeneration())})
+			if err != nil {
+				r.LogError(err, "cannot update observed generation for {{extid}}")
+				return pool.StatusCompleted(err)
+			}
+		}
 		return pool.StatusCompleted()
 	}
 
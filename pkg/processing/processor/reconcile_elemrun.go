@@ -1,15 +1,19 @@
 package processor
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"slices"
+	"time"
 
 	. "github.com/mandelsoft/engine/pkg/processing/mmids"
 
 	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/history"
 	"github.com/mandelsoft/engine/pkg/pool"
 	"github.com/mandelsoft/engine/pkg/processing/model"
+	"github.com/mandelsoft/engine/pkg/tracing"
 	"github.com/mandelsoft/engine/pkg/version"
 	"github.com/mandelsoft/goutils/general"
 	"github.com/mandelsoft/goutils/generics"
@@ -20,7 +24,8 @@ import (
 
 type elementRunReconcilation struct {
 	*elementReconcilation
-	ni *namespaceInfo
+	ni    *namespaceInfo
+	runid RunId
 }
 
 func newElementRunReconcilation(r *elementReconciler, bctx model.Logging, eid ElementId) *elementRunReconcilation {
@@ -37,11 +42,15 @@ func (r *elementRunReconcilation) Reconcile() pool.Status {
 		return pool.StatusCompleted()
 	}
 
-	runid := r.GetLock()
-	r.lctx = r.bctx.WithValues("namespace", r.GetNamespace(), "element", r.eid, "runid", runid).WithName(string(runid)).WithName(r.eid.String())
+	r.runid = r.GetLock()
+	r.lctx = r.bctx.WithValues("namespace", r.GetNamespace(), "element", r.eid, "runid", r.runid).WithName(string(r.runid)).WithName(r.eid.String())
 	r.Logger = r.lctx.Logger().WithValues("status", r.GetStatus())
 	r.ni = r.getNamespaceInfo(r.GetNamespace())
 
+	if r.Controller().forced.Has(r.eid) {
+		return r.forceDeletion()
+	}
+
 	var ready *ReadyState
 
 	mode := "process"
@@ -61,7 +70,13 @@ func (r *elementRunReconcilation) Reconcile() pool.Status {
 			return pool.StatusCompleted(err)
 		}
 
-		children := r.ni.GetChildren(r.Id())
+		children := r.processingModel.GetChildren(r.Id())
+		if len(children) > 0 {
+			children, err = r.propagateDeletion(children)
+			if err != nil {
+				return pool.StatusCompleted(err)
+			}
+		}
 		if len(children) == 0 {
 			r.Info("element {{element}} is deleting and no children found -> initiate deletion")
 			r.Info("  found links {{links}}", "links", stringutils.Join(curlinks))
@@ -76,6 +91,15 @@ func (r *elementRunReconcilation) Reconcile() pool.Status {
 		}
 	}
 	if !deletion && r.GetLock() == "" {
+		if r.GetStatus() != model.STATUS_FAILED {
+			dep, ok, err := r.deletedLink()
+			if err != nil {
+				return pool.StatusCompleted(err)
+			}
+			if ok {
+				return r.orphaned(dep)
+			}
+		}
 		if r.GetStatus() == model.STATUS_BLOCKED {
 			r.Info("checking ready condition for blocked element {{element}}")
 			ready := r.isReady()
@@ -90,6 +114,14 @@ func (r *elementRunReconcilation) Reconcile() pool.Status {
 		return pool.StatusCompleted()
 	}
 
+	r.startRun()
+	if !deletion {
+		if err := r.runs.Cancelled(r.Id(), r.GetLock()); err != nil {
+			r.Info("run aborted: {{cause}}", "cause", err)
+			return r.fail(false, err)
+		}
+	}
+
 	if !deletion {
 		if isExtTriggerable(r._Element) || r.isReTriggerable(r._Element) {
 			// wait for inputs to become ready
@@ -186,13 +218,20 @@ func (r *elementRunReconcilation) Reconcile() pool.Status {
 			}
 
 			formalVersion = r.formalVersion(ready.Inputs)
+			r.recordInputs(ready.Inputs, formalVersion)
 
+			if s, ok := r.checkApproval(target.GetInputVersion(ready.Inputs), formalVersion); !ok {
+				return s
+			}
+
+			update := model.StatusUpdate{
+				Status:        generics.Pointer(model.STATUS_PROCESSING),
+				FormalVersion: generics.Pointer(formalVersion),
+				Message:       generics.Pointer(fmt.Sprintf("processing phase %s", r.GetPhase())),
+			}
+			r.Controller().setConditions(r.Id(), &update)
 			upstate := func(log logging.Logger, o model.ExternalObject) error {
-				return o.UpdateStatus(r.lctx, r.Objectbase(), r.Id(), model.StatusUpdate{
-					Status:        generics.Pointer(model.STATUS_PROCESSING),
-					FormalVersion: generics.Pointer(formalVersion),
-					Message:       generics.Pointer(fmt.Sprintf("processing phase %s", r.GetPhase())),
-				})
+				return o.UpdateStatus(r.lctx, r.Objectbase(), r.Id(), update)
 			}
 
 			r.Info("update processing status of external objects")
@@ -214,8 +253,12 @@ func (r *elementRunReconcilation) Reconcile() pool.Status {
 				return r.fail(false, fmt.Errorf("unexpected state of parents"))
 			}
 			formalVersion = r.formalVersion(ready.Inputs)
+			r.recordInputs(ready.Inputs, formalVersion)
 		}
 	} else {
+		if r.GetStatus() != model.STATUS_DELETING {
+			r.recorder.Normal(r.eid, REASON_DELETION_STARTED, "deletion started")
+		}
 		err := r.setStatus(r, r._Element, model.STATUS_DELETING)
 		if err != nil {
 			return pool.StatusCompleted(err)
@@ -229,6 +272,7 @@ func (r *elementRunReconcilation) Reconcile() pool.Status {
 	if isProcessable(r._Element) {
 		// now we can process the phase
 		r.Info("executing phase {{phase}} of internal object {{intid}} (deletion {{deletion}})", "phase", r.GetPhase(), "intid", r.Id().ObjectId(), "deletion", deletion)
+		span := r.runSpan().Start("process "+string(r.GetPhase()), tracing.Attr("deletion", deletion), tracing.Attr("formal", formalVersion))
 		request := model.Request{
 			Logging:         r.lctx,
 			Model:           r.Controller().processingModel,
@@ -236,12 +280,43 @@ func (r *elementRunReconcilation) Reconcile() pool.Status {
 			Delete:          deletion,
 			FormalVersion:   formalVersion,
 			ElementAccess:   r.ni,
-			SlaveManagement: newSlaveManagement(r, r.ni, r._Element),
+			SlaveManagement: newSlaveManagement(r, r.ni, r._Element, span),
 		}
 		if ready != nil {
 			request.Inputs = ready.Inputs
 		}
-		result := r.GetObject().Process(request)
+		ctx := r.Controller().ctx
+		if !deletion {
+			var done func()
+			ctx, done = r.runs.Start(ctx, r.Id(), r.GetLock(), r.timeout())
+			defer done()
+		}
+		request.Context = tracing.ContextWithSpan(ctx, span)
+		start := time.Now()
+		var result model.ProcessingResult
+		cached := false
+		if !deletion && ready != nil {
+			result, cached = r.cachedResult(ready.Inputs)
+		}
+		if cached {
+			r.Info("using memoized result for object version {{objvers}}", "objvers", r.GetProcessingState().GetObjectVersion())
+			span.SetAttributes(tracing.Attr("memoized", true))
+		} else {
+			result = r.GetObject().Process(request)
+			observePhaseRun(r.MetaModel().Name(), r.Id(), start)
+			if !deletion && ready != nil {
+				r.cacheResult(ready.Inputs, result)
+			}
+		}
+		span.SetAttributes(tracing.Attr("status", result.Status))
+		span.EndWithError(result.Error)
+
+		if r.aborted(ctx, result) {
+			cause := context.Cause(ctx)
+			r.runs.Cancelled(r.Id(), r.GetLock())
+			r.Error("processing aborted", "error", cause)
+			return r.fail(false, cause, formalVersion)
+		}
 
 		if result.Error != nil {
 			if result.Status == model.STATUS_FAILED || result.Status == model.STATUS_INVALID {
@@ -250,6 +325,11 @@ func (r *elementRunReconcilation) Reconcile() pool.Status {
 				return r.fail(result.Status == model.STATUS_INVALID, result.Error, formalVersion)
 			}
 			r.Error("processing provides error", "error", result.Error)
+			if !deletion {
+				if s, ok := r.retry(result.Status, result.Error, formalVersion); ok {
+					return s
+				}
+			}
 			err := r.updateStatus(result.Status, result.Error.Error(), FormalVersion(formalVersion))
 			if err != nil {
 				return pool.StatusCompleted(err)
@@ -258,6 +338,7 @@ func (r *elementRunReconcilation) Reconcile() pool.Status {
 			if err != nil {
 				return pool.StatusCompleted(err)
 			}
+			r.records.Transition(r.eid, result.Status, result.Error.Error())
 			return pool.StatusCompleted(result.Error)
 		} else {
 			switch result.Status {
@@ -306,6 +387,27 @@ func (r *elementRunReconcilation) Reconcile() pool.Status {
 	return pool.StatusCompleted()
 }
 
+func (r *elementRunReconcilation) timeout() time.Duration {
+	t := r.MetaModel().GetElementType(r.Id().TypeId())
+	if t == nil {
+		return 0
+	}
+	return t.Timeout()
+}
+
+// aborted checks whether a processing step has been aborted by the
+// cancellation of its run. A successful result is kept, even if the
+// cancellation has been requested after the step did its work.
+func (r *elementRunReconcilation) aborted(ctx context.Context, result model.ProcessingResult) bool {
+	if ctx.Err() == nil || r.Controller().ctx.Err() != nil {
+		return false
+	}
+	if errors.Is(result.Error, context.Canceled) || errors.Is(result.Error, context.DeadlineExceeded) {
+		return true
+	}
+	return result.Error != nil || result.Status != model.STATUS_COMPLETED
+}
+
 func diff(log logging.Logger, kind string, old, new string) bool {
 	diff := old != new
 	if diff {
@@ -395,10 +497,17 @@ func (r *elementRunReconcilation) phaseDeleted() error {
 		return err
 	}
 
+	r.endRun(model.STATUS_DELETED, nil)
 	r.Info("removing element {{element}} from processing model")
 	var children []ElementId
 	for _, ph := range r.processingModel.MetaModel().Phases(r.GetType()) {
-		for _, c := range r.ni.GetChildren(NewElementIdForPhase(r, ph)) {
+		eid := NewElementIdForPhase(r, ph)
+		list := r.processingModel.GetChildren(eid)
+		for _, c := range r.Controller().dependants(eid, list) {
+			// remaining dependants are orphaned, they are failed by their next reconcilation.
+			r.Info("  orphaned dependent element {{depelem}}", "depelem", c.Id())
+		}
+		for _, c := range list {
 			if !slices.Contains(children, c.Id()) {
 				children = append(children, c.Id())
 			}
@@ -427,6 +536,7 @@ func (r *elementRunReconcilation) notifyCompletedState(msg string, eff *string,
 	if result != nil {
 		ci = &model.CommitInfo{
 			FormalVersion: formal,
+			FormalGraph:   r.formalGraph(inputs),
 			InputVersion:  target.GetInputVersion(inputs),
 			ObjectVersion: eff,
 			OutputState:   result,
@@ -434,7 +544,10 @@ func (r *elementRunReconcilation) notifyCompletedState(msg string, eff *string,
 	}
 	if target != nil {
 		r.Info("committing target state")
-		_, err := r.Commit(r.lctx, r.processingModel.ObjectBase(), r.GetLock(), ci)
+		err := r.traceWrite("commit", func() error {
+			_, err := r.Commit(r.lctx, r.processingModel.ObjectBase(), r.GetLock(), ci)
+			return err
+		})
 		if err != nil {
 			r.Error("cannot unlock element {{element}}", "error", err)
 			return err
@@ -443,11 +556,17 @@ func (r *elementRunReconcilation) notifyCompletedState(msg string, eff *string,
 	} else {
 		r.Info("skipping commit of target state")
 	}
+	err := r.resetRetry()
+	if err != nil {
+		return err
+	}
 	r.Info("completed processing of element {{element}}", "output")
-	err := r.updateStatus(model.STATUS_COMPLETED, msg, append(args, RunId(""), FormalVersion(formal))...)
+	err = r.updateStatus(model.STATUS_COMPLETED, msg, append(args, RunId(""), FormalVersion(formal))...)
 	if err != nil {
 		return err
 	}
+	r.endRun(model.STATUS_COMPLETED, nil)
+	r.recorder.Normal(r.eid, REASON_COMPLETED, "completed with output version %s", r.GetCurrentState().GetOutputVersion())
 	return nil
 }
 
@@ -507,22 +626,36 @@ func (r *elementRunReconcilation) block(msg string) pool.Status {
 func (r *elementRunReconcilation) blocked(msg string) error {
 	err := r.updateStatus(model.STATUS_BLOCKED, msg, r.GetLock())
 	if err == nil {
-		_, err = r.Rollback(r.lctx, r.Objectbase(), r.GetLock(), true)
+		err = r.rollback(true)
 	}
 	if err == nil {
 		err = r.setStatus(r, r._Element, model.STATUS_BLOCKED)
 	}
+	if err == nil {
+		r.endRun(model.STATUS_BLOCKED, errors.New(msg))
+		r.recorder.Warning(r.eid, REASON_BLOCKED, "%s", msg)
+	}
 	return err
 }
 
 func (r *elementRunReconcilation) fail(invalid bool, fail error, formal ...string) pool.Status {
-	err := r.failed(invalid, fail.Error(), formal...)
+	err := r.abort(invalid, fail, formal...)
 	if err != nil {
 		return pool.StatusCompleted(err)
 	}
+	return pool.StatusFailed(fail)
+}
+
+// abort fails the active run of the element and
+// triggers the elements depending on it.
+func (r *elementRunReconcilation) abort(invalid bool, fail error, formal ...string) error {
+	err := r.failed(invalid, fail.Error(), formal...)
+	if err != nil {
+		return err
+	}
 	r.Controller().pending.Add(-1)
 	r.triggerChildren(true)
-	return pool.StatusFailed(fail)
+	return nil
 }
 
 func (r *elementRunReconcilation) failed(invalid bool, msg string, formal ...string) error {
@@ -538,14 +671,29 @@ func (r *elementRunReconcilation) failed(invalid bool, msg string, formal ...str
 	}
 	err := r.updateStatus(status, msg, opts...)
 	if err == nil {
-		_, err = r.Rollback(r.lctx, r.Objectbase(), r.GetLock(), true, formal...)
+		err = r.rollback(true, formal...)
 	}
 	if err == nil {
 		err = r.setStatus(r, r._Element, status)
 	}
+	if err == nil {
+		r.endRun(status, errors.New(msg))
+		reason := REASON_FAILED
+		if invalid {
+			reason = REASON_INVALID
+		}
+		r.recorder.Warning(r.eid, reason, "%s", msg)
+	}
 	return err
 }
 
+func (r *elementRunReconcilation) rollback(keepobserved bool, formal ...string) error {
+	return r.traceWrite("rollback", func() error {
+		_, err := r.Rollback(r.lctx, r.Objectbase(), r.GetLock(), keepobserved, formal...)
+		return err
+	})
+}
+
 func (r *elementRunReconcilation) assignTargetState() (model.AcceptStatus, error) {
 	// determine potential external objects
 	if r.GetObject().GetTargetState(r.GetPhase()) != nil {
@@ -562,21 +710,27 @@ func (r *elementRunReconcilation) assignTargetState() (model.AcceptStatus, error
 		v := state.GetVersion()
 		log.Debug("  found effective external state from {{extid}} for phase {{phase}}: {{state}}",
 			"phase", r.GetPhase(), "state", general.DescribeObject(state))
-		err := o.UpdateStatus(r.lctx, r.Objectbase(), r.Id(), model.StatusUpdate{
-			RunId:           generics.Pointer(r.GetLock()),
-			DetectedVersion: &v,
-			ObservedVersion: nil,
-			Status:          generics.Pointer(model.STATUS_PREPARING),
-			Message:         generics.Pointer("preparing target state"),
-			ExternalState:   state,
-			ResultState:     nil,
-		})
+		update := model.StatusUpdate{
+			RunId:              generics.Pointer(r.GetLock()),
+			DetectedVersion:    &v,
+			ObservedVersion:    nil,
+			Status:             generics.Pointer(model.STATUS_PREPARING),
+			Message:            generics.Pointer("preparing target state"),
+			ExternalState:      state,
+			ResultState:        nil,
+			ObservedGeneration: generics.Pointer(o.GetGeneration()),
+		}
+		r.Controller().setConditions(r.Id(), &update)
+		err := o.UpdateStatus(r.lctx, r.Objectbase(), r.Id(), update)
 		if err != nil {
 			r.setStatus(r, r._Element, model.STATUS_PREPARING)
 			log.Error("cannot update status for external object {{extid}}", "error", err)
 			return err
 		}
 		extstate = state
+		r.records.Update(r.eid, r.runid, func(rec *history.Record) {
+			rec.Spec.Trigger = &history.Trigger{Type: o.GetType(), Version: v}
+		})
 		return nil
 	}
 
@@ -615,11 +769,15 @@ func (r *elementRunReconcilation) checkReady(kind string, lock RunId, links []El
 	state := NewReadyState()
 
 	r.Debug(fmt.Sprintf("evaluating %s links {{links}}", kind), "links", links)
+	foreign := r.foreignElements(links)
 	r.ni.lock.Lock()
 	defer r.ni.lock.Unlock()
 
 	for _, l := range links {
 		t := r.ni.elements[l]
+		if l.GetNamespace() != r.GetNamespace() {
+			t = foreign[l]
+		}
 		if t == nil {
 			r.Debug(" - {{link}} not found", "link", l)
 			state.AddMissing(l)
@@ -658,6 +816,24 @@ func (r *elementRunReconcilation) checkReady(kind string, lock RunId, links []El
 	return state
 }
 
+// foreignElements provides the linked elements of other namespaces.
+// They must be determined before locking the namespace info.
+func (r *elementRunReconcilation) foreignElements(links []ElementId) map[ElementId]_Element {
+	var elems map[ElementId]_Element
+	for _, l := range links {
+		if l.GetNamespace() == r.GetNamespace() {
+			continue
+		}
+		if e := r.processingModel._GetElement(l); e != nil {
+			if elems == nil {
+				elems = map[ElementId]_Element{}
+			}
+			elems[l] = e
+		}
+	}
+	return elems
+}
+
 func (r *elementReconcilation) forExtObjects(f func(log logging.Logger, object model.ExternalObject) error, set func(c *Controller, id TypeId) []string) error {
 	exttypes := set(r.Controller(), r.Id().TypeId())
 	for _, t := range exttypes {
@@ -714,22 +890,43 @@ func (r *elementRunReconcilation) updateStatus(status model.Status, message stri
 			panic(fmt.Sprintf("unknown status argument type %T", a))
 		}
 	}
+	r.Controller().setConditions(r.Id(), &update)
 	r.Info(" updating status of external objects to {{newstatus}}: {{message}}", keys...)
 
 	mod := func(log logging.Logger, o model.ExternalObject) error {
 		return o.UpdateStatus(r.lctx, r.Objectbase(), r.Id(), update)
 	}
-	return r.forExtObjects(mod, UpdateObjects)
+	return r.traceWrite("update status", func() error {
+		return r.forExtObjects(mod, UpdateObjects)
+	}, tracing.Attr("status", status))
 }
 
 func (r *elementRunReconcilation) triggerChildren(release bool) {
+	for _, ni := range r.processingModel.childNamespaces(r.ni) {
+		if ni != r.ni {
+			ni.lock.Lock()
+			r.triggerChildrenIn(ni)
+			ni.lock.Unlock()
+		}
+	}
+
 	r.ni.lock.Lock()
 	defer r.ni.lock.Unlock()
 	// TODO: dependency check must be synchronized with this trigger
 
+	r.triggerChildrenIn(r.ni)
+	if release {
+		r._Element.SetProcessingState(nil)
+	}
+}
+
+// triggerChildrenIn triggers the elements of a namespace
+// depending on the actual element.
+// The namespace info must be locked.
+func (r *elementRunReconcilation) triggerChildrenIn(ni *namespaceInfo) {
 	id := r.eid
-	r.Info("triggering children for {{element}} (checking {{amount}} elements in namespace)", "amount", len(r.ni.elements))
-	for _, e := range r.ni.elements {
+	r.Info("triggering children for {{element}} (checking {{amount}} elements in namespace {{childnamespace}})", "amount", len(ni.elements), "childnamespace", ni.GetNamespaceName())
+	for _, e := range ni.elements {
 		if e.GetProcessingState() != nil {
 			links := e.GetProcessingState().GetLinks()
 			r.Debug("  elem {{child}} has target links {{links}}", "child", e.Id(), "links", links)
@@ -750,12 +947,9 @@ func (r *elementRunReconcilation) triggerChildren(release bool) {
 			}
 		}
 	}
-	if release {
-		r._Element.SetProcessingState(nil)
-	}
 }
 
 func (r *elementRunReconcilation) formalVersion(inputs model.Inputs) string {
 	n := version.NewNode(r.Id().TypeId(), r.GetName(), r.GetTargetState().GetFormalObjectVersion())
-	return r.composer.Compose(n, formalInputVersions(inputs)...)
+	return r.composer.Compose(n, formalInputVersions(r.GetNamespace(), inputs)...)
 }
@@ -20,6 +20,12 @@ func newExternalObjectReconciler(c *Controller) *externalObjectReconciler {
 }
 
 func (r *externalObjectReconciler) Reconcile(_ pool.Pool, ctx pool.MessageContext, id database.ObjectId) pool.Status {
+	done, ok := r.controller.enterFor(id)
+	if !ok {
+		// namespace handed over to another shard
+		return pool.StatusCompleted()
+	}
+	defer done()
 	return newExternalObjectReconcilation(r, ctx, id).Reconcile()
 }
 
@@ -49,9 +55,23 @@ func (p *externalObjectReconcilation) Reconcile() pool.Status {
 
 	// check for deletion
 	err := p.handleExternalDeletion(*t)
-	if err != nil || p.ExternalObject == nil {
+	if err != nil {
 		return pool.StatusCompleted(err)
 	}
+	if p.ExternalObject == nil {
+		p.suspended.Update(p.oid, false)
+		return pool.StatusCompleted()
+	}
+
+	// retrigger elements suspended by this object
+	if p.suspended.Update(p.oid, p.IsSuspended()) {
+		err = p.resumeElements(p.lctx, p.Logger)
+		if err != nil {
+			// keep the suspension to resume again on retry
+			p.suspended.Update(p.oid, true)
+			return pool.StatusCompleted(err)
+		}
+	}
 
 	// check for obsolete event and handle finalizer
 	err = p.prepareExternalObject(*t)
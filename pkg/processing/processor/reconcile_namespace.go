@@ -23,6 +23,12 @@ func (a *namespaceReconciler) Command(p pool.Pool, ctx pool.MessageContext, comm
 	if cmd != CMD_NS {
 		return pool.StatusFailed(fmt.Errorf("invalid processor command %q", command))
 	}
+	done, ok := a.controller.enterNamespace(ns)
+	if !ok {
+		// namespace handed over to another shard
+		return pool.StatusCompleted()
+	}
+	defer done()
 
 	return newNamespaceReconcilation(a, ctx, ns).Reconcile()
 }
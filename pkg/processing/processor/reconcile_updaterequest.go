@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/mandelsoft/engine/pkg/processing/metamodel"
 	. "github.com/mandelsoft/engine/pkg/processing/mmids"
@@ -14,6 +15,7 @@ import (
 
 	"github.com/mandelsoft/engine/pkg/database"
 	"github.com/mandelsoft/engine/pkg/pool"
+	"github.com/mandelsoft/engine/pkg/utils"
 	"github.com/mandelsoft/logging"
 )
 
@@ -68,6 +70,12 @@ func (r *updaterequestReconciler) deleteStatus(oid database.ObjectId) {
 }
 
 func (r *updaterequestReconciler) Reconcile(_ pool.Pool, ctx pool.MessageContext, id database.ObjectId) pool.Status {
+	done, ok := r.controller.enterFor(id)
+	if !ok {
+		// namespace handed over to another shard
+		return pool.StatusCompleted()
+	}
+	defer done()
 	return newUpdateRequestReconcilation(r, ctx, id).Reconcile()
 }
 
@@ -99,6 +107,12 @@ func (r *updaterequestReconcilation) LoggingContext() model.Logging {
 }
 
 func (r *updaterequestReconcilation) Reconcile() pool.Status {
+	status := r.reconcile()
+	r.scheduleExpiry()
+	return status
+}
+
+func (r *updaterequestReconcilation) reconcile() pool.Status {
 	tmp := r.processingModel.GetNamespace(r.oid.GetNamespace())
 	if tmp != nil {
 		r.ni = tmp.(*namespaceInfo)
@@ -124,6 +138,17 @@ func (r *updaterequestReconcilation) Reconcile() pool.Status {
 		return pool.StatusCompleted(suberr)
 	}
 
+	if r.leaseExpired(action) {
+		msg := fmt.Sprintf("lease %s expired: locks released", action.Lease)
+		r.Info("lease expired for request {{reqid}}")
+		err := r.clearNamespaceLockForObject()
+		if err != nil {
+			return pool.StatusCompleted(err)
+		}
+		_, err = r.setStatus(model.REQ_STATUS_EXPIRED, msg)
+		return pool.StatusCompleted(err)
+	}
+
 	r.ni, err = r.processingModel.assureNamespace(r.Logger, r.GetNamespace(), true)
 	if err != nil {
 		return pool.StatusCompleted(err)
@@ -249,6 +274,10 @@ func (r *updaterequestReconcilation) prepareAction() (*model.UpdateAction, error
 		r.Info("request already done")
 		return nil, r.clearNamespaceLockForObject()
 	}
+	if status.Status == model.REQ_STATUS_EXPIRED {
+		r.Info("request expired")
+		return nil, r.clearNamespaceLockForObject()
+	}
 	return action, nil
 }
 
@@ -261,16 +290,87 @@ func (r *updaterequestReconcilation) setStatus(status string, message string) (b
 	snew.Status = status
 	snew.Message = message
 	snew.ObservedVersion = r.GetAction().Version()
+	snew.Expiry = r.leaseExpiry(r.getStatus(), &snew)
 	return r.updaterequestReconciler.setStatus(r.UpdateRequestObject, &snew)
 }
 
+// leaseExpiry determines the expiry time for a new request status.
+// The lease is (re-)started with every change of the requested action.
+func (r *updaterequestReconcilation) leaseExpiry(old, status *model.UpdateStatus) *utils.Timestamp {
+	switch status.Status {
+	case model.REQ_STATUS_RELEASED, model.REQ_STATUS_INVALID:
+		return nil
+	case model.REQ_STATUS_EXPIRED:
+		return old.Expiry
+	}
+	lease, err := parseLease(r.GetAction().Lease)
+	if err != nil || lease == 0 {
+		return nil
+	}
+	if old.Expiry != nil && old.ObservedVersion == status.ObservedVersion {
+		return old.Expiry
+	}
+	return utils.NewTimestampPFor(time.Now().Add(lease))
+}
+
+// leaseExpired checks whether the locks held by the request
+// have to be released because its lease expired.
+// A release request is always executed.
+func (r *updaterequestReconcilation) leaseExpired(action *model.UpdateAction) bool {
+	status := r.getStatus()
+	if action.Action == model.REQ_ACTION_RELEASE || !isActiveRequestStatus(status.Status) {
+		return false
+	}
+	if status.Expiry == nil || status.ObservedVersion != action.Version() {
+		return false
+	}
+	return !time.Now().Before(status.Expiry.Time())
+}
+
+// scheduleExpiry assures that the request is reconciled again
+// when the lease of an active request expires.
+func (r *updaterequestReconcilation) scheduleExpiry() {
+	if r.UpdateRequestObject == nil {
+		return
+	}
+	status := r.getStatus()
+	if status.Expiry == nil || !isActiveRequestStatus(status.Status) {
+		return
+	}
+	d := time.Until(status.Expiry.Time())
+	r.Info("lease expires in {{duration}}", "duration", d)
+	r.controller.pool.EnqueueKeyAfter(r.oid, d)
+}
+
+func isActiveRequestStatus(status string) bool {
+	switch status {
+	case model.REQ_STATUS_ACQUIRED, model.REQ_STATUS_LOCKED, model.REQ_STATUS_PENDING:
+		return true
+	}
+	return false
+}
+
+func parseLease(lease string) (time.Duration, error) {
+	if lease == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(lease)
+	if err != nil {
+		return 0, fmt.Errorf("invalid lease %q: %w", lease, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid lease %q: must be positive", lease)
+	}
+	return d, nil
+}
+
 func (r *updaterequestReconcilation) clearNamespaceLockForObject() error {
 	if r.ni == nil {
 		return nil
 	}
 	runid := r.ni.namespace.GetLock()
 	owner := IsObjectLock(runid)
-	if owner != nil && database.CompareObject((*owner).Id(r.GetNamespace(), r.processingModel.mm), r.oid) == 0 {
+	if owner != nil && database.CompareObject((*owner).Id(r.GetNamespace(), r.processingModel.MetaModel()), r.oid) == 0 {
 		r.Info("clear namespace lock {{runid}}", "runid", runid)
 		err := r.ni.clearLock(r, runid)
 		if err != nil {
@@ -293,6 +393,9 @@ func (r *updaterequestReconcilation) validateUpdateRequest(action *model.UpdateA
 	default:
 		return fmt.Errorf("invalid action %q", action.Action)
 	}
+	if _, err := parseLease(action.Lease); err != nil {
+		return err
+	}
 
 	for i, e := range action.Objects {
 		et := r.processingModel.MetaModel().GetExternalType(e.GetType())
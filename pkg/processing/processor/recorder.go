@@ -0,0 +1,125 @@
+package processor
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	. "github.com/mandelsoft/engine/pkg/processing/mmids"
+	"github.com/mandelsoft/engine/pkg/recorder"
+	"github.com/mandelsoft/logging"
+)
+
+var ErrNoEventRecorder = fmt.Errorf("no event recorder configured")
+
+// Reasons used for the events recorded for elements.
+const (
+	REASON_RUN_STARTED      = "RunStarted"
+	REASON_BLOCKED          = "Blocked"
+	REASON_FAILED           = "Failed"
+	REASON_INVALID          = "Invalid"
+	REASON_COMPLETED        = "Completed"
+	REASON_DELETION_STARTED = "DeletionStarted"
+	REASON_DELETION_BLOCKED = "DeletionBlocked"
+	REASON_ORPHANED         = "Orphaned"
+	REASON_FORCE_DELETED    = "ForceDeleted"
+	REASON_SLAVE_CREATED    = "SlaveCreated"
+)
+
+// EventRecorder persists events describing significant
+// status transitions of elements.
+type EventRecorder interface {
+	Record(spec recorder.EventSpec) error
+	List(ns string, closure bool, match func(r *recorder.Record) bool) ([]*recorder.Record, error)
+}
+
+type eventRecorder struct {
+	lock     sync.Mutex
+	log      logging.Logger
+	recorder EventRecorder
+}
+
+func newEventRecorder() *eventRecorder {
+	return &eventRecorder{}
+}
+
+func (e *eventRecorder) set(log logging.Logger, r EventRecorder) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.log = log
+	e.recorder = r
+}
+
+func (e *eventRecorder) get() (logging.Logger, EventRecorder) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.log, e.recorder
+}
+
+// Normal records a regular event for an element.
+func (e *eventRecorder) Normal(id ElementId, reason string, msg string, args ...interface{}) {
+	e.event(id, recorder.TYPE_NORMAL, reason, msg, args...)
+}
+
+// Warning records a problem for an element.
+func (e *eventRecorder) Warning(id ElementId, reason string, msg string, args ...interface{}) {
+	e.event(id, recorder.TYPE_WARNING, reason, msg, args...)
+}
+
+func (e *eventRecorder) event(id ElementId, typ recorder.EventType, reason string, msg string, args ...interface{}) {
+	log, r := e.get()
+	if r == nil {
+		return
+	}
+	spec := recorder.EventSpec{
+		Type:    typ,
+		Reason:  reason,
+		Message: fmt.Sprintf(msg, args...),
+		InvolvedObject: recorder.InvolvedObject{
+			Type:      id.GetType(),
+			Namespace: id.GetNamespace(),
+			Name:      id.GetName(),
+			Phase:     string(id.GetPhase()),
+		},
+	}
+	err := r.Record(spec)
+	if err != nil {
+		log.LogError(err, "cannot record event {{reason}} for {{element}}", "reason", reason, "element", id)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// SetEventRecorder sets the recorder used to record events
+// for element status transitions. By default, no events
+// are recorded.
+func (p *Controller) SetEventRecorder(r EventRecorder) {
+	p.recorder.set(p.logging.Logger().WithName("recorder"), r)
+}
+
+// Events provides the recorded events of a namespace closure.
+// If an object id is given, only the events for the phases of
+// this object are provided. Its type may be an internal type or
+// an external type triggering an internal type.
+func (p *Controller) Events(ns string, oid database.ObjectId) ([]*recorder.Record, error) {
+	_, r := p.recorder.get()
+	if r == nil {
+		return nil, ErrNoEventRecorder
+	}
+	if oid == nil {
+		return r.List(ns, true, nil)
+	}
+	ids, err := p.elementIdsFor(oid, "")
+	if err != nil {
+		return nil, err
+	}
+	return r.List(oid.GetNamespace(), false, func(r *recorder.Record) bool {
+		o := &r.Spec.InvolvedObject
+		for _, id := range ids {
+			if o.Type == id.GetType() && o.Name == id.GetName() && o.Phase == string(id.GetPhase()) {
+				return true
+			}
+		}
+		return false
+	})
+}
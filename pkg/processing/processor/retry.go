@@ -0,0 +1,89 @@
+package processor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mandelsoft/engine/pkg/pool"
+	"github.com/mandelsoft/engine/pkg/processing/model"
+	"github.com/mandelsoft/engine/pkg/utils"
+)
+
+// isRetryable is the default error classification used
+// for retry policies without an explicit classification.
+func isRetryable(err error) bool {
+	return !IsNonTemporary(err)
+}
+
+func (r *elementRunReconcilation) retryPolicy() *model.RetryPolicy {
+	t := r.MetaModel().GetElementType(r.Id().TypeId())
+	if t == nil {
+		return nil
+	}
+	return t.RetryPolicy()
+}
+
+// retry handles a temporary processing error according to the retry policy
+// of the element type. If no policy is configured, false is returned and
+// the default handling (rate limited requeue) should be used.
+// Otherwise, the attempt is recorded in the phase state and a new attempt
+// is scheduled according to the backoff of the policy. If the error
+// is not retryable or the attempts are exhausted, the run fails.
+// The retry is scheduled by the reconcile status, therefore, no
+// additional rate limited requeue is done.
+func (r *elementRunReconcilation) retry(status model.Status, fail error, formal string) (pool.Status, bool) {
+	policy := r.retryPolicy()
+	if policy == nil {
+		return pool.Status{}, false
+	}
+
+	state := &model.RetryState{
+		RunId:     r.GetLock(),
+		Attempts:  r.GetRetryState().AttemptsFor(r.GetLock()) + 1,
+		LastError: fail.Error(),
+	}
+
+	if !policy.IsRetryable(fail, isRetryable) {
+		r.Info("error for attempt {{attempt}} not retryable -> fail run", "attempt", state.Attempts)
+		return r.failRetry(state, fail, formal), true
+	}
+	if policy.Exhausted(state.Attempts) {
+		r.Info("retry attempts exhausted ({{attempt}}) -> fail run", "attempt", state.Attempts)
+		return r.failRetry(state, fmt.Errorf("giving up after %d attempts: %w", state.Attempts, fail), formal), true
+	}
+
+	d := policy.Delay(state.Attempts)
+	state.NextRetry = utils.NewTimestampPFor(time.Now().Add(d))
+	_, err := r.SetRetryState(r.Objectbase(), state)
+	if err != nil {
+		return pool.StatusCompleted(err), true
+	}
+	err = r.updateStatus(status, fmt.Sprintf("attempt %d failed: %s (retry in %s)", state.Attempts, fail, d), FormalVersion(formal))
+	if err != nil {
+		return pool.StatusCompleted(err), true
+	}
+	err = r.setStatus(r, r._Element, status)
+	if err != nil {
+		return pool.StatusCompleted(err), true
+	}
+	r.records.Transition(r.eid, status, fmt.Sprintf("attempt %d failed: %s", state.Attempts, fail))
+	r.Info("attempt {{attempt}} failed -> retry in {{delay}}", "attempt", state.Attempts, "delay", d)
+	return pool.StatusFailed(fail).RescheduleAfter(d), true
+}
+
+func (r *elementRunReconcilation) failRetry(state *model.RetryState, fail error, formal string) pool.Status {
+	_, err := r.SetRetryState(r.Objectbase(), state)
+	if err != nil {
+		return pool.StatusCompleted(err)
+	}
+	return r.fail(false, fail, formal)
+}
+
+// resetRetry clears the retry state after a successful run.
+func (r *elementRunReconcilation) resetRetry() error {
+	if r.GetRetryState() == nil {
+		return nil
+	}
+	_, err := r.SetRetryState(r.Objectbase(), nil)
+	return err
+}
@@ -0,0 +1,250 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	. "github.com/mandelsoft/engine/pkg/processing/mmids"
+	"github.com/mandelsoft/engine/pkg/processing/model"
+)
+
+var ErrRunCancelled = fmt.Errorf("run cancelled")
+var ErrRunDeadlineExceeded = fmt.Errorf("run deadline exceeded")
+
+type activeRun struct {
+	runid  RunId
+	cancel context.CancelCauseFunc
+}
+
+type cancellation struct {
+	runid RunId
+	cause error
+}
+
+// runRegistry keeps track of actually executed processing
+// steps and requested cancellations of runs.
+type runRegistry struct {
+	lock      sync.Mutex
+	active    map[ElementId]*activeRun
+	cancelled map[ElementId]*cancellation
+}
+
+func newRunRegistry() *runRegistry {
+	return &runRegistry{
+		active:    map[ElementId]*activeRun{},
+		cancelled: map[ElementId]*cancellation{},
+	}
+}
+
+// Start provides the context for a processing step of the given run.
+// The returned function must be called after the step has been finished.
+func (r *runRegistry) Start(ctx context.Context, id ElementId, runid RunId, timeout time.Duration) (context.Context, func()) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	done := func() { cancel(nil) }
+	if timeout > 0 {
+		var tcancel context.CancelFunc
+		ctx, tcancel = context.WithTimeoutCause(ctx, timeout, fmt.Errorf("%w (%s)", ErrRunDeadlineExceeded, timeout))
+		done = func() {
+			tcancel()
+			cancel(nil)
+		}
+	}
+	if c := r.cancelled[id]; c != nil && c.runid == runid {
+		cancel(c.cause)
+	}
+	r.active[id] = &activeRun{runid: runid, cancel: cancel}
+	return ctx, func() {
+		r.lock.Lock()
+		defer r.lock.Unlock()
+		if a := r.active[id]; a != nil && a.runid == runid {
+			delete(r.active, id)
+		}
+		done()
+	}
+}
+
+// Cancel requests the cancellation of the given run for an element.
+// An actually executed processing step is notified via its context.
+func (r *runRegistry) Cancel(id ElementId, runid RunId, cause error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.cancelled[id] = &cancellation{runid: runid, cause: cause}
+	if a := r.active[id]; a != nil && a.runid == runid {
+		a.cancel(cause)
+	}
+}
+
+// Cancelled checks for a requested cancellation of the given run.
+// The request is consumed.
+func (r *runRegistry) Cancelled(id ElementId, runid RunId) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	c := r.cancelled[id]
+	if c == nil {
+		return nil
+	}
+	delete(r.cancelled, id)
+	if c.runid != runid {
+		return nil
+	}
+	return c.cause
+}
+
+// Discard removes a requested cancellation for a finished run.
+func (r *runRegistry) Discard(id ElementId, runid RunId) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if c := r.cancelled[id]; c != nil && c.runid == runid {
+		delete(r.cancelled, id)
+	}
+}
+
+// Prune removes the requested cancellations for runs,
+// which are not valid anymore.
+func (r *runRegistry) Prune(valid func(id ElementId, runid RunId) bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for id, c := range r.cancelled {
+		if !valid(id, c.runid) {
+			delete(r.cancelled, id)
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// CancelRun aborts the actual run of the given element.
+// It returns the id of the cancelled run or an empty id,
+// if there is no active run.
+func (p *Controller) CancelRun(id ElementId, cause error) RunId {
+	e := p.processingModel._GetElement(id)
+	if e == nil {
+		return ""
+	}
+	runid := e.GetLock()
+	if runid == "" {
+		return ""
+	}
+	if cause == nil {
+		cause = ErrRunCancelled
+	}
+	p.logging.Logger().Info("cancel run {{runid}} for {{element}}: {{cause}}", "runid", runid, "element", id, "cause", cause)
+	p.runs.Cancel(id, runid, cause)
+	p.EnqueueKey(CMD_ELEM, id)
+	return runid
+}
+
+// CancelObject aborts the actual runs for the phases of an object.
+// The type may be an internal type or an external type triggering
+// an internal type. If no phase is given, all phases of the
+// internal type are cancelled.
+func (p *Controller) CancelObject(oid database.ObjectId, phase Phase) (map[ElementId]RunId, error) {
+	ids, err := p.elementIdsFor(oid, phase)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[ElementId]RunId{}
+	for _, id := range ids {
+		if runid := p.CancelRun(id, nil); runid != "" {
+			result[id] = runid
+		}
+	}
+	return result, nil
+}
+
+// elementIdsFor provides the element ids for the phases of an object.
+// The type may be an internal type or an external type triggering
+// an internal type. If no phase is given, the ids for all phases
+// of the internal type are returned.
+func (p *Controller) elementIdsFor(oid database.ObjectId, phase Phase) ([]ElementId, error) {
+	mm := p.MetaModel()
+	typ := oid.GetType()
+	if mm.IsExternalType(typ) {
+		typ = mm.GetTriggedElementType(typ).Id().GetType()
+	} else {
+		if !mm.IsInternalType(typ) {
+			return nil, fmt.Errorf("unknown type %q", oid.GetType())
+		}
+	}
+
+	phases := mm.Phases(typ)
+	if phase != "" {
+		if !mm.HasElementType(NewTypeId(typ, phase)) {
+			return nil, fmt.Errorf("unknown phase %q for type %q", phase, typ)
+		}
+		phases = []Phase{phase}
+	}
+
+	var ids []ElementId
+	for _, ph := range phases {
+		ids = append(ids, NewElementId(typ, oid.GetNamespace(), oid.GetName(), ph))
+	}
+	return ids, nil
+}
+
+// watchdog periodically checks the runs for exceeded deadlines.
+func (p *Controller) watchdog(ctx context.Context) {
+	if p.watchdogInterval <= 0 {
+		return
+	}
+	log := p.logging.Logger().WithName("watchdog")
+	log.Info("starting watchdog with interval {{interval}}", "interval", p.watchdogInterval)
+	ticker := time.NewTicker(p.watchdogInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("stopping watchdog")
+			return
+		case <-ticker.C:
+			p.checkDeadlines()
+			p.runs.Prune(p.isActiveRun)
+		}
+	}
+}
+
+// isActiveRun checks whether the given run is the actual run of an element.
+func (p *Controller) isActiveRun(id ElementId, runid RunId) bool {
+	e := p.processingModel._GetElement(id)
+	return e != nil && e.GetLock() == runid
+}
+
+func (p *Controller) checkDeadlines() {
+	now := time.Now()
+	for _, n := range p.processingModel.Namespaces() {
+		ns := p.processingModel.GetNamespace(n)
+		if ns == nil {
+			continue
+		}
+		for _, id := range ns.Elements() {
+			t := p.MetaModel().GetElementType(id.TypeId())
+			if t == nil || t.Timeout() <= 0 {
+				continue
+			}
+			e := p.processingModel._GetElement(id)
+			if e == nil || e.GetLock() == "" {
+				continue
+			}
+			status := e.GetStatus()
+			if status != model.STATUS_PROCESSING && status != model.STATUS_WAITING {
+				continue
+			}
+			since := e.GetStatusTime()
+			if since == nil || now.Sub(since.Time()) <= t.Timeout() {
+				continue
+			}
+			p.CancelRun(id, fmt.Errorf("%w (%s in status %s)", ErrRunDeadlineExceeded, t.Timeout(), status))
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package processor
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/pool"
+	"github.com/mandelsoft/engine/pkg/processing/model"
+)
+
+// priorityCache caches the scheduling priorities
+// declared by namespace objects.
+type priorityCache struct {
+	lock       sync.Mutex
+	namespaces map[string]int
+}
+
+func newPriorityCache() *priorityCache {
+	return &priorityCache{
+		namespaces: map[string]int{},
+	}
+}
+
+func (c *priorityCache) Get(ns string) (int, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	p, ok := c.namespaces[ns]
+	return p, ok
+}
+
+func (c *priorityCache) Set(ns string, prio int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.namespaces[ns] = prio
+}
+
+// Reset invalidates the cache. Because namespace priorities
+// are inherited, all entries are affected by a change.
+func (c *priorityCache) Reset() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.namespaces = map[string]int{}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// classify determines the scheduling class for requests of the
+// processing pool. Requests are scheduled fair between namespaces.
+// The priority is the sum of the priority of the element type and
+// the priority of the namespace.
+func (p *Controller) classify(cmd pool.Command, id database.ObjectId) pool.Class {
+	var ns string
+	var prio int
+
+	if id != nil {
+		ns = id.GetNamespace()
+		if t := p.MetaModel().GetTriggedElementType(id.GetType()); t != nil {
+			prio = t.Priority()
+		}
+	} else {
+		c, n, eid := DecodeCommand(cmd)
+		switch {
+		case c == CMD_NS:
+			ns = n
+		case eid != nil:
+			ns = eid.GetNamespace()
+			if t := p.MetaModel().GetElementType(eid.TypeId()); t != nil {
+				prio = t.Priority()
+			}
+		default:
+			return pool.Class{}
+		}
+	}
+	return pool.Class{
+		Priority: pool.Priority(prio + p.namespacePriority(ns)),
+		Tenant:   ns,
+	}
+}
+
+// namespacePriority provides the priority of a namespace. It is
+// declared by its namespace object, or inherited from the nearest
+// parent namespace declaring a priority.
+func (p *Controller) namespacePriority(ns string) int {
+	if ns == "" {
+		return 0
+	}
+	if prio, ok := p.priorities.Get(ns); ok {
+		return prio
+	}
+
+	prio := 0
+	nns, nn := NamespaceId(ns)
+	o, err := p.Objectbase().GetObject(database.NewObjectId(p.MetaModel().NamespaceType(), nns, nn))
+	if err != nil {
+		if !errors.Is(err, database.ErrNotExist) {
+			// don't cache temporary problems
+			return p.namespacePriority(ParentNamespace(ns))
+		}
+	} else if n, ok := o.(model.NamespaceObject); ok {
+		prio = n.GetPriority()
+	}
+	if prio == 0 {
+		prio = p.namespacePriority(ParentNamespace(ns))
+	}
+	p.priorities.Set(ns, prio)
+	return prio
+}
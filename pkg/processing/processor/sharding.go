@@ -0,0 +1,294 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/processing/model"
+	"github.com/mandelsoft/engine/pkg/sharding"
+	"github.com/mandelsoft/logging"
+)
+
+// ErrNotResponsible is reported for requests on objects of
+// namespaces handled by another member of the shard group.
+var ErrNotResponsible = errors.New("not responsible")
+
+// shardState keeps track of the shard assignment applied
+// by the controller and a pending new assignment.
+// During a hand-over the responsibility is restricted to
+// the namespaces covered by the old and the new assignment,
+// until the new assignment is acknowledged by all members.
+// Additionally, it keeps track of the reconcilations actually
+// executed for namespaces, which must be finished before
+// a namespace can be released.
+type shardState struct {
+	lock        sync.Mutex
+	assignment  sharding.Assignment
+	responsible func(ns string) bool
+	pending     sharding.Assignment
+	changed     chan struct{}
+	inflight    map[string]int
+}
+
+var _ sharding.Handler = (*shardState)(nil)
+
+func newShardState() *shardState {
+	return &shardState{
+		assignment:  sharding.All,
+		responsible: sharding.All.IsResponsible,
+		changed:     make(chan struct{}, 1),
+		inflight:    map[string]int{},
+	}
+}
+
+func (s *shardState) Assignment() sharding.Assignment {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.assignment
+}
+
+func (s *shardState) IsResponsible(ns string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.responsible(ns)
+}
+
+// enter registers a reconcilation for a namespace, if the controller
+// is responsible for it. The returned function must be called
+// when the reconcilation is finished.
+func (s *shardState) enter(ns string) (func(), bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.responsible(ns) {
+		return nil, false
+	}
+	s.inflight[ns]++
+	return func() {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		if s.inflight[ns]--; s.inflight[ns] <= 0 {
+			delete(s.inflight, ns)
+		}
+	}, true
+}
+
+// busy checks for actually executed reconcilations
+// for namespaces matching the given filter.
+func (s *shardState) busy(filter func(ns string) bool) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for ns := range s.inflight {
+		if filter(ns) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *shardState) responsibility() func(ns string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.responsible
+}
+
+func (s *shardState) set(a sharding.Assignment, responsible func(ns string) bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.assignment = a
+	s.responsible = responsible
+}
+
+func (s *shardState) ShardsChanged(a sharding.Assignment) {
+	s.lock.Lock()
+	s.pending = a
+	s.lock.Unlock()
+
+	select {
+	case s.changed <- struct{}{}:
+	default:
+	}
+}
+
+func (s *shardState) getPending() sharding.Assignment {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	a := s.pending
+	s.pending = nil
+	return a
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// SetSharding sets the sharding used to determine the namespaces
+// handled by the controller. Without sharding, the controller is
+// responsible for all namespaces. It must be called before the
+// controller is started.
+func (p *Controller) SetSharding(s sharding.Sharding) {
+	p.sharding = s
+}
+
+// Assignment provides the actually applied shard assignment.
+func (p *Controller) Assignment() sharding.Assignment {
+	return p.shards.Assignment()
+}
+
+func (p *Controller) isResponsible(ns string) bool {
+	return p.shards.IsResponsible(ns)
+}
+
+func (p *Controller) isResponsibleFor(id database.ObjectId) bool {
+	return p.isResponsible(p.shardNamespace(id))
+}
+
+// enterNamespace registers a reconcilation for a namespace, if the
+// controller is responsible for it. The returned function must be
+// called when the reconcilation is finished.
+func (p *Controller) enterNamespace(ns string) (func(), bool) {
+	return p.shards.enter(ns)
+}
+
+// enterFor registers a reconcilation for the namespace of an object,
+// if the controller is responsible for it.
+func (p *Controller) enterFor(id database.ObjectId) (func(), bool) {
+	return p.enterNamespace(p.shardNamespace(id))
+}
+
+// shardNamespace provides the namespace used to determine the shard
+// of a database object. Top-level namespace objects belong to the
+// namespace they describe. For nested namespaces the parent namespace
+// has the same shard key.
+func (p *Controller) shardNamespace(id database.ObjectId) string {
+	if id.GetNamespace() == "" && id.GetType() == p.MetaModel().NamespaceType() {
+		return id.GetName()
+	}
+	return id.GetNamespace()
+}
+
+func (p *Controller) initSharding() {
+	if p.sharding == nil {
+		return
+	}
+	p.sharding.RegisterHandler(p.shards)
+	// the namespaces are taken over by the shard manager,
+	// after the other members released them.
+	a := p.sharding.Assignment()
+	p.shards.set(a, func(string) bool { return false })
+	p.watches.SetPeers(a.Peers())
+	p.shards.ShardsChanged(a)
+}
+
+func (p *Controller) shardManager(ctx context.Context, lctx model.Logging) {
+	if p.sharding == nil {
+		return
+	}
+	log := p.logging.Logger().WithName("sharding")
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.shards.changed:
+		}
+		a := p.shards.getPending()
+		if a == nil {
+			continue
+		}
+		err := p.applyShards(lctx, log, a)
+		if err != nil {
+			log.LogError(err, "cannot apply shard assignment")
+		}
+	}
+}
+
+// awaitReleased waits for the termination of the actually executed
+// reconcilations for released namespaces.
+func (p *Controller) awaitReleased(log logging.Logger, released func(ns string) bool) {
+	if !p.shards.busy(released) {
+		return
+	}
+	log.Info("waiting for active reconcilations of released namespaces")
+	for p.shards.busy(released) {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// applyShards hands over the namespaces of the actual shard assignment
+// to the new assignment a.
+// First, the responsibility is restricted to the namespaces covered
+// by both assignments. After the active reconcilations for lost
+// namespaces are finished, those namespaces are dropped from the
+// processing model. This is acknowledged to the other members.
+// Gained namespaces are held back until all other members acknowledged
+// the new assignment, and therefore released them. Then the elements of
+// the gained namespaces are set up before the new assignment is activated.
+// Finally, all objects of gained namespaces are triggered to catch up
+// with changes done in the meantime.
+func (p *Controller) applyShards(lctx model.Logging, log logging.Logger, a sharding.Assignment) error {
+	log.Info("applying shard assignment for members {{members}}", "members", a.Members())
+
+	responsible := p.shards.responsibility()
+	kept := func(ns string) bool { return responsible(ns) && a.IsResponsible(ns) }
+	gained := func(ns string) bool { return !responsible(ns) && a.IsResponsible(ns) }
+
+	p.shards.set(a, kept)
+	p.watches.SetPeers(a.Peers())
+	// active reconcilations may still touch the elements of lost namespaces.
+	p.awaitReleased(log, func(ns string) bool { return !a.IsResponsible(ns) })
+	for _, ns := range p.processingModel.DropNamespaces(func(ns string) bool { return !a.IsResponsible(ns) }) {
+		log.Info("handing over namespace {{namespace}}", "namespace", ns)
+	}
+	err := p.sharding.Acknowledge(a)
+	if err != nil {
+		log.LogError(err, "cannot acknowledge shard assignment")
+	}
+	if !a.Acknowledged() {
+		log.Info("shard assignment not yet acknowledged by all members -> hold back gained namespaces")
+		return nil
+	}
+
+	err = p.setupNamespaces(lctx, log, gained)
+	p.shards.set(a, a.IsResponsible)
+	if err != nil {
+		return err
+	}
+
+	c := 0
+	for _, n := range p.processingModel.Namespaces() {
+		if !gained(n) {
+			continue
+		}
+		log.Info("taking over namespace {{namespace}}", "namespace", n)
+		for _, id := range p.processingModel.GetNamespace(n).Elements() {
+			p.EnqueueKey(CMD_ELEM, id)
+			c++
+		}
+	}
+
+	types := append([]string{p.MetaModel().NamespaceType()}, p.MetaModel().ExternalTypes()...)
+	if req := p.MetaModel().UpdateRequestType(); req != "" {
+		types = append(types, req)
+	}
+	for _, t := range types {
+		objs, err := p.Objectbase().ListObjects(t, true, "")
+		if err != nil {
+			return err
+		}
+		for _, o := range objs {
+			id := database.NewObjectIdFor(o)
+			if gained(p.shardNamespace(id)) {
+				p.EnqueueObject(id)
+				c++
+			}
+		}
+	}
+	log.Info("{{amount}} elements and objects triggered", "amount", c)
+	return nil
+}
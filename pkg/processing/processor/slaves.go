@@ -8,6 +8,9 @@ import (
 	. "github.com/mandelsoft/engine/pkg/processing/mmids"
 	"github.com/mandelsoft/engine/pkg/processing/model"
 	"github.com/mandelsoft/engine/pkg/processing/objectbase"
+	"github.com/mandelsoft/engine/pkg/tracing"
+	"github.com/mandelsoft/goutils/general"
+	"github.com/mandelsoft/goutils/stringutils"
 	"github.com/mandelsoft/logging"
 )
 
@@ -16,16 +19,21 @@ type SlaveManagement struct {
 	p    *Controller
 	ni   *namespaceInfo
 	elem _Element
+	span *tracing.Span
 }
 
 var _ model.SlaveManagement = (*SlaveManagement)(nil)
 
-func newSlaveManagement(r Reconcilation, ni *namespaceInfo, elem _Element) model.SlaveManagement {
+// newSlaveManagement provides the slave management for an element.
+// If a span is given, slave handling is traced as child spans and
+// the trace context is propagated into the slave objects.
+func newSlaveManagement(r Reconcilation, ni *namespaceInfo, elem _Element, span ...*tracing.Span) model.SlaveManagement {
 	return &SlaveManagement{
 		log:  r,
 		p:    r.Controller(),
 		ni:   ni,
 		elem: elem,
+		span: general.Optional(span...),
 	}
 }
 
@@ -35,7 +43,14 @@ func (s *SlaveManagement) AssureSlaves(check model.SlaveCheckFunction, update mo
 			return fmt.Errorf("unknown element type %q for slave of %q", eid.TypeId(), s.elem.Id())
 		}
 	}
-	return s.ni.assureSlaves(s.log, s.p, check, update, s.elem.GetLock(), eids...)
+	span := s.span.Start("assure slaves", tracing.Attr("slaves", stringutils.Join(eids)))
+	ob := objectbase.WithTraceParent(s.ObjectBase(), span.TraceParent())
+	created, err := s.ni.assureSlaves(s.log, s.p, ob, check, update, s.elem.Id(), s.elem.GetLock(), eids...)
+	span.EndWithError(err)
+	for _, eid := range created {
+		s.p.recorder.Normal(s.elem.Id(), REASON_SLAVE_CREATED, "created slave %s", eid)
+	}
+	return err
 }
 
 func (s *SlaveManagement) ObjectBase() objectbase.Objectbase {
@@ -88,6 +103,10 @@ func (s *SlaveManagement) AssureExternal(update model.ExternalUpdateFunction, ex
 	}
 
 	// second, update/create required objects
+	span := s.span.Start("assure external", tracing.Attr("external", database.StringId(extid)))
 	modobj = _o.(model.ExternalObject)
-	return update(ob, extid, modobj)
+	updated, o, err := update(objectbase.WithTraceParent(ob, span.TraceParent()), extid, modobj)
+	span.SetAttributes(tracing.Attr("updated", updated))
+	span.EndWithError(err)
+	return updated, o, err
 }
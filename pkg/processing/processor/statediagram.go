@@ -18,6 +18,7 @@ func init() {
 		model.STATUS_BLOCKED,
 		model.STATUS_COMPLETED,
 		model.STATUS_FAILED,
+		model.STATUS_AWAITING_APPROVAL,
 	)
 
 	processable.Insert(
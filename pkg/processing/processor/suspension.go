@@ -0,0 +1,214 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/pool"
+	. "github.com/mandelsoft/engine/pkg/processing/mmids"
+	"github.com/mandelsoft/engine/pkg/processing/model"
+	"github.com/mandelsoft/goutils/maputils"
+	"github.com/mandelsoft/logging"
+)
+
+// suspensionRegistry keeps track of elements, for which
+// the initiation of a new run has been suspended.
+// Those elements are retriggered on resume.
+// Additionally, it keeps track of the suspended objects
+// to detect a resume.
+type suspensionRegistry struct {
+	lock     sync.Mutex
+	elements map[ElementId]string
+	objects  map[database.ObjectId]struct{}
+}
+
+func newSuspensionRegistry() *suspensionRegistry {
+	return &suspensionRegistry{
+		elements: map[ElementId]string{},
+		objects:  map[database.ObjectId]struct{}{},
+	}
+}
+
+// Add registers a suspended element. It returns true,
+// if the element or the reason is new.
+func (s *suspensionRegistry) Add(id ElementId, reason string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.elements[id] == reason {
+		return false
+	}
+	s.elements[id] = reason
+	return true
+}
+
+func (s *suspensionRegistry) Remove(id ElementId) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.elements[id]; !ok {
+		return false
+	}
+	delete(s.elements, id)
+	return true
+}
+
+// Update records the suspension flag of an object.
+// It returns true, if a recorded suspension has been revoked.
+func (s *suspensionRegistry) Update(id database.ObjectId, suspended bool) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	oid := database.NewObjectIdFor(id)
+	_, ok := s.objects[oid]
+	if suspended {
+		s.objects[oid] = struct{}{}
+		return false
+	}
+	delete(s.objects, oid)
+	return ok
+}
+
+func (s *suspensionRegistry) Elements() []ElementId {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return maputils.Keys(s.elements, CompareElementId)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// checkSuspension checks whether the initiation of new runs is suspended
+// for an element. This is the case, if the triggering external object or
+// the namespace object of the element or one of its parent namespaces
+// is suspended. If suspended, a describing reason is returned.
+func (p *Controller) checkSuspension(id ElementId) (string, error) {
+	_, o, err := p.getTriggeringExternalObject(id)
+	if err != nil {
+		return "", err
+	}
+	if o != nil && o.IsSuspended() {
+		p.suspended.Update(o, true)
+		return fmt.Sprintf("object %s suspended", database.NewObjectIdFor(o)), nil
+	}
+
+	for ns := id.GetNamespace(); ns != ""; ns = ParentNamespace(ns) {
+		nns, nn := NamespaceId(ns)
+		o, err := p.Objectbase().GetObject(database.NewObjectId(p.MetaModel().NamespaceType(), nns, nn))
+		if err != nil {
+			if errors.Is(err, database.ErrNotExist) {
+				continue
+			}
+			return "", err
+		}
+		if o.IsSuspended() {
+			p.suspended.Update(o, true)
+			return fmt.Sprintf("namespace %s suspended", ns), nil
+		}
+	}
+	return "", nil
+}
+
+// updateExternalStatus reports a status and message for an element
+// at its external objects outside of a run reconcilation.
+func (p *Controller) updateExternalStatus(lctx model.Logging, id ElementId, status model.Status, message string) error {
+	update := model.StatusUpdate{
+		Status:  &status,
+		Message: &message,
+	}
+	p.setConditions(id, &update)
+	for _, t := range UpdateObjects(p, id.TypeId()) {
+		oid := database.NewObjectId(t, id.GetNamespace(), id.GetName())
+		_o, err := p.Objectbase().GetObject(oid)
+		if err != nil {
+			if errors.Is(err, database.ErrNotExist) {
+				continue
+			}
+			return err
+		}
+		err = _o.(model.ExternalObject).UpdateStatus(lctx, p.Objectbase(), id, update)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resumeElements retriggers all suspended elements,
+// which are not suspended anymore.
+func (p *Controller) resumeElements(lctx model.Logging, log logging.Logger) error {
+	var errs []error
+	for _, id := range p.suspended.Elements() {
+		reason, err := p.checkSuspension(id)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if reason != "" || !p.suspended.Remove(id) {
+			continue
+		}
+		log.Info("processing of {{element}} resumed", "element", id)
+		if e := p.processingModel._GetElement(id); e != nil {
+			err = p.updateExternalStatus(lctx, id, e.GetStatus(), "processing resumed")
+			if err != nil {
+				log.LogError(err, "cannot update status for {{element}}", "element", id)
+			}
+		}
+		p.EnqueueKey(CMD_EXT, id)
+		p.EnqueueKey(CMD_ELEM, id)
+	}
+	return errors.Join(errs...)
+}
+
+// suspended checks whether the initiation of a new run is suspended
+// for the element. If so, the element is registered to be retriggered
+// on resume and the suspension is reported at its external objects.
+func (r *elementReconcilation) suspended() (bool, error) {
+	reason, err := r.Controller().checkSuspension(r.eid)
+	if err != nil || reason == "" {
+		return false, err
+	}
+	if r.Controller().suspended.Add(r.eid, reason) {
+		r.Info("new runs for {{element}} suspended: {{reason}}", "reason", reason)
+	}
+	return true, r.Controller().updateExternalStatus(r.lctx, r.eid, model.STATUS_SUSPENDED, reason)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// namespaceObjectReconciler handles change events
+// for namespace objects to resume suspended elements
+// and to update the scheduling priorities.
+type namespaceObjectReconciler struct {
+	*reconciler
+}
+
+func newNamespaceObjectReconciler(c *Controller) *namespaceObjectReconciler {
+	return &namespaceObjectReconciler{&reconciler{controller: c}}
+}
+
+func (r *namespaceObjectReconciler) Reconcile(_ pool.Pool, ctx pool.MessageContext, id database.ObjectId) pool.Status {
+	log := ctx.Logger(REALM).WithValues("nsid", database.NewObjectIdFor(id))
+	r.controller.priorities.Reset()
+
+	suspended := false
+	o, err := r.controller.Objectbase().GetObject(id)
+	if err != nil {
+		if !errors.Is(err, database.ErrNotExist) {
+			return pool.StatusCompleted(err)
+		}
+	} else {
+		suspended = o.IsSuspended()
+	}
+	if !r.controller.suspended.Update(id, suspended) {
+		return pool.StatusCompleted()
+	}
+	err = r.resumeElements(ctx, log)
+	if err != nil {
+		// keep the suspension to resume again on retry
+		r.controller.suspended.Update(id, true)
+	}
+	return pool.StatusCompleted(err)
+}
@@ -0,0 +1,117 @@
+package processor
+
+import (
+	"fmt"
+	"sync"
+
+	. "github.com/mandelsoft/engine/pkg/processing/mmids"
+	"github.com/mandelsoft/engine/pkg/processing/model"
+	"github.com/mandelsoft/engine/pkg/tracing"
+)
+
+type runTrace struct {
+	runid RunId
+	span  *tracing.Span
+}
+
+// runTraces keeps track of the spans of element runs.
+// A run of an element spans several reconcilations, from the
+// initiation of the run until its commit or rollback.
+type runTraces struct {
+	lock  sync.Mutex
+	spans map[ElementId]*runTrace
+}
+
+func newRunTraces() *runTraces {
+	return &runTraces{spans: map[ElementId]*runTrace{}}
+}
+
+// Get provides the span of an active element run or nil.
+func (t *runTraces) Get(id ElementId, runid RunId) *tracing.Span {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if r := t.spans[id]; r != nil && r.runid == runid {
+		return r.span
+	}
+	return nil
+}
+
+// Start provides the span of an element run. If there is no span
+// for the run, yet, a new one is created. A span of a previous run
+// of the element is finished.
+func (t *runTraces) Start(tracer *tracing.Tracer, e _Element, runid RunId) *tracing.Span {
+	if !tracer.Enabled() {
+		return nil
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	id := e.Id()
+	if r := t.spans[id]; r != nil {
+		if r.runid == runid {
+			return r.span
+		}
+		r.span.SetStatus(tracing.STATUS_ERROR, fmt.Sprintf("superseded by run %s", runid))
+		r.span.End()
+	}
+
+	// all elements of a run share a trace.
+	trace, _ := tracing.TraceIdFor(string(runid))
+	attrs := []tracing.Attribute{
+		tracing.Attr("namespace", id.GetNamespace()),
+		tracing.Attr("element", id),
+		tracing.Attr("runid", runid),
+	}
+	var span *tracing.Span
+	if parent := tracing.SpanContextFor(e.GetObject()); parent.IsValid() && parent.TraceId == trace {
+		// slaves are created in the same run by the element
+		// and carry its trace context.
+		span = tracer.Start("run "+id.String(), parent, attrs...)
+	} else {
+		span = tracer.StartTrace("run "+id.String(), trace, attrs...)
+	}
+	t.spans[id] = &runTrace{runid: runid, span: span}
+	return span
+}
+
+// End finishes the span of an element run.
+func (t *runTraces) End(id ElementId, runid RunId, status model.Status, err error) {
+	t.lock.Lock()
+	r := t.spans[id]
+	if r == nil || r.runid != runid {
+		t.lock.Unlock()
+		return
+	}
+	delete(t.spans, id)
+	t.lock.Unlock()
+
+	r.span.SetAttributes(tracing.Attr("status", status))
+	r.span.EndWithError(err)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// SetTracer sets the tracer used to trace element runs.
+// By default, tracing.Default is used.
+func (p *Controller) SetTracer(t *tracing.Tracer) {
+	p.tracer = t
+}
+
+func (p *Controller) Tracer() *tracing.Tracer {
+	return p.tracer
+}
+
+// runSpan provides the span for the actual run of the element.
+func (r *elementRunReconcilation) runSpan() *tracing.Span {
+	return r.traces.Start(r.tracer, r._Element, r.runid)
+}
+
+// traceWrite executes a database write as child span of the actual run.
+func (r *elementRunReconcilation) traceWrite(name string, f func() error, attrs ...tracing.Attribute) error {
+	s := r.runSpan().Start(name, attrs...)
+	err := f()
+	s.EndWithError(err)
+	return err
+}
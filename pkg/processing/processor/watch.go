@@ -37,6 +37,10 @@ func NewWatchEvent(e _Element) *elemwatch.Event {
 		Status:   string(e.GetStatus()),
 	}
 
+	if a := e.GetApprovalState(); a != nil && a.RunId != "" && a.RunId == e.GetLock() {
+		evt.Message = approvalMessage(a)
+	}
+
 	var links []ElementId
 	state := e.GetProcessingState()
 	if state != nil {
@@ -48,6 +52,7 @@ func NewWatchEvent(e _Element) *elemwatch.Event {
 	for _, l := range links {
 		evt.Links = append(evt.Links, elemwatch.NewId(l))
 	}
+	evt.ForeignLinks = elemwatch.ForeignLinks(id.Namespace, evt.Links...)
 
 	return evt
 }
@@ -70,7 +75,7 @@ func (l *watchEventLister) ListObjectIds(typ string, closure bool, ns string, at
 		if !database.MatchNamespace(closure, ns, name) {
 			continue
 		}
-		if typ == "" || typ == l.m.mm.NamespaceType() {
+		if typ == "" || typ == l.m.MetaModel().NamespaceType() {
 			nsname := ni.GetNamespaceName()
 
 			for _, ni := range l.m.namespaces {
@@ -79,7 +84,7 @@ func (l *watchEventLister) ListObjectIds(typ string, closure bool, ns string, at
 				}
 			}
 		}
-		if typ != l.m.mm.NamespaceType() {
+		if typ != l.m.MetaModel().NamespaceType() {
 			ids := ni.list(typ)
 			for _, id := range ids {
 				e := ni._GetElement(id)
@@ -99,15 +104,15 @@ func (l *watchEventLister) ListObjectIds(typ string, closure bool, ns string, at
 func (l *watchEventLister) listAll(typ string) []elemwatch.Event {
 	var list []elemwatch.Event
 
-	if typ == "" || typ == l.m.mm.NamespaceType() {
+	if typ == "" || typ == l.m.MetaModel().NamespaceType() {
 		list = append(list, *NewWatchEventForNamespace(l.m.namespaces[""]))
 	}
 
 	for _, ni := range l.m.namespaces {
-		if typ == "" || typ == l.m.mm.NamespaceType() {
+		if typ == "" || typ == l.m.MetaModel().NamespaceType() {
 			list = append(list, *NewWatchEventForNamespace(ni))
 		}
-		if typ != l.m.mm.NamespaceType() {
+		if typ != l.m.MetaModel().NamespaceType() {
 			for _, id := range ni.list(typ) {
 				e := l.m._GetElement(id)
 				if e != nil {
@@ -1,12 +1,22 @@
 package testutils
 
+import (
+	"time"
+
+	"github.com/mandelsoft/vfs/pkg/vfs"
+)
+
 type Option interface {
 	ApplyTo(opts *Options)
 }
 
 type Options struct {
-	numWorker  int
-	debugLevel int
+	numWorker     int
+	debugLevel    int
+	fs            vfs.FileSystem
+	identity      string
+	leaseDuration time.Duration
+	shard         *shardOpt
 }
 
 type workerOpt int
@@ -28,3 +38,51 @@ func DebugLevel(n int) Option {
 func (o debugLevel) ApplyTo(opts *Options) {
 	opts.debugLevel = int(o)
 }
+
+type fsOpt struct {
+	fs vfs.FileSystem
+}
+
+// FileSystem uses the filesystem of another test environment
+// to run multiple engines against the same database.
+// The filesystem is not cleaned up by the environment.
+func FileSystem(fs vfs.FileSystem) Option {
+	return fsOpt{fs}
+}
+
+func (o fsOpt) ApplyTo(opts *Options) {
+	opts.fs = o.fs
+}
+
+type leaderOpt struct {
+	identity string
+	duration time.Duration
+}
+
+// LeaderElection runs the processor and the added
+// services only while holding the lease of the database.
+func LeaderElection(identity string, duration time.Duration) Option {
+	return leaderOpt{identity, duration}
+}
+
+func (o leaderOpt) ApplyTo(opts *Options) {
+	opts.identity = o.identity
+	opts.leaseDuration = o.duration
+}
+
+type shardOpt struct {
+	identity   string
+	duration   time.Duration
+	namespaces []string
+}
+
+// Sharding runs the processor only for the namespace shards
+// assigned to the given identity. Optionally, base namespaces can
+// explicitly be assigned.
+func Sharding(identity string, duration time.Duration, namespaces ...string) Option {
+	return &shardOpt{identity, duration, namespaces}
+}
+
+func (o *shardOpt) ApplyTo(opts *Options) {
+	opts.shard = o
+}
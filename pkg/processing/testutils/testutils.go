@@ -18,6 +18,7 @@ import (
 
 	"github.com/mandelsoft/engine/pkg/ctxutil"
 	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/election"
 	"github.com/mandelsoft/engine/pkg/future"
 	"github.com/mandelsoft/engine/pkg/impl/database/filesystem"
 	"github.com/mandelsoft/engine/pkg/processing/metamodel"
@@ -26,6 +27,7 @@ import (
 	"github.com/mandelsoft/engine/pkg/processing/objectbase"
 	"github.com/mandelsoft/engine/pkg/processing/processor"
 	"github.com/mandelsoft/engine/pkg/service"
+	"github.com/mandelsoft/engine/pkg/sharding"
 )
 
 var log = logging.DefaultContext().Logger(logging.NewRealm("testenv"))
@@ -41,6 +43,9 @@ type TestEnv struct {
 	logbuf       *bytes.Buffer
 	db           database.Database[db.Object]
 	proc         *processor.Controller
+	leader       *service.LeaderElected
+	shards       *sharding.Membership[db.Object]
+	sharedfs     bool
 	startables   []Startable
 	started      bool
 	objectStatus future.EventManager[ObjectId, model.Status]
@@ -62,15 +67,24 @@ func NewTestEnv(name string, path string, creator ModelCreator, opts ...Option)
 		o.ApplyTo(options)
 	}
 
-	fs, err := TestFileSystem(path, false)
-	if err != nil {
-		return nil, err
+	fs := options.fs
+	if fs == nil {
+		var err error
+		fs, err = TestFileSystem(path, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+	cleanup := func() {
+		if options.fs == nil {
+			vfs.Cleanup(fs)
+		}
 	}
 
 	spec := creator(name, filesystem.NewSpecification[db.Object](path, fs))
-	err = spec.Validate()
+	err := spec.Validate()
 	if err != nil {
-		vfs.Cleanup(fs)
+		cleanup()
 		return nil, err
 	}
 
@@ -86,19 +100,36 @@ func NewTestEnv(name string, path string, creator ModelCreator, opts ...Option)
 
 	m, err := model.NewModel(spec)
 	if err != nil {
-		vfs.Cleanup(fs)
+		cleanup()
 		return nil, err
 	}
 	proc := Must(processor.NewController(lctx, m, options.numWorker))
+	lease := db.NewLease("", "engine")
+	member := db.NewShardMember("", "")
+	var shards *sharding.Membership[db.Object]
 	db := objectbase.GetDatabase[db.Object](proc.Model().ObjectBase())
 
 	mgr := future.NewEventManager[ObjectId, model.Status]()
 
 	srvs := service.New(ctx)
-	srvs.Add(proc)
+	if o := options.shard; o != nil {
+		shards = sharding.New(lctx, db, member, sharding.MemberSpec{Identity: o.identity, Namespaces: o.namespaces}, o.duration)
+		proc.SetSharding(shards)
+		srvs.Add(shards)
+	}
+	var leader *service.LeaderElected
+	if options.identity != "" {
+		leader = service.NewLeaderElected(election.New(lctx, db, lease, options.identity, options.leaseDuration), proc)
+		srvs.Add(leader)
+	} else {
+		srvs.Add(proc)
+	}
 	db.RegisterHandler(&handler{db, mgr}, false, "", true, "/")
 	return &TestEnv{
 		services:     srvs,
+		leader:       leader,
+		shards:       shards,
+		sharedfs:     options.fs != nil,
 		fs:           fs,
 		ctx:          ctx,
 		lctx:         lctx,
@@ -129,10 +160,34 @@ func (t *TestEnv) MetaModel() metamodel.MetaModel {
 	return t.proc.Model().MetaModel()
 }
 
+func (t *TestEnv) FileSystem() vfs.FileSystem {
+	return t.fs
+}
+
+// AddService adds a service. If leader election is used,
+// the service is run only while holding the lease.
 func (t *TestEnv) AddService(s Startable) error {
+	if t.leader != nil {
+		return t.leader.Add(s)
+	}
 	return t.services.Add(s)
 }
 
+// WaitForLeadership waits until the lease has been acquired and the
+// processor has been started. Without leader election it returns
+// immediately.
+func (t *TestEnv) WaitForLeadership() error {
+	if t.leader == nil {
+		return nil
+	}
+	return t.leader.Leading().Wait()
+}
+
+// Sharding provides the shard membership, if sharding is used.
+func (t *TestEnv) Sharding() *sharding.Membership[db.Object] {
+	return t.shards
+}
+
 func (t *TestEnv) Start(st ...Startable) error {
 	return t.services.Start(st...)
 }
@@ -178,10 +233,18 @@ func Modify[O db.Object, R any](env *TestEnv, o *O, mod func(o O) (R, bool)) (R,
 	return database.Modify(env.db, o, mod)
 }
 
-func (t *TestEnv) Cleanup() {
+// Stop stops the services of the environment
+// without cleaning up the filesystem.
+func (t *TestEnv) Stop() {
 	ctxutil.Cancel(t.ctx)
 	t.services.Wait()
-	vfs.Cleanup(t.fs)
+}
+
+func (t *TestEnv) Cleanup() {
+	t.Stop()
+	if !t.sharedfs {
+		vfs.Cleanup(t.fs)
+	}
 }
 
 type handler struct {
@@ -0,0 +1,143 @@
+package updaterequest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/database/service"
+	"github.com/mandelsoft/engine/pkg/processing/model/support/db"
+)
+
+// Access provides access to the update request objects
+// stored in a database.
+type Access interface {
+	Get(id database.ObjectId) (*db.UpdateRequest, error)
+	Set(r *db.UpdateRequest) error
+	Delete(id database.ObjectId) error
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type dbAccess struct {
+	db database.Database[db.Object]
+}
+
+// ForDatabase provides an Access directly working on a database.
+// The scheme of the database must use db.UpdateRequest for the
+// update request type of the metamodel.
+func ForDatabase(odb database.Database[db.Object]) Access {
+	return &dbAccess{odb}
+}
+
+func (a *dbAccess) Get(id database.ObjectId) (*db.UpdateRequest, error) {
+	o, err := a.db.GetObject(id)
+	if err != nil {
+		return nil, err
+	}
+	r, ok := o.(*db.UpdateRequest)
+	if !ok {
+		return nil, fmt.Errorf("%s is no update request (%T)", database.NewObjectIdFor(id), o)
+	}
+	return r, nil
+}
+
+func (a *dbAccess) Set(r *db.UpdateRequest) error {
+	return a.db.SetObject(r)
+}
+
+func (a *dbAccess) Delete(id database.ObjectId) error {
+	_, err := a.db.DeleteObject(id)
+	return err
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type serviceAccess struct {
+	url string
+}
+
+// ForService provides an Access using the database service
+// of an engine server. The url is the base URL of the
+// database service.
+func ForService(url string) Access {
+	if !strings.HasSuffix(url, "/") {
+		url += "/"
+	}
+	return &serviceAccess{url}
+}
+
+func (a *serviceAccess) objectURL(id database.ObjectId) string {
+	return a.url + path.Join(id.GetType(), id.GetNamespace(), id.GetName())
+}
+
+func (a *serviceAccess) Get(id database.ObjectId) (*db.UpdateRequest, error) {
+	r, err := http.Get(a.objectURL(id))
+	if err != nil {
+		return nil, err
+	}
+	data, err := responseData(r)
+	if err != nil {
+		return nil, err
+	}
+	var ur db.UpdateRequest
+	err = json.Unmarshal(data, &ur)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", database.NewObjectIdFor(id), err)
+	}
+	return &ur, nil
+}
+
+func (a *serviceAccess) Set(ur *db.UpdateRequest) error {
+	data, err := json.Marshal(ur)
+	if err != nil {
+		return err
+	}
+	r, err := http.Post(a.objectURL(ur), "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	_, err = responseData(r)
+	return err
+}
+
+func (a *serviceAccess) Delete(id database.ObjectId) error {
+	req, err := http.NewRequest(http.MethodDelete, a.objectURL(id), nil)
+	if err != nil {
+		return err
+	}
+	r, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	_, err = responseData(r)
+	return err
+}
+
+func responseData(r *http.Response) ([]byte, error) {
+	defer r.Body.Close()
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	switch r.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusAccepted:
+		return data, nil
+	case http.StatusNotFound:
+		return nil, database.ErrNotExist
+	case http.StatusConflict:
+		return nil, database.ErrModified
+	}
+
+	var msg service.Error
+	if len(data) == 0 || json.Unmarshal(data, &msg) != nil {
+		return nil, fmt.Errorf("request failed with status %s", r.Status)
+	}
+	return nil, errors.New(msg.Error)
+}
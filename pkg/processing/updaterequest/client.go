@@ -0,0 +1,194 @@
+package updaterequest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/processing/model"
+	"github.com/mandelsoft/engine/pkg/processing/model/support/db"
+)
+
+// DEFAULT_POLL_INTERVAL is the default interval used to
+// check the status of an update request.
+const DEFAULT_POLL_INTERVAL = 500 * time.Millisecond
+
+var (
+	ErrExists  = errors.New("update request already exists")
+	ErrExpired = errors.New("update request lease expired")
+	ErrInvalid = errors.New("invalid update request")
+)
+
+// Client executes a transaction for a set of external
+// objects using an update request object.
+// Begin locks the elements triggered by the objects.
+// Afterwards, the objects can be modified without
+// triggering the processing. Commit releases the locks,
+// which processes all modifications together. Abort releases
+// the locks without waiting for the objects to be processed.
+// The request object exists only for the duration of the
+// transaction, it is deleted by Commit and Abort.
+type Client struct {
+	access   Access
+	id       database.ObjectId
+	lease    time.Duration
+	interval time.Duration
+}
+
+// New creates a client for the update request with the given
+// type (the update request type of the metamodel), namespace and name.
+func New(access Access, typ, ns, name string) *Client {
+	return &Client{
+		access:   access,
+		id:       database.NewObjectId(typ, ns, name),
+		interval: DEFAULT_POLL_INTERVAL,
+	}
+}
+
+// SetLease sets the lease used for the request.
+// If the client does not commit the transaction in time,
+// the engine releases the locks. 0 means no lease.
+func (c *Client) SetLease(d time.Duration) *Client {
+	c.lease = d
+	return c
+}
+
+// SetPollInterval sets the interval used to wait for
+// the status of the request.
+func (c *Client) SetPollInterval(d time.Duration) *Client {
+	if d > 0 {
+		c.interval = d
+	}
+	return c
+}
+
+func (c *Client) Id() database.ObjectId {
+	return c.id
+}
+
+// Status provides the actual status of the request.
+func (c *Client) Status() (*model.UpdateStatus, error) {
+	r, err := c.access.Get(c.id)
+	if err != nil {
+		return nil, err
+	}
+	return r.GetStatus(), nil
+}
+
+// Begin creates the request and waits until the given objects
+// are locked.
+func (c *Client) Begin(ctx context.Context, objs ...database.LocalObjectRef) error {
+	_, err := c.access.Get(c.id)
+	if err == nil {
+		return fmt.Errorf("%s: %w", c.id, ErrExists)
+	}
+	if !errors.Is(err, database.ErrNotExist) {
+		return err
+	}
+
+	r := &db.UpdateRequest{ObjectMeta: db.NewObjectMeta(c.id.GetType(), c.id.GetNamespace(), c.id.GetName())}
+	r.RequestAction(model.REQ_ACTION_LOCK, objs...)
+	if c.lease > 0 {
+		r.Spec.Lease = c.lease.String()
+	}
+	err = c.access.Set(r)
+	if err != nil {
+		return err
+	}
+	return c.wait(ctx, r.Spec.Version(), model.REQ_STATUS_LOCKED)
+}
+
+// Commit releases the locks of the request and waits
+// until the locked objects are handed over to the processing.
+// Afterwards, the request is deleted.
+func (c *Client) Commit(ctx context.Context) error {
+	var version string
+	for {
+		r, err := c.access.Get(c.id)
+		if err != nil {
+			return err
+		}
+		switch r.Status.Status {
+		case model.REQ_STATUS_EXPIRED:
+			c.delete()
+			return fmt.Errorf("%s: %w: %s", c.id, ErrExpired, r.Status.Message)
+		case model.REQ_STATUS_INVALID:
+			c.delete()
+			return fmt.Errorf("%s: %w: %s", c.id, ErrInvalid, r.Status.Message)
+		}
+		r.RequestAction(model.REQ_ACTION_RELEASE, r.Spec.Objects...)
+		version = r.Spec.Version()
+		err = c.access.Set(r)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, database.ErrModified) {
+			return err
+		}
+	}
+	err := c.wait(ctx, version, model.REQ_STATUS_RELEASED)
+	if err != nil {
+		return err
+	}
+	return c.delete()
+}
+
+// Abort deletes the request, which releases all locks held
+// by the request.
+func (c *Client) Abort() error {
+	return c.delete()
+}
+
+// Atomic executes the given function while the given objects
+// are locked. If the function fails the transaction is aborted.
+func (c *Client) Atomic(ctx context.Context, f func() error, objs ...database.LocalObjectRef) error {
+	err := c.Begin(ctx, objs...)
+	if err != nil {
+		c.Abort()
+		return err
+	}
+	err = f()
+	if err != nil {
+		c.Abort()
+		return err
+	}
+	return c.Commit(ctx)
+}
+
+func (c *Client) delete() error {
+	err := c.access.Delete(c.id)
+	if errors.Is(err, database.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (c *Client) wait(ctx context.Context, version string, status ...string) error {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		r, err := c.access.Get(c.id)
+		if err != nil {
+			return err
+		}
+		s := r.GetStatus()
+		if s.ObservedVersion == version && slices.Contains(status, s.Status) {
+			return nil
+		}
+		switch s.Status {
+		case model.REQ_STATUS_EXPIRED:
+			return fmt.Errorf("%s: %w: %s", c.id, ErrExpired, s.Message)
+		case model.REQ_STATUS_INVALID:
+			return fmt.Errorf("%s: %w: %s", c.id, ErrInvalid, s.Message)
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s: %w (status %q)", c.id, ctx.Err(), s.Status)
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package watch
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/mandelsoft/engine/pkg/watch"
+	"github.com/mandelsoft/logging"
+)
+
+var REALM = logging.DefineRealm("engine/watch/aggregator", "cross shard watch aggregation")
+
+type watchKey struct {
+	req     Request
+	handler EventHandler
+}
+
+type forwarder struct {
+	peers map[string]context.CancelFunc
+}
+
+// Aggregator is a watch registry aggregating the events of a local
+// registry and the registries of peer engine instances responsible for
+// other namespace shards. Non-local watch requests are forwarded as
+// local requests to all actual peers.
+// Peer connections are reestablished on a change of the peer set.
+type Aggregator struct {
+	lock    sync.Mutex
+	log     logging.Logger
+	local   watch.Registry[Request, Event]
+	peers   []string
+	watches map[watchKey]*forwarder
+}
+
+var _ watch.Registry[Request, Event] = (*Aggregator)(nil)
+
+func NewAggregator(lctx logging.AttributionContextProvider, local watch.Registry[Request, Event]) *Aggregator {
+	return &Aggregator{
+		log:     lctx.AttributionContext().Logger(REALM),
+		local:   local,
+		watches: map[watchKey]*forwarder{},
+	}
+}
+
+func (a *Aggregator) RegisterWatchHandler(req Request, h EventHandler) {
+	a.local.RegisterWatchHandler(req, h)
+	if req.Local {
+		return
+	}
+
+	key := watchKey{req, h}
+	a.lock.Lock()
+	if a.watches[key] != nil {
+		a.lock.Unlock()
+		return
+	}
+	f := &forwarder{peers: map[string]context.CancelFunc{}}
+	a.watches[key] = f
+	peers := slices.Clone(a.peers)
+	a.lock.Unlock()
+
+	for _, p := range peers {
+		a.connect(key, f, p)
+	}
+}
+
+func (a *Aggregator) UnregisterWatchHandler(req Request, h EventHandler) {
+	a.local.UnregisterWatchHandler(req, h)
+
+	key := watchKey{req, h}
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if f := a.watches[key]; f != nil {
+		for _, c := range f.peers {
+			c()
+		}
+		delete(a.watches, key)
+	}
+}
+
+// SetPeers sets the watch endpoints of the actual peers.
+func (a *Aggregator) SetPeers(peers []string) {
+	type connection struct {
+		key  watchKey
+		f    *forwarder
+		peer string
+	}
+	var connect []connection
+
+	a.lock.Lock()
+	a.peers = slices.Clone(peers)
+	for key, f := range a.watches {
+		for p, c := range f.peers {
+			if !slices.Contains(peers, p) {
+				a.log.Info("closing watch forwarding to {{peer}}", "peer", p)
+				c()
+				delete(f.peers, p)
+			}
+		}
+		for _, p := range peers {
+			if f.peers[p] == nil {
+				connect = append(connect, connection{key, f, p})
+			}
+		}
+	}
+	a.lock.Unlock()
+
+	for _, c := range connect {
+		a.connect(c.key, c.f, c.peer)
+	}
+}
+
+func (a *Aggregator) connect(key watchKey, f *forwarder, peer string) {
+	a.log.Info("forwarding watch to {{peer}}", "peer", peer)
+	ctx, cancel := context.WithCancel(context.Background())
+	req := key.req
+	req.Local = true
+	_, err := watch.NewClient[Request, Event](peer).Register(ctx, req, key.handler)
+	if err != nil {
+		a.log.LogError(err, "cannot forward watch to {{peer}}", "peer", peer)
+		cancel()
+		return
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if a.watches[key] != f || f.peers[peer] != nil || !slices.Contains(a.peers, peer) {
+		// outdated in the meantime
+		cancel()
+		return
+	}
+	f.peers[peer] = cancel
+}
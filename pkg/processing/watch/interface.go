@@ -42,6 +42,9 @@ type Event struct {
 	Deletion bool   `json:"deletion,omitempty"`
 
 	Links []Id `json:"links,omitempty"`
+	// ForeignLinks are the links to elements of other namespaces.
+	// They are included in Links, too.
+	ForeignLinks []Id `json:"foreignLinks,omitempty"`
 
 	Status  string `json:"status"`
 	Message string `json:"message"`
@@ -60,6 +63,9 @@ type Request struct {
 	Namespace string `json:"namespace"`
 	// Flat restricts watch to flat namespace
 	Flat bool `json:"flat,omitempty"`
+	// Local restricts watch to the events of the addressed engine instance.
+	// It is used to aggregate watches across namespace shards.
+	Local bool `json:"local,omitempty"`
 }
 
 func NewId(id ElementId) Id {
@@ -73,14 +79,27 @@ func NewId(id ElementId) Id {
 
 func NewEvent(id Id, lock, status, message string, links ...Id) Event {
 	return Event{
-		Node:    id,
-		Lock:    lock,
-		Links:   links,
-		Status:  status,
-		Message: message,
+		Node:         id,
+		Lock:         lock,
+		Links:        links,
+		ForeignLinks: ForeignLinks(id.Namespace, links...),
+		Status:       status,
+		Message:      message,
 	}
 }
 
+// ForeignLinks provides the links to elements outside
+// the given namespace.
+func ForeignLinks(ns string, links ...Id) []Id {
+	var r []Id
+	for _, l := range links {
+		if l.Namespace != ns {
+			r = append(r, l)
+		}
+	}
+	return r
+}
+
 type Trigger interface {
 	TriggerEvent(Event)
 }
@@ -0,0 +1,92 @@
+package recorder
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/mandelsoft/engine/pkg/database"
+)
+
+// EventType describes the severity of an event.
+type EventType string
+
+const (
+	// TYPE_NORMAL is used for events describing the regular processing.
+	TYPE_NORMAL EventType = "Normal"
+	// TYPE_WARNING is used for events describing problems.
+	TYPE_WARNING EventType = "Warning"
+)
+
+// InvolvedObject describes the element an event is reported for.
+type InvolvedObject struct {
+	Type      string `json:"type"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Phase     string `json:"phase,omitempty"`
+}
+
+// String provides the string representation of the involved element.
+func (o *InvolvedObject) String() string {
+	if o.Phase == "" {
+		return fmt.Sprintf("%s/%s/%s", o.Type, o.Namespace, o.Name)
+	}
+	return fmt.Sprintf("%s/%s/%s:%s", o.Type, o.Namespace, o.Name, o.Phase)
+}
+
+// EventSpec describes an event. Events with the same
+// spec are aggregated into a single event object.
+type EventSpec struct {
+	Type           EventType      `json:"type"`
+	Reason         string         `json:"reason"`
+	Message        string         `json:"message,omitempty"`
+	InvolvedObject InvolvedObject `json:"involvedObject"`
+}
+
+// EventStatus describes the occurrences of an event.
+type EventStatus struct {
+	Count          int       `json:"count"`
+	FirstTimestamp time.Time `json:"firstTimestamp"`
+	LastTimestamp  time.Time `json:"lastTimestamp"`
+}
+
+// Record is the complete description of an event.
+type Record struct {
+	Spec   EventSpec   `json:"spec"`
+	Status EventStatus `json:"status"`
+}
+
+// Name provides the name of the event object for the record.
+// It is unique for the spec of the event in its namespace.
+func (r *Record) Name() string {
+	return ObjectName(&r.Spec)
+}
+
+// ObjectName provides the name of an event object for
+// an event spec. Object names are restricted to alphanumeric
+// characters, dashes and underscores, therefore the components
+// are separated by underscores and the message is represented
+// by a digest.
+func ObjectName(s *EventSpec) string {
+	h := sha256.Sum256([]byte(string(s.Type) + "\n" + s.Message))
+	return fmt.Sprintf("%s_%s_%s_%s_%s", s.InvolvedObject.Type, s.InvolvedObject.Name, s.InvolvedObject.Phase, s.Reason, hex.EncodeToString(h[:6]))
+}
+
+// CompareRecord orders records by the time of their last occurrence.
+func CompareRecord(a, b *Record) int {
+	d := a.Status.LastTimestamp.Compare(b.Status.LastTimestamp)
+	if d == 0 {
+		d = a.Status.FirstTimestamp.Compare(b.Status.FirstTimestamp)
+	}
+	return d
+}
+
+// Event is the interface of database objects used to
+// persist events.
+type Event interface {
+	database.Object
+
+	GetEventRecord() Record
+	SetEventRecord(Record)
+}
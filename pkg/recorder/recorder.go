@@ -0,0 +1,135 @@
+package recorder
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/logging"
+)
+
+var REALM = logging.DefineRealm("engine/recorder", "event recorder")
+
+// DEFAULT_TTL is the default time events are kept
+// after their last occurrence.
+const DEFAULT_TTL = time.Hour
+
+// Recorder persists events as database objects.
+// Event objects are stored in the namespace of the involved
+// element using the name provided by ObjectName. Repeated
+// occurrences of the same event are aggregated by increasing
+// the count of the existing event object.
+// Events not observed for the TTL are removed. The cleanup
+// is done for the namespace of a recorded event at most once
+// per half TTL. Concurrent updates of event objects are handled
+// by the database, so only the cleanup bookkeeping is locked.
+type Recorder[O database.Object] struct {
+	lock   sync.Mutex
+	log    logging.Logger
+	db     database.Database[O]
+	typ    string
+	ttl    time.Duration
+	pruned map[string]time.Time
+}
+
+// New creates a Recorder storing events as objects with
+// the type of proto. A ttl <= 0 keeps events forever.
+func New[O database.Object](lctx logging.AttributionContextProvider, db database.Database[O], proto database.ObjectId, ttl time.Duration) *Recorder[O] {
+	return &Recorder[O]{
+		log:    lctx.AttributionContext().Logger(REALM),
+		db:     db,
+		typ:    proto.GetType(),
+		ttl:    ttl,
+		pruned: map[string]time.Time{},
+	}
+}
+
+func (r *Recorder[O]) TTL() time.Duration {
+	return r.ttl
+}
+
+// Record records an occurrence of an event.
+func (r *Recorder[O]) Record(spec EventSpec) error {
+	now := time.Now()
+	err := r.store(&spec, now)
+	if err != nil || r.ttl <= 0 {
+		return err
+	}
+	ns := spec.InvolvedObject.Namespace
+	r.lock.Lock()
+	due := now.Sub(r.pruned[ns]) >= r.ttl/2
+	if due {
+		r.pruned[ns] = now
+	}
+	r.lock.Unlock()
+	if !due {
+		return nil
+	}
+	return r.prune(ns, now)
+}
+
+func (r *Recorder[O]) store(spec *EventSpec, now time.Time) error {
+	id := database.NewObjectId(r.typ, spec.InvolvedObject.Namespace, ObjectName(spec))
+	return database.CreateOrUpdate(r.db, id, func(eo Event) error {
+		rec := eo.GetEventRecord()
+		if rec.Status.Count == 0 {
+			rec.Spec = *spec
+			rec.Status.FirstTimestamp = now
+		}
+		rec.Status.Count++
+		rec.Status.LastTimestamp = now
+		eo.SetEventRecord(rec)
+		return nil
+	})
+}
+
+// List provides the events of a namespace (or namespace closure)
+// matching the given function, ordered by their last occurrence.
+func (r *Recorder[O]) List(ns string, closure bool, match func(r *Record) bool) ([]*Record, error) {
+	list, err := database.ListObjectsAs[Event](r.db, r.typ, closure, ns)
+	if err != nil {
+		return nil, err
+	}
+	var result []*Record
+	for _, eo := range list {
+		rec := eo.GetEventRecord()
+		if match == nil || match(&rec) {
+			result = append(result, &rec)
+		}
+	}
+	slices.SortFunc(result, CompareRecord)
+	return result, nil
+}
+
+// Prune removes the events of a namespace not observed for the TTL.
+func (r *Recorder[O]) Prune(ns string) error {
+	now := time.Now()
+	r.lock.Lock()
+	r.pruned[ns] = now
+	r.lock.Unlock()
+	return r.prune(ns, now)
+}
+
+func (r *Recorder[O]) prune(ns string, now time.Time) error {
+	if r.ttl <= 0 {
+		return nil
+	}
+	list, err := r.List(ns, false, func(rec *Record) bool {
+		return now.Sub(rec.Status.LastTimestamp) > r.ttl
+	})
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, rec := range list {
+		r.log.Debug("removing event {{event}} for {{element}}", "event", rec.Name(), "element", rec.Spec.InvolvedObject.String())
+		_, err := r.db.DeleteObject(database.NewObjectId(r.typ, ns, rec.Name()))
+		if err != nil && !errors.Is(err, database.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
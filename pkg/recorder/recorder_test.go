@@ -0,0 +1,93 @@
+package recorder_test
+
+import (
+	"time"
+
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/impl/database/filesystem"
+	"github.com/mandelsoft/engine/pkg/processing/model/support/db"
+	"github.com/mandelsoft/engine/pkg/recorder"
+	"github.com/mandelsoft/logging"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+)
+
+var _ = Describe("event recorder", func() {
+	var odb database.Database[db.Object]
+
+	event := func(ns, name string, typ recorder.EventType, reason, msg string) recorder.EventSpec {
+		return recorder.EventSpec{
+			Type:    typ,
+			Reason:  reason,
+			Message: msg,
+			InvolvedObject: recorder.InvolvedObject{
+				Type:      "State",
+				Namespace: ns,
+				Name:      name,
+				Phase:     "Phase",
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		scheme := db.NewScheme[db.Object]()
+		database.MustRegisterType[db.Event, db.Object](scheme)
+		odb = Must(filesystem.New[db.Object](scheme, "/db", memoryfs.New()))
+	})
+
+	It("aggregates identical events", func() {
+		r := recorder.New(logging.DefaultContext(), odb, db.NewEvent("", ""), 0)
+
+		e := event("ns", "A", recorder.TYPE_WARNING, "Failed", "some error")
+		MustBeSuccessful(r.Record(e))
+		MustBeSuccessful(r.Record(e))
+		MustBeSuccessful(r.Record(event("ns", "A", recorder.TYPE_WARNING, "Failed", "other error")))
+
+		list := Must(r.List("ns", false, nil))
+		Expect(list).To(HaveLen(2))
+		Expect(list[0].Spec).To(Equal(e))
+		Expect(list[0].Status.Count).To(Equal(2))
+		Expect(list[0].Status.LastTimestamp).NotTo(BeTemporally("<", list[0].Status.FirstTimestamp))
+		Expect(list[1].Spec.Message).To(Equal("other error"))
+		Expect(list[1].Status.Count).To(Equal(1))
+
+		o := Must(odb.GetObject(database.NewObjectId(db.TYPE_EVENT, "ns", list[0].Name()))).(*db.Event)
+		Expect(o.Spec.InvolvedObject.String()).To(Equal("State/ns/A:Phase"))
+	})
+
+	It("lists events of namespace closures", func() {
+		r := recorder.New(logging.DefaultContext(), odb, db.NewEvent("", ""), 0)
+
+		MustBeSuccessful(r.Record(event("ns", "A", recorder.TYPE_NORMAL, "Completed", "")))
+		MustBeSuccessful(r.Record(event("ns/sub", "B", recorder.TYPE_NORMAL, "Completed", "")))
+
+		Expect(Must(r.List("ns", false, nil))).To(HaveLen(1))
+		Expect(Must(r.List("ns", true, nil))).To(HaveLen(2))
+		Expect(Must(r.List("", true, func(r *recorder.Record) bool { return r.Spec.InvolvedObject.Name == "B" }))).To(HaveLen(1))
+	})
+
+	It("removes outdated events", func() {
+		r := recorder.New(logging.DefaultContext(), odb, db.NewEvent("", ""), time.Hour)
+
+		old := event("ns", "A", recorder.TYPE_NORMAL, "Completed", "")
+		o := db.NewEvent("ns", recorder.ObjectName(&old))
+		o.SetEventRecord(recorder.Record{
+			Spec: old,
+			Status: recorder.EventStatus{
+				Count:          1,
+				FirstTimestamp: time.Now().Add(-2 * time.Hour),
+				LastTimestamp:  time.Now().Add(-2 * time.Hour),
+			},
+		})
+		MustBeSuccessful(odb.SetObject(o))
+
+		MustBeSuccessful(r.Record(event("ns", "B", recorder.TYPE_NORMAL, "Completed", "")))
+
+		list := Must(r.List("ns", false, nil))
+		Expect(list).To(HaveLen(1))
+		Expect(list[0].Spec.InvolvedObject.Name).To(Equal("B"))
+	})
+})
@@ -7,6 +7,7 @@ import (
 	"net"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/mandelsoft/engine/pkg/service"
@@ -20,9 +21,9 @@ type Server struct {
 	server *http.Server
 	mux    *http.ServeMux
 
-	closer []io.Closer
-	ready  service.Trigger
-	done   service.Trigger
+	handlers map[string]*handler
+	ready    service.Trigger
+	done     service.Trigger
 }
 
 var _ service.Service = (*Server)(nil)
@@ -39,6 +40,7 @@ func NewServer(port int, def bool, shutdownTimeout time.Duration) *Server {
 	return &Server{
 		server:          server,
 		mux:             mux,
+		handlers:        map[string]*handler{},
 		shutdownTimeout: shutdownTimeout,
 		done:            service.SyncTrigger(),
 		ready:           service.SyncTrigger(),
@@ -63,14 +65,25 @@ func (s *Server) Wait() error {
 	return s.done.Wait()
 }
 
-func (s *Server) Handle(pattern string, handler http.Handler) {
-	s.mux.Handle(pattern, handler)
-
-	if c, ok := handler.(io.Closer); ok {
-		s.lock.Lock()
-		defer s.lock.Unlock()
-		s.closer = append(s.closer, c)
+// Handle registers a handler for a pattern.
+// A handler registered for an already used pattern replaces
+// the previous one, a replaced handler implementing io.Closer is closed.
+// This is used to serve recreated services, for example after
+// a leadership has been acquired again.
+func (s *Server) Handle(pattern string, h http.Handler) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if old := s.handlers[pattern]; old != nil {
+		if c, ok := (*old.current.Swap(&h)).(io.Closer); ok {
+			c.Close()
+		}
+		return
 	}
+	n := &handler{}
+	n.current.Store(&h)
+	s.handlers[pattern] = n
+	s.mux.Handle(pattern, n)
 }
 
 func (s *Server) Shutdown(ctx context.Context) error {
@@ -78,10 +91,14 @@ func (s *Server) Shutdown(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	for _, c := range s.closer {
-		err2 := c.Close()
-		if err2 != nil {
-			err = err2
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, h := range s.handlers {
+		if c, ok := (*h.current.Load()).(io.Closer); ok {
+			err2 := c.Close()
+			if err2 != nil {
+				err = err2
+			}
 		}
 	}
 	s.done.SetError(err)
@@ -131,3 +148,12 @@ func (s *Server) listenAndServe() error {
 	}
 	return s.server.ServeTLS(ln, s.certFile, s.keyFile)
 }
+
+// handler is a replaceable handler registered for a pattern.
+type handler struct {
+	current atomic.Pointer[http.Handler]
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	(*h.current.Load()).ServeHTTP(w, req)
+}
@@ -44,6 +44,18 @@ var _ = Describe("Test Environment", func() {
 			data := Must(io.ReadAll(resp.Body))
 			Expect(string(data)).To(Equal("test handler\n"))
 		})
+
+		It("replaces handlers", func() {
+			srv.Handle("/test", http.HandlerFunc(testHandler))
+			srv.Handle("/test", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, "replaced handler\n")
+			}))
+
+			MustBeSuccessful(ready.Wait())
+			resp := Must(http.Get("http://localhost:8080/test"))
+			data := Must(io.ReadAll(resp.Body))
+			Expect(string(data)).To(Equal("replaced handler\n"))
+		})
 	})
 })
 
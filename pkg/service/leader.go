@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/mandelsoft/engine/pkg/ctxutil"
+)
+
+// ErrLeadershipLost is reported by LeaderElected services,
+// if the leadership has been lost.
+var ErrLeadershipLost = fmt.Errorf("leadership lost")
+
+// Leadership is a leader election used to gate
+// the execution of services.
+type Leadership interface {
+	// Acquire blocks until the leadership is acquired or the context
+	// is cancelled. The returned channel is closed, if the leadership
+	// is lost.
+	Acquire(ctx context.Context) (<-chan struct{}, error)
+	// Release gives up an acquired leadership.
+	Release() error
+}
+
+// ServiceFactory creates the services run for a term of leadership.
+type ServiceFactory func() ([]Service, error)
+
+// LeaderElected is a Service running a set of services
+// only while holding the leadership.
+// Services cannot be restarted, therefore the LeaderElected
+// service terminates with ErrLeadershipLost, if the leadership
+// is lost. If the services are provided by a ServiceFactory, the
+// services are stopped instead and the election is entered again.
+// Services for a new term of leadership are then created by the
+// factory. On regular termination, the services are stopped
+// before the leadership is released, to provide a clean hand-over
+// to another candidate.
+type LeaderElected struct {
+	lock       sync.Mutex
+	leadership Leadership
+	services   []Service
+	factory    ServiceFactory
+	leading    Trigger
+	syncher    Syncher
+}
+
+var _ Service = (*LeaderElected)(nil)
+
+func NewLeaderElected(l Leadership, services ...Service) *LeaderElected {
+	return &LeaderElected{
+		leadership: l,
+		services:   services,
+		leading:    SyncTrigger(),
+	}
+}
+
+// NewReelectedLeaderElected creates a LeaderElected service
+// creating the services for every term of leadership with
+// the given factory.
+func NewReelectedLeaderElected(l Leadership, factory ServiceFactory) *LeaderElected {
+	return &LeaderElected{
+		leadership: l,
+		factory:    factory,
+		leading:    SyncTrigger(),
+	}
+}
+
+// Add adds a service to be run while holding the leadership.
+// It must be called before the service is started.
+func (s *LeaderElected) Add(srv Service) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.syncher != nil {
+		return fmt.Errorf("leader elected services already started")
+	}
+	if s.factory != nil {
+		return fmt.Errorf("leader elected services are provided by a factory")
+	}
+	s.services = append(s.services, srv)
+	return nil
+}
+
+// Leading provides a Syncher, which can be used to wait for
+// the first leadership and the readiness of the gated services.
+func (s *LeaderElected) Leading() Syncher {
+	return s.leading
+}
+
+// Start starts the election. It is immediately ready, the gated
+// services are started asynchronously after acquiring the leadership.
+func (s *LeaderElected) Start(ctx context.Context) (Syncher, Syncher, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	ready := SyncTrigger()
+	ready.Trigger()
+	if s.syncher != nil {
+		return ready, s.syncher, nil
+	}
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	s.syncher = Sync(wg)
+	go func() {
+		defer wg.Done()
+		s.run(ctx)
+	}()
+	return ready, s.syncher, nil
+}
+
+func (s *LeaderElected) run(ctx context.Context) {
+	first := true
+	for {
+		lost, err := s.leadership.Acquire(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				s.syncher.SetError(err)
+			}
+			if first {
+				s.leading.SetError(err)
+				s.leading.Trigger()
+			}
+			return
+		}
+		if !s.term(ctx, lost, first) {
+			return
+		}
+		first = false
+	}
+}
+
+// term runs the services for a term of leadership.
+// It returns true, if the election should be entered again.
+func (s *LeaderElected) term(ctx context.Context, lost <-chan struct{}, first bool) bool {
+	services := s.services
+	var err error
+	if s.factory != nil {
+		services, err = s.factory()
+	}
+
+	sctx := ctxutil.CancelContext(ctx)
+	srvs := New(sctx)
+	if err == nil {
+		for _, srv := range services {
+			srvs.Add(srv)
+		}
+		err = srvs.Start()
+	}
+	if err != nil {
+		s.syncher.SetError(err)
+		if first {
+			s.leading.SetError(err)
+		}
+	}
+	if first {
+		s.leading.Trigger()
+	}
+
+	again := false
+	if err == nil {
+		select {
+		case <-ctx.Done():
+		case <-lost:
+			if s.factory != nil {
+				again = true
+			} else {
+				s.syncher.SetError(ErrLeadershipLost)
+			}
+		}
+	}
+	ctxutil.Cancel(sctx)
+	if err := srvs.Wait(); err != nil {
+		s.syncher.SetError(err)
+		again = false
+	}
+	if err := s.leadership.Release(); err != nil {
+		s.syncher.SetError(err)
+		again = false
+	}
+	return again
+}
+
+func (s *LeaderElected) Wait() error {
+	s.lock.Lock()
+	sy := s.syncher
+	s.lock.Unlock()
+
+	if sy == nil {
+		return nil
+	}
+	return sy.Wait()
+}
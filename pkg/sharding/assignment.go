@@ -0,0 +1,145 @@
+package sharding
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Assignment is an immutable snapshot of the distribution
+// of namespaces among the members of a sharded engine.
+type Assignment interface {
+	// Identity provides the identity of the local member.
+	Identity() string
+	// Owner provides the identity of the member responsible for a namespace.
+	Owner(ns string) string
+	// IsResponsible checks whether the local member is responsible
+	// for a namespace.
+	IsResponsible(ns string) bool
+	// Members provides the identities of all members.
+	Members() []string
+	// Peers provides the endpoints of the other members.
+	Peers() []string
+	// Acknowledged checks whether all other members have applied
+	// this assignment. Only then, namespaces previously owned by
+	// other members are released by them and may be taken over.
+	Acknowledged() bool
+}
+
+type assignment struct {
+	identity     string
+	ring         *Ring
+	explicit     map[string]string
+	peers        []string
+	digest       string
+	acknowledged bool
+}
+
+var _ Assignment = (*assignment)(nil)
+
+// NewAssignment provides an assignment for the given members
+// as seen by the member identity.
+// Explicitly assigned base namespaces are assigned to the first
+// member (in identity order) claiming them. All other namespaces are
+// distributed by a consistent hash ring.
+// The assignment is acknowledged, if all other members report
+// this assignment as applied.
+func NewAssignment(identity string, members ...MemberSpec) Assignment {
+	members = slices.Clone(members)
+	slices.SortFunc(members, func(a, b MemberSpec) int { return strings.Compare(a.Identity, b.Identity) })
+
+	var ids []string
+	var peers []string
+	var digest []string
+	explicit := map[string]string{}
+	for _, m := range members {
+		ids = append(ids, m.Identity)
+		if m.Identity != identity && m.Endpoint != "" {
+			peers = append(peers, m.Endpoint)
+		}
+		for _, ns := range m.Namespaces {
+			if _, ok := explicit[ns]; !ok {
+				explicit[ns] = m.Identity
+			}
+		}
+		digest = append(digest, fmt.Sprintf("%s[%s]%v", m.Identity, m.Endpoint, m.Namespaces))
+	}
+	a := &assignment{
+		identity:     identity,
+		ring:         NewRing(DEFAULT_REPLICAS, ids...),
+		explicit:     explicit,
+		peers:        peers,
+		digest:       strings.Join(digest, ","),
+		acknowledged: true,
+	}
+	for _, m := range members {
+		if m.Identity != identity && m.Applied != a.digest {
+			a.acknowledged = false
+		}
+	}
+	return a
+}
+
+func (a *assignment) Identity() string {
+	return a.identity
+}
+
+func (a *assignment) Owner(ns string) string {
+	key := Key(ns)
+	if o, ok := a.explicit[key]; ok {
+		return o
+	}
+	return a.ring.Owner(key)
+}
+
+func (a *assignment) IsResponsible(ns string) bool {
+	return a.Owner(ns) == a.identity
+}
+
+func (a *assignment) Members() []string {
+	return a.ring.Members()
+}
+
+func (a *assignment) Peers() []string {
+	return slices.Clone(a.peers)
+}
+
+func (a *assignment) Acknowledged() bool {
+	return a.acknowledged
+}
+
+// Digest provides a digest of the distribution described by
+// an assignment. It is independent of the local member.
+func Digest(a Assignment) string {
+	if aa, ok := a.(*assignment); ok {
+		return aa.digest
+	}
+	return ""
+}
+
+// Equal checks whether two assignments describe the same
+// distribution and acknowledgement.
+func Equal(a, b Assignment) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	aa, ok1 := a.(*assignment)
+	ab, ok2 := b.(*assignment)
+	if !ok1 || !ok2 {
+		return a == b
+	}
+	return aa.identity == ab.identity && aa.digest == ab.digest && aa.acknowledged == ab.acknowledged
+}
+
+type all struct{}
+
+// All is an assignment making the local member responsible
+// for all namespaces. It is used for non-sharded engines.
+var All Assignment = all{}
+
+func (all) Identity() string          { return "" }
+func (all) Owner(ns string) string    { return "" }
+func (all) IsResponsible(string) bool { return true }
+func (all) Members() []string         { return nil }
+func (all) Peers() []string           { return nil }
+func (all) Acknowledged() bool        { return true }
@@ -0,0 +1,47 @@
+package sharding
+
+import (
+	"time"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/utils"
+)
+
+// MemberSpec describes an engine instance participating
+// in the namespace sharding.
+type MemberSpec struct {
+	// Identity is the identity of the engine instance.
+	Identity string `json:"identity"`
+	// Endpoint is the watch endpoint of the instance used to aggregate
+	// watch requests across shards.
+	Endpoint string `json:"endpoint,omitempty"`
+	// Namespaces is an optional list of base namespaces explicitly
+	// assigned to this instance. All other namespaces are distributed by
+	// consistent hashing.
+	Namespaces []string `json:"namespaces,omitempty"`
+	// RenewTime is the time of the last renewal of the membership.
+	RenewTime *utils.Timestamp `json:"renewTime,omitempty"`
+	// DurationSeconds is the time other members have to wait after
+	// the last observed renewal before considering the member as gone.
+	DurationSeconds int `json:"durationSeconds,omitempty"`
+	// Applied is the digest of the assignment applied by the instance.
+	// It acknowledges the release of all namespaces not assigned
+	// to the instance by this assignment.
+	Applied string `json:"applied,omitempty"`
+}
+
+// GetDuration provides the membership duration.
+func (s *MemberSpec) GetDuration() time.Duration {
+	return time.Duration(s.DurationSeconds) * time.Second
+}
+
+// Member is the interface of database objects used to
+// announce the membership of an engine instance.
+// The object must support generations (database.GenerationAccess)
+// to detect renewals.
+type Member interface {
+	database.Object
+
+	GetMemberSpec() MemberSpec
+	SetMemberSpec(MemberSpec)
+}
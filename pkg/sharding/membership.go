@@ -0,0 +1,306 @@
+package sharding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/service"
+	"github.com/mandelsoft/engine/pkg/utils"
+	"github.com/mandelsoft/logging"
+)
+
+var REALM = logging.DefineRealm("engine/sharding", "namespace sharding")
+
+// DEFAULT_MEMBERSHIP_DURATION is the default duration of a membership.
+const DEFAULT_MEMBERSHIP_DURATION = 15 * time.Second
+
+// Handler is notified about changes of the shard assignment.
+type Handler interface {
+	ShardsChanged(a Assignment)
+}
+
+// Sharding provides the actual shard assignment for
+// an engine instance.
+type Sharding interface {
+	Assignment() Assignment
+	RegisterHandler(h Handler)
+	// Acknowledge reports an assignment as applied by the local
+	// instance, after it released all namespaces not assigned to it.
+	Acknowledge(a Assignment) error
+}
+
+type observation struct {
+	generation int64
+	time       time.Time
+}
+
+// Membership is a Sharding based on member objects stored in a
+// database shared by all engine instances. Every instance announces
+// itself by a member object, which is periodically renewed. Members,
+// whose renewal could not be observed for the membership duration,
+// are considered as gone and their shards are taken over by the
+// remaining members. On regular termination, the member object is
+// deleted to hand over the shards immediately.
+// A member object additionally reports the assignment applied by its
+// instance. Namespaces moving between living members are handed over
+// only after all other members acknowledged the new assignment, so that
+// a namespace is never processed by two instances.
+type Membership[O database.Object] struct {
+	lock     sync.Mutex
+	log      logging.Logger
+	db       database.Database[O]
+	id       database.ObjectId
+	spec     MemberSpec
+	duration time.Duration
+	period   time.Duration
+
+	joined     bool
+	observed   map[string]observation
+	assignment Assignment
+	handlers   []Handler
+
+	syncher service.Syncher
+}
+
+var (
+	_ Sharding        = (*Membership[database.Object])(nil)
+	_ service.Service = (*Membership[database.Object])(nil)
+)
+
+// New creates a Membership for the engine instance described by spec.
+// Member objects use the type and namespace of proto, the name
+// is the identity of the instance. The renewal period is 1/5 of the
+// membership duration.
+func New[O database.Object](lctx logging.AttributionContextProvider, db database.Database[O], proto database.ObjectId, spec MemberSpec, duration time.Duration) *Membership[O] {
+	if duration <= 0 {
+		duration = DEFAULT_MEMBERSHIP_DURATION
+	}
+	spec.Namespaces = slices.Clone(spec.Namespaces)
+	spec.DurationSeconds = int(duration / time.Second)
+	return &Membership[O]{
+		log:        lctx.AttributionContext().Logger(REALM).WithValues("identity", spec.Identity),
+		db:         db,
+		id:         database.NewObjectId(proto.GetType(), proto.GetNamespace(), spec.Identity),
+		spec:       spec,
+		duration:   duration,
+		period:     duration / 5,
+		observed:   map[string]observation{},
+		assignment: NewAssignment(spec.Identity, spec),
+	}
+}
+
+func (m *Membership[O]) Identity() string {
+	return m.spec.Identity
+}
+
+// Assignment provides the actual shard assignment.
+// If the membership has not yet been announced, this is done
+// first, so that a valid assignment is available independently
+// of the start order of services.
+func (m *Membership[O]) Assignment() Assignment {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	err := m.join()
+	if err != nil {
+		m.log.LogError(err, "cannot join shard members")
+	}
+	return m.assignment
+}
+
+func (m *Membership[O]) join() error {
+	if m.joined {
+		return nil
+	}
+	m.log.Info("joining shard members")
+	err := m.renew()
+	if err != nil {
+		return err
+	}
+	m.assignment = m.evaluate(time.Now())
+	m.joined = true
+	return nil
+}
+
+// RegisterHandler registers a handler for changes of the
+// shard assignment. The handlers are called sequentially by the
+// renewal loop, therefore they should not block.
+func (m *Membership[O]) RegisterHandler(h Handler) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.handlers = append(m.handlers, h)
+}
+
+// Acknowledge reports the given assignment as applied
+// by updating the member object. If this fails, the
+// acknowledgement is done by the next renewal.
+func (m *Membership[O]) Acknowledge(a Assignment) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	d := Digest(a)
+	if m.spec.Applied == d {
+		return nil
+	}
+	m.log.Info("acknowledging shard assignment for members {{members}}", "members", a.Members())
+	m.spec.Applied = d
+	return m.renew()
+}
+
+// Start announces the membership, if not yet done, and starts
+// the renewal loop.
+func (m *Membership[O]) Start(ctx context.Context) (service.Syncher, service.Syncher, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	ready := service.SyncTrigger()
+	ready.Trigger()
+	if m.syncher != nil {
+		return ready, m.syncher, nil
+	}
+
+	err := m.join()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	m.syncher = service.Sync(wg)
+	go func() {
+		defer wg.Done()
+		m.run(ctx)
+	}()
+	return ready, m.syncher, nil
+}
+
+func (m *Membership[O]) Wait() error {
+	m.lock.Lock()
+	sy := m.syncher
+	m.lock.Unlock()
+
+	if sy == nil {
+		return nil
+	}
+	return sy.Wait()
+}
+
+func (m *Membership[O]) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			m.leave()
+			return
+		case <-time.After(m.period):
+		}
+		m.lock.Lock()
+		err := m.renew()
+		m.lock.Unlock()
+		if err != nil {
+			m.log.LogError(err, "cannot renew membership")
+		}
+		m.update(time.Now())
+	}
+}
+
+// renew creates or updates the member object of the local instance.
+func (m *Membership[O]) renew() error {
+	for {
+		o, err := m.db.GetObject(m.id)
+		if err != nil {
+			if !errors.Is(err, database.ErrNotExist) {
+				return err
+			}
+			o, err = m.db.SchemeTypes().CreateObject(m.id.GetType(), database.SetObjectNameFromId[O](m.id))
+			if err != nil {
+				return err
+			}
+		}
+		mo, ok := any(o).(Member)
+		if !ok {
+			return fmt.Errorf("type %q is no member type", m.id.GetType())
+		}
+		spec := m.spec
+		spec.RenewTime = utils.NewTimestampP()
+		mo.SetMemberSpec(spec)
+		err = m.db.SetObject(o)
+		if !errors.Is(err, database.ErrModified) {
+			return err
+		}
+	}
+}
+
+// leave deletes the member object of the local instance.
+func (m *Membership[O]) leave() {
+	m.log.Info("leaving shard members")
+	o, err := m.db.GetObject(m.id)
+	if err == nil {
+		_, err = m.db.DeleteObject(o)
+	}
+	if err != nil && !errors.Is(err, database.ErrNotExist) {
+		m.log.LogError(err, "cannot delete member object")
+	}
+}
+
+// update evaluates the actual members and notifies the
+// handlers about a changed assignment.
+func (m *Membership[O]) update(now time.Time) {
+	m.lock.Lock()
+	a := m.evaluate(now)
+	if Equal(a, m.assignment) {
+		m.lock.Unlock()
+		return
+	}
+	m.log.Info("shard members changed: {{members}}", "members", a.Members())
+	m.assignment = a
+	handlers := slices.Clone(m.handlers)
+	m.lock.Unlock()
+
+	for _, h := range handlers {
+		h.ShardsChanged(a)
+	}
+}
+
+// evaluate determines the assignment for the actual members.
+// Expiration is based on the local time of the observation
+// of a change to be independent of clock skews between members.
+// If the members cannot be listed, the actual assignment is kept.
+func (m *Membership[O]) evaluate(now time.Time) Assignment {
+	list, err := m.db.ListObjects(m.id.GetType(), false, m.id.GetNamespace())
+	if err != nil {
+		m.log.LogError(err, "cannot list members")
+		return m.assignment
+	}
+
+	observed := map[string]observation{}
+	members := []MemberSpec{m.spec}
+	for _, o := range list {
+		mo, ok := any(o).(Member)
+		if !ok || o.GetName() == m.spec.Identity {
+			continue
+		}
+		spec := mo.GetMemberSpec()
+		gen := database.GetGeneration(o)
+		obs, ok := m.observed[o.GetName()]
+		if !ok || obs.generation != gen {
+			obs = observation{gen, now}
+		}
+		observed[o.GetName()] = obs
+
+		duration := spec.GetDuration()
+		if duration <= 0 {
+			duration = m.duration
+		}
+		if obs.time.Add(duration).After(now) {
+			spec.Identity = o.GetName()
+			members = append(members, spec)
+		}
+	}
+	m.observed = observed
+	return NewAssignment(m.spec.Identity, members...)
+}
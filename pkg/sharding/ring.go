@@ -0,0 +1,87 @@
+package sharding
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// DEFAULT_REPLICAS is the default number of virtual nodes
+// used for every member on the hash ring.
+const DEFAULT_REPLICAS = 100
+
+// Key provides the shard key for a namespace. It is the
+// base namespace, the first element of the namespace path,
+// which is also used by the hashmapped objectbase to group objects.
+// Therefore, complete namespace trees are assigned to the same shard.
+func Key(ns string) string {
+	i := strings.Index(ns, "/")
+	if i < 0 {
+		return ns
+	}
+	return ns[:i]
+}
+
+type point struct {
+	hash   uint64
+	member string
+}
+
+// Ring is a consistent hash ring distributing shard keys
+// among a set of members. If a member joins or leaves the ring,
+// only the keys of this member are moved.
+type Ring struct {
+	members []string
+	points  []point
+}
+
+// NewRing creates a hash ring for the given members using
+// replicas virtual nodes per member.
+func NewRing(replicas int, members ...string) *Ring {
+	if replicas <= 0 {
+		replicas = DEFAULT_REPLICAS
+	}
+	r := &Ring{members: slices.Clone(members)}
+	slices.Sort(r.members)
+	r.members = slices.Compact(r.members)
+
+	for _, m := range r.members {
+		for i := 0; i < replicas; i++ {
+			r.points = append(r.points, point{hash(fmt.Sprintf("%s#%d", m, i)), m})
+		}
+	}
+	sort.Slice(r.points, func(i, j int) bool {
+		if r.points[i].hash == r.points[j].hash {
+			return r.points[i].member < r.points[j].member
+		}
+		return r.points[i].hash < r.points[j].hash
+	})
+	return r
+}
+
+// Members provides the ordered list of members of the ring.
+func (r *Ring) Members() []string {
+	return slices.Clone(r.members)
+}
+
+// Owner provides the member responsible for the given shard key.
+// For an empty ring, an empty string is returned.
+func (r *Ring) Owner(key string) string {
+	if len(r.points) == 0 {
+		return ""
+	}
+	h := hash(key)
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i].hash >= h })
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.points[i].member
+}
+
+func hash(s string) uint64 {
+	h := sha256.Sum256([]byte(s))
+	return binary.BigEndian.Uint64(h[:8])
+}
@@ -0,0 +1,166 @@
+package sharding_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/engine/pkg/ctxutil"
+	"github.com/mandelsoft/engine/pkg/database"
+	"github.com/mandelsoft/engine/pkg/impl/database/filesystem"
+	"github.com/mandelsoft/engine/pkg/processing/model/support/db"
+	"github.com/mandelsoft/engine/pkg/sharding"
+	"github.com/mandelsoft/logging"
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+)
+
+type handler struct {
+	changes chan sharding.Assignment
+}
+
+func (h *handler) ShardsChanged(a sharding.Assignment) {
+	h.changes <- a
+}
+
+var _ = Describe("sharding", func() {
+	Context("ring", func() {
+		keys := func(n int) []string {
+			var list []string
+			for i := 0; i < n; i++ {
+				list = append(list, fmt.Sprintf("ns%d", i))
+			}
+			return list
+		}
+
+		It("uses base namespaces as keys", func() {
+			Expect(sharding.Key("")).To(Equal(""))
+			Expect(sharding.Key("a")).To(Equal("a"))
+			Expect(sharding.Key("a/b/c")).To(Equal("a"))
+		})
+
+		It("distributes keys", func() {
+			r := sharding.NewRing(0, "c", "a", "b", "a")
+			Expect(r.Members()).To(Equal([]string{"a", "b", "c"}))
+
+			count := map[string]int{}
+			for _, k := range keys(3000) {
+				count[r.Owner(k)]++
+			}
+			Expect(count).To(HaveLen(3))
+			for _, c := range count {
+				Expect(c).To(BeNumerically(">", 500))
+			}
+		})
+
+		It("moves only the keys of a leaving member", func() {
+			r3 := sharding.NewRing(0, "a", "b", "c")
+			r2 := sharding.NewRing(0, "a", "b")
+			for _, k := range keys(1000) {
+				if o := r3.Owner(k); o != "c" {
+					Expect(r2.Owner(k)).To(Equal(o))
+				}
+			}
+		})
+
+		It("handles empty rings", func() {
+			Expect(sharding.NewRing(0).Owner("a")).To(Equal(""))
+		})
+	})
+
+	Context("assignment", func() {
+		It("respects explicit assignments", func() {
+			members := []sharding.MemberSpec{
+				{Identity: "b", Endpoint: "ws://b/watch", Namespaces: []string{"x", "y"}},
+				{Identity: "a", Endpoint: "ws://a/watch", Namespaces: []string{"y"}},
+			}
+			a := sharding.NewAssignment("a", members...)
+			Expect(a.Members()).To(Equal([]string{"a", "b"}))
+			Expect(a.Peers()).To(Equal([]string{"ws://b/watch"}))
+			Expect(a.Owner("x/nested")).To(Equal("b"))
+			Expect(a.IsResponsible("y")).To(BeTrue())
+			Expect(sharding.Equal(a, sharding.NewAssignment("a", members[1], members[0]))).To(BeTrue())
+			Expect(sharding.Equal(a, sharding.NewAssignment("b", members...))).To(BeFalse())
+		})
+
+		It("is acknowledged if applied by all other members", func() {
+			members := []sharding.MemberSpec{
+				{Identity: "a"},
+				{Identity: "b", Namespaces: []string{"x"}},
+			}
+			a := sharding.NewAssignment("a", members...)
+			Expect(a.Acknowledged()).To(BeFalse())
+
+			members[1].Applied = sharding.Digest(a)
+			Expect(sharding.NewAssignment("a", members...).Acknowledged()).To(BeTrue())
+			Expect(sharding.NewAssignment("b", members...).Acknowledged()).To(BeFalse())
+			Expect(sharding.Equal(a, sharding.NewAssignment("a", members...))).To(BeFalse())
+		})
+	})
+
+	Context("membership", func() {
+		var odb database.Database[db.Object]
+		var ctx context.Context
+
+		proto := db.NewShardMember("", "")
+
+		BeforeEach(func() {
+			scheme := db.NewScheme[db.Object]()
+			database.MustRegisterType[db.ShardMember, db.Object](scheme)
+			odb = Must(filesystem.New[db.Object](scheme, "/db", memoryfs.New()))
+			ctx = ctxutil.CancelContext(context.Background())
+		})
+
+		AfterEach(func() {
+			ctxutil.Cancel(ctx)
+		})
+
+		It("detects joining and leaving members", func() {
+			a := sharding.New(logging.DefaultContext(), odb, proto, sharding.MemberSpec{Identity: "a"}, time.Second)
+			h := &handler{make(chan sharding.Assignment, 10)}
+			a.RegisterHandler(h)
+			_, _, err := a.Start(ctx)
+			MustBeSuccessful(err)
+			Expect(a.Assignment().Members()).To(Equal([]string{"a"}))
+
+			bctx := ctxutil.CancelContext(ctx)
+			b := sharding.New(logging.DefaultContext(), odb, proto, sharding.MemberSpec{Identity: "b", Namespaces: []string{"ns"}}, time.Second)
+			_, _, err = b.Start(bctx)
+			MustBeSuccessful(err)
+			Expect(b.Assignment().Members()).To(Equal([]string{"a", "b"}))
+
+			var c sharding.Assignment
+			Eventually(h.changes, 2*time.Second).Should(Receive(&c))
+			Expect(c.Members()).To(Equal([]string{"a", "b"}))
+			Expect(c.Owner("ns/sub")).To(Equal("b"))
+
+			ctxutil.Cancel(bctx)
+			MustBeSuccessful(b.Wait())
+			Expect(Must(odb.ListObjectIds(db.TYPE_SHARD_MEMBER, false, ""))).To(HaveLen(1))
+
+			Eventually(h.changes, 2*time.Second).Should(Receive(&c))
+			Expect(c.Members()).To(Equal([]string{"a"}))
+			Expect(c.IsResponsible("ns")).To(BeTrue())
+		})
+
+		It("expires members without renewal", func() {
+			m := db.NewShardMember("", "crashed")
+			m.Spec = sharding.MemberSpec{Identity: "crashed", DurationSeconds: 1}
+			MustBeSuccessful(odb.SetObject(m))
+
+			a := sharding.New(logging.DefaultContext(), odb, proto, sharding.MemberSpec{Identity: "a"}, time.Second)
+			h := &handler{make(chan sharding.Assignment, 10)}
+			a.RegisterHandler(h)
+			_, _, err := a.Start(ctx)
+			MustBeSuccessful(err)
+			Expect(a.Assignment().Members()).To(Equal([]string{"a", "crashed"}))
+
+			var c sharding.Assignment
+			Eventually(h.changes, 3*time.Second).Should(Receive(&c))
+			Expect(c.Members()).To(Equal([]string{"a"}))
+		})
+	})
+})
@@ -0,0 +1,157 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// TraceId identifies a trace.
+type TraceId [16]byte
+
+// SpanId identifies a span of a trace.
+type SpanId [8]byte
+
+func (t TraceId) IsValid() bool {
+	return t != TraceId{}
+}
+
+func (t TraceId) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+func (s SpanId) IsValid() bool {
+	return s != SpanId{}
+}
+
+func (s SpanId) String() string {
+	if !s.IsValid() {
+		return ""
+	}
+	return hex.EncodeToString(s[:])
+}
+
+// NewTraceId provides a new random trace id.
+func NewTraceId() TraceId {
+	var t TraceId
+	rand.Read(t[:])
+	return t
+}
+
+// NewSpanId provides a new random span id.
+func NewSpanId() SpanId {
+	var s SpanId
+	rand.Read(s[:])
+	return s
+}
+
+// TraceIdFor derives a trace id from a string containing a 128 bit
+// hex encoded value, like a UUID. Dashes are ignored.
+func TraceIdFor(s string) (TraceId, error) {
+	var t TraceId
+	data, err := hex.DecodeString(strings.ReplaceAll(s, "-", ""))
+	if err != nil {
+		return t, err
+	}
+	if len(data) != len(t) {
+		return t, fmt.Errorf("invalid trace id length %d", len(data))
+	}
+	copy(t[:], data)
+	return t, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// SpanContext is the part of a span propagated to other spans
+// to establish a parent relation.
+type SpanContext struct {
+	TraceId TraceId
+	SpanId  SpanId
+}
+
+func (c SpanContext) IsValid() bool {
+	return c.TraceId.IsValid() && c.SpanId.IsValid()
+}
+
+// TraceParent provides the W3C trace context representation
+// of a valid span context.
+func (c SpanContext) TraceParent() string {
+	if !c.IsValid() {
+		return ""
+	}
+	return fmt.Sprintf("00-%s-%s-01", c.TraceId, c.SpanId)
+}
+
+func (c SpanContext) String() string {
+	return c.TraceParent()
+}
+
+// ParseTraceParent parses a W3C trace context representation.
+// An empty string provides an invalid span context.
+func ParseTraceParent(s string) (SpanContext, error) {
+	var c SpanContext
+
+	if s == "" {
+		return c, nil
+	}
+	parts := strings.Split(s, "-")
+	if len(parts) != 4 || parts[0] != "00" {
+		return c, fmt.Errorf("invalid trace parent %q", s)
+	}
+	t, err := hex.DecodeString(parts[1])
+	if err != nil || len(t) != len(c.TraceId) {
+		return c, fmt.Errorf("invalid trace id in trace parent %q", s)
+	}
+	id, err := hex.DecodeString(parts[2])
+	if err != nil || len(id) != len(c.SpanId) {
+		return c, fmt.Errorf("invalid span id in trace parent %q", s)
+	}
+	copy(c.TraceId[:], t)
+	copy(c.SpanId[:], id)
+	return c, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Carrier is implemented by objects carrying a trace context
+// used to propagate traces to controllers processing them.
+type Carrier interface {
+	GetTraceParent() string
+	SetTraceParent(string) bool
+}
+
+// SpanContextFor provides the span context propagated by an object.
+// It returns an invalid span context, if the object is no Carrier
+// or does not carry a valid trace context.
+func SpanContextFor(o any) SpanContext {
+	if c, ok := o.(Carrier); ok {
+		sc, err := ParseTraceParent(c.GetTraceParent())
+		if err == nil {
+			return sc
+		}
+	}
+	return SpanContext{}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+type spanKey struct{}
+
+// ContextWithSpan provides a context containing the given span.
+func ContextWithSpan(ctx context.Context, s *Span) context.Context {
+	if s == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, spanKey{}, s)
+}
+
+// SpanFromContext provides the span of a context, or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
@@ -0,0 +1,218 @@
+package tracing
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/mandelsoft/goutils/general"
+	"github.com/mandelsoft/vfs/pkg/osfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+)
+
+// MemoryExporter keeps exported spans in memory.
+// It is intended for tests.
+type MemoryExporter struct {
+	lock  sync.Mutex
+	spans []SpanData
+}
+
+var _ Exporter = (*MemoryExporter)(nil)
+
+func NewMemoryExporter() *MemoryExporter {
+	return &MemoryExporter{}
+}
+
+func (e *MemoryExporter) Export(spans ...SpanData) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func (e *MemoryExporter) Shutdown() error {
+	return nil
+}
+
+// Spans provides the exported spans in the order of their end.
+func (e *MemoryExporter) Spans() []SpanData {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return slices.Clone(e.spans)
+}
+
+// Find provides the spans matching the given function.
+func (e *MemoryExporter) Find(match func(d *SpanData) bool) []SpanData {
+	var result []SpanData
+	for _, d := range e.Spans() {
+		if match(&d) {
+			result = append(result, d)
+		}
+	}
+	return result
+}
+
+func (e *MemoryExporter) Reset() {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.spans = nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// FileExporter appends spans to a file using the OTLP JSON
+// encoding. Every export is written as separate line containing
+// an OTLP trace export request, like it is done by the OpenTelemetry
+// collector file exporter.
+type FileExporter struct {
+	lock sync.Mutex
+	file vfs.File
+}
+
+var _ Exporter = (*FileExporter)(nil)
+
+func NewFileExporter(path string, fss ...vfs.FileSystem) (*FileExporter, error) {
+	fs := general.OptionalDefaulted(vfs.FileSystem(osfs.OsFs), fss...)
+
+	f, err := fs.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	// O_APPEND is not supported by all virtual filesystems.
+	fi, err := f.Stat()
+	if err == nil && fi.Size() > 0 {
+		_, err = f.Seek(0, io.SeekEnd)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &FileExporter{file: f}, nil
+}
+
+func (e *FileExporter) Export(spans ...SpanData) error {
+	if len(spans) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(OTLPRequest(spans...))
+	if err != nil {
+		return err
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.file == nil {
+		return os.ErrClosed
+	}
+	_, err = e.file.Write(append(data, '\n'))
+	return err
+}
+
+func (e *FileExporter) Shutdown() error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.file == nil {
+		return nil
+	}
+	err := e.file.Close()
+	e.file = nil
+	return err
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// OTLP JSON encoding
+
+const SCOPE = "github.com/mandelsoft/engine"
+
+const (
+	_SPAN_KIND_INTERNAL = 1
+)
+
+type ExportTraceServiceRequest struct {
+	ResourceSpans []ResourceSpans `json:"resourceSpans"`
+}
+
+type ResourceSpans struct {
+	Resource   Resource     `json:"resource"`
+	ScopeSpans []ScopeSpans `json:"scopeSpans"`
+}
+
+type Resource struct {
+	Attributes []KeyValue `json:"attributes,omitempty"`
+}
+
+type ScopeSpans struct {
+	Scope Scope      `json:"scope"`
+	Spans []OTLPSpan `json:"spans"`
+}
+
+type Scope struct {
+	Name string `json:"name"`
+}
+
+type KeyValue struct {
+	Key   string   `json:"key"`
+	Value AnyValue `json:"value"`
+}
+
+type AnyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type OTLPSpan struct {
+	TraceId           string     `json:"traceId"`
+	SpanId            string     `json:"spanId"`
+	ParentSpanId      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              int        `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Attributes        []KeyValue `json:"attributes,omitempty"`
+	Status            Status     `json:"status"`
+}
+
+type Status struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// OTLPRequest converts spans into an OTLP trace export request.
+// The spans are grouped by service.
+func OTLPRequest(spans ...SpanData) *ExportTraceServiceRequest {
+	req := &ExportTraceServiceRequest{}
+	index := map[string]int{}
+	for _, d := range spans {
+		i, ok := index[d.Service]
+		if !ok {
+			i = len(req.ResourceSpans)
+			index[d.Service] = i
+			req.ResourceSpans = append(req.ResourceSpans, ResourceSpans{
+				Resource:   Resource{Attributes: []KeyValue{{"service.name", AnyValue{d.Service}}}},
+				ScopeSpans: []ScopeSpans{{Scope: Scope{SCOPE}}},
+			})
+		}
+		scope := &req.ResourceSpans[i].ScopeSpans[0]
+		scope.Spans = append(scope.Spans, otlpSpan(&d))
+	}
+	return req
+}
+
+func otlpSpan(d *SpanData) OTLPSpan {
+	s := OTLPSpan{
+		TraceId:           d.Context.TraceId.String(),
+		SpanId:            d.Context.SpanId.String(),
+		ParentSpanId:      d.Parent.String(),
+		Name:              d.Name,
+		Kind:              _SPAN_KIND_INTERNAL,
+		StartTimeUnixNano: strconv.FormatInt(d.Start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(d.End.UnixNano(), 10),
+		Status:            Status{Code: int(d.Status), Message: d.Message},
+	}
+	for _, a := range d.Attributes {
+		s.Attributes = append(s.Attributes, KeyValue{a.Key, AnyValue{a.Value}})
+	}
+	return s
+}
@@ -0,0 +1,263 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mandelsoft/logging"
+)
+
+var REALM = logging.DefineRealm("engine/tracing", "run tracing")
+
+// StatusCode is the final state of a span.
+type StatusCode int
+
+const (
+	STATUS_UNSET StatusCode = iota
+	STATUS_OK
+	STATUS_ERROR
+)
+
+// Attribute is a key/value pair describing a span.
+type Attribute struct {
+	Key   string
+	Value string
+}
+
+// Attr provides an attribute. Values are converted to their
+// string representation.
+func Attr(key string, value any) Attribute {
+	if s, ok := value.(string); ok {
+		return Attribute{key, s}
+	}
+	return Attribute{key, fmt.Sprint(value)}
+}
+
+// SpanData is the exported representation of a finished span.
+type SpanData struct {
+	Service    string
+	Name       string
+	Context    SpanContext
+	Parent     SpanId
+	Start      time.Time
+	End        time.Time
+	Attributes []Attribute
+	Status     StatusCode
+	Message    string
+}
+
+// Attribute provides the value of the attribute with the given key.
+func (d *SpanData) Attribute(key string) string {
+	for _, a := range d.Attributes {
+		if a.Key == key {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// Exporter gets finished spans.
+type Exporter interface {
+	Export(spans ...SpanData) error
+	Shutdown() error
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Tracer creates spans and passes them to its exporter
+// when they are ended. A Tracer without exporter is disabled
+// and provides nil spans. All Span methods can be called
+// on nil spans, so the callers don't need to check for
+// an enabled tracing.
+type Tracer struct {
+	lock     sync.RWMutex
+	service  string
+	exporter Exporter
+}
+
+// Default is the tracer used by the engine packages.
+// It is disabled, until an exporter is set.
+var Default = NewTracer("engine", nil)
+
+func NewTracer(service string, e Exporter) *Tracer {
+	return &Tracer{service: service, exporter: e}
+}
+
+// SetExporter sets a new exporter and returns the previous one.
+// A nil exporter disables the tracer.
+func (t *Tracer) SetExporter(e Exporter) Exporter {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	old := t.exporter
+	t.exporter = e
+	return old
+}
+
+func (t *Tracer) Enabled() bool {
+	if t == nil {
+		return false
+	}
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.exporter != nil
+}
+
+// Start starts a new span. If the parent context is valid, the span
+// is a child span, otherwise it starts a new trace.
+func (t *Tracer) Start(name string, parent SpanContext, attrs ...Attribute) *Span {
+	if parent.IsValid() {
+		return t.start(name, parent.TraceId, parent.SpanId, attrs)
+	}
+	return t.start(name, NewTraceId(), SpanId{}, attrs)
+}
+
+// StartTrace starts a root span for the given trace id.
+func (t *Tracer) StartTrace(name string, trace TraceId, attrs ...Attribute) *Span {
+	if !trace.IsValid() {
+		trace = NewTraceId()
+	}
+	return t.start(name, trace, SpanId{}, attrs)
+}
+
+// StartContext starts a span as child of the span found in the
+// context. It returns a context containing the new span.
+func (t *Tracer) StartContext(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	s := t.Start(name, SpanFromContext(ctx).Context(), attrs...)
+	return ContextWithSpan(ctx, s), s
+}
+
+func (t *Tracer) start(name string, trace TraceId, parent SpanId, attrs []Attribute) *Span {
+	if !t.Enabled() {
+		return nil
+	}
+	return &Span{
+		tracer: t,
+		data: SpanData{
+			Service:    t.service,
+			Name:       name,
+			Context:    SpanContext{TraceId: trace, SpanId: NewSpanId()},
+			Parent:     parent,
+			Start:      time.Now(),
+			Attributes: attrs,
+		},
+	}
+}
+
+func (t *Tracer) export(d SpanData) {
+	t.lock.RLock()
+	e := t.exporter
+	t.lock.RUnlock()
+	if e == nil {
+		return
+	}
+	err := e.Export(d)
+	if err != nil {
+		logging.DefaultContext().Logger(REALM).LogError(err, "cannot export span {{span}}", "span", d.Name)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Span is an operation of a trace.
+type Span struct {
+	lock   sync.Mutex
+	tracer *Tracer
+	data   SpanData
+	ended  bool
+}
+
+// Context provides the span context to be used for child spans.
+func (s *Span) Context() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.data.Context
+}
+
+// TraceParent provides the W3C trace context representation
+// used to propagate the span via objects.
+func (s *Span) TraceParent() string {
+	return s.Context().TraceParent()
+}
+
+// Tracer provides the tracer of the span.
+func (s *Span) Tracer() *Tracer {
+	if s == nil {
+		return nil
+	}
+	return s.tracer
+}
+
+// Start starts a child span.
+func (s *Span) Start(name string, attrs ...Attribute) *Span {
+	if s == nil {
+		return nil
+	}
+	return s.tracer.Start(name, s.Context(), attrs...)
+}
+
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+outer:
+	for _, a := range attrs {
+		for i, o := range s.data.Attributes {
+			if o.Key == a.Key {
+				s.data.Attributes[i] = a
+				continue outer
+			}
+		}
+		s.data.Attributes = append(s.data.Attributes, a)
+	}
+}
+
+func (s *Span) SetStatus(code StatusCode, msg string) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.data.Status = code
+	s.data.Message = msg
+}
+
+// SetError sets an error status for a non-nil error
+// and an ok status, otherwise.
+func (s *Span) SetError(err error) {
+	if err != nil {
+		s.SetStatus(STATUS_ERROR, err.Error())
+	} else {
+		s.SetStatus(STATUS_OK, "")
+	}
+}
+
+// End finishes the span and passes it to the exporter.
+// Only the first call has an effect.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	if s.ended {
+		s.lock.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	d := s.data
+	s.lock.Unlock()
+
+	s.tracer.export(d)
+}
+
+// EndWithError sets the status according to the given error
+// and finishes the span.
+func (s *Span) EndWithError(err error) {
+	s.SetError(err)
+	s.End()
+}
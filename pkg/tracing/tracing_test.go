@@ -0,0 +1,157 @@
+package tracing_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mandelsoft/vfs/pkg/memoryfs"
+	"github.com/mandelsoft/vfs/pkg/vfs"
+
+	"github.com/mandelsoft/engine/pkg/tracing"
+)
+
+type carrier struct {
+	parent string
+}
+
+func (c *carrier) GetTraceParent() string {
+	return c.parent
+}
+
+func (c *carrier) SetTraceParent(s string) bool {
+	c.parent = s
+	return true
+}
+
+var _ = Describe("Tracing", func() {
+	Context("span context", func() {
+		It("converts trace parents", func() {
+			sc := tracing.SpanContext{TraceId: tracing.NewTraceId(), SpanId: tracing.NewSpanId()}
+			tp := sc.TraceParent()
+			Expect(tp).To(MatchRegexp("^00-[0-9a-f]{32}-[0-9a-f]{16}-01$"))
+			Expect(tracing.ParseTraceParent(tp)).To(Equal(sc))
+		})
+
+		It("handles invalid trace parents", func() {
+			Expect(tracing.ParseTraceParent("")).To(Equal(tracing.SpanContext{}))
+			Expect(tracing.SpanContext{}.TraceParent()).To(Equal(""))
+
+			_, err := tracing.ParseTraceParent("00-xyz-0102030405060708-01")
+			Expect(err).To(HaveOccurred())
+			Expect(tracing.SpanContextFor(&carrier{"garbage"}).IsValid()).To(BeFalse())
+			Expect(tracing.SpanContextFor("no carrier").IsValid()).To(BeFalse())
+		})
+
+		It("derives trace ids from run ids", func() {
+			t := Must(tracing.TraceIdFor("bafd3fb0-5184-410d-9ff5-092372f8bb94"))
+			Expect(t.String()).To(Equal("bafd3fb05184410d9ff5092372f8bb94"))
+
+			_, err := tracing.TraceIdFor("short")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("tracer", func() {
+		var exp *tracing.MemoryExporter
+		var tracer *tracing.Tracer
+
+		BeforeEach(func() {
+			exp = tracing.NewMemoryExporter()
+			tracer = tracing.NewTracer("test", exp)
+		})
+
+		It("is disabled without exporter", func() {
+			t := tracing.NewTracer("test", nil)
+			s := t.Start("span", tracing.SpanContext{})
+			Expect(s).To(BeNil())
+			Expect(s.Start("child")).To(BeNil())
+			Expect(s.TraceParent()).To(Equal(""))
+			s.SetAttributes(tracing.Attr("a", 1))
+			s.EndWithError(fmt.Errorf("failed"))
+		})
+
+		It("exports spans with parent relation", func() {
+			root := tracer.Start("root", tracing.SpanContext{}, tracing.Attr("key", "value"))
+			ctx := tracing.ContextWithSpan(context.Background(), root)
+
+			ctx, child := tracer.StartContext(ctx, "child")
+			Expect(tracing.SpanFromContext(ctx)).To(BeIdenticalTo(child))
+			child.SetAttributes(tracing.Attr("count", 1), tracing.Attr("count", 2))
+			child.EndWithError(fmt.Errorf("failed"))
+			child.End()
+
+			c := &carrier{}
+			c.SetTraceParent(root.TraceParent())
+			foreign := tracer.Start("foreign", tracing.SpanContextFor(c))
+			foreign.End()
+			root.EndWithError(nil)
+
+			spans := exp.Spans()
+			Expect(spans).To(HaveLen(3))
+			Expect(spans[0].Name).To(Equal("child"))
+			Expect(spans[0].Status).To(Equal(tracing.STATUS_ERROR))
+			Expect(spans[0].Message).To(Equal("failed"))
+			Expect(spans[0].Attributes).To(Equal([]tracing.Attribute{{"count", "2"}}))
+			Expect(spans[0].Parent).To(Equal(root.Context().SpanId))
+			Expect(spans[0].Context.TraceId).To(Equal(root.Context().TraceId))
+
+			Expect(spans[1].Parent).To(Equal(root.Context().SpanId))
+			Expect(spans[1].Context.TraceId).To(Equal(root.Context().TraceId))
+
+			Expect(spans[2].Name).To(Equal("root"))
+			Expect(spans[2].Parent.IsValid()).To(BeFalse())
+			Expect(spans[2].Status).To(Equal(tracing.STATUS_OK))
+			Expect(spans[2].Attribute("key")).To(Equal("value"))
+			Expect(spans[2].Service).To(Equal("test"))
+		})
+
+		It("starts traces with given id", func() {
+			t := tracing.NewTraceId()
+			s := tracer.StartTrace("root", t)
+			Expect(s.Context().TraceId).To(Equal(t))
+			Expect(s.Context().SpanId.IsValid()).To(BeTrue())
+		})
+	})
+
+	Context("file exporter", func() {
+		It("writes OTLP JSON lines", func() {
+			fs := memoryfs.New()
+			exp := Must(tracing.NewFileExporter("/traces.json", fs))
+			tracer := tracing.NewTracer("engine", exp)
+
+			root := tracer.Start("root", tracing.SpanContext{}, tracing.Attr("element", "A"))
+			child := root.Start("child")
+			child.EndWithError(fmt.Errorf("failed"))
+			root.End()
+			MustBeSuccessful(exp.Shutdown())
+
+			data := Must(vfs.ReadFile(fs, "/traces.json"))
+			lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+			Expect(lines).To(HaveLen(2))
+
+			var req tracing.ExportTraceServiceRequest
+			MustBeSuccessful(json.Unmarshal([]byte(lines[0]), &req))
+			Expect(req.ResourceSpans).To(HaveLen(1))
+			Expect(req.ResourceSpans[0].Resource.Attributes[0].Key).To(Equal("service.name"))
+			Expect(req.ResourceSpans[0].Resource.Attributes[0].Value.StringValue).To(Equal("engine"))
+			span := req.ResourceSpans[0].ScopeSpans[0].Spans[0]
+			Expect(span.Name).To(Equal("child"))
+			Expect(span.TraceId).To(Equal(root.Context().TraceId.String()))
+			Expect(span.ParentSpanId).To(Equal(root.Context().SpanId.String()))
+			Expect(span.Status).To(Equal(tracing.Status{Code: 2, Message: "failed"}))
+
+			req = tracing.ExportTraceServiceRequest{}
+			MustBeSuccessful(json.Unmarshal([]byte(lines[1]), &req))
+			span = req.ResourceSpans[0].ScopeSpans[0].Spans[0]
+			Expect(span.Name).To(Equal("root"))
+			Expect(span.ParentSpanId).To(Equal(""))
+			Expect(span.Attributes).To(Equal([]tracing.KeyValue{{"element", tracing.AnyValue{"A"}}}))
+		})
+	})
+})
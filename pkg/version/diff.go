@@ -0,0 +1,96 @@
+package version
+
+import (
+	"fmt"
+	"slices"
+)
+
+// ChangeKind describes the kind of difference between
+// two composed versions.
+type ChangeKind string
+
+const (
+	// CHANGE_VERSION indicates a changed version of a node.
+	CHANGE_VERSION ChangeKind = "version"
+	// CHANGE_ADDED indicates a new dependency.
+	CHANGE_ADDED ChangeKind = "added"
+	// CHANGE_REMOVED indicates a removed dependency.
+	CHANGE_REMOVED ChangeKind = "removed"
+)
+
+// Change describes a difference between two
+// composed versions.
+type Change struct {
+	// Path is the list of nodes leading from the root to
+	// the changed node.
+	Path []string `json:"path,omitempty"`
+	// Node is the effective name of the changed node.
+	Node string     `json:"node"`
+	Kind ChangeKind `json:"kind"`
+	Old  string     `json:"old,omitempty"`
+	New  string     `json:"new,omitempty"`
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case CHANGE_VERSION:
+		return fmt.Sprintf("%s: version %q -> %q", c.Node, c.Old, c.New)
+	default:
+		return fmt.Sprintf("%s: %s", c.Node, c.Kind)
+	}
+}
+
+// Diff parses two composed versions and provides the
+// differences of the new one compared to the old one.
+// An empty old version results in a single added root node.
+func Diff(old, new string) ([]Change, error) {
+	n, err := Parse(new)
+	if err != nil {
+		return nil, fmt.Errorf("new version: %w", err)
+	}
+	if old == "" {
+		return []Change{{Node: GetEffName(n.GetId()), Kind: CHANGE_ADDED, New: n.GetVersion()}}, nil
+	}
+	o, err := Parse(old)
+	if err != nil {
+		return nil, fmt.Errorf("old version: %w", err)
+	}
+	return DiffNodes(o, n), nil
+}
+
+// DiffNodes provides the differences between two parsed
+// node graphs.
+func DiffNodes(old, new *parsedNode) []Change {
+	if CompareId(old.GetId(), new.GetId()) != 0 {
+		return []Change{
+			{Node: GetEffName(old.GetId()), Kind: CHANGE_REMOVED, Old: old.GetVersion()},
+			{Node: GetEffName(new.GetId()), Kind: CHANGE_ADDED, New: new.GetVersion()},
+		}
+	}
+	return diffNodes(nil, old, new)
+}
+
+func diffNodes(path []string, old, new *parsedNode) []Change {
+	var changes []Change
+
+	name := GetEffName(new.GetId())
+	if old.GetVersion() != new.GetVersion() {
+		changes = append(changes, Change{Path: path, Node: name, Kind: CHANGE_VERSION, Old: old.GetVersion(), New: new.GetVersion()})
+	}
+
+	sub := append(slices.Clone(path), name)
+	olinks := old.GetNodeLinks()
+	for _, n := range new.GetNodeLinks() {
+		i := slices.IndexFunc(olinks, func(o *parsedNode) bool { return CompareId(o, n) == 0 })
+		if i < 0 {
+			changes = append(changes, Change{Path: sub, Node: GetEffName(n.GetId()), Kind: CHANGE_ADDED, New: n.GetVersion()})
+			continue
+		}
+		changes = append(changes, diffNodes(sub, olinks[i], n)...)
+		olinks = append(olinks[:i], olinks[i+1:]...)
+	}
+	for _, o := range olinks {
+		changes = append(changes, Change{Path: sub, Node: GetEffName(o.GetId()), Kind: CHANGE_REMOVED, Old: o.GetVersion()})
+	}
+	return changes
+}
@@ -0,0 +1,45 @@
+package version_test
+
+import (
+	. "github.com/mandelsoft/goutils/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	me "github.com/mandelsoft/engine/pkg/version"
+)
+
+var _ = Describe("diff", func() {
+	It("finds no changes", func() {
+		v := "node/A[v1](node/B[v2],node/C[v3])"
+		Expect(Must(me.Diff(v, v))).To(BeEmpty())
+	})
+
+	It("finds changed root version", func() {
+		Expect(Must(me.Diff("node/A[v1](node/B[v2])", "node/A[v2](node/B[v2])"))).To(Equal([]me.Change{
+			{Node: "node/A", Kind: me.CHANGE_VERSION, Old: "v1", New: "v2"},
+		}))
+	})
+
+	It("finds changed nested version", func() {
+		Expect(Must(me.Diff(
+			"node/A[v1](node/B[v2](node/D[v4]),node/C[v3](node/D[v4]))",
+			"node/A[v1](node/B[v2](node/D[v5]),node/C[v3](node/D[v5]))",
+		))).To(Equal([]me.Change{
+			{Path: []string{"node/A", "node/B"}, Node: "node/D", Kind: me.CHANGE_VERSION, Old: "v4", New: "v5"},
+			{Path: []string{"node/A", "node/C"}, Node: "node/D", Kind: me.CHANGE_VERSION, Old: "v4", New: "v5"},
+		}))
+	})
+
+	It("finds added and removed dependencies", func() {
+		Expect(Must(me.Diff("node/A[v1](node/B[v2])", "node/A[v1](node/C[v3])"))).To(Equal([]me.Change{
+			{Path: []string{"node/A"}, Node: "node/C", Kind: me.CHANGE_ADDED, New: "v3"},
+			{Path: []string{"node/A"}, Node: "node/B", Kind: me.CHANGE_REMOVED, Old: "v2"},
+		}))
+	})
+
+	It("handles initial versions", func() {
+		Expect(Must(me.Diff("", "node/A[v1](node/B[v2])"))).To(Equal([]me.Change{
+			{Node: "node/A", Kind: me.CHANGE_ADDED, New: "v1"},
+		}))
+	})
+})